@@ -0,0 +1,402 @@
+// Package activitypub publishes the items of a feed to the fediverse as an
+// ActivityStreams 2.0 outbox, so that Mastodon and other ActivityPub
+// servers can follow a site without a separate system.
+//
+// A Builder turns the live items of a feed into Create activities wrapping
+// Article objects, or Note objects for untitled items, newest first. Outbox
+// renders the OrderedCollection and Page its pages; Actor and WebFinger
+// render the documents servers fetch to discover the account.
+package activitypub
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.rumenx.com/feed"
+)
+
+// Common errors
+var (
+	ErrInvalidActor     = errors.New("actor requires an absolute ID and a username")
+	ErrPageNotFound     = errors.New("outbox page not found")
+	ErrUnknownResource  = errors.New("unknown webfinger resource")
+	ErrInvalidSignature = errors.New("invalid http signature")
+)
+
+// Media types of ActivityStreams documents and WebFinger responses
+const (
+	ContentType          = "application/activity+json"
+	WebFingerContentType = "application/jrd+json"
+)
+
+// DefaultPageSize is the number of activities on an outbox page
+const DefaultPageSize = 20
+
+// Object types
+const (
+	Article = "Article"
+	Note    = "Note"
+)
+
+const (
+	activityStreams = "https://www.w3.org/ns/activitystreams"
+	securityContext = "https://w3id.org/security/v1"
+	public          = activityStreams + "#Public"
+)
+
+// Builder builds ActivityPub documents from the items of a feed
+type Builder struct {
+	feed       *feed.Feed
+	actor      Actor
+	pageSize   int
+	objectType func(feed.Item) string
+	now        func() time.Time
+	verifier   Verifier
+}
+
+// NewBuilder creates a Builder publishing the items of f as actor
+func NewBuilder(f *feed.Feed, actor Actor) *Builder {
+	return &Builder{
+		feed:     f,
+		actor:    actor,
+		pageSize: DefaultPageSize,
+		now:      time.Now,
+	}
+}
+
+// SetPageSize sets the number of activities on an outbox page
+func (b *Builder) SetPageSize(n int) *Builder {
+	if n > 0 {
+		b.pageSize = n
+	}
+	return b
+}
+
+// SetObjectType sets the function choosing the object type of an item.
+// By default titled items are Articles and untitled ones Notes.
+func (b *Builder) SetObjectType(fn func(feed.Item) string) *Builder {
+	b.objectType = fn
+	return b
+}
+
+// SetNow sets the clock used to leave out scheduled and expired items
+func (b *Builder) SetNow(now func() time.Time) *Builder {
+	b.now = now
+	return b
+}
+
+// Activity is a Create activity in an outbox
+type Activity struct {
+	ID        string   `json:"id"`
+	Type      string   `json:"type"`
+	Actor     string   `json:"actor"`
+	Published string   `json:"published,omitempty"`
+	To        []string `json:"to"`
+	Cc        []string `json:"cc,omitempty"`
+	Object    Object   `json:"object"`
+}
+
+// Object is the Article or Note a Create activity wraps
+type Object struct {
+	ID           string       `json:"id"`
+	Type         string       `json:"type"`
+	AttributedTo string       `json:"attributedTo"`
+	Name         string       `json:"name,omitempty"`
+	Content      string       `json:"content,omitempty"`
+	URL          string       `json:"url,omitempty"`
+	Published    string       `json:"published,omitempty"`
+	To           []string     `json:"to"`
+	Cc           []string     `json:"cc,omitempty"`
+	Tag          []Tag        `json:"tag,omitempty"`
+	Attachment   []Attachment `json:"attachment,omitempty"`
+}
+
+// Tag is a hashtag taken from an item category
+type Tag struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// Attachment is an image or media file attached to an object
+type Attachment struct {
+	Type      string `json:"type"`
+	MediaType string `json:"mediaType,omitempty"`
+	URL       string `json:"url"`
+	Name      string `json:"name,omitempty"`
+}
+
+type collection struct {
+	Context    string `json:"@context"`
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	TotalItems int    `json:"totalItems"`
+	First      string `json:"first,omitempty"`
+	Last       string `json:"last,omitempty"`
+}
+
+type collectionPage struct {
+	Context      string     `json:"@context"`
+	ID           string     `json:"id"`
+	Type         string     `json:"type"`
+	PartOf       string     `json:"partOf"`
+	TotalItems   int        `json:"totalItems"`
+	Next         string     `json:"next,omitempty"`
+	Prev         string     `json:"prev,omitempty"`
+	OrderedItems []Activity `json:"orderedItems"`
+}
+
+// Activities returns the Create activities of the live items of the feed,
+// newest first. Items need a link or a GUID to be given an ID; the first
+// item with a given ID wins.
+func (b *Builder) Activities() []Activity {
+	if b.feed == nil {
+		return nil
+	}
+	base, _ := url.Parse(b.feed.GetLink())
+	now := b.now()
+
+	var items []feed.Item
+	for _, item := range b.feed.GetItems() {
+		if item.IsLive(now) {
+			items = append(items, item)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].PubDate.After(items[j].PubDate)
+	})
+
+	var activities []Activity
+	seen := map[string]bool{}
+	for _, item := range items {
+		obj, ok := b.object(item, base)
+		if !ok || seen[obj.ID] {
+			continue
+		}
+		seen[obj.ID] = true
+		activities = append(activities, Activity{
+			ID:        obj.ID + "#create",
+			Type:      "Create",
+			Actor:     b.actor.ID,
+			Published: obj.Published,
+			To:        obj.To,
+			Cc:        obj.Cc,
+			Object:    obj,
+		})
+	}
+	return activities
+}
+
+// object converts an item to an ActivityStreams object
+func (b *Builder) object(item feed.Item, base *url.URL) (Object, bool) {
+	link := resolve(base, item.Link)
+	id := link
+	if id == "" {
+		if item.GUID == "" {
+			return Object{}, false
+		}
+		id = strings.TrimSuffix(b.actor.ID, "/") + "/objects/" + url.PathEscape(item.GUID)
+	}
+
+	objectType := Article
+	if item.Title == "" {
+		objectType = Note
+	}
+	if b.objectType != nil {
+		objectType = b.objectType(item)
+	}
+
+	content := item.Content
+	if content == "" {
+		content = item.Description
+	}
+	if s := b.feed.GetSanitizer(); s != nil {
+		content = s.Sanitize(content)
+	}
+
+	obj := Object{
+		ID:           id,
+		Type:         objectType,
+		AttributedTo: b.actor.ID,
+		Name:         item.Title,
+		Content:      content,
+		URL:          link,
+		Published:    formatDate(item.PubDate),
+		To:           []string{public},
+		Cc:           []string{b.actor.followers()},
+		Attachment:   attachments(item, base),
+	}
+	if objectType == Note {
+		obj.Name = ""
+	}
+	for _, category := range item.Categories {
+		if name := hashtag(category); name != "" {
+			obj.Tag = append(obj.Tag, Tag{Type: "Hashtag", Name: name})
+		}
+	}
+	return obj, true
+}
+
+// Outbox renders the outbox as an OrderedCollection linking to its first
+// and last pages
+func (b *Builder) Outbox() ([]byte, error) {
+	if err := b.actor.validate(); err != nil {
+		return nil, err
+	}
+	total := len(b.Activities())
+	c := collection{
+		Context:    activityStreams,
+		ID:         b.actor.outbox(),
+		Type:       "OrderedCollection",
+		TotalItems: total,
+	}
+	if total > 0 {
+		c.First = b.pageURL(1)
+		c.Last = b.pageURL(b.pages(total))
+	}
+	return marshal(c)
+}
+
+// Page renders page n of the outbox, counting from 1. It returns
+// ErrPageNotFound for pages past the end.
+func (b *Builder) Page(n int) ([]byte, error) {
+	if err := b.actor.validate(); err != nil {
+		return nil, err
+	}
+	activities := b.Activities()
+	pages := b.pages(len(activities))
+	if n < 1 || n > pages {
+		return nil, fmt.Errorf("%w: %d", ErrPageNotFound, n)
+	}
+
+	start := (n - 1) * b.pageSize
+	end := min(start+b.pageSize, len(activities))
+	p := collectionPage{
+		Context:      activityStreams,
+		ID:           b.pageURL(n),
+		Type:         "OrderedCollectionPage",
+		PartOf:       b.actor.outbox(),
+		TotalItems:   len(activities),
+		OrderedItems: activities[start:end],
+	}
+	if p.OrderedItems == nil {
+		p.OrderedItems = []Activity{}
+	}
+	if n < pages {
+		p.Next = b.pageURL(n + 1)
+	}
+	if n > 1 {
+		p.Prev = b.pageURL(n - 1)
+	}
+	return marshal(p)
+}
+
+// pages returns the number of outbox pages; an empty outbox has one empty
+// page
+func (b *Builder) pages(total int) int {
+	if total == 0 {
+		return 1
+	}
+	return (total + b.pageSize - 1) / b.pageSize
+}
+
+// pageURL returns the URL of outbox page n
+func (b *Builder) pageURL(n int) string {
+	outbox := b.actor.outbox()
+	u, err := url.Parse(outbox)
+	if err != nil {
+		return outbox
+	}
+	q := u.Query()
+	q.Set("page", strconv.Itoa(n))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// attachments returns the images and enclosures of an item as attachments
+func attachments(item feed.Item, base *url.URL) []Attachment {
+	var out []Attachment
+	for _, img := range item.Images {
+		if img.URL != "" {
+			out = append(out, Attachment{Type: "Image", URL: resolve(base, img.URL), Name: img.Title})
+		}
+	}
+	enclosures := item.Enclosures
+	if item.Enclosure != nil {
+		enclosures = append([]feed.Enclosure{*item.Enclosure}, enclosures...)
+	}
+	for _, e := range enclosures {
+		if e.URL == "" {
+			continue
+		}
+		out = append(out, Attachment{Type: attachmentType(e.Type), MediaType: e.Type, URL: resolve(base, e.URL)})
+	}
+	return out
+}
+
+// attachmentType returns the ActivityStreams type for a media type
+func attachmentType(mediaType string) string {
+	switch kind, _, _ := strings.Cut(mediaType, "/"); kind {
+	case "image":
+		return "Image"
+	case "audio":
+		return "Audio"
+	case "video":
+		return "Video"
+	}
+	return "Document"
+}
+
+// hashtag turns a category into a hashtag name, dropping characters
+// hashtags cannot contain
+func hashtag(category string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, category)
+	if name == "" {
+		return ""
+	}
+	return "#" + name
+}
+
+// resolve resolves ref against base
+func resolve(base *url.URL, ref string) string {
+	if ref == "" || base == nil {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
+
+// formatDate formats a time as ActivityStreams expects
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// marshal encodes v as JSON without escaping HTML, which ActivityPub
+// content is full of
+func marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to encode activitypub document: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,384 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.rumenx.com/feed"
+)
+
+var now = time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+var testActor = Actor{ID: "https://example.com/actor", Username: "blog"}
+
+func testFeed() *feed.Feed {
+	return feed.New().
+		SetTitle("Example Blog").
+		SetDescription("Posts about Go").
+		SetLink("https://example.com/").
+		SetImage(feed.Image{URL: "/logo.png"}).
+		AddItem(feed.Item{
+			Title:       "Older post",
+			Description: "<p>Hello <b>fediverse</b></p>",
+			Link:        "/posts/older",
+			PubDate:     now.Add(-48 * time.Hour),
+			Categories:  []string{"Go", "open source"},
+			Images:      []feed.Image{{URL: "/img/older.png", Title: "Cover"}},
+		}).
+		AddItem(feed.Item{
+			Description: "A short update",
+			GUID:        "update-1",
+			PubDate:     now.Add(-time.Hour),
+			Enclosure:   &feed.Enclosure{URL: "/audio/update.mp3", Type: "audio/mpeg"},
+		}).
+		AddItem(feed.Item{
+			Title:     "Scheduled",
+			Link:      "/posts/scheduled",
+			PubDate:   now.Add(time.Hour),
+			PublishAt: now.Add(time.Hour),
+		}).
+		AddItem(feed.Item{Title: "Without ID", PubDate: now})
+}
+
+func decode(t *testing.T, data []byte) map[string]interface{} {
+	t.Helper()
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Failed to decode %s: %v", data, err)
+	}
+	return doc
+}
+
+func TestActivities(t *testing.T) {
+	activities := NewBuilder(testFeed(), testActor).SetNow(func() time.Time { return now }).Activities()
+	if len(activities) != 2 {
+		t.Fatalf("Expected 2 activities, got %+v", activities)
+	}
+
+	note, article := activities[0], activities[1]
+	if note.Type != "Create" || note.Actor != testActor.ID {
+		t.Errorf("Unexpected activity: %+v", note)
+	}
+	if note.Object.Type != Note || note.Object.ID != "https://example.com/actor/objects/update-1" || note.ID != note.Object.ID+"#create" {
+		t.Errorf("Expected the untitled item to be a Note with a GUID based ID, got %+v", note)
+	}
+	if note.Published != "2024-03-10T11:00:00Z" {
+		t.Errorf("Expected published date, got %q", note.Published)
+	}
+	if len(note.Object.Attachment) != 1 || note.Object.Attachment[0] != (Attachment{Type: "Audio", MediaType: "audio/mpeg", URL: "https://example.com/audio/update.mp3"}) {
+		t.Errorf("Unexpected attachments: %+v", note.Object.Attachment)
+	}
+
+	obj := article.Object
+	if obj.Type != Article || obj.ID != "https://example.com/posts/older" || obj.URL != obj.ID {
+		t.Errorf("Expected an Article identified by its link, got %+v", obj)
+	}
+	if obj.Name != "Older post" || obj.Content != "<p>Hello <b>fediverse</b></p>" || obj.AttributedTo != testActor.ID {
+		t.Errorf("Unexpected object: %+v", obj)
+	}
+	if len(obj.To) != 1 || obj.To[0] != public || len(obj.Cc) != 1 || obj.Cc[0] != "https://example.com/actor/followers" {
+		t.Errorf("Expected a public post copied to followers, got to %v cc %v", obj.To, obj.Cc)
+	}
+	if len(obj.Tag) != 2 || obj.Tag[0].Name != "#Go" || obj.Tag[1].Name != "#opensource" {
+		t.Errorf("Unexpected hashtags: %+v", obj.Tag)
+	}
+	if len(obj.Attachment) != 1 || obj.Attachment[0].Type != "Image" || obj.Attachment[0].URL != "https://example.com/img/older.png" {
+		t.Errorf("Unexpected attachments: %+v", obj.Attachment)
+	}
+}
+
+func TestActivitiesObjectType(t *testing.T) {
+	activities := NewBuilder(testFeed(), testActor).
+		SetNow(func() time.Time { return now }).
+		SetObjectType(func(feed.Item) string { return Note }).
+		Activities()
+	for _, a := range activities {
+		if a.Object.Type != Note || a.Object.Name != "" {
+			t.Errorf("Expected a Note without a name, got %+v", a.Object)
+		}
+	}
+}
+
+func TestActivitiesSanitized(t *testing.T) {
+	f := testFeed().SetSanitizer(sanitizerFunc(func(s string) string { return strings.ReplaceAll(s, "<b>", "") }))
+	activities := NewBuilder(f, testActor).SetNow(func() time.Time { return now }).Activities()
+	if got := activities[1].Object.Content; strings.Contains(got, "<b>") {
+		t.Errorf("Expected the feed sanitizer to be applied, got %q", got)
+	}
+}
+
+type sanitizerFunc func(string) string
+
+func (fn sanitizerFunc) Sanitize(s string) string { return fn(s) }
+
+// manyItems returns a feed with n items, the newest first
+func manyItems(n int) *feed.Feed {
+	f := feed.New().SetLink("https://example.com/")
+	for i := 0; i < n; i++ {
+		f.AddItem(feed.Item{Title: "Post", Link: fmt.Sprintf("/posts/%d", i), PubDate: now.Add(-time.Duration(i) * time.Hour)})
+	}
+	return f
+}
+
+func TestOutboxPaging(t *testing.T) {
+	b := NewBuilder(manyItems(5), testActor).SetNow(func() time.Time { return now }).SetPageSize(2)
+
+	data, err := b.Outbox()
+	if err != nil {
+		t.Fatalf("Outbox failed: %v", err)
+	}
+	outbox := decode(t, data)
+	if outbox["@context"] != activityStreams || outbox["type"] != "OrderedCollection" || outbox["id"] != "https://example.com/actor/outbox" {
+		t.Errorf("Unexpected outbox: %s", data)
+	}
+	if outbox["totalItems"] != float64(5) || outbox["first"] != "https://example.com/actor/outbox?page=1" || outbox["last"] != "https://example.com/actor/outbox?page=3" {
+		t.Errorf("Unexpected outbox links: %s", data)
+	}
+
+	data, err = b.Page(2)
+	if err != nil {
+		t.Fatalf("Page failed: %v", err)
+	}
+	page := decode(t, data)
+	if page["type"] != "OrderedCollectionPage" || page["partOf"] != "https://example.com/actor/outbox" {
+		t.Errorf("Unexpected page: %s", data)
+	}
+	if page["prev"] != "https://example.com/actor/outbox?page=1" || page["next"] != "https://example.com/actor/outbox?page=3" {
+		t.Errorf("Unexpected page links: %s", data)
+	}
+	items := page["orderedItems"].([]interface{})
+	if len(items) != 2 || items[0].(map[string]interface{})["id"] != "https://example.com/posts/2#create" {
+		t.Errorf("Expected the third and fourth activities, got %v", items)
+	}
+
+	data, err = b.Page(3)
+	if err != nil {
+		t.Fatalf("Page failed: %v", err)
+	}
+	if page := decode(t, data); page["next"] != nil || len(page["orderedItems"].([]interface{})) != 1 {
+		t.Errorf("Expected a last page with one activity, got %s", data)
+	}
+
+	if _, err := b.Page(4); !errors.Is(err, ErrPageNotFound) {
+		t.Errorf("Expected ErrPageNotFound, got %v", err)
+	}
+}
+
+func TestOutboxEmpty(t *testing.T) {
+	b := NewBuilder(feed.New(), testActor)
+	data, err := b.Outbox()
+	if err != nil {
+		t.Fatalf("Outbox failed: %v", err)
+	}
+	if outbox := decode(t, data); outbox["totalItems"] != float64(0) || outbox["first"] != nil {
+		t.Errorf("Unexpected empty outbox: %s", data)
+	}
+	data, err = b.Page(1)
+	if err != nil {
+		t.Fatalf("Page failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"orderedItems":[]`) {
+		t.Errorf("Expected an empty page, got %s", data)
+	}
+}
+
+func TestInvalidActor(t *testing.T) {
+	for _, actor := range []Actor{{}, {ID: "/actor", Username: "blog"}, {ID: "https://example.com/actor"}} {
+		b := NewBuilder(testFeed(), actor)
+		if _, err := b.Outbox(); !errors.Is(err, ErrInvalidActor) {
+			t.Errorf("Expected ErrInvalidActor for %+v, got %v", actor, err)
+		}
+		if _, err := b.Actor(); !errors.Is(err, ErrInvalidActor) {
+			t.Errorf("Expected ErrInvalidActor for %+v, got %v", actor, err)
+		}
+	}
+}
+
+func TestActorDocument(t *testing.T) {
+	data, err := NewBuilder(testFeed(), Actor{
+		ID:           "https://example.com/actor",
+		Username:     "blog",
+		PublicKeyPEM: "-----BEGIN PUBLIC KEY-----\n...\n-----END PUBLIC KEY-----\n",
+		Inbox:        "https://example.com/inbox",
+	}).Actor()
+	if err != nil {
+		t.Fatalf("Actor failed: %v", err)
+	}
+	for _, want := range []string{
+		`"@context":["https://www.w3.org/ns/activitystreams","https://w3id.org/security/v1"]`,
+		`"type":"Service"`,
+		`"preferredUsername":"blog"`,
+		`"name":"Example Blog"`,
+		`"summary":"Posts about Go"`,
+		`"url":"https://example.com/"`,
+		`"icon":{"type":"Image","url":"https://example.com/logo.png"}`,
+		`"inbox":"https://example.com/inbox"`,
+		`"outbox":"https://example.com/actor/outbox"`,
+		`"followers":"https://example.com/actor/followers"`,
+		`"publicKey":{"id":"https://example.com/actor#main-key","owner":"https://example.com/actor"`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected actor to contain %s, got %s", want, data)
+		}
+	}
+}
+
+func TestWebFinger(t *testing.T) {
+	b := NewBuilder(testFeed(), testActor)
+	if got := testActor.Handle(); got != "blog@example.com" {
+		t.Errorf("Expected handle blog@example.com, got %q", got)
+	}
+
+	for _, resource := range []string{"acct:blog@example.com", "acct:Blog@Example.com", "https://example.com/actor"} {
+		data, err := b.WebFinger(resource)
+		if err != nil {
+			t.Fatalf("WebFinger(%q) failed: %v", resource, err)
+		}
+		want := `{"subject":"acct:blog@example.com","aliases":["https://example.com/actor","https://example.com/"],"links":[{"rel":"self","type":"application/activity+json","href":"https://example.com/actor"},{"rel":"http://webfinger.net/rel/profile-page","type":"text/html","href":"https://example.com/"}]}` + "\n"
+		if string(data) != want {
+			t.Errorf("Unexpected WebFinger response for %q: %s", resource, data)
+		}
+	}
+
+	if _, err := b.WebFinger("acct:someone@example.com"); !errors.Is(err, ErrUnknownResource) {
+		t.Errorf("Expected ErrUnknownResource, got %v", err)
+	}
+
+	data, err := NewBuilder(nil, Actor{ID: "https://example.com/actor", Username: "blog", Domain: "example.org"}).WebFinger("acct:blog@example.org")
+	if err != nil || !strings.Contains(string(data), `"subject":"acct:blog@example.org"`) {
+		t.Errorf("Expected the handle domain to be used, got %s, %v", data, err)
+	}
+}
+
+func TestAccepts(t *testing.T) {
+	tests := map[string]bool{
+		"application/activity+json": true,
+		`application/ld+json; profile="https://www.w3.org/ns/activitystreams"`: true,
+		"text/html, application/activity+json;q=0.9":                           true,
+		"application/ld+json":             false,
+		"text/html,application/xhtml+xml": false,
+		"":                                false,
+	}
+	for accept, want := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/actor", nil)
+		req.Header.Set("Accept", accept)
+		if got := Accepts(req); got != want {
+			t.Errorf("Accepts(%q) = %v, expected %v", accept, got, want)
+		}
+	}
+}
+
+func get(h http.Handler, target, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandlers(t *testing.T) {
+	generator := func() *feed.Feed { return manyItems(3) }
+	configure := func(b *Builder) { b.SetNow(func() time.Time { return now }).SetPageSize(2) }
+
+	rec := get(ActorHandler(generator, testActor, configure), "/actor", ContentType)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/activity+json; charset=utf-8" {
+		t.Errorf("Unexpected actor response: %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if rec.Header().Get("Vary") != "Accept" {
+		t.Error("Expected Vary: Accept")
+	}
+
+	rec = get(ActorHandler(generator, testActor, configure), "/actor", "text/html")
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "https://example.com/" {
+		t.Errorf("Expected browsers to be redirected to the profile, got %d %s", rec.Code, rec.Header().Get("Location"))
+	}
+
+	rec = get(ActorHandler(func() *feed.Feed { return feed.New() }, testActor), "/actor", "text/html")
+	if rec.Code != http.StatusNotAcceptable {
+		t.Errorf("Expected 406 without a profile page, got %d", rec.Code)
+	}
+
+	outbox := OutboxHandler(generator, testActor, configure)
+	if rec := get(outbox, "/actor/outbox", ContentType); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"type":"OrderedCollection"`) {
+		t.Errorf("Unexpected outbox response %d: %s", rec.Code, rec.Body)
+	}
+	if rec := get(outbox, "/actor/outbox?page=2", ContentType); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"id":"https://example.com/actor/outbox?page=2"`) {
+		t.Errorf("Unexpected page response %d: %s", rec.Code, rec.Body)
+	}
+	for _, page := range []string{"3", "x"} {
+		if rec := get(outbox, "/actor/outbox?page="+page, ContentType); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for page %s, got %d", page, rec.Code)
+		}
+	}
+
+	webfinger := WebFingerHandler(generator, testActor)
+	rec = get(webfinger, "/.well-known/webfinger?resource=acct:blog@example.com", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/jrd+json; charset=utf-8" || rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("Unexpected WebFinger response: %d %v", rec.Code, rec.Header())
+	}
+	if rec := get(webfinger, "/.well-known/webfinger?resource=acct:other@example.com", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown resource, got %d", rec.Code)
+	}
+	if rec := get(webfinger, "/.well-known/webfinger", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a resource, got %d", rec.Code)
+	}
+}
+
+func TestHandlerVerifier(t *testing.T) {
+	var verified *Signature
+	verifier := VerifierFunc(func(req *http.Request) error {
+		sig, err := ParseSignature(req)
+		if err != nil {
+			return err
+		}
+		if sig.KeyID != "https://mastodon.example/users/alice#main-key" {
+			return fmt.Errorf("%w: unknown key", ErrInvalidSignature)
+		}
+		verified = sig
+		return nil
+	})
+	h := OutboxHandler(func() *feed.Feed { return manyItems(1) }, testActor, func(b *Builder) { b.SetVerifier(verifier) })
+
+	if rec := get(h, "/actor/outbox", ContentType); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for an unsigned request, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/actor/outbox", nil)
+	req.Header.Set("Accept", ContentType)
+	req.Header.Set("Signature", `keyId="https://mastodon.example/users/alice#main-key",algorithm="rsa-sha256",headers="(request-target) host date",signature="c2lnbmF0dXJl"`)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected a signed request to be served, got %d", rec.Code)
+	}
+	if verified.Algorithm != "rsa-sha256" || strings.Join(verified.Headers, " ") != "(request-target) host date" || verified.Value != "c2lnbmF0dXJl" {
+		t.Errorf("Unexpected signature: %+v", verified)
+	}
+}
+
+func TestParseSignature(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Signature", `keyId="https://a.example/key,1",signature="abc="`)
+	sig, err := ParseSignature(req)
+	if err != nil {
+		t.Fatalf("ParseSignature failed: %v", err)
+	}
+	if sig.KeyID != "https://a.example/key,1" || sig.Value != "abc=" || len(sig.Headers) != 1 || sig.Headers[0] != "date" {
+		t.Errorf("Unexpected signature: %+v", sig)
+	}
+
+	for _, header := range []string{"", `keyId="k"`, `signature="s"`, "garbage"} {
+		req.Header.Set("Signature", header)
+		if _, err := ParseSignature(req); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Expected ErrInvalidSignature for %q, got %v", header, err)
+		}
+	}
+}
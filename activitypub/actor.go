@@ -0,0 +1,181 @@
+package activitypub
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Actor describes the account a feed is published as. Only ID and Username
+// are required; the other URLs default to paths below the ID, and Name,
+// Summary, URL and Icon to the title, description, link and image of the
+// feed.
+type Actor struct {
+	ID       string // the actor document URL, e.g. https://example.com/actor
+	Type     string // Service by default, or Person, Organization, ...
+	Username string // the name before the @ in the account handle
+	Domain   string // the domain of the handle; defaults to the host of ID
+
+	Name    string
+	Summary string // HTML
+	URL     string // profile page
+	Icon    string
+
+	Inbox     string
+	Outbox    string
+	Followers string
+
+	// PublicKeyPEM is the key servers verify the actor's signatures with
+	PublicKeyPEM string
+}
+
+type actorDocument struct {
+	Context           []string   `json:"@context"`
+	ID                string     `json:"id"`
+	Type              string     `json:"type"`
+	PreferredUsername string     `json:"preferredUsername"`
+	Name              string     `json:"name,omitempty"`
+	Summary           string     `json:"summary,omitempty"`
+	URL               string     `json:"url,omitempty"`
+	Icon              *image     `json:"icon,omitempty"`
+	Inbox             string     `json:"inbox"`
+	Outbox            string     `json:"outbox"`
+	Followers         string     `json:"followers"`
+	PublicKey         *publicKey `json:"publicKey,omitempty"`
+}
+
+type image struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type publicKey struct {
+	ID           string `json:"id"`
+	Owner        string `json:"owner"`
+	PublicKeyPEM string `json:"publicKeyPem"`
+}
+
+type jrd struct {
+	Subject string    `json:"subject"`
+	Aliases []string  `json:"aliases,omitempty"`
+	Links   []jrdLink `json:"links"`
+}
+
+type jrdLink struct {
+	Rel  string `json:"rel"`
+	Type string `json:"type,omitempty"`
+	Href string `json:"href"`
+}
+
+// Handle returns the account handle, e.g. blog@example.com
+func (a Actor) Handle() string {
+	return a.Username + "@" + a.domain()
+}
+
+// KeyID returns the ID of the actor's public key
+func (a Actor) KeyID() string {
+	return a.ID + "#main-key"
+}
+
+func (a Actor) validate() error {
+	u, err := url.Parse(a.ID)
+	if err != nil || !u.IsAbs() || a.Username == "" {
+		return ErrInvalidActor
+	}
+	return nil
+}
+
+func (a Actor) domain() string {
+	if a.Domain != "" {
+		return a.Domain
+	}
+	if u, err := url.Parse(a.ID); err == nil {
+		return u.Hostname()
+	}
+	return ""
+}
+
+func (a Actor) path(value, name string) string {
+	if value != "" {
+		return value
+	}
+	return strings.TrimSuffix(a.ID, "/") + "/" + name
+}
+
+func (a Actor) inbox() string     { return a.path(a.Inbox, "inbox") }
+func (a Actor) outbox() string    { return a.path(a.Outbox, "outbox") }
+func (a Actor) followers() string { return a.path(a.Followers, "followers") }
+
+// Actor renders the actor document
+func (b *Builder) Actor() ([]byte, error) {
+	a := b.actor
+	if err := a.validate(); err != nil {
+		return nil, err
+	}
+	if b.feed != nil {
+		if a.Name == "" {
+			a.Name = b.feed.GetTitle()
+		}
+		if a.Summary == "" {
+			a.Summary = b.feed.GetDescription()
+		}
+		if a.URL == "" {
+			a.URL = b.feed.GetLink()
+		}
+		if a.Icon == "" && b.feed.GetImage() != nil {
+			base, _ := url.Parse(b.feed.GetLink())
+			a.Icon = resolve(base, b.feed.GetImage().URL)
+		}
+	}
+
+	doc := actorDocument{
+		Context:           []string{activityStreams, securityContext},
+		ID:                a.ID,
+		Type:              a.Type,
+		PreferredUsername: a.Username,
+		Name:              a.Name,
+		Summary:           a.Summary,
+		URL:               a.URL,
+		Inbox:             a.inbox(),
+		Outbox:            a.outbox(),
+		Followers:         a.followers(),
+	}
+	if doc.Type == "" {
+		doc.Type = "Service"
+	}
+	if a.Icon != "" {
+		doc.Icon = &image{Type: "Image", URL: a.Icon}
+	}
+	if a.PublicKeyPEM != "" {
+		doc.PublicKey = &publicKey{ID: a.KeyID(), Owner: a.ID, PublicKeyPEM: a.PublicKeyPEM}
+	}
+	return marshal(doc)
+}
+
+// WebFinger renders the WebFinger response for resource, which must be
+// the acct: URI of the actor or its ID. It returns ErrUnknownResource for
+// any other resource.
+func (b *Builder) WebFinger(resource string) ([]byte, error) {
+	a := b.actor
+	if err := a.validate(); err != nil {
+		return nil, err
+	}
+	subject := "acct:" + a.Handle()
+	if !strings.EqualFold(strings.TrimPrefix(resource, "acct:"), a.Handle()) && resource != a.ID {
+		return nil, ErrUnknownResource
+	}
+
+	profile := a.URL
+	if profile == "" && b.feed != nil {
+		profile = b.feed.GetLink()
+	}
+	doc := jrd{
+		Subject: subject,
+		Aliases: []string{a.ID},
+		Links:   []jrdLink{{Rel: "self", Type: ContentType, Href: a.ID}},
+	}
+	if profile != "" {
+		doc.Aliases = append(doc.Aliases, profile)
+		doc.Links = append(doc.Links, jrdLink{Rel: "http://webfinger.net/rel/profile-page", Type: "text/html", Href: profile})
+	}
+	return marshal(doc)
+}
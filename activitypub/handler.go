@@ -0,0 +1,157 @@
+package activitypub
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.rumenx.com/feed"
+)
+
+// Accepts reports whether req asks for an ActivityStreams document, either
+// as application/activity+json or as JSON-LD with the ActivityStreams
+// profile
+func Accepts(req *http.Request) bool {
+	for _, part := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case ContentType:
+			return true
+		case "application/ld+json":
+			if strings.Contains(params["profile"], activityStreams) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ActorHandler returns an http.Handler serving the actor document to
+// ActivityPub clients. Other clients, such as web browsers, are redirected
+// to the profile page.
+func ActorHandler(generator func() *feed.Feed, actor Actor, configure ...func(*Builder)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, ok := build(w, req, generator, actor, configure)
+		if !ok {
+			return
+		}
+		data, err := b.Actor()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		serve(w, req, ContentType, data)
+	})
+}
+
+// OutboxHandler returns an http.Handler serving the outbox to ActivityPub
+// clients, and the outbox page given by the page query parameter. Other
+// clients are redirected to the profile page.
+func OutboxHandler(generator func() *feed.Feed, actor Actor, configure ...func(*Builder)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, ok := build(w, req, generator, actor, configure)
+		if !ok {
+			return
+		}
+
+		var data []byte
+		var err error
+		if page := req.URL.Query().Get("page"); page != "" {
+			n, convErr := strconv.Atoi(page)
+			if convErr != nil {
+				http.NotFound(w, req)
+				return
+			}
+			data, err = b.Page(n)
+		} else {
+			data, err = b.Outbox()
+		}
+		if errors.Is(err, ErrPageNotFound) {
+			http.NotFound(w, req)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		serve(w, req, ContentType, data)
+	})
+}
+
+// WebFingerHandler returns an http.Handler answering WebFinger lookups of
+// the actor. Route it at /.well-known/webfinger.
+func WebFingerHandler(generator func() *feed.Feed, actor Actor, configure ...func(*Builder)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		resource := req.URL.Query().Get("resource")
+		if resource == "" {
+			http.Error(w, "Missing resource parameter", http.StatusBadRequest)
+			return
+		}
+		b := NewBuilder(generator(), actor)
+		for _, fn := range configure {
+			fn(b)
+		}
+
+		data, err := b.WebFinger(resource)
+		if errors.Is(err, ErrUnknownResource) {
+			http.NotFound(w, req)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		serve(w, req, WebFingerContentType, data)
+	})
+}
+
+// build negotiates the response and creates the Builder for a request,
+// writing the response itself when the client does not ask for
+// ActivityStreams, the signature is rejected or the feed cannot be
+// generated
+func build(w http.ResponseWriter, req *http.Request, generator func() *feed.Feed, actor Actor, configure []func(*Builder)) (*Builder, bool) {
+	w.Header().Set("Vary", "Accept")
+	f := generator()
+	if f == nil {
+		http.Error(w, "Failed to generate feed", http.StatusInternalServerError)
+		return nil, false
+	}
+	b := NewBuilder(f, actor)
+	for _, fn := range configure {
+		fn(b)
+	}
+
+	if !Accepts(req) {
+		profile := actor.URL
+		if profile == "" {
+			profile = f.GetLink()
+		}
+		if profile == "" {
+			http.Error(w, "Not Acceptable", http.StatusNotAcceptable)
+			return nil, false
+		}
+		http.Redirect(w, req, profile, http.StatusSeeOther)
+		return nil, false
+	}
+	if b.verifier != nil {
+		if err := b.verifier.Verify(req); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return nil, false
+		}
+	}
+	return b, true
+}
+
+func serve(w http.ResponseWriter, req *http.Request, contentType string, data []byte) {
+	w.Header().Set("Content-Type", contentType+"; charset=utf-8")
+	if req.Method == http.MethodHead {
+		return
+	}
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,90 @@
+package activitypub
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Verifier checks the HTTP signature of an inbound request. Servers running
+// in authorized fetch mode sign the requests they make for the actor and
+// outbox; a Verifier set with SetVerifier makes the handlers reject requests
+// it does not accept. Fetching and caching the signers' keys is left to the
+// implementation.
+type Verifier interface {
+	Verify(req *http.Request) error
+}
+
+// VerifierFunc adapts a function to the Verifier interface
+type VerifierFunc func(req *http.Request) error
+
+// Verify calls fn(req)
+func (fn VerifierFunc) Verify(req *http.Request) error {
+	return fn(req)
+}
+
+// SetVerifier sets the Verifier the handlers check requests with. By
+// default requests are not checked.
+func (b *Builder) SetVerifier(v Verifier) *Builder {
+	b.verifier = v
+	return b
+}
+
+// Signature holds the parameters of a Signature header
+// (draft-cavage-http-signatures), as sent by Mastodon
+type Signature struct {
+	KeyID     string
+	Algorithm string
+	Headers   []string // the signed headers, (request-target) included
+	Value     string   // base64 encoded
+}
+
+// ParseSignature parses the Signature header of req. It returns an error
+// wrapping ErrInvalidSignature when the header is missing or lacks the key
+// ID or the signature.
+func ParseSignature(req *http.Request) (*Signature, error) {
+	header := req.Header.Get("Signature")
+	if header == "" {
+		return nil, fmt.Errorf("%w: missing signature header", ErrInvalidSignature)
+	}
+
+	sig := &Signature{Headers: []string{"date"}}
+	for header != "" {
+		var param string
+		param, header = nextParam(header)
+		name, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed parameter %q", ErrInvalidSignature, param)
+		}
+		value = strings.Trim(value, `"`)
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "keyid":
+			sig.KeyID = value
+		case "algorithm":
+			sig.Algorithm = value
+		case "headers":
+			sig.Headers = strings.Fields(strings.ToLower(value))
+		case "signature":
+			sig.Value = value
+		}
+	}
+	if sig.KeyID == "" || sig.Value == "" {
+		return nil, fmt.Errorf("%w: missing keyId or signature", ErrInvalidSignature)
+	}
+	return sig, nil
+}
+
+// nextParam splits the first comma separated parameter off s, ignoring
+// commas inside quoted values
+func nextParam(s string) (string, string) {
+	quoted := false
+	for i, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:])
+		}
+	}
+	return strings.TrimSpace(s), ""
+}
@@ -2,8 +2,13 @@ package chi
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"go.rumenx.com/feed"
+	"go.rumenx.com/feed/activitypub"
+	"go.rumenx.com/feed/registry"
+	"go.rumenx.com/feed/sitemap"
 )
 
 // FeedGenerator is a function that generates a feed
@@ -25,7 +30,7 @@ func Feed(generator FeedGenerator) http.HandlerFunc {
 		}
 
 		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
-		w.Header().Set("Cache-Control", "public, max-age=3600")
+		w.Header().Set("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
 		w.WriteHeader(http.StatusOK)
 		w.Write(rss)
 	}
@@ -47,7 +52,7 @@ func AtomFeed(generator FeedGenerator) http.HandlerFunc {
 		}
 
 		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
-		w.Header().Set("Cache-Control", "public, max-age=3600")
+		w.Header().Set("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
 		w.WriteHeader(http.StatusOK)
 		w.Write(atom)
 	}
@@ -76,7 +81,7 @@ func FeedWithFormat(generator FeedGenerator) http.HandlerFunc {
 				return
 			}
 			w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
-			w.Header().Set("Cache-Control", "public, max-age=3600")
+			w.Header().Set("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
 			w.WriteHeader(http.StatusOK)
 			w.Write(atom)
 
@@ -89,13 +94,173 @@ func FeedWithFormat(generator FeedGenerator) http.HandlerFunc {
 				return
 			}
 			w.Header().Set("Content-Type", "application/xml; charset=utf-8")
-			w.Header().Set("Cache-Control", "public, max-age=3600")
+			w.Header().Set("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
 			w.WriteHeader(http.StatusOK)
 			w.Write(rss)
 		}
 	}
 }
 
+// SnapshotSource returns an immutable feed snapshot, such as (*feed.SafeFeed).Snapshot
+type SnapshotSource func() *feed.Snapshot
+
+// SnapshotFeed creates a Chi handler that serves a feed snapshot in the format
+// given by the 'format' query parameter (RSS by default).
+// Rendering works on an immutable snapshot, so it never blocks feed updates.
+func SnapshotFeed(source SnapshotSource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s := source()
+		if s == nil {
+			http.Error(w, "Failed to generate feed", http.StatusInternalServerError)
+			return
+		}
+
+		data, contentType, err := render(s, r.URL.Query().Get("format"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", cacheControl(s.MaxAge(time.Hour)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(data)
+	}
+}
+
+// DeltaFeed creates a Chi handler that serves a feed in the format given by
+// the 'format' query parameter (RSS by default) with an ETag and RFC 3229
+// delta encoding: clients sending "A-IM: feed" with the ETag of a version
+// remembered by versions get 226 IM Used with only the new and changed
+// items. A nil index remembers the last 64 versions.
+func DeltaFeed(generator FeedGenerator, versions *feed.VersionIndex) http.HandlerFunc {
+	if versions == nil {
+		versions = feed.NewVersionIndex(64)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		f := generator()
+		if f == nil {
+			http.Error(w, "Failed to generate feed", http.StatusInternalServerError)
+			return
+		}
+
+		format := feed.FormatRSS
+		if r.URL.Query().Get("format") == "atom" {
+			format = feed.FormatAtom
+		}
+		resp, err := versions.Respond(r.URL.Path, f, format, r.Header.Get("If-None-Match"), r.Header.Get("A-IM"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", format.ContentType())
+		w.Header().Set("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
+		for name, values := range resp.Header {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(resp.Status)
+		w.Write(resp.Body)
+	}
+}
+
+// cacheControl returns a public Cache-Control value for the given lifetime
+func cacheControl(maxAge time.Duration) string {
+	return "public, max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+}
+
+// render renders r in the given format and returns the matching content type
+func render(r feed.Renderer, format string) ([]byte, string, error) {
+	if format == "atom" {
+		data, err := r.Atom()
+		return data, "application/atom+xml; charset=utf-8", err
+	}
+	data, err := r.RSS()
+	return data, "application/xml; charset=utf-8", err
+}
+
+// Registry returns a handler that serves all feeds of a registry.
+// Mount it at the registry prefix, e.g. r.Mount("/feeds", chi.Registry(reg)).
+func Registry(reg *registry.Registry) http.Handler {
+	return reg
+}
+
+// Sitemap returns a handler that serves an XML sitemap of the feed, or a
+// sitemap index with numbered pages when it does not fit in one file
+func Sitemap(generator FeedGenerator, configure ...func(*sitemap.Builder)) http.Handler {
+	return sitemap.Handler(generator, configure...)
+}
+
+// NewsSitemap returns a handler that serves a Google News sitemap of the
+// items published in the last 48 hours
+func NewsSitemap(generator FeedGenerator, configure ...func(*sitemap.Builder)) http.Handler {
+	return sitemap.NewsHandler(generator, configure...)
+}
+
+// ActivityPubActor returns a handler that serves the ActivityPub actor
+// document to clients asking for application/activity+json and redirects
+// browsers to the profile page
+func ActivityPubActor(generator FeedGenerator, actor activitypub.Actor, configure ...func(*activitypub.Builder)) http.Handler {
+	return activitypub.ActorHandler(generator, actor, configure...)
+}
+
+// ActivityPubOutbox returns a handler that serves the feed items as an
+// ActivityPub outbox, one page at a time
+func ActivityPubOutbox(generator FeedGenerator, actor activitypub.Actor, configure ...func(*activitypub.Builder)) http.Handler {
+	return activitypub.OutboxHandler(generator, actor, configure...)
+}
+
+// WebFinger returns a handler that answers WebFinger lookups of the actor.
+// Route it at /.well-known/webfinger.
+func WebFinger(generator FeedGenerator, actor activitypub.Actor, configure ...func(*activitypub.Builder)) http.Handler {
+	return activitypub.WebFingerHandler(generator, actor, configure...)
+}
+
+// Stylesheet creates a Chi handler that serves the built-in XSL stylesheet.
+// Route it at the URL passed to (*feed.Feed).SetStylesheet.
+func Stylesheet() http.HandlerFunc {
+	xsl := feed.DefaultStylesheet()
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", feed.StylesheetContentType)
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		w.WriteHeader(http.StatusOK)
+		w.Write(xsl)
+	}
+}
+
+// PreviewFeed creates a Chi handler that serves an HTML preview of the feed
+// to web browsers and the feed itself, in the format given by the 'format'
+// query parameter, to everything else
+func PreviewFeed(generator FeedGenerator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f := generator()
+		if f == nil {
+			http.Error(w, "Failed to generate feed", http.StatusInternalServerError)
+			return
+		}
+
+		var data []byte
+		var contentType string
+		var err error
+		if feed.IsBrowserRequest(r) {
+			data, err = f.HTML(feed.RequestURL(r))
+			contentType = "text/html; charset=utf-8"
+		} else {
+			data, contentType, err = render(f, r.URL.Query().Get("format"))
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
+		w.Header().Set("Vary", "Accept, User-Agent")
+		w.WriteHeader(http.StatusOK)
+		w.Write(data)
+	}
+}
+
 // FeedMiddleware creates a Chi middleware that adds feed generation capability
 // This can be useful for adding feeds to existing routes
 func FeedMiddleware(generator FeedGenerator) func(http.Handler) http.Handler {
@@ -121,7 +286,7 @@ func FeedMiddleware(generator FeedGenerator) func(http.Handler) http.Handler {
 						return
 					}
 					w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
-					w.Header().Set("Cache-Control", "public, max-age=3600")
+					w.Header().Set("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
 					w.WriteHeader(http.StatusOK)
 					w.Write(atom)
 				} else {
@@ -131,7 +296,7 @@ func FeedMiddleware(generator FeedGenerator) func(http.Handler) http.Handler {
 						return
 					}
 					w.Header().Set("Content-Type", "application/xml; charset=utf-8")
-					w.Header().Set("Cache-Control", "public, max-age=3600")
+					w.Header().Set("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
 					w.WriteHeader(http.StatusOK)
 					w.Write(rss)
 				}
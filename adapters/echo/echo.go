@@ -2,9 +2,14 @@ package echo
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"go.rumenx.com/feed"
+	"go.rumenx.com/feed/activitypub"
+	"go.rumenx.com/feed/registry"
+	"go.rumenx.com/feed/sitemap"
 )
 
 // FeedGenerator is a function that generates a feed
@@ -24,7 +29,7 @@ func Feed(generator FeedGenerator) echo.HandlerFunc {
 		}
 
 		c.Response().Header().Set("Content-Type", "application/xml; charset=utf-8")
-		c.Response().Header().Set("Cache-Control", "public, max-age=3600")
+		c.Response().Header().Set("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
 		return c.Blob(http.StatusOK, "application/xml; charset=utf-8", rss)
 	}
 }
@@ -43,7 +48,7 @@ func AtomFeed(generator FeedGenerator) echo.HandlerFunc {
 		}
 
 		c.Response().Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
-		c.Response().Header().Set("Cache-Control", "public, max-age=3600")
+		c.Response().Header().Set("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
 		return c.Blob(http.StatusOK, "application/atom+xml; charset=utf-8", atom)
 	}
 }
@@ -69,7 +74,7 @@ func FeedWithFormat(generator FeedGenerator) echo.HandlerFunc {
 				return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 			}
 			c.Response().Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
-			c.Response().Header().Set("Cache-Control", "public, max-age=3600")
+			c.Response().Header().Set("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
 			return c.Blob(http.StatusOK, "application/atom+xml; charset=utf-8", atom)
 
 		case "rss":
@@ -80,8 +85,157 @@ func FeedWithFormat(generator FeedGenerator) echo.HandlerFunc {
 				return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 			}
 			c.Response().Header().Set("Content-Type", "application/xml; charset=utf-8")
-			c.Response().Header().Set("Cache-Control", "public, max-age=3600")
+			c.Response().Header().Set("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
 			return c.Blob(http.StatusOK, "application/xml; charset=utf-8", rss)
 		}
 	}
 }
+
+// SnapshotSource returns an immutable feed snapshot, such as (*feed.SafeFeed).Snapshot
+type SnapshotSource func() *feed.Snapshot
+
+// SnapshotFeed creates an Echo handler that serves a feed snapshot in the format
+// given by the 'format' query parameter (RSS by default).
+// Rendering works on an immutable snapshot, so it never blocks feed updates.
+func SnapshotFeed(source SnapshotSource) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		s := source()
+		if s == nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to generate feed"})
+		}
+
+		data, contentType, err := render(s, c.QueryParam("format"))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+
+		c.Response().Header().Set("Content-Type", contentType)
+		c.Response().Header().Set("Cache-Control", cacheControl(s.MaxAge(time.Hour)))
+		return c.Blob(http.StatusOK, contentType, data)
+	}
+}
+
+// DeltaFeed creates an Echo handler that serves a feed in the format given by
+// the 'format' query parameter (RSS by default) with an ETag and RFC 3229
+// delta encoding: clients sending "A-IM: feed" with the ETag of a version
+// remembered by versions get 226 IM Used with only the new and changed
+// items. A nil index remembers the last 64 versions.
+func DeltaFeed(generator FeedGenerator, versions *feed.VersionIndex) echo.HandlerFunc {
+	if versions == nil {
+		versions = feed.NewVersionIndex(64)
+	}
+	return func(c echo.Context) error {
+		f := generator()
+		if f == nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to generate feed"})
+		}
+
+		format := feed.FormatRSS
+		if c.QueryParam("format") == "atom" {
+			format = feed.FormatAtom
+		}
+		req := c.Request()
+		resp, err := versions.Respond(req.URL.Path, f, format, req.Header.Get("If-None-Match"), req.Header.Get("A-IM"))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+
+		c.Response().Header().Set("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
+		for name, values := range resp.Header {
+			c.Response().Header()[name] = values
+		}
+		return c.Blob(resp.Status, format.ContentType(), resp.Body)
+	}
+}
+
+// cacheControl returns a public Cache-Control value for the given lifetime
+func cacheControl(maxAge time.Duration) string {
+	return "public, max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+}
+
+// render renders r in the given format and returns the matching content type
+func render(r feed.Renderer, format string) ([]byte, string, error) {
+	if format == "atom" {
+		data, err := r.Atom()
+		return data, "application/atom+xml; charset=utf-8", err
+	}
+	data, err := r.RSS()
+	return data, "application/xml; charset=utf-8", err
+}
+
+// Registry creates an Echo handler that serves all feeds of a registry.
+// Route it with a wildcard below the registry prefix, e.g.
+// e.GET("/feeds/*", echo.Registry(reg)).
+func Registry(reg *registry.Registry) echo.HandlerFunc {
+	return echo.WrapHandler(reg)
+}
+
+// Sitemap creates a Echo handler that serves an XML sitemap of the feed, or a
+// sitemap index with numbered pages when it does not fit in one file
+func Sitemap(generator FeedGenerator, configure ...func(*sitemap.Builder)) echo.HandlerFunc {
+	return echo.WrapHandler(sitemap.Handler(generator, configure...))
+}
+
+// NewsSitemap creates a Echo handler that serves a Google News sitemap of the
+// items published in the last 48 hours
+func NewsSitemap(generator FeedGenerator, configure ...func(*sitemap.Builder)) echo.HandlerFunc {
+	return echo.WrapHandler(sitemap.NewsHandler(generator, configure...))
+}
+
+// ActivityPubActor creates a Echo handler that serves the ActivityPub actor
+// document to clients asking for application/activity+json and redirects
+// browsers to the profile page
+func ActivityPubActor(generator FeedGenerator, actor activitypub.Actor, configure ...func(*activitypub.Builder)) echo.HandlerFunc {
+	return echo.WrapHandler(activitypub.ActorHandler(generator, actor, configure...))
+}
+
+// ActivityPubOutbox creates a Echo handler that serves the feed items as an
+// ActivityPub outbox, one page at a time
+func ActivityPubOutbox(generator FeedGenerator, actor activitypub.Actor, configure ...func(*activitypub.Builder)) echo.HandlerFunc {
+	return echo.WrapHandler(activitypub.OutboxHandler(generator, actor, configure...))
+}
+
+// WebFinger creates a Echo handler that answers WebFinger lookups of the actor.
+// Route it at /.well-known/webfinger.
+func WebFinger(generator FeedGenerator, actor activitypub.Actor, configure ...func(*activitypub.Builder)) echo.HandlerFunc {
+	return echo.WrapHandler(activitypub.WebFingerHandler(generator, actor, configure...))
+}
+
+// Stylesheet creates an Echo handler that serves the built-in XSL stylesheet.
+// Route it at the URL passed to (*feed.Feed).SetStylesheet.
+func Stylesheet() echo.HandlerFunc {
+	xsl := feed.DefaultStylesheet()
+	return func(c echo.Context) error {
+		c.Response().Header().Set("Cache-Control", "public, max-age=86400")
+		return c.Blob(http.StatusOK, feed.StylesheetContentType, xsl)
+	}
+}
+
+// PreviewFeed creates an Echo handler that serves an HTML preview of the feed
+// to web browsers and the feed itself, in the format given by the 'format'
+// query parameter, to everything else
+func PreviewFeed(generator FeedGenerator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		f := generator()
+		if f == nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to generate feed"})
+		}
+
+		var data []byte
+		var contentType string
+		var err error
+		if feed.IsBrowserRequest(c.Request()) {
+			data, err = f.HTML(feed.RequestURL(c.Request()))
+			contentType = "text/html; charset=utf-8"
+		} else {
+			data, contentType, err = render(f, c.QueryParam("format"))
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+
+		c.Response().Header().Set("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
+		c.Response().Header().Set("Vary", "Accept, User-Agent")
+		return c.Blob(http.StatusOK, contentType, data)
+	}
+}
@@ -1,8 +1,16 @@
 package fiber
 
 import (
+	"net/http"
+	"strconv"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"go.rumenx.com/feed"
+	"go.rumenx.com/feed/activitypub"
+	"go.rumenx.com/feed/registry"
+	"go.rumenx.com/feed/sitemap"
 )
 
 // FeedGenerator is a function that generates a feed
@@ -22,6 +30,7 @@ func Feed(generator FeedGenerator) fiber.Handler {
 		}
 
 		c.Set("Content-Type", "application/xml")
+		c.Set("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
 		return c.Send(rss)
 	}
 }
@@ -40,6 +49,7 @@ func AtomFeed(generator FeedGenerator) fiber.Handler {
 		}
 
 		c.Set("Content-Type", "application/atom+xml")
+		c.Set("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
 		return c.Send(atom)
 	}
 }
@@ -61,6 +71,7 @@ func FeedWithFormat(generator FeedGenerator) fiber.Handler {
 				return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 			}
 			c.Set("Content-Type", "application/atom+xml")
+			c.Set("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
 			return c.Send(atom)
 
 		case "rss":
@@ -71,7 +82,162 @@ func FeedWithFormat(generator FeedGenerator) fiber.Handler {
 				return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 			}
 			c.Set("Content-Type", "application/xml")
+			c.Set("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
 			return c.Send(rss)
 		}
 	}
 }
+
+// SnapshotSource returns an immutable feed snapshot, such as (*feed.SafeFeed).Snapshot
+type SnapshotSource func() *feed.Snapshot
+
+// SnapshotFeed returns a Fiber handler that serves a feed snapshot in the format
+// given by the 'format' query parameter (RSS by default).
+// Rendering works on an immutable snapshot, so it never blocks feed updates.
+func SnapshotFeed(source SnapshotSource) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		s := source()
+		if s == nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to generate feed"})
+		}
+
+		data, contentType, err := render(s, c.Query("format", "rss"))
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		c.Set("Content-Type", contentType)
+		c.Set("Cache-Control", cacheControl(s.MaxAge(time.Hour)))
+		return c.Send(data)
+	}
+}
+
+// DeltaFeed returns a Fiber handler that serves a feed in the format given by
+// the 'format' query parameter (RSS by default) with an ETag and RFC 3229
+// delta encoding: clients sending "A-IM: feed" with the ETag of a version
+// remembered by versions get 226 IM Used with only the new and changed
+// items. A nil index remembers the last 64 versions.
+func DeltaFeed(generator FeedGenerator, versions *feed.VersionIndex) fiber.Handler {
+	if versions == nil {
+		versions = feed.NewVersionIndex(64)
+	}
+	return func(c *fiber.Ctx) error {
+		f := generator()
+		if f == nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to generate feed"})
+		}
+
+		format := feed.FormatRSS
+		if c.Query("format") == "atom" {
+			format = feed.FormatAtom
+		}
+		resp, err := versions.Respond(c.Path(), f, format, c.Get("If-None-Match"), c.Get("A-IM"))
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		c.Set("Content-Type", format.ContentType())
+		c.Set("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
+		for name := range resp.Header {
+			c.Set(name, resp.Header.Get(name))
+		}
+		return c.Status(resp.Status).Send(resp.Body)
+	}
+}
+
+// cacheControl returns a public Cache-Control value for the given lifetime
+func cacheControl(maxAge time.Duration) string {
+	return "public, max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+}
+
+// render renders r in the given format and returns the matching content type
+func render(r feed.Renderer, format string) ([]byte, string, error) {
+	if format == "atom" {
+		data, err := r.Atom()
+		return data, "application/atom+xml", err
+	}
+	data, err := r.RSS()
+	return data, "application/xml", err
+}
+
+// Registry returns a Fiber handler that serves all feeds of a registry.
+// Route it with a wildcard below the registry prefix, e.g.
+// app.Get("/feeds/*", fiber.Registry(reg)).
+func Registry(reg *registry.Registry) fiber.Handler {
+	return adaptor.HTTPHandler(reg)
+}
+
+// Sitemap returns a Fiber handler that serves an XML sitemap of the feed, or a
+// sitemap index with numbered pages when it does not fit in one file
+func Sitemap(generator FeedGenerator, configure ...func(*sitemap.Builder)) fiber.Handler {
+	return adaptor.HTTPHandler(sitemap.Handler(generator, configure...))
+}
+
+// NewsSitemap returns a Fiber handler that serves a Google News sitemap of the
+// items published in the last 48 hours
+func NewsSitemap(generator FeedGenerator, configure ...func(*sitemap.Builder)) fiber.Handler {
+	return adaptor.HTTPHandler(sitemap.NewsHandler(generator, configure...))
+}
+
+// ActivityPubActor returns a Fiber handler that serves the ActivityPub actor
+// document to clients asking for application/activity+json and redirects
+// browsers to the profile page
+func ActivityPubActor(generator FeedGenerator, actor activitypub.Actor, configure ...func(*activitypub.Builder)) fiber.Handler {
+	return adaptor.HTTPHandler(activitypub.ActorHandler(generator, actor, configure...))
+}
+
+// ActivityPubOutbox returns a Fiber handler that serves the feed items as an
+// ActivityPub outbox, one page at a time
+func ActivityPubOutbox(generator FeedGenerator, actor activitypub.Actor, configure ...func(*activitypub.Builder)) fiber.Handler {
+	return adaptor.HTTPHandler(activitypub.OutboxHandler(generator, actor, configure...))
+}
+
+// WebFinger returns a Fiber handler that answers WebFinger lookups of the actor.
+// Route it at /.well-known/webfinger.
+func WebFinger(generator FeedGenerator, actor activitypub.Actor, configure ...func(*activitypub.Builder)) fiber.Handler {
+	return adaptor.HTTPHandler(activitypub.WebFingerHandler(generator, actor, configure...))
+}
+
+// Stylesheet returns a Fiber handler that serves the built-in XSL stylesheet.
+// Route it at the URL passed to (*feed.Feed).SetStylesheet.
+func Stylesheet() fiber.Handler {
+	xsl := feed.DefaultStylesheet()
+	return func(c *fiber.Ctx) error {
+		c.Set("Content-Type", feed.StylesheetContentType)
+		c.Set("Cache-Control", "public, max-age=86400")
+		return c.Send(xsl)
+	}
+}
+
+// PreviewFeed returns a Fiber handler that serves an HTML preview of the feed
+// to web browsers and the feed itself, in the format given by the 'format'
+// query parameter, to everything else
+func PreviewFeed(generator FeedGenerator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		f := generator()
+		if f == nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to generate feed"})
+		}
+
+		var data []byte
+		var contentType string
+		var err error
+		if feed.IsBrowser(c.Get("Accept"), c.Get("User-Agent")) {
+			var req *http.Request
+			if req, err = adaptor.ConvertRequest(c, false); err == nil {
+				data, err = f.HTML(feed.RequestURL(req))
+			}
+			contentType = "text/html; charset=utf-8"
+		} else {
+			data, contentType, err = render(f, c.Query("format", "rss"))
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		c.Set("Content-Type", contentType)
+		c.Set("Vary", "Accept, User-Agent")
+		c.Set("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
+		return c.Send(data)
+	}
+}
@@ -2,9 +2,14 @@ package gin
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.rumenx.com/feed"
+	"go.rumenx.com/feed/activitypub"
+	"go.rumenx.com/feed/registry"
+	"go.rumenx.com/feed/sitemap"
 )
 
 // FeedGenerator is a function that generates a feed
@@ -26,6 +31,7 @@ func Feed(generator FeedGenerator) gin.HandlerFunc {
 		}
 
 		c.Header("Content-Type", "application/xml")
+		c.Header("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
 		c.Data(http.StatusOK, "application/xml", rss)
 	}
 }
@@ -46,6 +52,7 @@ func AtomFeed(generator FeedGenerator) gin.HandlerFunc {
 		}
 
 		c.Header("Content-Type", "application/atom+xml")
+		c.Header("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
 		c.Data(http.StatusOK, "application/atom+xml", atom)
 	}
 }
@@ -69,6 +76,7 @@ func FeedWithFormat(generator FeedGenerator) gin.HandlerFunc {
 				return
 			}
 			c.Header("Content-Type", "application/atom+xml")
+			c.Header("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
 			c.Data(http.StatusOK, "application/atom+xml", atom)
 
 		case "rss":
@@ -80,7 +88,162 @@ func FeedWithFormat(generator FeedGenerator) gin.HandlerFunc {
 				return
 			}
 			c.Header("Content-Type", "application/xml")
+			c.Header("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
 			c.Data(http.StatusOK, "application/xml", rss)
 		}
 	}
 }
+
+// SnapshotSource returns an immutable feed snapshot, such as (*feed.SafeFeed).Snapshot
+type SnapshotSource func() *feed.Snapshot
+
+// SnapshotFeed returns a Gin handler that serves a feed snapshot in the format
+// given by the 'format' query parameter (RSS by default).
+// Rendering works on an immutable snapshot, so it never blocks feed updates.
+func SnapshotFeed(source SnapshotSource) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s := source()
+		if s == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate feed"})
+			return
+		}
+
+		data, contentType, err := render(s, c.DefaultQuery("format", "rss"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Header("Content-Type", contentType)
+		c.Header("Cache-Control", cacheControl(s.MaxAge(time.Hour)))
+		c.Data(http.StatusOK, contentType, data)
+	}
+}
+
+// DeltaFeed returns a Gin handler that serves a feed in the format given by
+// the 'format' query parameter (RSS by default) with an ETag and RFC 3229
+// delta encoding: clients sending "A-IM: feed" with the ETag of a version
+// remembered by versions get 226 IM Used with only the new and changed
+// items. A nil index remembers the last 64 versions.
+func DeltaFeed(generator FeedGenerator, versions *feed.VersionIndex) gin.HandlerFunc {
+	if versions == nil {
+		versions = feed.NewVersionIndex(64)
+	}
+	return func(c *gin.Context) {
+		f := generator()
+		if f == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate feed"})
+			return
+		}
+
+		format := feed.FormatRSS
+		if c.Query("format") == "atom" {
+			format = feed.FormatAtom
+		}
+		resp, err := versions.Respond(c.Request.URL.Path, f, format, c.GetHeader("If-None-Match"), c.GetHeader("A-IM"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Header("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
+		for name, values := range resp.Header {
+			c.Writer.Header()[name] = values
+		}
+		c.Data(resp.Status, format.ContentType(), resp.Body)
+	}
+}
+
+// cacheControl returns a public Cache-Control value for the given lifetime
+func cacheControl(maxAge time.Duration) string {
+	return "public, max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+}
+
+// render renders r in the given format and returns the matching content type
+func render(r feed.Renderer, format string) ([]byte, string, error) {
+	if format == "atom" {
+		data, err := r.Atom()
+		return data, "application/atom+xml", err
+	}
+	data, err := r.RSS()
+	return data, "application/xml", err
+}
+
+// Registry returns a Gin handler that serves all feeds of a registry.
+// Route it with a wildcard below the registry prefix, e.g.
+// r.GET("/feeds/*path", gin.Registry(reg)).
+func Registry(reg *registry.Registry) gin.HandlerFunc {
+	return gin.WrapH(reg)
+}
+
+// Sitemap returns a Gin handler that serves an XML sitemap of the feed, or a
+// sitemap index with numbered pages when it does not fit in one file
+func Sitemap(generator FeedGenerator, configure ...func(*sitemap.Builder)) gin.HandlerFunc {
+	return gin.WrapH(sitemap.Handler(generator, configure...))
+}
+
+// NewsSitemap returns a Gin handler that serves a Google News sitemap of the
+// items published in the last 48 hours
+func NewsSitemap(generator FeedGenerator, configure ...func(*sitemap.Builder)) gin.HandlerFunc {
+	return gin.WrapH(sitemap.NewsHandler(generator, configure...))
+}
+
+// ActivityPubActor returns a Gin handler that serves the ActivityPub actor
+// document to clients asking for application/activity+json and redirects
+// browsers to the profile page
+func ActivityPubActor(generator FeedGenerator, actor activitypub.Actor, configure ...func(*activitypub.Builder)) gin.HandlerFunc {
+	return gin.WrapH(activitypub.ActorHandler(generator, actor, configure...))
+}
+
+// ActivityPubOutbox returns a Gin handler that serves the feed items as an
+// ActivityPub outbox, one page at a time
+func ActivityPubOutbox(generator FeedGenerator, actor activitypub.Actor, configure ...func(*activitypub.Builder)) gin.HandlerFunc {
+	return gin.WrapH(activitypub.OutboxHandler(generator, actor, configure...))
+}
+
+// WebFinger returns a Gin handler that answers WebFinger lookups of the actor.
+// Route it at /.well-known/webfinger.
+func WebFinger(generator FeedGenerator, actor activitypub.Actor, configure ...func(*activitypub.Builder)) gin.HandlerFunc {
+	return gin.WrapH(activitypub.WebFingerHandler(generator, actor, configure...))
+}
+
+// Stylesheet returns a Gin handler that serves the built-in XSL stylesheet.
+// Route it at the URL passed to (*feed.Feed).SetStylesheet.
+func Stylesheet() gin.HandlerFunc {
+	xsl := feed.DefaultStylesheet()
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=86400")
+		c.Data(http.StatusOK, feed.StylesheetContentType, xsl)
+	}
+}
+
+// PreviewFeed returns a Gin handler that serves an HTML preview of the feed
+// to web browsers and the feed itself, in the format given by the 'format'
+// query parameter, to everything else
+func PreviewFeed(generator FeedGenerator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f := generator()
+		if f == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate feed"})
+			return
+		}
+
+		var data []byte
+		var contentType string
+		var err error
+		if feed.IsBrowserRequest(c.Request) {
+			data, err = f.HTML(feed.RequestURL(c.Request))
+			contentType = "text/html; charset=utf-8"
+		} else {
+			data, contentType, err = render(f, c.DefaultQuery("format", "rss"))
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Header("Vary", "Accept, User-Agent")
+		c.Header("Cache-Control", cacheControl(f.MaxAge(time.Hour)))
+		c.Data(http.StatusOK, contentType, data)
+	}
+}
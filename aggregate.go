@@ -0,0 +1,178 @@
+package feed
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Aggregator merges items from many feeds into a single "planet" feed.
+// Items are attributed to their originating feed, interleaved by date and
+// deduplicated across sources.
+type Aggregator struct {
+	sources      []aggregateSource
+	maxPerSource int
+}
+
+type aggregateSource struct {
+	feed *Feed
+	url  string
+}
+
+// aggregateItem is a merged item and the index of the source it came from
+type aggregateItem struct {
+	item   Item
+	source int
+}
+
+// NewAggregator creates a new Aggregator
+func NewAggregator() *Aggregator {
+	return &Aggregator{}
+}
+
+// AddFeed adds a source feed. Its link is used for attribution.
+func (a *Aggregator) AddFeed(f *Feed) *Aggregator {
+	return a.AddFeedURL(f, "")
+}
+
+// AddFeedURL adds a source feed along with the URL the feed itself is served
+// from, which is used for attribution instead of the feed's site link
+func (a *Aggregator) AddFeedURL(f *Feed, url string) *Aggregator {
+	if f != nil {
+		a.sources = append(a.sources, aggregateSource{feed: f, url: url})
+	}
+	return a
+}
+
+// SetMaxPerSource caps the number of items taken from each source feed.
+// The newest items are kept; zero or a negative value means no limit.
+func (a *Aggregator) SetMaxPerSource(n int) *Aggregator {
+	a.maxPerSource = n
+	return a
+}
+
+// Build merges the source feeds into a new feed.
+// The result contains copies of the source items, sorted newest first, with
+// Item.Source filled in where the source item did not already carry one.
+// Cross-posted entries are detected by GUID, link and normalized title across
+// different sources, and only the earliest published copy is kept. The caller is expected to set
+// the title, description and link of the returned feed.
+func (a *Aggregator) Build() *Feed {
+	out := New()
+
+	var merged []aggregateItem
+	for i, src := range a.sources {
+		items := make([]Item, 0, len(src.feed.items))
+		for _, item := range src.feed.items {
+			item = item.Clone()
+			if item.Source == nil {
+				item.Source = src.attribution()
+			}
+			items = append(items, item)
+		}
+
+		sortByDate(items)
+		if a.maxPerSource > 0 && len(items) > a.maxPerSource {
+			items = items[:a.maxPerSource]
+		}
+		for _, item := range items {
+			merged = append(merged, aggregateItem{item: item, source: i})
+		}
+	}
+
+	// Walk oldest first so the original of a cross-post wins. Undated items
+	// come last, so an undated copy never beats a dated original.
+	sort.SliceStable(merged, func(i, j int) bool {
+		a, b := merged[i].item.PubDate, merged[j].item.PubDate
+		if a.IsZero() || b.IsZero() {
+			return !a.IsZero() && b.IsZero()
+		}
+		return a.Before(b)
+	})
+
+	// Keys are only compared across sources: two posts of one feed that
+	// share a title are different posts
+	owner := make(map[string]int)
+	for _, m := range merged {
+		keys := aggregateKeys(m.item)
+		duplicate := false
+		for _, key := range keys {
+			if src, ok := owner[key]; ok && src != m.source {
+				duplicate = true
+				break
+			}
+		}
+		for _, key := range keys {
+			if _, ok := owner[key]; !ok {
+				owner[key] = m.source
+			}
+		}
+		if !duplicate {
+			out.items = append(out.items, m.item)
+		}
+	}
+
+	out.SortByDate()
+	if len(out.items) > 0 && !out.items[0].PubDate.IsZero() {
+		out.lastBuildDate = out.items[0].PubDate
+	}
+
+	return out
+}
+
+// attribution describes the source feed for Item.Source
+func (s aggregateSource) attribution() *Source {
+	url := s.url
+	if url == "" {
+		url = s.feed.link
+	}
+	return &Source{
+		URL:       url,
+		Value:     s.feed.title,
+		ID:        s.feed.link,
+		Updated:   s.feed.lastBuildDate,
+		IsFeedURL: s.url != "",
+	}
+}
+
+// sortByDate sorts items newest first, keeping the order of equal dates
+func sortByDate(items []Item) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].PubDate.After(items[j].PubDate)
+	})
+}
+
+// aggregateKeys returns the identities used to detect cross-posted items
+func aggregateKeys(item Item) []string {
+	var keys []string
+	if item.GUID != "" {
+		keys = append(keys, "guid:"+item.GUID)
+	}
+	if item.Link != "" {
+		keys = append(keys, "link:"+item.Link)
+	}
+	if title := normalizeTitle(item.Title); title != "" {
+		keys = append(keys, "title:"+title)
+	}
+	return keys
+}
+
+// normalizeTitle lowercases a title and reduces it to letters and digits
+// separated by single spaces, so trivial formatting differences match
+func normalizeTitle(title string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			space = false
+		default:
+			space = true
+		}
+	}
+	return b.String()
+}
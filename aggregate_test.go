@@ -0,0 +1,207 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func newSourceFeed(title, link string, items ...Item) *Feed {
+	f := New()
+	f.SetTitle(title).
+		SetDescription(title + " posts").
+		SetLink(link).
+		SetLastBuildDate(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC))
+	f.AddItems(items)
+	return f
+}
+
+func TestAggregatorMergesAndAttributes(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	alice := newSourceFeed("Alice", "https://alice.example.com",
+		Item{Title: "Alice 1", Link: "https://alice.example.com/1", PubDate: base},
+		Item{Title: "Alice 2", Link: "https://alice.example.com/2", PubDate: base.Add(2 * time.Hour)},
+	)
+	bob := newSourceFeed("Bob", "https://bob.example.com",
+		Item{Title: "Bob 1", Link: "https://bob.example.com/1", PubDate: base.Add(time.Hour)},
+	)
+
+	planet := NewAggregator().
+		AddFeed(alice).
+		AddFeedURL(bob, "https://bob.example.com/feed.xml").
+		Build()
+
+	if got := itemTitles(planet.GetItems()); got != "Alice 2,Bob 1,Alice 1" {
+		t.Errorf("Expected interleaved items, got %s", got)
+	}
+
+	if !planet.GetLastBuildDate().Equal(base.Add(2 * time.Hour)) {
+		t.Errorf("Expected last build date of newest item, got %v", planet.GetLastBuildDate())
+	}
+
+	src := planet.GetItems()[1].Source
+	if src == nil {
+		t.Fatal("Expected item source to be set")
+	}
+	if src.URL != "https://bob.example.com/feed.xml" || src.Value != "Bob" || src.ID != "https://bob.example.com" {
+		t.Errorf("Unexpected source attribution: %+v", src)
+	}
+
+	// Source feeds must not be modified
+	if alice.GetItems()[0].Source != nil {
+		t.Error("Aggregation should not modify source items")
+	}
+}
+
+func TestAggregatorKeepsExistingSource(t *testing.T) {
+	orig := &Source{URL: "https://origin.example.com/feed.xml", Value: "Origin"}
+	f := newSourceFeed("Relay", "https://relay.example.com",
+		Item{Title: "Relayed", Link: "https://origin.example.com/1", Source: orig},
+	)
+
+	planet := NewAggregator().AddFeed(f).Build()
+	if planet.GetItems()[0].Source.Value != "Origin" {
+		t.Error("Existing item source should be preserved")
+	}
+}
+
+func TestAggregatorDedupe(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	a := newSourceFeed("A", "https://a.example.com",
+		Item{Title: "Shared GUID", GUID: "guid-1", Link: "https://a.example.com/1", PubDate: base},
+		Item{Title: "Hello, World!", Link: "https://a.example.com/hello", PubDate: base.Add(time.Hour)},
+		Item{Title: "Same link", Link: "https://shared.example.com/post", PubDate: base.Add(2 * time.Hour)},
+	)
+	b := newSourceFeed("B", "https://b.example.com",
+		Item{Title: "Shared GUID copy", GUID: "guid-1", Link: "https://b.example.com/1", PubDate: base.Add(3 * time.Hour)},
+		Item{Title: "hello   world", Link: "https://b.example.com/hello", PubDate: base.Add(4 * time.Hour)},
+		Item{Title: "Same link copy", Link: "https://shared.example.com/post", PubDate: base.Add(5 * time.Hour)},
+		Item{Title: "Unique", Link: "https://b.example.com/unique", PubDate: base.Add(6 * time.Hour)},
+	)
+
+	planet := NewAggregator().AddFeed(a).AddFeed(b).Build()
+
+	if got := itemTitles(planet.GetItems()); got != "Unique,Same link,Hello, World!,Shared GUID" {
+		t.Errorf("Expected cross-posts to keep the earliest copy, got %s", got)
+	}
+}
+
+func TestAggregatorKeepsSameSourcePosts(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	a := newSourceFeed("A", "https://a.example.com",
+		Item{Title: "Weekly update", Link: "https://a.example.com/week-1", PubDate: base},
+		Item{Title: "Weekly update", Link: "https://a.example.com/week-2", PubDate: base.Add(7 * 24 * time.Hour)},
+	)
+
+	planet := NewAggregator().AddFeed(a).Build()
+	if len(planet.GetItems()) != 2 {
+		t.Errorf("Expected both posts of one source to be kept, got %d", len(planet.GetItems()))
+	}
+}
+
+func TestAggregatorUndatedCopy(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	a := newSourceFeed("A", "https://a.example.com",
+		Item{Title: "Original", GUID: "guid-1", Link: "https://a.example.com/1", PubDate: base},
+	)
+	b := newSourceFeed("B", "https://b.example.com",
+		Item{Title: "Undated copy", GUID: "guid-1", Link: "https://b.example.com/1"},
+	)
+
+	planet := NewAggregator().AddFeed(b).AddFeed(a).Build()
+	if got := itemTitles(planet.GetItems()); got != "Original" {
+		t.Errorf("Expected the dated original to win over an undated copy, got %s", got)
+	}
+}
+
+func TestAggregatorMaxPerSource(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	busy := newSourceFeed("Busy", "https://busy.example.com")
+	for i := 0; i < 5; i++ {
+		busy.AddItem(Item{
+			Title:   "Busy " + string(rune('A'+i)),
+			Link:    "https://busy.example.com/" + string(rune('a'+i)),
+			PubDate: base.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	quiet := newSourceFeed("Quiet", "https://quiet.example.com",
+		Item{Title: "Quiet A", Link: "https://quiet.example.com/a", PubDate: base},
+	)
+
+	planet := NewAggregator().AddFeed(busy).AddFeed(quiet).SetMaxPerSource(2).Build()
+
+	if got := itemTitles(planet.GetItems()); got != "Busy E,Busy D,Quiet A" {
+		t.Errorf("Expected newest two items per source, got %s", got)
+	}
+}
+
+func TestAggregatorAtomSource(t *testing.T) {
+	f := newSourceFeed("Alice", "https://alice.example.com",
+		Item{Title: "Post", Link: "https://alice.example.com/post", PubDate: time.Now()},
+	)
+
+	planet := NewAggregator().AddFeedURL(f, "https://alice.example.com/atom.xml").Build()
+	planet.SetTitle("Planet").SetDescription("All the blogs").SetLink("https://planet.example.com")
+
+	atom, err := planet.Atom()
+	if err != nil {
+		t.Fatalf("Atom generation failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"<source>",
+		"<id>https://alice.example.com</id>",
+		"<title>Alice</title>",
+		`<link href="https://alice.example.com/atom.xml" rel="self"></link>`,
+		"<updated>2025-02-01T00:00:00Z</updated>",
+	} {
+		if !strings.Contains(string(atom), want) {
+			t.Errorf("Atom output should contain %s", want)
+		}
+	}
+
+	rss, err := planet.RSS()
+	if err != nil {
+		t.Fatalf("RSS generation failed: %v", err)
+	}
+	if !strings.Contains(string(rss), `<source url="https://alice.example.com/atom.xml">Alice</source>`) {
+		t.Error("RSS output should contain item source")
+	}
+}
+
+func TestAggregatorAtomSourceSiteLink(t *testing.T) {
+	f := newSourceFeed("Alice", "https://alice.example.com",
+		Item{Title: "Post", Link: "https://alice.example.com/post", PubDate: time.Now()},
+	)
+
+	planet := NewAggregator().AddFeed(f).Build()
+	planet.SetTitle("Planet").SetDescription("All the blogs").SetLink("https://planet.example.com")
+
+	atom, err := planet.Atom()
+	if err != nil {
+		t.Fatalf("Atom generation failed: %v", err)
+	}
+	if !strings.Contains(string(atom), `<link href="https://alice.example.com" rel="alternate"></link>`) {
+		t.Errorf("Atom source should link to the site as alternate, got:\n%s", atom)
+	}
+}
+
+func TestNormalizeTitle(t *testing.T) {
+	tests := map[string]string{
+		"Hello, World!":   "hello world",
+		"  hello\tworld ": "hello world",
+		"Go 1.22 Release": "go 1 22 release",
+		"Привет, мир":     "привет мир",
+		"!!!":             "",
+	}
+	for in, want := range tests {
+		if got := normalizeTitle(in); got != want {
+			t.Errorf("normalizeTitle(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+<?xml version="1.0" encoding="UTF-8"?>
+<!--
+  Default go-feed stylesheet. Browsers apply it to RSS 2.0 and Atom 1.0
+  feeds that reference it through an xml-stylesheet processing instruction,
+  turning the raw XML into a readable page with subscribe instructions.
+-->
+<xsl:stylesheet version="1.0"
+  xmlns:xsl="http://www.w3.org/1999/XSL/Transform"
+  xmlns:atom="http://www.w3.org/2005/Atom">
+  <xsl:output method="html" version="1.0" encoding="UTF-8" indent="yes"/>
+
+  <xsl:template match="/">
+    <html lang="en">
+      <head>
+        <meta charset="UTF-8"/>
+        <meta name="viewport" content="width=device-width, initial-scale=1"/>
+        <title>
+          <xsl:value-of select="/rss/channel/title | /atom:feed/atom:title"/>
+          <xsl:text> (feed)</xsl:text>
+        </title>
+        <style>
+          body { font-family: system-ui, sans-serif; max-width: 48rem; margin: 2rem auto; padding: 0 1rem; color: #222; line-height: 1.5; }
+          .notice { background: #fff8e1; border: 1px solid #f0c36d; border-radius: 6px; padding: 1rem; margin-bottom: 2rem; }
+          .notice code { background: #fff; padding: 0.1rem 0.3rem; border-radius: 3px; word-break: break-all; }
+          h1 { margin-bottom: 0.25rem; }
+          .description { color: #555; margin-top: 0; }
+          article { border-top: 1px solid #eee; padding: 1rem 0; }
+          article h2 { font-size: 1.2rem; margin: 0 0 0.25rem; }
+          time { color: #777; font-size: 0.9rem; }
+        </style>
+      </head>
+      <body>
+        <div class="notice">
+          <strong>This is a web feed.</strong>
+          Subscribe by copying the address of this page into your feed reader.
+          Visit <a href="https://aboutfeeds.com/">About Feeds</a> to learn more and get started.
+        </div>
+        <xsl:apply-templates select="/rss/channel | /atom:feed"/>
+      </body>
+    </html>
+  </xsl:template>
+
+  <!-- RSS 2.0 -->
+  <xsl:template match="channel">
+    <header>
+      <h1><a href="{link}"><xsl:value-of select="title"/></a></h1>
+      <p class="description"><xsl:value-of select="description"/></p>
+    </header>
+    <xsl:for-each select="item">
+      <article>
+        <h2><a href="{link}"><xsl:value-of select="title"/></a></h2>
+        <time><xsl:value-of select="pubDate"/></time>
+      </article>
+    </xsl:for-each>
+  </xsl:template>
+
+  <!-- Atom 1.0 -->
+  <xsl:template match="atom:feed">
+    <header>
+      <h1>
+        <a href="{atom:link[@rel='alternate']/@href | atom:link[not(@rel)]/@href}">
+          <xsl:value-of select="atom:title"/>
+        </a>
+      </h1>
+      <p class="description"><xsl:value-of select="atom:subtitle"/></p>
+    </header>
+    <xsl:for-each select="atom:entry">
+      <article>
+        <h2>
+          <a href="{atom:link[@rel='alternate']/@href | atom:link[not(@rel)]/@href}">
+            <xsl:value-of select="atom:title"/>
+          </a>
+        </h2>
+        <time><xsl:value-of select="atom:published | atom:updated[not(../atom:published)]"/></time>
+      </article>
+    </xsl:for-each>
+  </xsl:template>
+</xsl:stylesheet>
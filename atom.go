@@ -3,21 +3,25 @@ package feed
 import (
 	"encoding/xml"
 	"fmt"
+	"net/url"
 	"time"
 )
 
 // AtomFeed represents the Atom 1.0 feed structure
 type AtomFeed struct {
-	XMLName   xml.Name       `xml:"http://www.w3.org/2005/Atom feed"`
-	Title     string         `xml:"title"`
-	Subtitle  string         `xml:"subtitle,omitempty"`
-	ID        string         `xml:"id"`
-	Link      []AtomLink     `xml:"link"`
-	Updated   string         `xml:"updated"`
-	Rights    string         `xml:"rights,omitempty"`
-	Author    *AtomAuthor    `xml:"author,omitempty"`
-	Generator *AtomGenerator `xml:"generator,omitempty"`
-	Entries   []AtomEntry    `xml:"entry"`
+	XMLName    xml.Name       `xml:"http://www.w3.org/2005/Atom feed"`
+	XMLBase    string         `xml:"xml:base,attr,omitempty"`
+	XMLNSMedia string         `xml:"xmlns:media,attr,omitempty"`
+	Title      string         `xml:"title"`
+	Subtitle   string         `xml:"subtitle,omitempty"`
+	ID         string         `xml:"id"`
+	Link       []AtomLink     `xml:"link"`
+	Updated    string         `xml:"updated"`
+	Rights     string         `xml:"rights,omitempty"`
+	Author     *AtomAuthor    `xml:"author,omitempty"`
+	Generator  *AtomGenerator `xml:"generator,omitempty"`
+	*MediaMetadata
+	Entries []AtomEntry `xml:"entry"`
 }
 
 // AtomLink represents an Atom link
@@ -43,6 +47,7 @@ type AtomGenerator struct {
 
 // AtomEntry represents an Atom entry
 type AtomEntry struct {
+	XMLBase   string         `xml:"xml:base,attr,omitempty"`
 	Title     string         `xml:"title"`
 	ID        string         `xml:"id"`
 	Link      []AtomLink     `xml:"link"`
@@ -53,6 +58,7 @@ type AtomEntry struct {
 	Author    *AtomAuthor    `xml:"author,omitempty"`
 	Category  []AtomCategory `xml:"category,omitempty"`
 	Source    *AtomSource    `xml:"source,omitempty"`
+	*Media
 }
 
 // AtomContent represents Atom content
@@ -70,7 +76,14 @@ type AtomCategory struct {
 
 // AtomSource represents an Atom source
 type AtomSource struct {
-	URI string `xml:"uri,attr"`
+	ID      string     `xml:"id,omitempty"`
+	Title   string     `xml:"title,omitempty"`
+	Link    []AtomLink `xml:"link,omitempty"`
+	Updated string     `xml:"updated,omitempty"`
+
+	// Deprecated: Atom 1.0 has no uri attribute on <source>, so URI is no
+	// longer rendered. Use Link instead.
+	URI string `xml:"-"`
 }
 
 // Atom generates Atom 1.0 XML output
@@ -79,6 +92,26 @@ func (f *Feed) Atom() ([]byte, error) {
 		return nil, err
 	}
 
+	atom := f.atomFeed()
+
+	// Convert items to entries
+	base := f.baseURL()
+	for _, item := range f.renderItems(FormatAtom) {
+		atom.Entries = append(atom.Entries, f.atomEntry(item, base))
+	}
+
+	// Generate XML with header
+	xmlData, err := xml.MarshalIndent(atom, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Atom XML: %w", err)
+	}
+
+	// Add XML declaration and stylesheet instruction
+	return append(f.xmlPreamble(), xmlData...), nil
+}
+
+// atomFeed builds the Atom document without its entries
+func (f *Feed) atomFeed() AtomFeed {
 	atom := AtomFeed{
 		Title:    f.title,
 		Subtitle: f.description,
@@ -109,67 +142,95 @@ func (f *Feed) Atom() ([]byte, error) {
 		atom.Author = parseAuthor(f.managingEditor)
 	}
 
-	// Convert items to entries
-	for _, item := range f.items {
-		entry := AtomEntry{
-			Title: item.Title,
-			ID:    item.GUID,
-			Link: []AtomLink{
-				{
-					Href: item.Link,
-					Rel:  "alternate",
-					Type: "text/html",
-				},
+	// Let readers resolve relative URLs in content
+	if f.atomXMLBase {
+		atom.XMLBase = f.link
+	}
+
+	atom.MediaMetadata = f.channelMedia()
+	if f.usesMedia() {
+		atom.XMLNSMedia = mediaNamespace
+	}
+
+	return atom
+}
+
+// atomEntry converts a rendered item into an Atom entry
+func (f *Feed) atomEntry(item Item, base *url.URL) AtomEntry {
+	entry := AtomEntry{
+		Title: item.Title,
+		ID:    item.GUID,
+		Link: []AtomLink{
+			{
+				Href: item.Link,
+				Rel:  "alternate",
+				Type: "text/html",
 			},
-			Updated:   formatRFC3339Date(item.PubDate),
-			Published: formatRFC3339Date(item.PubDate),
-			Summary:   item.Description,
-		}
+		},
+		Updated:   formatRFC3339Date(item.PubDate),
+		Published: formatRFC3339Date(item.PubDate),
+		Summary:   item.Description,
+		Media:     item.Media,
+	}
 
-		// Use link as ID if GUID is not set
-		if entry.ID == "" {
-			entry.ID = item.Link
-		}
+	// Use link as ID if GUID is not set
+	if entry.ID == "" {
+		entry.ID = item.Link
+	}
 
-		// Add content if different from summary
-		if item.Description != "" {
-			entry.Content = &AtomContent{
-				Type: "html",
-				Text: item.Description,
-			}
+	if f.atomXMLBase && item.Link != "" {
+		if itemBase := itemBaseURL(base, item); itemBase != nil {
+			// The entry link would otherwise be resolved against itself
+			entry.XMLBase = itemBase.String()
+			entry.Link[0].Href = entry.XMLBase
 		}
+	}
 
-		// Add author if available
-		if item.Author != "" {
-			entry.Author = parseAuthor(item.Author)
+	// Add full content, falling back to the description
+	if item.Content != "" {
+		entry.Content = &AtomContent{
+			Type: "html",
+			Text: item.Content,
 		}
-
-		// Add categories
-		for _, cat := range item.Categories {
-			entry.Category = append(entry.Category, AtomCategory{
-				Term: cat,
-			})
+	} else if item.Description != "" {
+		entry.Content = &AtomContent{
+			Type: "html",
+			Text: item.Description,
 		}
+	}
 
-		// Add source if available
-		if item.Source != nil {
-			entry.Source = &AtomSource{
-				URI: item.Source.URL,
-			}
-		}
+	// Add author if available
+	if item.Author != "" {
+		entry.Author = parseAuthor(item.Author)
+	}
 
-		atom.Entries = append(atom.Entries, entry)
+	// Add categories
+	for _, cat := range item.Categories {
+		entry.Category = append(entry.Category, AtomCategory{
+			Term: cat,
+		})
 	}
 
-	// Generate XML with header
-	xmlData, err := xml.MarshalIndent(atom, "", "  ")
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal Atom XML: %w", err)
+	// Add source if available
+	if item.Source != nil {
+		entry.Source = &AtomSource{
+			ID:      item.Source.ID,
+			Title:   item.Source.Value,
+			Updated: formatRFC3339Date(item.Source.Updated),
+		}
+		if entry.Source.ID == "" {
+			entry.Source.ID = item.Source.URL
+		}
+		if item.Source.URL != "" {
+			rel := "alternate"
+			if item.Source.IsFeedURL {
+				rel = "self"
+			}
+			entry.Source.Link = []AtomLink{{Href: item.Source.URL, Rel: rel}}
+		}
 	}
 
-	// Add XML declaration
-	xmlHeader := []byte(xml.Header)
-	return append(xmlHeader, xmlData...), nil
+	return entry
 }
 
 // formatRFC3339Date formats a time.Time as RFC 3339 date string (required for Atom)
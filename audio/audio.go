@@ -0,0 +1,231 @@
+// Package audio reads the technical metadata of podcast media files: byte
+// length, MIME type and duration, plus chapter markers and embedded artwork
+// when present. It understands MP3 (ID3v2 tags with Xing, Info or VBRI
+// headers, or constant bitrate), MP4 and M4A, Ogg Vorbis and Opus, and WAV,
+// without external tools.
+package audio
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.rumenx.com/feed"
+)
+
+// Common errors
+var (
+	ErrUnsupported = errors.New("unsupported media format")
+	ErrMalformed   = errors.New("malformed media file")
+)
+
+// MIME types reported by Probe
+const (
+	TypeMP3  = "audio/mpeg"
+	TypeM4A  = "audio/x-m4a"
+	TypeMP4  = "video/mp4"
+	TypeOgg  = "audio/ogg"
+	TypeOpus = "audio/opus"
+	TypeWAV  = "audio/wav"
+)
+
+// Info describes a media file
+type Info struct {
+	Length   int64  // size in bytes
+	MIMEType string // one of the Type constants
+	Duration time.Duration
+	Chapters []Chapter // sorted by start time
+	Artwork  *Artwork
+}
+
+// Chapter is a chapter marker. When the file gives no end, the chapter ends
+// where the next one starts.
+type Chapter struct {
+	Start time.Duration
+	End   time.Duration
+	Title string
+}
+
+// Artwork is an image embedded in a media file
+type Artwork struct {
+	MIMEType string
+	Data     []byte
+}
+
+// Probe reads the metadata of the media file in r. It seeks around the
+// file rather than reading the audio data, so large files are cheap.
+func Probe(r io.ReadSeeker) (*Info, error) {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to seek: %w", err)
+	}
+	head := make([]byte, 12)
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek: %w", err)
+	}
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	head = head[:n]
+
+	info := &Info{Length: size}
+	switch {
+	case bytes.HasPrefix(head, []byte("ID3")) || isFrameSync(head):
+		err = probeMP3(r, size, info)
+	case len(head) >= 8 && string(head[4:8]) == "ftyp":
+		err = probeMP4(r, size, info)
+	case bytes.HasPrefix(head, []byte("OggS")):
+		err = probeOgg(r, size, info)
+	case len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WAVE":
+		err = probeWAV(r, size, info)
+	default:
+		return nil, ErrUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Chapters without an end run until the next one, or the end of the file
+	sort.SliceStable(info.Chapters, func(i, j int) bool {
+		return info.Chapters[i].Start < info.Chapters[j].Start
+	})
+	for i := range info.Chapters {
+		if info.Chapters[i].End > 0 {
+			continue
+		}
+		if i+1 < len(info.Chapters) {
+			info.Chapters[i].End = info.Chapters[i+1].Start
+		} else {
+			info.Chapters[i].End = info.Duration
+		}
+	}
+	return info, nil
+}
+
+// ProbeFile reads the metadata of the named file in fsys
+func ProbeFile(fsys fs.FS, name string) (*Info, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rs, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		rs = bytes.NewReader(data)
+	}
+	info, err := Probe(rs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return info, nil
+}
+
+// Enclosure returns an enclosure for the file served at url
+func (info *Info) Enclosure(url string) *feed.Enclosure {
+	return &feed.Enclosure{
+		URL:    url,
+		Length: strconv.FormatInt(info.Length, 10),
+		Type:   info.MIMEType,
+	}
+}
+
+// Apply sets the item's enclosure to the file served at url and fills in
+// its iTunes duration. Artwork and chapters have to be published separately
+// and are linked with ApplyArtwork and ApplyChapters.
+func (info *Info) Apply(item *feed.Item, url string) {
+	item.Enclosure = info.Enclosure(url)
+	if info.Duration > 0 {
+		item.ITunesDuration = FormatDuration(info.Duration)
+	}
+}
+
+// ApplyArtwork links the item to the embedded artwork once the caller has
+// published Artwork.Data at url. The image is added to the item's Images,
+// used by sitemaps and ActivityPub, and as a Media RSS thumbnail rendered in
+// RSS and Atom. It does nothing when the file has no artwork.
+func (info *Info) ApplyArtwork(item *feed.Item, url string) {
+	if info.Artwork == nil || url == "" {
+		return
+	}
+	item.Images = append(item.Images, feed.Image{URL: url, Title: item.Title})
+	if item.Media == nil {
+		item.Media = &feed.Media{}
+	}
+	item.Media.Thumbnails = append(item.Media.Thumbnails, feed.MediaThumbnail{URL: url})
+}
+
+// ChaptersType is the media type of the chapters file ChaptersJSON renders
+const ChaptersType = "application/json+chapters"
+
+// ApplyChapters links the item to its chapters as podcast:chapters once the
+// caller has published ChaptersJSON at url. It does nothing when the file
+// has no chapters.
+func (info *Info) ApplyChapters(item *feed.Item, url string) {
+	if len(info.Chapters) == 0 || url == "" {
+		return
+	}
+	if item.Podcast == nil {
+		item.Podcast = &feed.PodcastItem{}
+	}
+	item.Podcast.Chapters = &feed.PodcastChapters{URL: url, Type: ChaptersType}
+}
+
+// ChaptersJSON renders the chapters as a Podcasting 2.0 JSON chapters
+// file, to be served at the URL given to ApplyChapters
+func (info *Info) ChaptersJSON() ([]byte, error) {
+	type chapter struct {
+		StartTime float64 `json:"startTime"`
+		EndTime   float64 `json:"endTime,omitempty"`
+		Title     string  `json:"title,omitempty"`
+	}
+	doc := struct {
+		Version  string    `json:"version"`
+		Chapters []chapter `json:"chapters"`
+	}{Version: "1.2.0", Chapters: []chapter{}}
+	for _, c := range info.Chapters {
+		doc.Chapters = append(doc.Chapters, chapter{
+			StartTime: c.Start.Seconds(),
+			EndTime:   c.End.Seconds(),
+			Title:     c.Title,
+		})
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// FormatDuration formats a duration as HH:MM:SS for itunes:duration,
+// rounded to whole seconds
+func FormatDuration(d time.Duration) string {
+	s := int64(d.Round(time.Second) / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", s/3600, s/60%60, s%60)
+}
+
+// readAt reads exactly len(buf) bytes at offset off
+func readAt(r io.ReadSeeker, off int64, buf []byte) error {
+	if _, err := r.Seek(off, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return fmt.Errorf("%w: unexpected end of file", ErrMalformed)
+	}
+	return nil
+}
+
+// seconds converts a count of units at the given rate to a duration
+func seconds(units, rate int64) time.Duration {
+	if rate <= 0 {
+		return 0
+	}
+	return time.Duration(units/rate)*time.Second + time.Duration(units%rate)*time.Second/time.Duration(rate)
+}
@@ -0,0 +1,277 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"go.rumenx.com/feed"
+)
+
+var png = []byte("\x89PNG\r\n\x1a\nimage")
+
+// fixture reads a file written by testdata/gen.go
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	return data
+}
+
+func probe(t *testing.T, name string) *Info {
+	t.Helper()
+	data := fixture(t, name)
+	info, err := Probe(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Probe failed: %v", err)
+	}
+	if info.Length != int64(len(data)) {
+		t.Errorf("Expected length %d, got %d", len(data), info.Length)
+	}
+	return info
+}
+
+func TestProbeFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		mime     string
+		duration time.Duration
+	}{
+		{"cbr mp3", "cbr.mp3", TypeMP3, 2606250 * time.Microsecond},
+		{"xing mp3", "xing.mp3", TypeMP3, 26122448979},
+		{"info mp3", "info.mp3", TypeMP3, 11520 * time.Millisecond},
+		{"vbri mp3", "vbri.mp3", TypeMP3, 13061224489},
+		{"m4a", "audio.m4a", TypeM4A, 90500 * time.Millisecond},
+		{"mp4 video", "video.mp4", TypeMP4, 125 * time.Second},
+		{"opus", "audio.opus", TypeOpus, 5 * time.Second},
+		{"vorbis", "audio.ogg", TypeOgg, 1500 * time.Millisecond},
+		{"wav", "audio.wav", TypeWAV, 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := probe(t, tt.file)
+			if info.MIMEType != tt.mime {
+				t.Errorf("Expected MIME type %q, got %q", tt.mime, info.MIMEType)
+			}
+			if info.Duration != tt.duration {
+				t.Errorf("Expected duration %v, got %v", tt.duration, info.Duration)
+			}
+		})
+	}
+}
+
+func TestProbeChaptersAndArtwork(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		chapters []Chapter
+		artwork  bool
+	}{
+		{"mp3", "cbr.mp3", []Chapter{
+			{Start: 0, End: time.Second, Title: "Intro"},
+			{Start: time.Second, End: 2 * time.Second, Title: "Interview"},
+		}, true},
+		{"m4a", "audio.m4a", []Chapter{
+			{Start: 0, End: time.Minute, Title: "Opening"},
+			{Start: time.Minute, End: 90500 * time.Millisecond, Title: "Closing"},
+		}, true},
+		{"opus", "audio.opus", []Chapter{
+			{Start: 0, End: 2500 * time.Millisecond, Title: "First"},
+			{Start: 2500 * time.Millisecond, End: 5 * time.Second, Title: "Second"},
+		}, true},
+		{"wav", "audio.wav", []Chapter{
+			{Start: 0, End: 1500 * time.Millisecond, Title: "Start"},
+			{Start: 1500 * time.Millisecond, End: 2 * time.Second, Title: "Middle"},
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := probe(t, tt.file)
+			if len(info.Chapters) != len(tt.chapters) {
+				t.Fatalf("Expected %d chapters, got %+v", len(tt.chapters), info.Chapters)
+			}
+			for i, want := range tt.chapters {
+				if info.Chapters[i] != want {
+					t.Errorf("Expected chapter %d to be %+v, got %+v", i, want, info.Chapters[i])
+				}
+			}
+			if !tt.artwork {
+				if info.Artwork != nil {
+					t.Errorf("Expected no artwork, got %+v", info.Artwork)
+				}
+				return
+			}
+			if info.Artwork == nil {
+				t.Fatal("Expected artwork")
+			}
+			if info.Artwork.MIMEType != "image/png" {
+				t.Errorf("Expected artwork type image/png, got %q", info.Artwork.MIMEType)
+			}
+			if !bytes.Equal(info.Artwork.Data, png) {
+				t.Errorf("Expected artwork data %q, got %q", png, info.Artwork.Data)
+			}
+		})
+	}
+}
+
+func TestProbeErrors(t *testing.T) {
+	if _, err := Probe(bytes.NewReader([]byte("plain text file"))); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported, got %v", err)
+	}
+	if _, err := Probe(bytes.NewReader(nil)); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported for an empty file, got %v", err)
+	}
+
+	truncated := map[string]int{
+		"cbr.mp3":    40,
+		"audio.m4a":  60,
+		"audio.opus": 50,
+		"audio.wav":  40,
+	}
+	for name, n := range truncated {
+		data := fixture(t, name)[:n]
+		if _, err := Probe(bytes.NewReader(data)); !errors.Is(err, ErrMalformed) {
+			t.Errorf("Expected ErrMalformed for truncated %s, got %v", name, err)
+		}
+	}
+}
+
+func TestProbeFile(t *testing.T) {
+	fsys := os.DirFS("testdata")
+	info, err := ProbeFile(fsys, "cbr.mp3")
+	if err != nil {
+		t.Fatalf("ProbeFile failed: %v", err)
+	}
+	if info.MIMEType != TypeMP3 {
+		t.Errorf("Expected MIME type %q, got %q", TypeMP3, info.MIMEType)
+	}
+
+	// File systems whose files cannot seek are read into memory
+	mapfs := fstest.MapFS{"episodes/1.ogg": {Data: fixture(t, "audio.ogg")}}
+	info, err = ProbeFile(mapfs, "episodes/1.ogg")
+	if err != nil {
+		t.Fatalf("ProbeFile failed: %v", err)
+	}
+	if info.MIMEType != TypeOgg {
+		t.Errorf("Expected MIME type %q, got %q", TypeOgg, info.MIMEType)
+	}
+
+	if _, err := ProbeFile(fsys, "missing.mp3"); err == nil {
+		t.Error("Expected error for a missing file")
+	}
+}
+
+func TestApply(t *testing.T) {
+	info := &Info{Length: 12345678, MIMEType: TypeMP3, Duration: 3723600 * time.Millisecond}
+	item := &feed.Item{Title: "Episode 1"}
+	info.Apply(item, "https://example.com/1.mp3")
+
+	if item.Enclosure == nil {
+		t.Fatal("Expected enclosure to be set")
+	}
+	if item.Enclosure.URL != "https://example.com/1.mp3" || item.Enclosure.Length != "12345678" || item.Enclosure.Type != TypeMP3 {
+		t.Errorf("Unexpected enclosure: %+v", item.Enclosure)
+	}
+	if item.ITunesDuration != "01:02:04" {
+		t.Errorf("Expected duration 01:02:04, got %q", item.ITunesDuration)
+	}
+}
+
+func TestApplyArtworkAndChapters(t *testing.T) {
+	info := probe(t, "cbr.mp3")
+	item := &feed.Item{Title: "Episode 1", Link: "https://example.com/1", Description: "First episode"}
+	info.Apply(item, "https://example.com/1.mp3")
+	info.ApplyArtwork(item, "https://example.com/1.png")
+	info.ApplyChapters(item, "https://example.com/1.json")
+
+	if len(item.Images) != 1 || item.Images[0].URL != "https://example.com/1.png" {
+		t.Errorf("Expected the artwork as an item image, got %+v", item.Images)
+	}
+	if item.Podcast == nil || item.Podcast.Chapters == nil || *item.Podcast.Chapters != (feed.PodcastChapters{URL: "https://example.com/1.json", Type: ChaptersType}) {
+		t.Errorf("Expected podcast chapters, got %+v", item.Podcast)
+	}
+
+	f := feed.New().SetTitle("Show").SetDescription("Episodes").SetLink("https://example.com").AddItem(*item)
+	rss, err := f.RSS()
+	if err != nil {
+		t.Fatalf("RSS generation failed: %v", err)
+	}
+	for _, want := range []string{
+		`<media:thumbnail url="https://example.com/1.png"`,
+		`<podcast:chapters url="https://example.com/1.json" type="application/json+chapters">`,
+	} {
+		if !strings.Contains(string(rss), want) {
+			t.Errorf("Expected RSS to contain %s, got:\n%s", want, rss)
+		}
+	}
+
+	bare := &feed.Item{Title: "Episode 2"}
+	(&Info{}).ApplyArtwork(bare, "https://example.com/2.png")
+	(&Info{}).ApplyChapters(bare, "https://example.com/2.json")
+	if bare.Images != nil || bare.Media != nil || bare.Podcast != nil {
+		t.Errorf("Expected files without artwork or chapters to leave the item alone, got %+v", bare)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{0, "00:00:00"},
+		{59 * time.Second, "00:00:59"},
+		{90 * time.Minute, "01:30:00"},
+		{25*time.Hour + 1500*time.Millisecond, "25:00:02"},
+	}
+	for _, tt := range tests {
+		if got := FormatDuration(tt.in); got != tt.want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestChaptersJSON(t *testing.T) {
+	info := &Info{Chapters: []Chapter{
+		{Start: 0, End: 90 * time.Second, Title: "Intro"},
+		{Start: 90 * time.Second, End: 150500 * time.Millisecond, Title: "Main"},
+	}}
+	data, err := info.ChaptersJSON()
+	if err != nil {
+		t.Fatalf("ChaptersJSON failed: %v", err)
+	}
+
+	var doc struct {
+		Version  string `json:"version"`
+		Chapters []struct {
+			StartTime float64 `json:"startTime"`
+			EndTime   float64 `json:"endTime"`
+			Title     string  `json:"title"`
+		} `json:"chapters"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Failed to decode chapters: %v", err)
+	}
+	if doc.Version != "1.2.0" {
+		t.Errorf("Expected version 1.2.0, got %q", doc.Version)
+	}
+	if len(doc.Chapters) != 2 || doc.Chapters[1].StartTime != 90 || doc.Chapters[1].EndTime != 150.5 || doc.Chapters[1].Title != "Main" {
+		t.Errorf("Unexpected chapters: %+v", doc.Chapters)
+	}
+
+	empty, err := (&Info{}).ChaptersJSON()
+	if err != nil {
+		t.Fatalf("ChaptersJSON failed: %v", err)
+	}
+	if !strings.Contains(string(empty), `"chapters": []`) {
+		t.Errorf("Expected an empty chapters list, got %s", empty)
+	}
+}
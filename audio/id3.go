@@ -0,0 +1,254 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// id3Tag holds what is read from an ID3v2 tag
+type id3Tag struct {
+	chapters []Chapter
+	artwork  *Artwork
+}
+
+// id3Size returns the total size of the ID3v2 tag starting with header,
+// header and footer included, or 0 when there is none
+func id3Size(header []byte) int64 {
+	if len(header) < 10 || string(header[:3]) != "ID3" {
+		return 0
+	}
+	size := int64(syncsafe(header[6:10])) + 10
+	if header[5]&0x10 != 0 {
+		size += 10 // footer
+	}
+	return size
+}
+
+// parseID3 reads the chapters and artwork of an ID3v2.2, 2.3 or 2.4 tag.
+// Unknown frames are skipped and damaged ones end parsing quietly, since
+// the tag is only a source of extras.
+func parseID3(tag []byte) id3Tag {
+	var out id3Tag
+	if len(tag) < 10 {
+		return out
+	}
+	version, flags := tag[3], tag[5]
+	end := 10 + int(syncsafe(tag[6:10]))
+	if end > len(tag) {
+		end = len(tag)
+	}
+	body := tag[10:end]
+	if flags&0x80 != 0 && version < 4 {
+		body = unsynchronise(body)
+	}
+	if flags&0x40 != 0 && len(body) >= 4 {
+		// Skip the extended header
+		n := int(binary.BigEndian.Uint32(body))
+		if version == 3 {
+			n += 4
+		} else {
+			n = int(syncsafe(body[:4]))
+		}
+		if n > len(body) {
+			return out
+		}
+		body = body[n:]
+	}
+
+	var covers []Artwork
+	parseID3Frames(body, version, func(id string, data []byte) {
+		switch id {
+		case "APIC", "PIC":
+			if art, kind, ok := parsePicture(id, data); ok {
+				if kind == 3 { // front cover
+					covers = append([]Artwork{art}, covers...)
+				} else {
+					covers = append(covers, art)
+				}
+			}
+		case "CHAP":
+			if c, ok := parseChapter(data, version); ok {
+				out.chapters = append(out.chapters, c)
+			}
+		}
+	})
+	if len(covers) > 0 {
+		out.artwork = &covers[0]
+	}
+	return out
+}
+
+// parseID3Frames calls fn for each frame in body
+func parseID3Frames(body []byte, version byte, fn func(id string, data []byte)) {
+	idLen, headerLen := 4, 10
+	if version == 2 {
+		idLen, headerLen = 3, 6
+	}
+	for len(body) >= headerLen && body[0] != 0 {
+		id := string(body[:idLen])
+		var size int
+		switch version {
+		case 2:
+			size = int(body[3])<<16 | int(body[4])<<8 | int(body[5])
+		case 3:
+			size = int(binary.BigEndian.Uint32(body[4:8]))
+		default:
+			size = int(syncsafe(body[4:8]))
+		}
+		if size < 0 || headerLen+size > len(body) {
+			return
+		}
+		data := body[headerLen : headerLen+size]
+		if version == 4 {
+			formatFlags := body[9]
+			if formatFlags&0x01 != 0 && len(data) >= 4 {
+				data = data[4:] // data length indicator
+			}
+			if formatFlags&0x02 != 0 {
+				data = unsynchronise(data)
+			}
+		}
+		fn(id, data)
+		body = body[headerLen+size:]
+	}
+}
+
+// parsePicture reads an APIC (or ID3v2.2 PIC) frame
+func parsePicture(id string, data []byte) (Artwork, byte, bool) {
+	if len(data) < 2 {
+		return Artwork{}, 0, false
+	}
+	enc := data[0]
+	data = data[1:]
+	var mime string
+	if id == "PIC" {
+		if len(data) < 3 {
+			return Artwork{}, 0, false
+		}
+		switch strings.ToUpper(string(data[:3])) {
+		case "PNG":
+			mime = "image/png"
+		default:
+			mime = "image/jpeg"
+		}
+		data = data[3:]
+	} else {
+		i := bytes.IndexByte(data, 0)
+		if i < 0 {
+			return Artwork{}, 0, false
+		}
+		mime = string(data[:i])
+		data = data[i+1:]
+	}
+	if len(data) < 1 {
+		return Artwork{}, 0, false
+	}
+	kind := data[0]
+	_, data = splitText(enc, data[1:]) // description
+	if len(data) == 0 {
+		return Artwork{}, 0, false
+	}
+	if mime == "" || !strings.Contains(mime, "/") {
+		mime = "image/" + strings.ToLower(mime)
+	}
+	return Artwork{MIMEType: mime, Data: append([]byte(nil), data...)}, kind, true
+}
+
+// parseChapter reads a CHAP frame with its embedded TIT2 title
+func parseChapter(data []byte, version byte) (Chapter, bool) {
+	i := bytes.IndexByte(data, 0)
+	if i < 0 || len(data) < i+1+16 {
+		return Chapter{}, false
+	}
+	times := data[i+1:]
+	c := Chapter{
+		Start: time.Duration(binary.BigEndian.Uint32(times[0:4])) * time.Millisecond,
+		End:   time.Duration(binary.BigEndian.Uint32(times[4:8])) * time.Millisecond,
+	}
+	parseID3Frames(times[16:], version, func(id string, sub []byte) {
+		if id == "TIT2" && len(sub) > 0 {
+			c.Title, _ = splitText(sub[0], sub[1:])
+		}
+	})
+	return c, true
+}
+
+// splitText decodes a terminated string in the given ID3 text encoding and
+// returns it with the remaining data
+func splitText(enc byte, data []byte) (string, []byte) {
+	if enc == 1 || enc == 2 {
+		// UTF-16: the terminator is two zero bytes on a character boundary
+		end := len(data)
+		for i := 0; i+1 < len(data); i += 2 {
+			if data[i] == 0 && data[i+1] == 0 {
+				end = i
+				break
+			}
+		}
+		rest := data[end:]
+		if len(rest) >= 2 {
+			rest = rest[2:]
+		}
+		return decodeUTF16(data[:end], enc == 2), rest
+	}
+
+	end := bytes.IndexByte(data, 0)
+	rest := []byte(nil)
+	if end < 0 {
+		end = len(data)
+	} else {
+		rest = data[end+1:]
+	}
+	text := data[:end]
+	if enc == 0 {
+		// ISO-8859-1 maps directly to the first 256 code points
+		runes := make([]rune, len(text))
+		for i, b := range text {
+			runes[i] = rune(b)
+		}
+		return string(runes), rest
+	}
+	return string(text), rest
+}
+
+// decodeUTF16 decodes UTF-16 text, honouring a byte order mark
+func decodeUTF16(b []byte, bigEndian bool) string {
+	if len(b) >= 2 {
+		switch {
+		case b[0] == 0xFF && b[1] == 0xFE:
+			bigEndian, b = false, b[2:]
+		case b[0] == 0xFE && b[1] == 0xFF:
+			bigEndian, b = true, b[2:]
+		}
+	}
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = binary.BigEndian.Uint16(b[2*i:])
+		} else {
+			units[i] = binary.LittleEndian.Uint16(b[2*i:])
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// unsynchronise reverses ID3 unsynchronisation, which inserts a zero byte
+// after every 0xFF
+func unsynchronise(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		out = append(out, b[i])
+		if b[i] == 0xFF && i+1 < len(b) && b[i+1] == 0 {
+			i++
+		}
+	}
+	return out
+}
+
+// syncsafe decodes a 28-bit integer stored in the low 7 bits of 4 bytes
+func syncsafe(b []byte) uint32 {
+	return uint32(b[0]&0x7f)<<21 | uint32(b[1]&0x7f)<<14 | uint32(b[2]&0x7f)<<7 | uint32(b[3]&0x7f)
+}
@@ -0,0 +1,183 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// MPEG audio versions, as encoded in the frame header
+const (
+	mpeg25 = 0
+	mpeg2  = 2
+	mpeg1  = 3
+)
+
+// bitrates in kbit/s by [MPEG1][layer index], where layer index 0 is layer I
+var bitrates = [2][3][15]int{
+	{ // MPEG 2 and 2.5
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	},
+	{ // MPEG 1
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	},
+}
+
+var sampleRates = map[int][3]int{
+	mpeg1:  {44100, 48000, 32000},
+	mpeg2:  {22050, 24000, 16000},
+	mpeg25: {11025, 12000, 8000},
+}
+
+// mpegFrame is a decoded MPEG audio frame header
+type mpegFrame struct {
+	version    int
+	layer      int // 1, 2 or 3
+	bitrate    int // bit/s
+	sampleRate int
+	mono       bool
+	size       int // bytes, header included
+}
+
+// samples returns the number of samples per frame
+func (f mpegFrame) samples() int {
+	switch {
+	case f.layer == 1:
+		return 384
+	case f.layer == 3 && f.version != mpeg1:
+		return 576
+	}
+	return 1152
+}
+
+// isFrameSync reports whether b starts with an MPEG audio frame header
+func isFrameSync(b []byte) bool {
+	_, ok := parseFrameHeader(b)
+	return ok
+}
+
+// parseFrameHeader decodes the 4-byte frame header at the start of b
+func parseFrameHeader(b []byte) (mpegFrame, bool) {
+	if len(b) < 4 || b[0] != 0xFF || b[1]&0xE0 != 0xE0 {
+		return mpegFrame{}, false
+	}
+	version := int(b[1]>>3) & 3
+	layerBits := int(b[1]>>1) & 3
+	bitrateIndex := int(b[2] >> 4)
+	rateIndex := int(b[2]>>2) & 3
+	if version == 1 || layerBits == 0 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return mpegFrame{}, false
+	}
+
+	f := mpegFrame{version: version, layer: 4 - layerBits, mono: b[3]>>6 == 3}
+	v1 := 0
+	if version == mpeg1 {
+		v1 = 1
+	}
+	f.bitrate = bitrates[v1][f.layer-1][bitrateIndex] * 1000
+	f.sampleRate = sampleRates[version][rateIndex]
+	padding := int(b[2]>>1) & 1
+
+	if f.layer == 1 {
+		f.size = (12*f.bitrate/f.sampleRate + padding) * 4
+	} else {
+		f.size = f.samples()/8*f.bitrate/f.sampleRate + padding
+	}
+	return f, true
+}
+
+// probeMP3 reads the ID3v2 tag, then the first audio frame, whose Xing,
+// Info or VBRI header gives the frame count of variable bitrate files.
+// Files without one are assumed to have a constant bitrate.
+func probeMP3(r io.ReadSeeker, size int64, info *Info) error {
+	info.MIMEType = TypeMP3
+
+	header := make([]byte, 10)
+	if err := readAt(r, 0, header); err != nil {
+		return err
+	}
+	start := id3Size(header)
+	if start > size {
+		return fmt.Errorf("%w: ID3 tag is larger than the file", ErrMalformed)
+	}
+	if start > 0 {
+		tag := make([]byte, start)
+		if err := readAt(r, 0, tag); err != nil {
+			return err
+		}
+		id3 := parseID3(tag)
+		info.Chapters = id3.chapters
+		info.Artwork = id3.artwork
+	}
+
+	// Find the first frame whose successor also syncs, skipping padding
+	// and junk between the tag and the audio
+	window := make([]byte, min(size-start, 64<<10))
+	if err := readAt(r, start, window); err != nil {
+		return err
+	}
+	offset := -1
+	var frame mpegFrame
+	for i := 0; i+4 <= len(window); i++ {
+		f, ok := parseFrameHeader(window[i:])
+		if !ok {
+			continue
+		}
+		if next := i + f.size; next+4 <= len(window) && !isFrameSync(window[next:]) {
+			continue
+		}
+		offset, frame = i, f
+		break
+	}
+	if offset < 0 {
+		return fmt.Errorf("%w: no MPEG audio frame found", ErrMalformed)
+	}
+	first := window[offset:]
+
+	if frames, ok := vbrFrames(first, frame); ok {
+		info.Duration = seconds(int64(frames)*int64(frame.samples()), int64(frame.sampleRate))
+		return nil
+	}
+
+	audio := size - start - int64(offset)
+	if size >= 128 {
+		trailer := make([]byte, 3)
+		if err := readAt(r, size-128, trailer); err == nil && string(trailer) == "TAG" {
+			audio -= 128 // ID3v1 tag
+		}
+	}
+	info.Duration = seconds(audio*8, int64(frame.bitrate))
+	return nil
+}
+
+// vbrFrames reads the frame count from a Xing, Info or VBRI header in the
+// first frame
+func vbrFrames(b []byte, f mpegFrame) (uint32, bool) {
+	// The Xing header follows the side information
+	side := 32
+	switch {
+	case f.version == mpeg1 && f.mono, f.version != mpeg1 && !f.mono:
+		side = 17
+	case f.version != mpeg1 && f.mono:
+		side = 9
+	}
+	if x := 4 + side; len(b) >= x+12 {
+		if tag := string(b[x : x+4]); tag == "Xing" || tag == "Info" {
+			flags := binary.BigEndian.Uint32(b[x+4:])
+			if flags&1 != 0 {
+				return binary.BigEndian.Uint32(b[x+8:]), true
+			}
+		}
+	}
+
+	// The VBRI header sits at a fixed offset
+	if len(b) >= 36+18 && bytes.Equal(b[36:40], []byte("VBRI")) {
+		return binary.BigEndian.Uint32(b[36+14:]), true
+	}
+	return 0, false
+}
@@ -0,0 +1,202 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// mp4Box is a box (atom) header
+type mp4Box struct {
+	kind  string
+	start int64 // offset of the payload
+	end   int64 // offset just past the box
+}
+
+// readBoxes lists the boxes between start and end
+func readBoxes(r io.ReadSeeker, start, end int64) ([]mp4Box, error) {
+	var boxes []mp4Box
+	header := make([]byte, 16)
+	for off := start; off+8 <= end; {
+		if err := readAt(r, off, header[:8]); err != nil {
+			return nil, err
+		}
+		size := int64(binary.BigEndian.Uint32(header))
+		box := mp4Box{kind: string(header[4:8]), start: off + 8}
+		switch size {
+		case 0: // extends to the end of the enclosing box
+			size = end - off
+		case 1: // 64-bit size follows the type
+			if err := readAt(r, off+8, header[8:16]); err != nil {
+				return nil, err
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:]))
+			box.start += 8
+		}
+		if size < box.start-off || off+size > end {
+			return nil, fmt.Errorf("%w: box %q overruns its parent", ErrMalformed, box.kind)
+		}
+		box.end = off + size
+		boxes = append(boxes, box)
+		off = box.end
+	}
+	return boxes, nil
+}
+
+// readPayload reads a box's payload, up to limit bytes
+func readPayload(r io.ReadSeeker, box mp4Box, limit int64) ([]byte, error) {
+	n := box.end - box.start
+	if n > limit {
+		return nil, fmt.Errorf("%w: box %q is too large", ErrMalformed, box.kind)
+	}
+	buf := make([]byte, n)
+	return buf, readAt(r, box.start, buf)
+}
+
+// probeMP4 walks the moov box for the movie duration, the track handlers
+// telling audio from video, Nero chapters (udta/chpl) and iTunes cover art
+// (udta/meta/ilst/covr). The media data itself is never read.
+func probeMP4(r io.ReadSeeker, size int64, info *Info) error {
+	info.MIMEType = TypeM4A
+
+	top, err := readBoxes(r, 0, size)
+	if err != nil {
+		return err
+	}
+	var moov *mp4Box
+	for i := range top {
+		if top[i].kind == "moov" {
+			moov = &top[i]
+		}
+	}
+	if moov == nil {
+		return fmt.Errorf("%w: no moov box", ErrMalformed)
+	}
+
+	return walkBoxes(r, *moov, func(box mp4Box) error {
+		switch box.kind {
+		case "mvhd":
+			data, err := readPayload(r, box, 1<<10)
+			if err != nil {
+				return err
+			}
+			return parseMvhd(data, info)
+		case "hdlr":
+			data, err := readPayload(r, box, 1<<10)
+			if err != nil {
+				return err
+			}
+			if len(data) >= 12 && string(data[8:12]) == "vide" {
+				info.MIMEType = TypeMP4
+			}
+		case "chpl":
+			data, err := readPayload(r, box, 1<<20)
+			if err != nil {
+				return err
+			}
+			info.Chapters = parseChpl(data)
+		case "data":
+			// Only reached inside covr, see walkBoxes
+			data, err := readPayload(r, box, 16<<20)
+			if err != nil {
+				return err
+			}
+			if info.Artwork == nil && len(data) > 8 {
+				mime := "image/jpeg"
+				if binary.BigEndian.Uint32(data) == 14 {
+					mime = "image/png"
+				}
+				info.Artwork = &Artwork{MIMEType: mime, Data: data[8:]}
+			}
+		}
+		return nil
+	})
+}
+
+// walkBoxes calls fn for every box below parent, descending into the
+// containers that hold the metadata probeMP4 needs
+func walkBoxes(r io.ReadSeeker, parent mp4Box, fn func(mp4Box) error) error {
+	start := parent.start
+	if parent.kind == "meta" {
+		// ISO meta boxes carry a version and flags, QuickTime ones do not
+		peek := make([]byte, 8)
+		if err := readAt(r, start, peek); err == nil && string(peek[4:8]) != "hdlr" {
+			start += 4
+		}
+	}
+	boxes, err := readBoxes(r, start, parent.end)
+	if err != nil {
+		return err
+	}
+	for _, box := range boxes {
+		switch box.kind {
+		case "trak", "mdia", "udta", "meta", "ilst", "covr":
+			if err := walkBoxes(r, box, fn); err != nil {
+				return err
+			}
+		case "data":
+			if parent.kind != "covr" {
+				continue
+			}
+			fallthrough
+		default:
+			if err := fn(box); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseMvhd reads the duration from a movie header
+func parseMvhd(data []byte, info *Info) error {
+	if len(data) < 20 {
+		return fmt.Errorf("%w: short mvhd box", ErrMalformed)
+	}
+	var scale, duration int64
+	if data[0] == 1 {
+		if len(data) < 32 {
+			return fmt.Errorf("%w: short mvhd box", ErrMalformed)
+		}
+		scale = int64(binary.BigEndian.Uint32(data[20:]))
+		duration = int64(binary.BigEndian.Uint64(data[24:]))
+	} else {
+		scale = int64(binary.BigEndian.Uint32(data[12:]))
+		duration = int64(binary.BigEndian.Uint32(data[16:]))
+	}
+	info.Duration = seconds(duration, scale)
+	return nil
+}
+
+// parseChpl reads Nero chapters, whose start times are in 100ns units
+func parseChpl(data []byte) []Chapter {
+	if len(data) < 5 {
+		return nil
+	}
+	off := 4
+	if data[0] == 1 {
+		off += 4 // reserved
+	}
+	if off >= len(data) {
+		return nil
+	}
+	count := int(data[off])
+	off++
+
+	var chapters []Chapter
+	for i := 0; i < count && off+9 <= len(data); i++ {
+		start := binary.BigEndian.Uint64(data[off:])
+		n := int(data[off+8])
+		off += 9
+		if off+n > len(data) {
+			break
+		}
+		chapters = append(chapters, Chapter{
+			Start: time.Duration(start) * 100,
+			Title: string(data[off : off+n]),
+		})
+		off += n
+	}
+	return chapters
+}
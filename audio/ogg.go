@@ -0,0 +1,251 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// oggPage is a decoded Ogg page header with its payload
+type oggPage struct {
+	granule  int64
+	serial   uint32
+	segments []byte // lacing values
+	data     []byte
+	size     int64 // header and payload
+}
+
+// readOggPage reads the page at off
+func readOggPage(r io.ReadSeeker, off int64) (oggPage, error) {
+	header := make([]byte, 27)
+	if err := readAt(r, off, header); err != nil {
+		return oggPage{}, err
+	}
+	if string(header[:4]) != "OggS" {
+		return oggPage{}, fmt.Errorf("%w: missing Ogg page at offset %d", ErrMalformed, off)
+	}
+	p := oggPage{
+		granule:  int64(binary.LittleEndian.Uint64(header[6:])),
+		serial:   binary.LittleEndian.Uint32(header[14:]),
+		segments: make([]byte, header[26]),
+	}
+	if _, err := io.ReadFull(r, p.segments); err != nil {
+		return oggPage{}, fmt.Errorf("%w: unexpected end of file", ErrMalformed)
+	}
+	n := 0
+	for _, l := range p.segments {
+		n += int(l)
+	}
+	p.data = make([]byte, n)
+	if _, err := io.ReadFull(r, p.data); err != nil {
+		return oggPage{}, fmt.Errorf("%w: unexpected end of file", ErrMalformed)
+	}
+	p.size = int64(27 + len(p.segments) + n)
+	return p, nil
+}
+
+// probeOgg reads the identification and comment headers of the first
+// logical stream, which must be Vorbis or Opus, and takes the duration from
+// the granule position of the stream's last page
+func probeOgg(r io.ReadSeeker, size int64, info *Info) error {
+	// Reassemble the first two packets from the lacing values
+	var packets [][]byte
+	var current []byte
+	var serial uint32
+	for off := int64(0); len(packets) < 2 && off < size; {
+		page, err := readOggPage(r, off)
+		if err != nil {
+			return err
+		}
+		if off == 0 {
+			serial = page.serial
+		}
+		off += page.size
+		if page.serial != serial {
+			continue
+		}
+		pos := 0
+		for _, l := range page.segments {
+			current = append(current, page.data[pos:pos+int(l)]...)
+			pos += int(l)
+			if l < 255 {
+				packets = append(packets, current)
+				current = nil
+			}
+		}
+		if len(current) > 16<<20 {
+			return fmt.Errorf("%w: Ogg header packet is too large", ErrMalformed)
+		}
+	}
+	if len(packets) < 2 {
+		return fmt.Errorf("%w: missing Ogg header packets", ErrMalformed)
+	}
+
+	var rate, skip int64
+	var comments []byte
+	switch id := packets[0]; {
+	case len(id) >= 16 && bytes.HasPrefix(id, []byte("\x01vorbis")):
+		info.MIMEType = TypeOgg
+		rate = int64(binary.LittleEndian.Uint32(id[12:]))
+		comments = bytes.TrimPrefix(packets[1], []byte("\x03vorbis"))
+	case len(id) >= 19 && bytes.HasPrefix(id, []byte("OpusHead")):
+		info.MIMEType = TypeOpus
+		rate = 48000 // Opus granule positions always count 48 kHz samples
+		skip = int64(binary.LittleEndian.Uint16(id[10:]))
+		comments = bytes.TrimPrefix(packets[1], []byte("OpusTags"))
+	default:
+		return fmt.Errorf("%w: Ogg stream is neither Vorbis nor Opus", ErrUnsupported)
+	}
+	parseVorbisComments(comments, info)
+
+	granule, err := lastGranule(r, size, serial)
+	if err != nil {
+		return err
+	}
+	if granule > skip {
+		info.Duration = seconds(granule-skip, rate)
+	}
+	return nil
+}
+
+// lastGranule finds the granule position of the last page of the stream,
+// searching backwards from the end of the file
+func lastGranule(r io.ReadSeeker, size int64, serial uint32) (int64, error) {
+	const chunk = 64 << 10
+	for end := size; end > 0; end -= chunk - 27 {
+		start := max(end-chunk, 0)
+		buf := make([]byte, end-start)
+		if err := readAt(r, start, buf); err != nil {
+			return 0, err
+		}
+		for i := len(buf) - 27; i >= 0; i-- {
+			if buf[i] != 'O' || string(buf[i:i+4]) != "OggS" {
+				continue
+			}
+			granule := int64(binary.LittleEndian.Uint64(buf[i+6:]))
+			if binary.LittleEndian.Uint32(buf[i+14:]) == serial && granule >= 0 {
+				return granule, nil
+			}
+		}
+		if start == 0 {
+			break
+		}
+	}
+	return 0, fmt.Errorf("%w: no Ogg page with a granule position", ErrMalformed)
+}
+
+// parseVorbisComments reads chapters (CHAPTERxxx and CHAPTERxxxNAME) and
+// cover art (METADATA_BLOCK_PICTURE) from a Vorbis comment block
+func parseVorbisComments(b []byte, info *Info) {
+	next := func() ([]byte, bool) {
+		if len(b) < 4 {
+			return nil, false
+		}
+		n := binary.LittleEndian.Uint32(b)
+		if uint64(n) > uint64(len(b)-4) {
+			return nil, false
+		}
+		s := b[4 : 4+n]
+		b = b[4+n:]
+		return s, true
+	}
+	if _, ok := next(); !ok { // vendor
+		return
+	}
+	if len(b) < 4 {
+		return
+	}
+	count := binary.LittleEndian.Uint32(b)
+	b = b[4:]
+
+	starts := map[string]time.Duration{}
+	names := map[string]string{}
+	for i := uint32(0); i < count; i++ {
+		c, ok := next()
+		if !ok {
+			break
+		}
+		key, value, ok := strings.Cut(string(c), "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToUpper(key)
+		switch {
+		case key == "METADATA_BLOCK_PICTURE":
+			if info.Artwork == nil {
+				info.Artwork = parseFLACPicture(value)
+			}
+		case strings.HasPrefix(key, "CHAPTER") && strings.HasSuffix(key, "NAME"):
+			names[strings.TrimSuffix(key, "NAME")] = value
+		case strings.HasPrefix(key, "CHAPTER"):
+			if d, ok := parseTimestamp(value); ok {
+				starts[key] = d
+			}
+		}
+	}
+	for key, start := range starts {
+		info.Chapters = append(info.Chapters, Chapter{Start: start, Title: names[key]})
+	}
+}
+
+// parseFLACPicture decodes a base64 FLAC picture block
+func parseFLACPicture(value string) *Artwork {
+	b, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil
+	}
+	field := func() ([]byte, bool) {
+		if len(b) < 4 {
+			return nil, false
+		}
+		n := binary.BigEndian.Uint32(b)
+		if uint64(n) > uint64(len(b)-4) {
+			return nil, false
+		}
+		s := b[4 : 4+n]
+		b = b[4+n:]
+		return s, true
+	}
+	if len(b) < 4 {
+		return nil
+	}
+	b = b[4:] // picture type
+	mime, ok := field()
+	if !ok {
+		return nil
+	}
+	if _, ok := field(); !ok { // description
+		return nil
+	}
+	if len(b) < 16 {
+		return nil
+	}
+	b = b[16:] // width, height, depth and colours
+	data, ok := field()
+	if !ok || len(data) == 0 {
+		return nil
+	}
+	return &Artwork{MIMEType: string(mime), Data: data}
+}
+
+// parseTimestamp parses a chapter time such as "01:02:03.500"
+func parseTimestamp(s string) (time.Duration, bool) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) > 3 {
+		return 0, false
+	}
+	var d time.Duration
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil || v < 0 || (i < len(parts)-1 && strings.Contains(part, ".")) {
+			return 0, false
+		}
+		d = d*60 + time.Duration(v*float64(time.Second))
+	}
+	return d, true
+}
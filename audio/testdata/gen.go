@@ -0,0 +1,271 @@
+//go:build ignore
+
+// gen writes the fixtures used by the audio tests. Run it from this
+// directory with: go run gen.go
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+	files := map[string][]byte{
+		"cbr.mp3":    cbrMP3(),
+		"xing.mp3":   vbrMP3("Xing", 1000),
+		"info.mp3":   vbrMP3("Info", 441),
+		"vbri.mp3":   vbrMP3("VBRI", 500),
+		"audio.m4a":  m4a(),
+		"video.mp4":  mp4Video(),
+		"audio.opus": opus(),
+		"audio.ogg":  vorbis(),
+		"audio.wav":  wav(),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(name, data, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+var png = []byte("\x89PNG\r\n\x1a\nimage")
+
+// id3Frame builds an ID3v2.3 frame
+func id3Frame(id string, data []byte) []byte {
+	b := append([]byte(id), 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(b[4:], uint32(len(data)))
+	return append(b, data...)
+}
+
+// id3v23 builds an ID3v2.3 tag with a front cover and two chapters
+func id3v23() []byte {
+	var frames []byte
+	frames = append(frames, id3Frame("APIC", append([]byte("\x00image/png\x00\x03cover\x00"), png...))...)
+	for i, title := range []string{"Intro", "Interview"} {
+		chap := []byte("ch" + string(rune('0'+i)) + "\x00")
+		times := make([]byte, 16)
+		binary.BigEndian.PutUint32(times, uint32(i*1000))
+		binary.BigEndian.PutUint32(times[4:], uint32((i+1)*1000))
+		chap = append(chap, times...)
+		chap = append(chap, id3Frame("TIT2", append([]byte{3}, title...))...)
+		frames = append(frames, id3Frame("CHAP", chap)...)
+	}
+	frames = append(frames, make([]byte, 32)...) // padding
+	n := len(frames)
+	header := []byte{'I', 'D', '3', 3, 0, 0, byte(n >> 21 & 0x7f), byte(n >> 14 & 0x7f), byte(n >> 7 & 0x7f), byte(n & 0x7f)}
+	return append(header, frames...)
+}
+
+// mp3Frame is an MPEG 1 layer III frame at 128 kbit/s and 44.1 kHz
+func mp3Frame() []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	return frame
+}
+
+func cbrMP3() []byte {
+	b := id3v23()
+	for i := 0; i < 100; i++ {
+		b = append(b, mp3Frame()...)
+	}
+	trailer := make([]byte, 128)
+	copy(trailer, "TAG")
+	return append(b, trailer...)
+}
+
+func vbrMP3(tag string, frames uint32) []byte {
+	first := mp3Frame()
+	if tag == "VBRI" {
+		copy(first[36:], tag)
+		binary.BigEndian.PutUint32(first[36+14:], frames)
+	} else {
+		copy(first[36:], tag)
+		binary.BigEndian.PutUint32(first[40:], 1)
+		binary.BigEndian.PutUint32(first[44:], frames)
+	}
+	return append(append(first, mp3Frame()...), mp3Frame()...)
+}
+
+// box builds an MP4 box
+func box(kind string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	b := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(b, uint32(8+len(body)))
+	copy(b[4:], kind)
+	return append(b, body...)
+}
+
+func m4a() []byte {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)  // time scale
+	binary.BigEndian.PutUint32(mvhd[16:], 90500) // duration
+	hdlr := append(make([]byte, 8), "soun"...)
+
+	chpl := []byte{1, 0, 0, 0, 0, 0, 0, 0, 2}
+	for i, title := range []string{"Opening", "Closing"} {
+		start := make([]byte, 8)
+		binary.BigEndian.PutUint64(start, uint64(i)*60*1e7)
+		chpl = append(append(append(chpl, start...), byte(len(title))), title...)
+	}
+	data := append([]byte{0, 0, 0, 14, 0, 0, 0, 0}, png...)
+	meta := box("meta", []byte{0, 0, 0, 0},
+		box("hdlr", make([]byte, 8), []byte("mdir")),
+		box("ilst", box("covr", box("data", data))))
+
+	return bytes.Join([][]byte{
+		box("ftyp", []byte("M4A \x00\x00\x00\x00")),
+		box("moov",
+			box("mvhd", mvhd),
+			box("trak", box("mdia", box("hdlr", hdlr))),
+			box("udta", box("chpl", chpl), meta)),
+		box("mdat", make([]byte, 64)),
+	}, nil)
+}
+
+func mp4Video() []byte {
+	mvhd := make([]byte, 112)
+	mvhd[0] = 1
+	binary.BigEndian.PutUint32(mvhd[20:], 600)
+	binary.BigEndian.PutUint64(mvhd[24:], 600*125)
+	return bytes.Join([][]byte{
+		box("ftyp", []byte("isom\x00\x00\x00\x00")),
+		box("moov",
+			box("mvhd", mvhd),
+			box("trak", box("mdia", box("hdlr", make([]byte, 8), []byte("vide"))))),
+	}, nil)
+}
+
+// oggPageBytes builds an Ogg page holding whole packets
+func oggPageBytes(granule int64, seq uint32, packets ...[]byte) []byte {
+	var lacing, data []byte
+	for _, p := range packets {
+		n := len(p)
+		for ; n >= 255; n -= 255 {
+			lacing = append(lacing, 255)
+		}
+		lacing = append(lacing, byte(n))
+		data = append(data, p...)
+	}
+	b := make([]byte, 27)
+	copy(b, "OggS")
+	binary.LittleEndian.PutUint64(b[6:], uint64(granule))
+	binary.LittleEndian.PutUint32(b[14:], 0x1234)
+	binary.LittleEndian.PutUint32(b[18:], seq)
+	b[26] = byte(len(lacing))
+	b = append(append(b, lacing...), data...)
+
+	var crc uint32
+	for _, c := range b {
+		crc ^= uint32(c) << 24
+		for i := 0; i < 8; i++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04C11DB7
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	binary.LittleEndian.PutUint32(b[22:], crc)
+	return b
+}
+
+func vorbisComments(prefix string, comments ...string) []byte {
+	b := []byte(prefix)
+	le := func(n int) { b = binary.LittleEndian.AppendUint32(b, uint32(n)) }
+	le(len("test"))
+	b = append(b, "test"...)
+	le(len(comments))
+	for _, c := range comments {
+		le(len(c))
+		b = append(b, c...)
+	}
+	return b
+}
+
+func flacPicture() string {
+	var b []byte
+	be := func(n int) { b = binary.BigEndian.AppendUint32(b, uint32(n)) }
+	be(3)
+	be(len("image/png"))
+	b = append(b, "image/png"...)
+	be(0)
+	b = append(b, make([]byte, 16)...)
+	be(len(png))
+	b = append(b, png...)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func opus() []byte {
+	head := []byte("OpusHead\x01\x02\x38\x01\x80\xbb\x00\x00\x00\x00\x00")
+	// 300 bytes of padding makes the tags packet span lacing values
+	tags := vorbisComments("OpusTags",
+		"CHAPTER001=00:00:02.500", "CHAPTER001NAME=Second",
+		"CHAPTER000=00:00:00.000", "CHAPTER000NAME=First",
+		"METADATA_BLOCK_PICTURE="+flacPicture(),
+		"COMMENT="+strings.Repeat("x", 300))
+	return bytes.Join([][]byte{
+		oggPageBytes(0, 0, head),
+		oggPageBytes(0, 1, tags),
+		oggPageBytes(48000*2, 2, make([]byte, 40)),
+		oggPageBytes(48000*5+312, 3, make([]byte, 40)),
+	}, nil)
+}
+
+func vorbis() []byte {
+	id := make([]byte, 30)
+	copy(id, "\x01vorbis")
+	binary.LittleEndian.PutUint32(id[12:], 44100)
+	return bytes.Join([][]byte{
+		oggPageBytes(0, 0, id),
+		oggPageBytes(0, 1, vorbisComments("\x03vorbis"), []byte("\x05vorbis")),
+		oggPageBytes(44100*3/2, 2, make([]byte, 40)),
+	}, nil)
+}
+
+// chunk builds a RIFF chunk
+func chunk(kind string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	b := append([]byte(kind), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(b[4:], uint32(len(body)))
+	b = append(b, body...)
+	if len(body)%2 == 1 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func wav() []byte {
+	format := make([]byte, 16)
+	binary.LittleEndian.PutUint16(format, 1)         // PCM
+	binary.LittleEndian.PutUint16(format[2:], 1)     // mono
+	binary.LittleEndian.PutUint32(format[4:], 8000)  // sample rate
+	binary.LittleEndian.PutUint32(format[8:], 16000) // byte rate
+	binary.LittleEndian.PutUint16(format[12:], 2)
+	binary.LittleEndian.PutUint16(format[14:], 16)
+
+	cue := binary.LittleEndian.AppendUint32(nil, 2)
+	var labels []byte
+	for i, c := range []struct {
+		offset uint32
+		label  string
+	}{{0, "Start"}, {12000, "Middle"}} {
+		p := make([]byte, 24)
+		binary.LittleEndian.PutUint32(p, uint32(i+1))
+		binary.LittleEndian.PutUint32(p[20:], c.offset)
+		cue = append(cue, p...)
+		labels = append(labels, chunk("labl", binary.LittleEndian.AppendUint32(nil, uint32(i+1)), []byte(c.label+"\x00"))...)
+	}
+
+	body := bytes.Join([][]byte{
+		[]byte("WAVE"),
+		chunk("fmt ", format),
+		chunk("cue ", cue),
+		chunk("LIST", []byte("adtl"), labels),
+		chunk("data", make([]byte, 32000)),
+	}, nil)
+	return chunk("RIFF", body)
+}
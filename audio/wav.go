@@ -0,0 +1,121 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// cuePoint is a cue point, its offset counted in samples
+type cuePoint struct {
+	id     uint32
+	offset uint32
+}
+
+// probeWAV walks the RIFF chunks: fmt for the sample and byte rates, data
+// for the length of the audio, cue points with their adtl labels for
+// chapters, and an embedded ID3 chunk for artwork
+func probeWAV(r io.ReadSeeker, size int64, info *Info) error {
+	info.MIMEType = TypeWAV
+
+	var sampleRate, byteRate int64
+	var dataSize int64 = -1
+	var cues []cuePoint
+	labels := map[uint32]string{}
+
+	header := make([]byte, 8)
+	for off := int64(12); off+8 <= size; {
+		if err := readAt(r, off, header); err != nil {
+			return err
+		}
+		kind := string(header[:4])
+		n := int64(binary.LittleEndian.Uint32(header[4:]))
+		body := off + 8
+		// Chunks are word aligned; data may be cut short by streaming writers
+		off = body + n + n&1
+		if kind == "data" {
+			dataSize = min(n, size-body)
+			continue
+		}
+		if body+n > size {
+			return fmt.Errorf("%w: chunk %q overruns the file", ErrMalformed, kind)
+		}
+
+		switch kind {
+		case "fmt ", "cue ", "LIST", "id3 ", "ID3 ":
+		default:
+			continue
+		}
+		if n > 16<<20 {
+			return fmt.Errorf("%w: chunk %q is too large", ErrMalformed, kind)
+		}
+		data := make([]byte, n)
+		if err := readAt(r, body, data); err != nil {
+			return err
+		}
+
+		switch kind {
+		case "fmt ":
+			if len(data) < 12 {
+				return fmt.Errorf("%w: short fmt chunk", ErrMalformed)
+			}
+			sampleRate = int64(binary.LittleEndian.Uint32(data[4:]))
+			byteRate = int64(binary.LittleEndian.Uint32(data[8:]))
+		case "cue ":
+			if len(data) < 4 {
+				continue
+			}
+			count := int(binary.LittleEndian.Uint32(data))
+			for i := 0; i < count && 4+24*(i+1) <= len(data); i++ {
+				p := data[4+24*i:]
+				cues = append(cues, cuePoint{
+					id:     binary.LittleEndian.Uint32(p),
+					offset: binary.LittleEndian.Uint32(p[20:]),
+				})
+			}
+		case "LIST":
+			if bytes.HasPrefix(data, []byte("adtl")) {
+				parseLabels(data[4:], labels)
+			}
+		case "id3 ", "ID3 ":
+			id3 := parseID3(data)
+			if info.Artwork == nil {
+				info.Artwork = id3.artwork
+			}
+			if len(cues) == 0 {
+				info.Chapters = id3.chapters
+			}
+		}
+	}
+	if byteRate == 0 || dataSize < 0 {
+		return fmt.Errorf("%w: missing fmt or data chunk", ErrMalformed)
+	}
+	info.Duration = seconds(dataSize, byteRate)
+
+	if len(cues) > 0 && sampleRate > 0 {
+		info.Chapters = nil
+		for _, c := range cues {
+			info.Chapters = append(info.Chapters, Chapter{
+				Start: seconds(int64(c.offset), sampleRate),
+				Title: labels[c.id],
+			})
+		}
+	}
+	return nil
+}
+
+// parseLabels reads the labl sub-chunks of an associated data list
+func parseLabels(b []byte, labels map[uint32]string) {
+	for len(b) >= 8 {
+		n := int(binary.LittleEndian.Uint32(b[4:]))
+		if n > len(b)-8 {
+			return
+		}
+		if string(b[:4]) == "labl" && n >= 4 {
+			text := b[12 : 8+n]
+			labels[binary.LittleEndian.Uint32(b[8:])] = string(bytes.TrimRight(text, "\x00"))
+		}
+		b = b[min(8+n+n&1, len(b)):]
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.rumenx.com/feed"
+	"go.rumenx.com/feed/internal/frontmatter"
+	"go.rumenx.com/feed/static"
+)
+
+// spec describes a feed in a JSON or YAML file
+type spec struct {
+	Title          string      `json:"title"`
+	Description    string      `json:"description"`
+	Link           string      `json:"link"`
+	Language       string      `json:"language"`
+	Copyright      string      `json:"copyright"`
+	ManagingEditor string      `json:"managingEditor"`
+	Webmaster      string      `json:"webmaster"`
+	TTL            int         `json:"ttl"`
+	MaxItems       int         `json:"maxItems"`
+	Stylesheet     string      `json:"stylesheet"`
+	Image          *feed.Image `json:"image"`
+	Items          []specItem  `json:"items"`
+}
+
+type specItem struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Content     string         `json:"content"`
+	Link        string         `json:"link"`
+	Author      string         `json:"author"`
+	PubDate     string         `json:"pubDate"`
+	GUID        string         `json:"guid"`
+	Categories  []string       `json:"categories"`
+	Comments    string         `json:"comments"`
+	Enclosure   *specEnclosure `json:"enclosure"`
+}
+
+type specEnclosure struct {
+	URL    string      `json:"url"`
+	Length json.Number `json:"length"`
+	Type   string      `json:"type"`
+}
+
+func runBuild(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("feed build", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "rss", "output format of a spec: rss or atom")
+	output := flags.String("o", "", "output file for a spec, or output directory for Markdown posts")
+	title := flags.String("title", "", "site title (Markdown posts)")
+	description := flags.String("description", "", "site description (Markdown posts)")
+	link := flags.String("link", "", "site URL (Markdown posts)")
+	language := flags.String("language", "", "site language (Markdown posts)")
+	author := flags.String("author", "", "default post author (Markdown posts)")
+	drafts := flags.Bool("drafts", false, "include drafts (Markdown posts)")
+	future := flags.Bool("future", false, "include future-dated posts (Markdown posts)")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: feed build [flags] spec.json|spec.yaml|dir")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitTrouble
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitTrouble
+	}
+	input := flags.Arg(0)
+
+	if info, err := os.Stat(input); err == nil && info.IsDir() {
+		if *output == "" {
+			return fail(stderr, "build", errors.New("-o is required when building from a directory"))
+		}
+		b := static.NewBuilder(os.DirFS(input), static.Site{
+			Title:       *title,
+			Description: *description,
+			Link:        *link,
+			Language:    *language,
+			Author:      *author,
+		}).SetIncludeDrafts(*drafts).SetIncludeFuture(*future)
+		if err := b.WriteDir(*output); err != nil {
+			return fail(stderr, "build", err)
+		}
+		return exitOK
+	}
+
+	outFormat, ok := feed.ParseFormat(*format)
+	if !ok {
+		return fail(stderr, "build", unsupportedFormat(*format))
+	}
+	data, err := readInput(input)
+	if err != nil {
+		return fail(stderr, "build", err)
+	}
+	f, err := buildSpec(data, strings.ToLower(filepath.Ext(input)))
+	if err != nil {
+		return fail(stderr, "build", fmt.Errorf("%s: %w", input, err))
+	}
+	rendered, err := f.Render(outFormat)
+	if err != nil {
+		return fail(stderr, "build", err)
+	}
+	if err := writeOutput(*output, rendered, stdout); err != nil {
+		return fail(stderr, "build", err)
+	}
+	return exitOK
+}
+
+// buildSpec decodes a spec and builds its feed. YAML is assumed for .yaml
+// and .yml files, JSON otherwise.
+func buildSpec(data []byte, ext string) (*feed.Feed, error) {
+	if ext == ".yaml" || ext == ".yml" {
+		values, err := frontmatter.ParseYAML(string(data))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = json.Marshal(values); err != nil {
+			return nil, err
+		}
+	}
+
+	var s spec
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("failed to decode spec: %w", err)
+	}
+
+	f := feed.New().
+		SetTitle(s.Title).
+		SetDescription(s.Description).
+		SetLink(s.Link).
+		SetLanguage(s.Language).
+		SetCopyright(s.Copyright).
+		SetManagingEditor(s.ManagingEditor).
+		SetWebmaster(s.Webmaster).
+		SetTTL(s.TTL).
+		SetMaxItems(s.MaxItems).
+		SetStylesheet(s.Stylesheet)
+	if s.Image != nil {
+		f.SetImage(*s.Image)
+	}
+
+	var newest time.Time
+	for i, si := range s.Items {
+		item := feed.Item{
+			Title:       si.Title,
+			Description: si.Description,
+			Content:     si.Content,
+			Link:        si.Link,
+			Author:      si.Author,
+			GUID:        si.GUID,
+			Categories:  si.Categories,
+			Comments:    si.Comments,
+		}
+		if si.PubDate != "" {
+			t, ok := parseSpecDate(si.PubDate)
+			if !ok {
+				return nil, fmt.Errorf("item %d: %w: %q", i+1, feed.ErrInvalidDate, si.PubDate)
+			}
+			item.PubDate = t
+			if t.After(newest) {
+				newest = t
+			}
+		}
+		if si.Enclosure != nil {
+			item.Enclosure = &feed.Enclosure{URL: si.Enclosure.URL, Length: si.Enclosure.Length.String(), Type: si.Enclosure.Type}
+		}
+		f.AddItem(item)
+	}
+
+	// Date the feed by its newest item, so builds are reproducible
+	f.SetLastBuildDate(newest)
+	return f, nil
+}
+
+// parseSpecDate parses the date formats accepted in specs
+func parseSpecDate(s string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC1123Z, time.RFC1123} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return frontmatter.ParseDate(s)
+}
+
+// unsupportedFormat describes an unknown output format
+func unsupportedFormat(name string) error {
+	names := make([]string, len(feed.Formats))
+	for i, format := range feed.Formats {
+		names[i] = string(format)
+	}
+	return fmt.Errorf("%w %q; supported formats: %s", feed.ErrUnknownFormat, name, strings.Join(names, ", "))
+}
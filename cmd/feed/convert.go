@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+
+	"go.rumenx.com/feed"
+)
+
+func runConvert(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("feed convert", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	to := flags.String("to", "atom", "output format: rss or atom")
+	output := flags.String("o", "", "output file; default standard output")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: feed convert [-to rss|atom] [-o file] input")
+		fmt.Fprintln(stderr, "JSON Feed is not supported as an input or output format.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitTrouble
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitTrouble
+	}
+
+	format, ok := feed.ParseFormat(*to)
+	if !ok {
+		return fail(stderr, "convert", unsupportedFormat(*to))
+	}
+	data, err := readInput(flags.Arg(0))
+	if err != nil {
+		return fail(stderr, "convert", err)
+	}
+	f, err := feed.Parse(bytes.NewReader(data))
+	if err != nil {
+		return fail(stderr, "convert", fmt.Errorf("%s: %w", flags.Arg(0), err))
+	}
+	rendered, err := f.Render(format)
+	if err != nil {
+		return fail(stderr, "convert", err)
+	}
+	if err := writeOutput(*output, rendered, stdout); err != nil {
+		return fail(stderr, "convert", err)
+	}
+	return exitOK
+}
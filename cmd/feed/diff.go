@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.rumenx.com/feed"
+)
+
+func runDiff(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("feed diff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	asJSON := flags.Bool("json", false, "print the differences as JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: feed diff [-json] old new")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitTrouble
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return exitTrouble
+	}
+
+	var feeds [2]*feed.Feed
+	for i, name := range flags.Args() {
+		data, err := readInput(name)
+		if err != nil {
+			return fail(stderr, "diff", err)
+		}
+		if feeds[i], err = feed.Parse(bytes.NewReader(data)); err != nil {
+			return fail(stderr, "diff", fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	d := feed.Diff(feeds[0], feeds[1])
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(d); err != nil {
+			return fail(stderr, "diff", err)
+		}
+	} else {
+		for _, c := range d.Channel {
+			fmt.Fprintf(stdout, "* %s: %v -> %v\n", c.Field, c.Old, c.New)
+		}
+		for _, item := range d.Added {
+			fmt.Fprintf(stdout, "+ %s\n", describeItem(item))
+		}
+		for _, item := range d.Removed {
+			fmt.Fprintf(stdout, "- %s\n", describeItem(item))
+		}
+		for _, item := range d.Changed {
+			fields := make([]string, len(item.Changes))
+			for i, c := range item.Changes {
+				fields[i] = c.Field
+			}
+			fmt.Fprintf(stdout, "~ %s (%s)\n", describeItem(item), strings.Join(fields, ", "))
+		}
+	}
+
+	if d.Empty() {
+		return exitOK
+	}
+	return exitFailed
+}
+
+func describeItem(item feed.ItemDiff) string {
+	if item.Link != "" {
+		return fmt.Sprintf("%s <%s>", item.Title, item.Link)
+	}
+	return item.Title
+}
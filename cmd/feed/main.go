@@ -0,0 +1,106 @@
+// Command feed builds, converts, validates, compares and previews feeds
+// from the command line.
+//
+// Usage:
+//
+//	feed build [-format rss|atom] [-o file] spec.json|spec.yaml
+//	feed build -o dir [-title ... -description ... -link ...] dir
+//	feed convert -to rss|atom [-o file] input
+//	feed validate [-json] [-strict] file...
+//	feed diff [-json] old new
+//	feed serve [-addr host:port] file
+//
+// convert reads and writes RSS 2.0 and Atom 1.0 only; JSON Feed is not
+// supported. Inputs may be "-" for standard input, and output goes to
+// standard output unless -o is given. validate and diff exit with status 1 when the feeds
+// are invalid or differ, and all commands exit with status 2 on errors.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Exit codes
+const (
+	exitOK      = 0
+	exitFailed  = 1 // the feed is invalid, or the feeds differ
+	exitTrouble = 2 // bad usage or an I/O error
+)
+
+// command is a feed subcommand
+type command struct {
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) int
+}
+
+var commands = map[string]command{
+	"build":    {"build a feed from a JSON or YAML spec, or a directory of Markdown posts", runBuild},
+	"convert":  {"convert between RSS and Atom", runConvert},
+	"validate": {"check feed files and print a validation report", runValidate},
+	"diff":     {"compare the items of two feed files", runDiff},
+	"serve":    {"preview a feed file over HTTP", runServe},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run dispatches to a subcommand and returns the exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" || args[0] == "help" {
+		usage(stderr)
+		if len(args) == 0 {
+			return exitTrouble
+		}
+		return exitOK
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "feed: unknown command %q\n\n", args[0])
+		usage(stderr)
+		return exitTrouble
+	}
+	return cmd.run(args[1:], stdout, stderr)
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: feed <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-9s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "feed <command> -h" for the flags of a command.`)
+}
+
+// readInput reads a file, or standard input for "-"
+func readInput(name string) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(name)
+}
+
+// writeOutput writes data to a file, or to stdout when name is empty
+func writeOutput(name string, data []byte, stdout io.Writer) error {
+	if name == "" || name == "-" {
+		_, err := stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(name, data, 0o644)
+}
+
+// fail reports an error and returns the trouble exit code
+func fail(stderr io.Writer, cmd string, err error) int {
+	fmt.Fprintf(stderr, "feed %s: %v\n", cmd, err)
+	return exitTrouble
+}
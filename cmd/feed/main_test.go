@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.rumenx.com/feed"
+)
+
+// runCmd runs the command line and returns its exit code and output
+func runCmd(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestUsage(t *testing.T) {
+	if code, _, stderr := runCmd(); code != exitTrouble || !strings.Contains(stderr, "validate") {
+		t.Errorf("Expected usage with exit code 2, got %d: %s", code, stderr)
+	}
+	if code, _, _ := runCmd("help"); code != exitOK {
+		t.Errorf("Expected exit code 0 for help, got %d", code)
+	}
+	if code, _, stderr := runCmd("frobnicate"); code != exitTrouble || !strings.Contains(stderr, `unknown command "frobnicate"`) {
+		t.Errorf("Expected unknown command error, got %d: %s", code, stderr)
+	}
+	if code, _, _ := runCmd("diff", "only-one.xml"); code != exitTrouble {
+		t.Errorf("Expected exit code 2 for missing arguments, got %d", code)
+	}
+}
+
+func TestBuildSpec(t *testing.T) {
+	code, stdout, stderr := runCmd("build", "testdata/spec.yaml")
+	if code != exitOK {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	for _, want := range []string{
+		"<title>Release notes</title>",
+		"<category>major</category>",
+		"<content:encoded><![CDATA[<p>Big changes.</p>\n]]></content:encoded>",
+		`<enclosure url="https://example.com/releases/1.1.zip" length="1024" type="application/zip"></enclosure>`,
+		"<lastBuildDate>01 Mar 24 10:00 UTC</lastBuildDate>",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, stdout)
+		}
+	}
+
+	out := filepath.Join(t.TempDir(), "feed.atom")
+	if code, _, stderr := runCmd("build", "-format", "atom", "-o", out, "testdata/spec.json"); code != exitOK {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil || !strings.Contains(string(data), "<feed xmlns=\"http://www.w3.org/2005/Atom\">") {
+		t.Errorf("Expected Atom output file, got %v: %s", err, data)
+	}
+}
+
+func TestBuildSpecErrors(t *testing.T) {
+	dir := t.TempDir()
+	unknown := filepath.Join(dir, "unknown.json")
+	os.WriteFile(unknown, []byte(`{"title": "x", "tittle": "typo"}`), 0o644)
+	if code, _, stderr := runCmd("build", unknown); code != exitTrouble || !strings.Contains(stderr, "tittle") {
+		t.Errorf("Expected unknown field error, got %d: %s", code, stderr)
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	os.WriteFile(invalid, []byte(`{"title": "x", "description": "y"}`), 0o644)
+	if code, _, stderr := runCmd("build", invalid); code != exitTrouble || !strings.Contains(stderr, "feed link is required") {
+		t.Errorf("Expected validation error, got %d: %s", code, stderr)
+	}
+
+	if code, _, stderr := runCmd("build", "-format", "json", "testdata/spec.json"); code != exitTrouble || !strings.Contains(stderr, "supported formats: rss, atom") {
+		t.Errorf("Expected unsupported format error, got %d: %s", code, stderr)
+	}
+}
+
+func TestBuildMarkdown(t *testing.T) {
+	out := t.TempDir()
+	code, _, stderr := runCmd("build", "-o", out, "-title", "Blog", "-description", "Posts", "-link", "https://example.com", "testdata/posts")
+	if code != exitOK {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	for _, name := range []string{"feed.xml", "feed.atom", "tags/news/feed.xml"} {
+		if _, err := os.Stat(filepath.Join(out, name)); err != nil {
+			t.Errorf("Expected %s to be written: %v", name, err)
+		}
+	}
+
+	if code, _, stderr := runCmd("build", "testdata/posts"); code != exitTrouble || !strings.Contains(stderr, "-o is required") {
+		t.Errorf("Expected missing output error, got %d: %s", code, stderr)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	code, stdout, stderr := runCmd("convert", "-to", "atom", "testdata/new.xml")
+	if code != exitOK {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "<entry>") || !strings.Contains(stdout, "<title>Version 2.0</title>") {
+		t.Errorf("Expected Atom entries, got:\n%s", stdout)
+	}
+
+	if code, _, stderr := runCmd("convert", "-to", "json", "testdata/new.xml"); code != exitTrouble || !strings.Contains(stderr, "unknown feed format") {
+		t.Errorf("Expected unsupported format error, got %d: %s", code, stderr)
+	}
+	if code, _, _ := runCmd("convert", "testdata/missing.xml"); code != exitTrouble {
+		t.Errorf("Expected exit code 2 for a missing file, got %d", code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	code, stdout, _ := runCmd("validate", "testdata/old.xml")
+	if code != exitOK || !strings.Contains(stdout, "testdata/old.xml: 0 errors, 0 warnings") {
+		t.Errorf("Expected valid feed, got %d:\n%s", code, stdout)
+	}
+
+	code, stdout, _ = runCmd("validate", "testdata/old.xml", "testdata/invalid.xml")
+	if code != exitFailed {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	for _, want := range []string{
+		"testdata/invalid.xml: error: feed description is required",
+		`testdata/invalid.xml: error: invalid URL format: "not a url"`,
+		"testdata/invalid.xml: item 1: warning: item link is required",
+		"testdata/invalid.xml: item 2: warning: item title is required",
+		`testdata/invalid.xml: item 2: error: duplicate GUID "same", also used by item 1`,
+		"testdata/invalid.xml: 3 errors, 4 warnings",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected report to contain %q, got:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestValidateJSON(t *testing.T) {
+	code, stdout, _ := runCmd("validate", "-json", "-strict", "testdata/spec.json")
+	if code != exitFailed {
+		t.Errorf("Expected exit code 1 for a spec that is not a feed, got %d", code)
+	}
+
+	var reports []report
+	if err := json.Unmarshal([]byte(stdout), &reports); err != nil {
+		t.Fatalf("Expected JSON report, got %v:\n%s", err, stdout)
+	}
+	if len(reports) != 1 || reports[0].Valid || reports[0].Errors != 1 {
+		t.Errorf("Unexpected report %+v", reports)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	code, stdout, _ := runCmd("diff", "testdata/old.xml", "testdata/new.xml")
+	if code != exitFailed {
+		t.Errorf("Expected exit code 1 for differing feeds, got %d", code)
+	}
+	want := "+ Version 2.0 <https://example.com/releases/2.0>\n" +
+		"- Version 1.0 <https://example.com/releases/1.0>\n" +
+		"~ Version 1.1 (patched) <https://example.com/releases/1.1> (title)\n"
+	if stdout != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, stdout)
+	}
+
+	code, stdout, _ = runCmd("diff", "-json", "testdata/old.xml", "testdata/old.xml")
+	if code != exitOK {
+		t.Errorf("Expected exit code 0 for identical feeds, got %d", code)
+	}
+	var d feed.DiffResult
+	if err := json.Unmarshal([]byte(stdout), &d); err != nil || !d.Empty() {
+		t.Errorf("Expected empty JSON diff, got %v:\n%s", err, stdout)
+	}
+}
+
+func TestPreviewHandler(t *testing.T) {
+	server := httptest.NewServer(previewHandler("testdata/new.xml"))
+	defer server.Close()
+
+	tests := []struct {
+		path, contentType, contains string
+		status                      int
+	}{
+		{"/", "text/html; charset=utf-8", "Version 2.0", http.StatusOK},
+		{"/", "text/html; charset=utf-8", server.URL + "/feed.xml", http.StatusOK},
+		{"/feed.xml", "application/xml; charset=utf-8", "<rss version=\"2.0\">", http.StatusOK},
+		{"/feed.atom", "application/atom+xml; charset=utf-8", "<entry>", http.StatusOK},
+		{"/missing", "", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(server.URL + tt.path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", tt.path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != tt.status {
+			t.Errorf("GET %s: expected status %d, got %d", tt.path, tt.status, resp.StatusCode)
+		}
+		if tt.contentType != "" && resp.Header.Get("Content-Type") != tt.contentType {
+			t.Errorf("GET %s: expected Content-Type %q, got %q", tt.path, tt.contentType, resp.Header.Get("Content-Type"))
+		}
+		if !strings.Contains(string(body), tt.contains) {
+			t.Errorf("GET %s: expected body to contain %q", tt.path, tt.contains)
+		}
+	}
+
+	broken := httptest.NewServer(previewHandler("testdata/spec.json"))
+	defer broken.Close()
+	resp, err := http.Get(broken.URL + "/feed.xml")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 for a file that is not a feed, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.rumenx.com/feed"
+)
+
+func runServe(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("feed serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	addr := flags.String("addr", "localhost:8080", "address to listen on")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: feed serve [-addr host:port] file")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitTrouble
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitTrouble
+	}
+
+	fmt.Fprintf(stdout, "Serving %s on http://%s/ (feed.xml, feed.atom)\n", flags.Arg(0), *addr)
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           previewHandler(flags.Arg(0)),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if err := server.ListenAndServe(); err != nil {
+		return fail(stderr, "serve", err)
+	}
+	return exitOK
+}
+
+// previewHandler serves a feed file as an HTML preview at /, and as RSS and
+// Atom at /feed.xml and /feed.atom. The file is read on every request, so
+// edits show up on reload.
+func previewHandler(name string) http.Handler {
+	mux := http.NewServeMux()
+	load := func(w http.ResponseWriter) *feed.Feed {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return nil
+		}
+		f, err := feed.Parse(bytes.NewReader(data))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("%s: %v", name, err), http.StatusUnprocessableEntity)
+			return nil
+		}
+		return f
+	}
+
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		f := load(w)
+		if f == nil {
+			return
+		}
+		page, err := f.HTML(strings.TrimSuffix(feed.RequestURL(r), r.URL.RequestURI()) + "/feed.xml")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page)
+	})
+	for _, format := range feed.Formats {
+		format := format
+		mux.HandleFunc("/feed"+format.Extension(), func(w http.ResponseWriter, r *http.Request) {
+			f := load(w)
+			if f == nil {
+				return
+			}
+			data, err := f.Render(format)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			}
+			w.Header().Set("Content-Type", format.ContentType())
+			w.Write(data)
+		})
+	}
+	return mux
+}
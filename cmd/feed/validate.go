@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+
+	"go.rumenx.com/feed"
+)
+
+// Issue severities
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+)
+
+// issue is a single validation finding
+type issue struct {
+	Item     int    `json:"item,omitempty"` // 1-based item index; 0 for the channel
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// report is the validation report of one file
+type report struct {
+	File     string  `json:"file"`
+	Valid    bool    `json:"valid"`
+	Errors   int     `json:"errors"`
+	Warnings int     `json:"warnings"`
+	Issues   []issue `json:"issues"`
+}
+
+func (r *report) add(item int, severity, message string) {
+	r.Issues = append(r.Issues, issue{Item: item, Severity: severity, Message: message})
+	if severity == severityError {
+		r.Errors++
+	} else {
+		r.Warnings++
+	}
+}
+
+func runValidate(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("feed validate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	strict := flags.Bool("strict", false, "treat warnings as errors")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: feed validate [-json] [-strict] file...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitTrouble
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitTrouble
+	}
+
+	reports := make([]report, 0, flags.NArg())
+	for _, name := range flags.Args() {
+		data, err := readInput(name)
+		if err != nil {
+			return fail(stderr, "validate", err)
+		}
+		r := validate(name, data)
+		r.Valid = r.Errors == 0 && (!*strict || r.Warnings == 0)
+		reports = append(reports, r)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(reports); err != nil {
+			return fail(stderr, "validate", err)
+		}
+	} else {
+		for _, r := range reports {
+			for _, is := range r.Issues {
+				if is.Item > 0 {
+					fmt.Fprintf(stdout, "%s: item %d: %s: %s\n", r.File, is.Item, is.Severity, is.Message)
+				} else {
+					fmt.Fprintf(stdout, "%s: %s: %s\n", r.File, is.Severity, is.Message)
+				}
+			}
+			fmt.Fprintf(stdout, "%s: %s, %s\n", r.File, plural(r.Errors, "error"), plural(r.Warnings, "warning"))
+		}
+	}
+
+	for _, r := range reports {
+		if !r.Valid {
+			return exitFailed
+		}
+	}
+	return exitOK
+}
+
+// validate parses a feed document and checks it
+func validate(name string, data []byte) report {
+	r := report{File: name, Issues: []issue{}}
+	f, err := feed.Parse(bytes.NewReader(data))
+	if err != nil {
+		r.add(0, severityError, err.Error())
+		return r
+	}
+
+	if f.GetTitle() == "" {
+		r.add(0, severityError, feed.ErrMissingTitle.Error())
+	}
+	if f.GetDescription() == "" {
+		r.add(0, severityError, feed.ErrMissingDescription.Error())
+	}
+	if f.GetLink() == "" {
+		r.add(0, severityError, feed.ErrMissingLink.Error())
+	} else if !validURL(f.GetLink()) {
+		r.add(0, severityError, fmt.Sprintf("%v: %q", feed.ErrInvalidURL, f.GetLink()))
+	}
+	if len(f.GetItems()) == 0 {
+		r.add(0, severityWarning, feed.ErrEmptyFeed.Error())
+	}
+
+	guids := make(map[string]int)
+	for i, item := range f.GetItems() {
+		n := i + 1
+		if item.Title == "" && item.Description == "" {
+			r.add(n, severityError, "item title or description is required")
+		} else if item.Title == "" {
+			r.add(n, severityWarning, feed.ErrMissingItemTitle.Error())
+		}
+		if item.Link == "" {
+			r.add(n, severityWarning, feed.ErrMissingItemLink.Error())
+		} else if !validURL(item.Link) {
+			r.add(n, severityError, fmt.Sprintf("%v: %q", feed.ErrInvalidURL, item.Link))
+		}
+		if item.PubDate.IsZero() {
+			r.add(n, severityWarning, "item publication date is missing")
+		}
+		if item.GUID == "" && item.Link == "" {
+			r.add(n, severityWarning, "item has neither a GUID nor a link to identify it")
+		}
+		if item.GUID != "" {
+			if first, ok := guids[item.GUID]; ok {
+				r.add(n, severityError, fmt.Sprintf("duplicate GUID %q, also used by item %d", item.GUID, first))
+			} else {
+				guids[item.GUID] = n
+			}
+		}
+		if item.Enclosure != nil && !validURL(item.Enclosure.URL) {
+			r.add(n, severityError, fmt.Sprintf("%v: enclosure %q", feed.ErrInvalidURL, item.Enclosure.URL))
+		}
+	}
+	return r
+}
+
+// validURL reports whether s is an absolute http or https URL
+func validURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,532 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// targets lists the tag targets understood by feed.ItemsFrom and the item
+// field each one is written to
+var targets = map[string]string{
+	"title":            "Title",
+	"description":      "Description",
+	"content":          "Content",
+	"link":             "Link",
+	"author":           "Author",
+	"pubDate":          "PubDate",
+	"guid":             "GUID",
+	"category":         "Categories",
+	"comments":         "Comments",
+	"enclosure.url":    "Enclosure.URL",
+	"enclosure.length": "Enclosure.Length",
+	"enclosure.type":   "Enclosure.Type",
+	"source.url":       "Source.URL",
+	"source.title":     "Source.Value",
+}
+
+// valueKind is how a field value is turned into an item value
+type valueKind int
+
+const (
+	kindString valueKind = iota
+	kindInt
+	kindUint
+	kindFloat
+	kindBool
+	kindStringer
+	kindTime
+	kindStruct
+)
+
+// fieldType describes a supported field type
+type fieldType struct {
+	kind        valueKind
+	named       bool // a named type whose value must be converted
+	pointer     bool // *T
+	slice       bool // []T
+	elemPointer bool // []*T
+	structName  string
+	structType  *ast.StructType
+}
+
+// Reasons a field type cannot be mapped
+var (
+	errUnsupported   = errors.New("unsupported type")
+	errNeedsStringer = errors.New("unsupported type from another package")
+	errNeedsTime     = errors.New("time.Time is required")
+)
+
+// basicKinds maps predeclared types to value kinds
+var basicKinds = map[string]valueKind{
+	"string": kindString,
+	"bool":   kindBool,
+	"int":    kindInt, "int8": kindInt, "int16": kindInt, "int32": kindInt, "int64": kindInt,
+	"uint": kindUint, "uint8": kindUint, "uint16": kindUint, "uint32": kindUint, "uint64": kindUint,
+	"float32": kindFloat, "float64": kindFloat,
+	"byte": kindUint, "rune": kindInt,
+}
+
+// generator holds the parsed package
+type generator struct {
+	fset     *token.FileSet
+	pkgName  string
+	types    map[string]*ast.TypeSpec
+	stringer map[string]bool // types with a String() string method
+	imports  map[*ast.File]map[string]string
+	fileOf   map[*ast.TypeSpec]*ast.File
+	errs     []error
+
+	buf      bytes.Buffer
+	assigned map[string]bool // targets written by an earlier field
+	strconv  bool
+	visiting map[string]bool
+}
+
+// Generate parses the package in dir and returns the formatted source of
+// converters for the named types. skip names a file to ignore, usually the
+// previous output.
+func Generate(dir string, typeNames []string, skip string) ([]byte, error) {
+	g := &generator{
+		fset:     token.NewFileSet(),
+		types:    make(map[string]*ast.TypeSpec),
+		stringer: make(map[string]bool),
+		imports:  make(map[*ast.File]map[string]string),
+		fileOf:   make(map[*ast.TypeSpec]*ast.File),
+	}
+	if err := g.parse(dir, skip); err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	for _, name := range typeNames {
+		name = strings.TrimSpace(name)
+		spec, ok := g.types[name]
+		if !ok {
+			g.errs = append(g.errs, fmt.Errorf("type %s not found in %s", name, dir))
+			continue
+		}
+		st, ok := spec.Type.(*ast.StructType)
+		if !ok {
+			g.errorf(spec.Pos(), "type %s is not a struct", name)
+			continue
+		}
+		g.generateType(name, st)
+		body.Write(g.buf.Bytes())
+		g.buf.Reset()
+	}
+	if len(g.errs) > 0 {
+		return nil, errors.Join(g.errs...)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by feedgen-mapper; DO NOT EDIT.\n\npackage %s\n\nimport (\n", g.pkgName)
+	if g.strconv {
+		out.WriteString("\t\"strconv\"\n\n")
+	}
+	out.WriteString("\t\"go.rumenx.com/feed\"\n)\n")
+	out.Write(body.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return src, nil
+}
+
+// parse reads the non-test Go files of the package
+func (g *generator) parse(dir, skip string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		base := filepath.Base(path)
+		if strings.HasSuffix(base, "_test.go") || base == skip {
+			continue
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		file, err := parser.ParseFile(g.fset, path, src, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+		if g.pkgName == "" {
+			g.pkgName = file.Name.Name
+		}
+
+		imports := make(map[string]string)
+		for _, imp := range file.Imports {
+			path, _ := strconv.Unquote(imp.Path.Value)
+			name := path[strings.LastIndex(path, "/")+1:]
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			imports[name] = path
+		}
+		g.imports[file] = imports
+
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					if ts, ok := spec.(*ast.TypeSpec); ok {
+						g.types[ts.Name.Name] = ts
+						g.fileOf[ts] = file
+					}
+				}
+			case *ast.FuncDecl:
+				if recv := receiverName(d); recv != "" && isStringMethod(d) {
+					g.stringer[recv] = true
+				}
+			}
+		}
+	}
+
+	if g.pkgName == "" {
+		return fmt.Errorf("no Go files in %s", dir)
+	}
+	return nil
+}
+
+// receiverName returns the receiver type name of a method
+func receiverName(d *ast.FuncDecl) string {
+	if d.Recv == nil || len(d.Recv.List) != 1 {
+		return ""
+	}
+	t := d.Recv.List[0].Type
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = star.X
+	}
+	if ident, ok := t.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// isStringMethod reports whether d is String() string
+func isStringMethod(d *ast.FuncDecl) bool {
+	if d.Name.Name != "String" || d.Type.Params.NumFields() != 0 || d.Type.Results.NumFields() != 1 {
+		return false
+	}
+	ident, ok := d.Type.Results.List[0].Type.(*ast.Ident)
+	return ok && ident.Name == "string"
+}
+
+func (g *generator) errorf(pos token.Pos, format string, args ...any) {
+	g.errs = append(g.errs, fmt.Errorf("%s: %s", g.fset.Position(pos), fmt.Sprintf(format, args...)))
+}
+
+// generateType writes the converters of one struct type
+func (g *generator) generateType(name string, st *ast.StructType) {
+	g.assigned = make(map[string]bool)
+	g.visiting = map[string]bool{name: true}
+
+	g.buf.WriteString("\n// ToFeedItem converts v into a feed item\n")
+	fmt.Fprintf(&g.buf, "func (v *%s) ToFeedItem() feed.Item {\n\tvar item feed.Item\n", name)
+	g.fields(name, st, "v", g.fileOf[g.types[name]])
+	g.buf.WriteString("\treturn item\n}\n")
+
+	fmt.Fprintf(&g.buf, "\n// %sFeedItems converts values into feed items\n", name)
+	fmt.Fprintf(&g.buf, "func %sFeedItems(values []%s) []feed.Item {\n", name, name)
+	g.buf.WriteString("\titems := make([]feed.Item, len(values))\n")
+	g.buf.WriteString("\tfor i := range values {\n\t\titems[i] = values[i].ToFeedItem()\n\t}\n\treturn items\n}\n")
+}
+
+// fields writes the mapping of the tagged fields of a struct, recursing
+// into untagged nested structs. expr is the expression of the struct value.
+func (g *generator) fields(path string, st *ast.StructType, expr string, file *ast.File) {
+	for _, field := range st.Fields.List {
+		names := make([]string, 0, len(field.Names))
+		for _, n := range field.Names {
+			names = append(names, n.Name)
+		}
+		if len(names) == 0 {
+			names = append(names, embeddedName(field.Type))
+		}
+
+		var tag string
+		tagged := false
+		if field.Tag != nil {
+			raw, _ := strconv.Unquote(field.Tag.Value)
+			tag, tagged = reflect.StructTag(raw).Lookup("feed")
+		}
+		if tag == "-" {
+			continue
+		}
+		target, options, _ := strings.Cut(tag, ",")
+
+		for _, name := range names {
+			if name == "" {
+				continue
+			}
+			fieldPath := path + "." + name
+			fieldExpr := expr + "." + name
+
+			if !tagged {
+				ft, err := g.resolve(field.Type, file, false)
+				if err != nil || ft.kind != kindStruct || ft.slice || !ast.IsExported(name) || g.visiting[ft.structName] && ft.structName != "" {
+					continue
+				}
+				g.nested(fieldPath, ft, fieldExpr, file)
+				continue
+			}
+
+			if _, ok := targets[target]; !ok {
+				g.errorf(field.Pos(), "%s: unknown feed tag target %q", fieldPath, target)
+				continue
+			}
+			if !ast.IsExported(name) {
+				g.errorf(field.Pos(), "%s: field is not exported", fieldPath)
+				continue
+			}
+			ft, err := g.resolve(field.Type, file, options == "stringer")
+			if err == nil {
+				err = g.assign(target, ft, fieldExpr)
+			}
+			switch {
+			case errors.Is(err, errNeedsStringer):
+				g.errorf(field.Pos(), "%s: unsupported type %s; add the \",stringer\" tag option if it implements fmt.Stringer", fieldPath, typeString(field.Type))
+			case errors.Is(err, errNeedsTime):
+				g.errorf(field.Pos(), "%s: unsupported type %s for %q, time.Time is required", fieldPath, typeString(field.Type), target)
+			case err != nil:
+				g.errorf(field.Pos(), "%s: unsupported type %s for %q", fieldPath, typeString(field.Type), target)
+			}
+		}
+	}
+}
+
+// nested writes the mapping of an untagged nested struct
+func (g *generator) nested(path string, ft fieldType, expr string, file *ast.File) {
+	if ft.structName != "" {
+		g.visiting[ft.structName] = true
+		defer delete(g.visiting, ft.structName)
+		file = g.fileOf[g.types[ft.structName]]
+	}
+	if ft.pointer {
+		fmt.Fprintf(&g.buf, "\tif %s != nil {\n", expr)
+		defer g.buf.WriteString("\t}\n")
+	}
+	g.fields(path, ft.structType, expr, file)
+}
+
+// embeddedName returns the field name of an embedded field
+func embeddedName(t ast.Expr) string {
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = star.X
+	}
+	switch t := t.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	}
+	return ""
+}
+
+// resolve classifies a field type. stringer forces types from other
+// packages to be treated as fmt.Stringer.
+func (g *generator) resolve(expr ast.Expr, file *ast.File, stringer bool) (fieldType, error) {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		ft, err := g.resolve(t.X, file, stringer)
+		if err != nil {
+			return ft, err
+		}
+		if ft.pointer || ft.slice {
+			return ft, errUnsupported
+		}
+		ft.pointer = true
+		return ft, nil
+
+	case *ast.ArrayType:
+		ft, err := g.resolve(t.Elt, file, stringer)
+		if err != nil {
+			return ft, err
+		}
+		if ft.slice || ft.kind == kindStruct || ft.kind == kindTime {
+			return ft, errUnsupported
+		}
+		ft.elemPointer = ft.pointer
+		ft.pointer = false
+		ft.slice = true
+		return ft, nil
+
+	case *ast.Ident:
+		if kind, ok := basicKinds[t.Name]; ok {
+			return fieldType{kind: kind}, nil
+		}
+		if g.stringer[t.Name] {
+			return fieldType{kind: kindStringer}, nil
+		}
+		spec, ok := g.types[t.Name]
+		if !ok {
+			return fieldType{}, errUnsupported
+		}
+		if st, ok := spec.Type.(*ast.StructType); ok {
+			return fieldType{kind: kindStruct, structName: t.Name, structType: st}, nil
+		}
+		ft, err := g.resolve(spec.Type, g.fileOf[spec], stringer)
+		if err != nil || ft.pointer || ft.slice || ft.kind == kindStruct {
+			return ft, errUnsupported
+		}
+		if ft.kind == kindTime {
+			if !spec.Assign.IsValid() {
+				// A defined type based on time.Time has none of its methods
+				return ft, errUnsupported
+			}
+			return ft, nil
+		}
+		ft.named = true
+		return ft, nil
+
+	case *ast.SelectorExpr:
+		pkg, _ := t.X.(*ast.Ident)
+		if pkg != nil && g.imports[file][pkg.Name] == "time" && t.Sel.Name == "Time" {
+			return fieldType{kind: kindTime}, nil
+		}
+		if stringer {
+			return fieldType{kind: kindStringer}, nil
+		}
+		return fieldType{}, errNeedsStringer
+
+	case *ast.StructType:
+		return fieldType{kind: kindStruct, structType: t}, nil
+	}
+	return fieldType{}, errUnsupported
+}
+
+// typeString renders a type expression for diagnostics
+func typeString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), expr); err != nil {
+		return fmt.Sprintf("%T", expr)
+	}
+	return buf.String()
+}
+
+// stringValue returns an expression converting a scalar value to a string
+func (g *generator) stringValue(ft fieldType, expr string) (string, error) {
+	switch ft.kind {
+	case kindString:
+		if ft.named {
+			return "string(" + expr + ")", nil
+		}
+		return expr, nil
+	case kindInt:
+		g.strconv = true
+		return "strconv.FormatInt(int64(" + expr + "), 10)", nil
+	case kindUint:
+		g.strconv = true
+		return "strconv.FormatUint(uint64(" + expr + "), 10)", nil
+	case kindFloat:
+		g.strconv = true
+		return "strconv.FormatFloat(float64(" + expr + "), 'f', -1, 64)", nil
+	case kindBool:
+		g.strconv = true
+		return "strconv.FormatBool(bool(" + expr + "))", nil
+	case kindStringer:
+		return expr + ".String()", nil
+	}
+	return "", errUnsupported
+}
+
+// deref returns the expression of a pointer's value; Stringers are called
+// through the pointer so pointer receivers work
+func deref(ft fieldType, expr string) string {
+	if ft.kind == kindStringer {
+		return expr
+	}
+	return "*" + expr
+}
+
+// assign writes the mapping of one tagged field
+func (g *generator) assign(target string, ft fieldType, expr string) error {
+	first := !g.assigned[target]
+	g.assigned[target] = true
+
+	if ft.pointer {
+		fmt.Fprintf(&g.buf, "\tif %s != nil {\n", expr)
+		defer g.buf.WriteString("\t}\n")
+		expr = deref(ft, expr)
+	}
+
+	switch target {
+	case "pubDate":
+		if ft.kind != kindTime || ft.slice {
+			return errNeedsTime
+		}
+		if first {
+			fmt.Fprintf(&g.buf, "\titem.PubDate = %s\n", expr)
+		} else {
+			fmt.Fprintf(&g.buf, "\tif item.PubDate.IsZero() {\n\t\titem.PubDate = %s\n\t}\n", expr)
+		}
+		return nil
+
+	case "category":
+		if !ft.slice {
+			value, err := g.stringValue(ft, expr)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&g.buf, "\tif s := %s; s != \"\" {\n\t\titem.Categories = append(item.Categories, s)\n\t}\n", value)
+			return nil
+		}
+		elem := ft
+		elem.slice, elem.pointer = false, false
+		value, err := g.stringValue(elem, "c")
+		if elem.elemPointer {
+			value, err = g.stringValue(elem, deref(elem, "c"))
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&g.buf, "\tfor _, c := range %s {\n", expr)
+		if elem.elemPointer {
+			g.buf.WriteString("\t\tif c == nil {\n\t\t\tcontinue\n\t\t}\n")
+		}
+		if value == "c" {
+			g.buf.WriteString("\t\tif c != \"\" {\n\t\t\titem.Categories = append(item.Categories, c)\n\t\t}\n\t}\n")
+			return nil
+		}
+		fmt.Fprintf(&g.buf, "\t\tif s := %s; s != \"\" {\n\t\t\titem.Categories = append(item.Categories, s)\n\t\t}\n\t}\n", value)
+		return nil
+	}
+
+	if ft.slice {
+		return errUnsupported
+	}
+	value, err := g.stringValue(ft, expr)
+	if err != nil {
+		return err
+	}
+
+	dst := "item." + targets[target]
+	if parent, _, ok := strings.Cut(targets[target], "."); ok {
+		// Enclosures and sources are only created for non-empty values
+		fmt.Fprintf(&g.buf, "\tif s := %s; s != \"\" {\n", value)
+		fmt.Fprintf(&g.buf, "\t\tif item.%s == nil {\n\t\t\titem.%s = &feed.%s{}\n\t\t}\n", parent, parent, parent)
+		fmt.Fprintf(&g.buf, "\t\tif %s == \"\" {\n\t\t\t%s = s\n\t\t}\n\t}\n", dst, dst)
+		return nil
+	}
+	if first {
+		fmt.Fprintf(&g.buf, "\t%s = %s\n", dst, value)
+	} else {
+		fmt.Fprintf(&g.buf, "\tif %s == \"\" {\n\t\t%s = %s\n\t}\n", dst, dst, value)
+	}
+	return nil
+}
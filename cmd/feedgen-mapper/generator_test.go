@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestGenerateGolden(t *testing.T) {
+	dir := filepath.Join("testdata", "basic")
+	got, err := Generate(dir, []string{"BlogPost", "Episode"}, "blogpost_feed.go")
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	golden := filepath.Join(dir, "blogpost_feed.go.golden")
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("Generated code does not match %s; run go test -update to refresh it\n%s", golden, got)
+	}
+}
+
+func TestGenerateDiagnostics(t *testing.T) {
+	_, err := Generate(filepath.Join("testdata", "invalid"), []string{"Post"}, "")
+	if err == nil {
+		t.Fatal("Expected diagnostics for unsupported fields")
+	}
+
+	for _, want := range []string{
+		`post.go:11:2: Post.Title: unknown feed tag target "headline"`,
+		`post.go:12:2: Post.Link: unsupported type *url.URL; add the ",stringer" tag option if it implements fmt.Stringer`,
+		`post.go:13:2: Post.Meta: unsupported type map[string]string for "description"`,
+		`post.go:14:2: Post.Created: unsupported type string for "pubDate", time.Time is required`,
+		`post.go:15:2: Post.Tags: unsupported type [][]string for "category"`,
+		`post.go:16:2: Post.Date: unsupported type Date for "pubDate"`,
+		`post.go:17:2: Post.internal: field is not exported`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected diagnostic %q in:\n%v", want, err)
+		}
+	}
+}
+
+func TestGenerateUnknownType(t *testing.T) {
+	_, err := Generate(filepath.Join("testdata", "basic"), []string{"Missing", "Status"}, "")
+	if err == nil || !strings.Contains(err.Error(), "type Missing not found") || !strings.Contains(err.Error(), "type Status is not a struct") {
+		t.Errorf("Expected errors for unknown and non-struct types, got %v", err)
+	}
+}
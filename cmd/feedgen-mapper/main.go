@@ -0,0 +1,51 @@
+// Command feedgen-mapper generates reflection-free feed item converters for
+// structs tagged with `feed:"..."` struct tags, the same tags understood by
+// feed.ItemsFrom.
+//
+// Add a directive next to the type and run go generate:
+//
+//	//go:generate go run go.rumenx.com/feed/cmd/feedgen-mapper -type BlogPost
+//
+// For each type the tool writes a ToFeedItem method and a <Type>FeedItems
+// slice helper to <type>_feed.go.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma-separated list of struct type names; required")
+	output := flag.String("output", "", "output file name; default <type>_feed.go")
+	dir := flag.String("dir", ".", "package directory")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: feedgen-mapper -type T[,T...] [-output file] [-dir dir]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	types := strings.Split(*typeNames, ",")
+
+	if *output == "" {
+		*output = strings.ToLower(types[0]) + "_feed.go"
+	}
+	outPath := filepath.Join(*dir, *output)
+
+	src, err := Generate(*dir, types, *output)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "feedgen-mapper:", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(outPath, src, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "feedgen-mapper:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,62 @@
+package blog
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Status int
+
+func (s Status) String() string {
+	return [...]string{"draft", "published"}[s]
+}
+
+type Slug string
+
+type Tag struct {
+	Name string
+}
+
+func (t *Tag) String() string {
+	return "#" + t.Name
+}
+
+type Author struct {
+	Email string `feed:"author"`
+	Name  string
+}
+
+type Media struct {
+	URL    string `feed:"enclosure.url"`
+	Size   int64  `feed:"enclosure.length"`
+	Format string `feed:"enclosure.type"`
+}
+
+// BlogPost is mapped to feed items
+type BlogPost struct {
+	ID        uuid.UUID  `feed:"guid,stringer"`
+	Title     string     `feed:"title"`
+	Summary   string     `feed:"description"`
+	Body      *string    `feed:"content"`
+	Slug      Slug       `feed:"link"`
+	Published time.Time  `feed:"pubDate"`
+	Updated   *time.Time `feed:"pubDate"`
+	Tags      []*Tag     `feed:"category"`
+	Labels    []string   `feed:"category"`
+	Status    Status     `feed:"category"`
+	Author    Author
+	Media     *Media
+	Meta      struct {
+		Permalink string `feed:"link"`
+	}
+	Internal string `feed:"-"`
+	Next     *BlogPost
+}
+
+type Episode struct {
+	Title    string `feed:"title"`
+	URL      string `feed:"link"`
+	Duration uint   `feed:"comments"`
+	Rating   float32
+}
@@ -0,0 +1,18 @@
+package blog
+
+import (
+	"net/url"
+	"time"
+)
+
+type Date time.Time
+
+type Post struct {
+	Title    string            `feed:"headline"`
+	Link     *url.URL          `feed:"link"`
+	Meta     map[string]string `feed:"description"`
+	Created  string            `feed:"pubDate"`
+	Tags     [][]string        `feed:"category"`
+	Date     Date              `feed:"pubDate"`
+	internal string            `feed:"guid"`
+}
@@ -0,0 +1,252 @@
+package feed
+
+import (
+	"net/url"
+	"sort"
+)
+
+// SortItems sorts the feed items using the given less function.
+// The sort is stable, so items that compare equal keep their relative order.
+func (f *Feed) SortItems(less func(a, b Item) bool) *Feed {
+	sort.SliceStable(f.items, func(i, j int) bool {
+		return less(f.items[i], f.items[j])
+	})
+	return f
+}
+
+// SortByDate sorts the feed items by publication date, newest first
+func (f *Feed) SortByDate() *Feed {
+	return f.SortItems(func(a, b Item) bool {
+		return a.PubDate.After(b.PubDate)
+	})
+}
+
+// Limit keeps at most n items, dropping the rest.
+// Combine it with SortByDate to keep only the latest n items.
+func (f *Feed) Limit(n int) *Feed {
+	if n >= 0 && n < len(f.items) {
+		f.items = f.items[:n]
+	}
+	return f
+}
+
+// Filter keeps only the items for which keep returns true
+func (f *Feed) Filter(keep func(Item) bool) *Feed {
+	filtered := f.items[:0]
+	for _, item := range f.items {
+		if keep(item) {
+			filtered = append(filtered, item)
+		}
+	}
+	// Clear the tail so dropped items can be garbage collected
+	for i := len(filtered); i < len(f.items); i++ {
+		f.items[i] = Item{}
+	}
+	f.items = filtered
+	return f
+}
+
+// Dedupe removes items that share a GUID or, when the GUID is empty, a link
+// with an earlier item. The first occurrence is kept.
+func (f *Feed) Dedupe() *Feed {
+	seen := make(map[string]bool, len(f.items))
+	return f.Filter(func(item Item) bool {
+		key := item.key()
+		if key == "" {
+			return true
+		}
+		if seen[key] {
+			return false
+		}
+		seen[key] = true
+		return true
+	})
+}
+
+// RemoveItem removes all items with the given GUID.
+// It reports whether any item was removed.
+func (f *Feed) RemoveItem(guid string) bool {
+	before := len(f.items)
+	f.Filter(func(item Item) bool {
+		return item.GUID != guid
+	})
+	return len(f.items) != before
+}
+
+// ReplaceItem replaces the first item with the given GUID.
+// It reports whether an item was replaced.
+func (f *Feed) ReplaceItem(guid string, item Item) bool {
+	for i := range f.items {
+		if f.items[i].GUID == guid {
+			f.items[i] = item
+			return true
+		}
+	}
+	return false
+}
+
+// SetMaxItems sets the maximum number of items rendered by RSS() and Atom().
+// The feed itself is not modified; zero or a negative value means no limit.
+func (f *Feed) SetMaxItems(n int) *Feed {
+	f.maxItems = n
+	return f
+}
+
+// GetMaxItems returns the maximum number of rendered items
+func (f *Feed) GetMaxItems() int {
+	return f.maxItems
+}
+
+// servedItems returns the items that are rendered: the live ones, honouring
+// max items
+func (f *Feed) servedItems() []Item {
+	items := f.items
+	if f.scheduled() {
+		now := f.now()
+		items = make([]Item, 0, len(f.items))
+		for _, item := range f.items {
+			if item.IsLive(now) {
+				items = append(items, item)
+			}
+		}
+	}
+	if f.maxItems > 0 && f.maxItems < len(items) {
+		return items[:f.maxItems]
+	}
+	return items
+}
+
+// renderItems returns the items to render in the given format, honouring
+// the max items policy and applying render-time transforms: URL resolution,
+// excerpts and sanitization. format is empty for HTML previews.
+func (f *Feed) renderItems(format Format) []Item {
+	items := f.servedItems()
+	if f.sanitizer == nil && f.excerpter == nil && !f.resolveURLs {
+		return items
+	}
+
+	base := f.baseURL()
+	rendered := make([]Item, len(items))
+	for i, item := range items {
+		rendered[i] = f.renderItem(item, base, format)
+	}
+	return rendered
+}
+
+// renderItem applies the render-time transforms to a single item
+func (f *Feed) renderItem(item Item, base *url.URL, format Format) Item {
+	if f.resolveURLs {
+		rewriteHTML := !(format == FormatAtom && f.atomXMLBase)
+		item = f.resolveItem(item, base, rewriteHTML)
+	}
+	item = f.excerptItem(item, format)
+	return f.sanitizeItem(item)
+}
+
+// Clone returns a deep copy of the feed
+func (f *Feed) Clone() *Feed {
+	clone := *f
+
+	if f.image != nil {
+		image := *f.image
+		clone.image = &image
+	}
+
+	if f.podcast != nil {
+		clone.podcast = f.podcast.clone()
+	}
+
+	if f.media != nil {
+		clone.media = f.media.clone()
+	}
+
+	if f.itunes != nil {
+		clone.itunes = f.itunes.clone()
+	}
+
+	if f.skipHours != nil {
+		clone.skipHours = append([]int(nil), f.skipHours...)
+	}
+	if f.skipDays != nil {
+		clone.skipDays = append([]string(nil), f.skipDays...)
+	}
+
+	clone.items = make([]Item, len(f.items))
+	for i, item := range f.items {
+		clone.items[i] = item.Clone()
+	}
+
+	clone.customElements = make(map[string]interface{}, len(f.customElements))
+	for k, v := range f.customElements {
+		clone.customElements[k] = v
+	}
+
+	clone.namespaces = make(map[string]string, len(f.namespaces))
+	for k, v := range f.namespaces {
+		clone.namespaces[k] = v
+	}
+
+	return &clone
+}
+
+// Clone returns a deep copy of the item
+func (i Item) Clone() Item {
+	clone := i
+
+	if i.Categories != nil {
+		clone.Categories = append([]string(nil), i.Categories...)
+	}
+	if i.Enclosure != nil {
+		enclosure := *i.Enclosure
+		clone.Enclosure = &enclosure
+	}
+	if i.Enclosures != nil {
+		clone.Enclosures = append([]Enclosure(nil), i.Enclosures...)
+	}
+	if i.Images != nil {
+		clone.Images = append([]Image(nil), i.Images...)
+	}
+	if i.Source != nil {
+		source := *i.Source
+		clone.Source = &source
+	}
+	if i.CustomElements != nil {
+		clone.CustomElements = make(map[string]interface{}, len(i.CustomElements))
+		for k, v := range i.CustomElements {
+			clone.CustomElements[k] = v
+		}
+	}
+	if i.DCTerms != nil {
+		dc := *i.DCTerms
+		clone.DCTerms = &dc
+	}
+	if i.Podcast != nil {
+		clone.Podcast = i.Podcast.clone()
+	}
+	if i.Media != nil {
+		clone.Media = i.Media.clone()
+	}
+
+	return clone
+}
+
+// HasCategory reports whether the item is tagged with the given category
+func (i Item) HasCategory(category string) bool {
+	for _, c := range i.Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// key returns the identity used for deduplication: the GUID, or the link
+func (i Item) key() string {
+	if i.GUID != "" {
+		return "guid:" + i.GUID
+	}
+	if i.Link != "" {
+		return "link:" + i.Link
+	}
+	return ""
+}
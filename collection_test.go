@@ -0,0 +1,202 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func newCollectionFeed() *Feed {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	f := New()
+	f.SetTitle("Test Feed").
+		SetDescription("Test Description").
+		SetLink("https://example.com")
+	f.AddItems([]Item{
+		{Title: "Old", Link: "https://example.com/old", GUID: "old", PubDate: base, Categories: []string{"go"}},
+		{Title: "New", Link: "https://example.com/new", GUID: "new", PubDate: base.Add(48 * time.Hour), Categories: []string{"news"}},
+		{Title: "Mid", Link: "https://example.com/mid", GUID: "mid", PubDate: base.Add(24 * time.Hour), Categories: []string{"go", "news"}},
+	})
+	return f
+}
+
+func itemTitles(items []Item) string {
+	titles := make([]string, len(items))
+	for i, item := range items {
+		titles[i] = item.Title
+	}
+	return strings.Join(titles, ",")
+}
+
+func TestSortByDate(t *testing.T) {
+	f := newCollectionFeed()
+
+	if f.SortByDate() != f {
+		t.Error("SortByDate should return the same feed instance for chaining")
+	}
+
+	if got := itemTitles(f.GetItems()); got != "New,Mid,Old" {
+		t.Errorf("Expected items sorted newest first, got %s", got)
+	}
+}
+
+func TestSortItems(t *testing.T) {
+	f := newCollectionFeed()
+
+	f.SortItems(func(a, b Item) bool {
+		return a.Title < b.Title
+	})
+
+	if got := itemTitles(f.GetItems()); got != "Mid,New,Old" {
+		t.Errorf("Expected items sorted by title, got %s", got)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	f := newCollectionFeed()
+
+	f.SortByDate().Limit(2)
+	if got := itemTitles(f.GetItems()); got != "New,Mid" {
+		t.Errorf("Expected latest two items, got %s", got)
+	}
+
+	// A limit larger than the item count is a no-op
+	f.Limit(10)
+	if len(f.GetItems()) != 2 {
+		t.Errorf("Expected 2 items, got %d", len(f.GetItems()))
+	}
+}
+
+func TestFilter(t *testing.T) {
+	f := newCollectionFeed()
+
+	f.Filter(func(item Item) bool {
+		return item.HasCategory("go")
+	})
+
+	if got := itemTitles(f.GetItems()); got != "Old,Mid" {
+		t.Errorf("Expected items in category 'go', got %s", got)
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	f := New()
+	f.AddItems([]Item{
+		{Title: "A", GUID: "a", Link: "https://example.com/a"},
+		{Title: "A again", GUID: "a", Link: "https://example.com/a2"},
+		{Title: "B", Link: "https://example.com/b"},
+		{Title: "B again", Link: "https://example.com/b"},
+		{Title: "No identity"},
+		{Title: "No identity either"},
+	})
+
+	f.Dedupe()
+
+	if got := itemTitles(f.GetItems()); got != "A,B,No identity,No identity either" {
+		t.Errorf("Unexpected items after dedupe: %s", got)
+	}
+}
+
+func TestRemoveAndReplaceItem(t *testing.T) {
+	f := newCollectionFeed()
+
+	if !f.RemoveItem("mid") {
+		t.Error("RemoveItem should report that an item was removed")
+	}
+	if f.RemoveItem("missing") {
+		t.Error("RemoveItem should report false for an unknown GUID")
+	}
+	if got := itemTitles(f.GetItems()); got != "Old,New" {
+		t.Errorf("Unexpected items after remove: %s", got)
+	}
+
+	if !f.ReplaceItem("old", Item{Title: "Updated", GUID: "old"}) {
+		t.Error("ReplaceItem should report that an item was replaced")
+	}
+	if f.ReplaceItem("missing", Item{Title: "Nope"}) {
+		t.Error("ReplaceItem should report false for an unknown GUID")
+	}
+	if got := itemTitles(f.GetItems()); got != "Updated,New" {
+		t.Errorf("Unexpected items after replace: %s", got)
+	}
+}
+
+func TestClone(t *testing.T) {
+	f := newCollectionFeed()
+	f.SetImage(Image{URL: "https://example.com/logo.png"})
+	f.AddNamespace("dc", "http://purl.org/dc/elements/1.1/")
+	f.AddItem(Item{
+		Title:          "Rich",
+		Enclosure:      &Enclosure{URL: "https://example.com/a.mp3"},
+		Source:         &Source{URL: "https://other.example.com"},
+		CustomElements: map[string]interface{}{"k": "v"},
+		DCTerms:        &DCTerms{Creator: "Jane"},
+	})
+
+	clone := f.Clone()
+	clone.SetTitle("Clone")
+	clone.GetImage().URL = "changed"
+	clone.items[0].Categories[0] = "changed"
+	clone.items[3].Enclosure.URL = "changed"
+	clone.items[3].Source.URL = "changed"
+	clone.items[3].CustomElements["k"] = "changed"
+	clone.items[3].DCTerms.Creator = "changed"
+	clone.namespaces["dc"] = "changed"
+	clone.Limit(1)
+
+	if f.GetTitle() != "Test Feed" {
+		t.Error("Changing the clone title should not affect the original")
+	}
+	if f.GetImage().URL != "https://example.com/logo.png" {
+		t.Error("Changing the clone image should not affect the original")
+	}
+	if len(f.GetItems()) != 4 {
+		t.Errorf("Expected original to keep 4 items, got %d", len(f.GetItems()))
+	}
+
+	items := f.GetItems()
+	if items[0].Categories[0] != "go" {
+		t.Error("Changing clone categories should not affect the original")
+	}
+	if items[3].Enclosure.URL != "https://example.com/a.mp3" ||
+		items[3].Source.URL != "https://other.example.com" ||
+		items[3].CustomElements["k"] != "v" ||
+		items[3].DCTerms.Creator != "Jane" {
+		t.Error("Changing clone item pointers should not affect the original")
+	}
+	if f.namespaces["dc"] != "http://purl.org/dc/elements/1.1/" {
+		t.Error("Changing clone namespaces should not affect the original")
+	}
+}
+
+func TestMaxItemsRendering(t *testing.T) {
+	f := newCollectionFeed()
+	f.SortByDate().SetMaxItems(2)
+
+	if f.GetMaxItems() != 2 {
+		t.Errorf("Expected max items 2, got %d", f.GetMaxItems())
+	}
+
+	rss, err := f.RSS()
+	if err != nil {
+		t.Fatalf("RSS generation failed: %v", err)
+	}
+	if strings.Count(string(rss), "<item>") != 2 {
+		t.Error("RSS should render at most 2 items")
+	}
+	if strings.Contains(string(rss), "<title>Old</title>") {
+		t.Error("RSS should not render items beyond the limit")
+	}
+
+	atom, err := f.Atom()
+	if err != nil {
+		t.Fatalf("Atom generation failed: %v", err)
+	}
+	if strings.Count(string(atom), "<entry>") != 2 {
+		t.Error("Atom should render at most 2 entries")
+	}
+
+	if len(f.GetItems()) != 3 {
+		t.Errorf("Rendering should not mutate the feed, got %d items", len(f.GetItems()))
+	}
+}
@@ -0,0 +1,51 @@
+package compliance
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // directories accept JPEG and PNG artwork only
+	_ "image/png"
+	"io"
+	"io/fs"
+)
+
+// Artwork describes a show artwork image
+type Artwork struct {
+	Format string // "jpeg" or "png"
+	Width  int
+	Height int
+	Color  string // "RGB", "Gray" or "CMYK"
+}
+
+// ReadArtwork reads the format, dimensions and color space of a JPEG or PNG
+// image from its header
+func ReadArtwork(r io.Reader) (*Artwork, error) {
+	cfg, format, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+	art := &Artwork{Format: format, Width: cfg.Width, Height: cfg.Height, Color: "RGB"}
+	switch cfg.ColorModel {
+	case color.GrayModel, color.Gray16Model:
+		art.Color = "Gray"
+	case color.CMYKModel:
+		art.Color = "CMYK"
+	}
+	return art, nil
+}
+
+// ReadArtworkFile reads the artwork header of the named image in fsys
+func ReadArtworkFile(fsys fs.FS, name string) (*Artwork, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	art, err := ReadArtwork(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return art, nil
+}
@@ -0,0 +1,65 @@
+package compliance
+
+import (
+	_ "embed"
+	"strings"
+	"sync"
+
+	"go.rumenx.com/feed"
+)
+
+// categoriesFile lists Apple's podcast categories, one per line, with
+// subcategories indented by a tab
+//
+//go:embed categories.txt
+var categoriesFile string
+
+// legacyCategories maps categories retired from Apple's taxonomy in 2019 to
+// their closest replacement
+var legacyCategories = map[string]string{
+	"Games & Hobbies":     "Leisure",
+	"Health":              "Health & Fitness",
+	"Science & Medicine":  "Science",
+	"Sports & Recreation": "Sports",
+}
+
+var taxonomy = sync.OnceValue(func() []feed.ITunesCategory {
+	var categories []feed.ITunesCategory
+	for _, line := range strings.Split(strings.TrimSpace(categoriesFile), "\n") {
+		if sub, ok := strings.CutPrefix(line, "\t"); ok {
+			parent := &categories[len(categories)-1]
+			parent.Subcategories = append(parent.Subcategories, feed.ITunesCategory{Text: sub})
+			continue
+		}
+		categories = append(categories, feed.ITunesCategory{Text: line})
+	}
+	return categories
+})
+
+// Categories returns Apple's podcast category taxonomy, which Spotify uses
+// as well
+func Categories() []feed.ITunesCategory {
+	categories := taxonomy()
+	out := make([]feed.ITunesCategory, len(categories))
+	for i, c := range categories {
+		c.Subcategories = append([]feed.ITunesCategory(nil), c.Subcategories...)
+		out[i] = c
+	}
+	return out
+}
+
+// findCategory looks a category up by name, ignoring case, and returns its
+// exact spelling and its parent, which is empty for top-level categories
+func findCategory(name string) (exact, parent string, ok bool) {
+	for _, c := range taxonomy() {
+		if strings.EqualFold(c.Text, name) {
+			return c.Text, "", true
+		}
+		for _, sub := range c.Subcategories {
+			if strings.EqualFold(sub.Text, name) {
+				return sub.Text, c.Text, true
+			}
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,439 @@
+// Package compliance checks a podcast feed against the rules Apple Podcasts
+// and Spotify enforce when a show is submitted or refreshed: required
+// channel tags, artwork, the explicit flag, stable episode GUIDs, enclosure
+// types and lengths, duration formats and categories. Each problem found
+// comes with a suggested fix.
+//
+//	art, err := compliance.ReadArtworkFile(os.DirFS("public"), "artwork.jpg")
+//	if err != nil {
+//		return err
+//	}
+//	report := compliance.NewChecker().SetArtwork(art).Check(f)
+//	if !report.OK() {
+//		fmt.Print(report)
+//	}
+package compliance
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.rumenx.com/feed"
+)
+
+// Common errors
+var (
+	ErrUnsupportedImage = errors.New("unsupported image")
+)
+
+// Directories
+const (
+	Apple   = "Apple Podcasts"
+	Spotify = "Spotify"
+)
+
+// Severity tells whether an issue blocks a directory listing
+type Severity int
+
+const (
+	// Error means the directory rejects the feed or the episode
+	Error Severity = iota
+	// Warning means the feed is accepted but displays or updates poorly
+	Warning
+)
+
+// String returns "error" or "warning"
+func (s Severity) String() string {
+	if s == Error {
+		return "error"
+	}
+	return "warning"
+}
+
+// Issue is a problem found in the feed
+type Issue struct {
+	Severity    Severity
+	Directories []string // the directories enforcing the rule
+	Episode     string   // the GUID, or title, of the episode; empty for the channel
+	Tag         string   // the tag at fault, e.g. "itunes:image"
+	Message     string
+	Fix         string
+}
+
+// String formats the issue on one line
+func (i Issue) String() string {
+	where := "channel"
+	if i.Episode != "" {
+		where = fmt.Sprintf("episode %q", i.Episode)
+	}
+	return fmt.Sprintf("%s: %s %s: %s (%s)", i.Severity, where, i.Tag, i.Message, strings.Join(i.Directories, ", "))
+}
+
+// Report lists the issues found by a check, channel issues first
+type Report struct {
+	Issues []Issue
+}
+
+// OK reports whether the feed has no errors; warnings are allowed
+func (r *Report) OK() bool {
+	return len(r.Errors()) == 0
+}
+
+// Errors returns the issues that block a listing
+func (r *Report) Errors() []Issue {
+	return r.filter(Error)
+}
+
+// Warnings returns the issues that do not block a listing
+func (r *Report) Warnings() []Issue {
+	return r.filter(Warning)
+}
+
+// For returns the issues enforced by the given directory
+func (r *Report) For(directory string) []Issue {
+	var out []Issue
+	for _, issue := range r.Issues {
+		for _, d := range issue.Directories {
+			if d == directory {
+				out = append(out, issue)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func (r *Report) filter(s Severity) []Issue {
+	var out []Issue
+	for _, issue := range r.Issues {
+		if issue.Severity == s {
+			out = append(out, issue)
+		}
+	}
+	return out
+}
+
+// String formats the report with one issue per line, each followed by its
+// fix
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s, %s\n", plural(len(r.Errors()), "error"), plural(len(r.Warnings()), "warning"))
+	for _, issue := range r.Issues {
+		fmt.Fprintf(&b, "%s\n", issue)
+		if issue.Fix != "" {
+			fmt.Fprintf(&b, "  fix: %s\n", issue.Fix)
+		}
+	}
+	return b.String()
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// Checker checks feeds against the directory rules
+type Checker struct {
+	artwork  *Artwork
+	previous *feed.Feed
+}
+
+// NewChecker creates a Checker
+func NewChecker() *Checker {
+	return &Checker{}
+}
+
+// SetArtwork sets the show artwork, as read by ReadArtwork, so that its
+// format and dimensions are checked. The checker never downloads the image
+// at the itunes:image URL.
+func (c *Checker) SetArtwork(art *Artwork) *Checker {
+	c.artwork = art
+	return c
+}
+
+// SetPrevious sets the previously published version of the feed, against
+// which episode GUIDs are checked for stability
+func (c *Checker) SetPrevious(previous *feed.Feed) *Checker {
+	c.previous = previous
+	return c
+}
+
+// Check checks the feed without artwork or a previous version
+func Check(f *feed.Feed) *Report {
+	return NewChecker().Check(f)
+}
+
+// Check checks the feed and returns the issues found
+func (c *Checker) Check(f *feed.Feed) *Report {
+	r := &Report{Issues: []Issue{}}
+	c.checkChannel(r, f)
+	c.checkArtwork(r)
+	c.checkEpisodes(r, f)
+	return r
+}
+
+var both = []string{Apple, Spotify}
+
+func (r *Report) add(s Severity, directories []string, episode, tag, message, fix string) {
+	r.Issues = append(r.Issues, Issue{
+		Severity:    s,
+		Directories: directories,
+		Episode:     episode,
+		Tag:         tag,
+		Message:     message,
+		Fix:         fix,
+	})
+}
+
+// maxDescription is the longest show or episode description Apple accepts
+const maxDescription = 4000
+
+var languagePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+func (c *Checker) checkChannel(r *Report, f *feed.Feed) {
+	if f.GetTitle() == "" {
+		r.add(Error, both, "", "title", "the show has no title", "call SetTitle")
+	}
+	switch d := f.GetDescription(); {
+	case d == "":
+		r.add(Error, both, "", "description", "the show has no description", "call SetDescription")
+	case len(d) > maxDescription:
+		r.add(Error, []string{Apple}, "", "description", fmt.Sprintf("the description is %d bytes long", len(d)), fmt.Sprintf("shorten it to %d bytes", maxDescription))
+	}
+	if f.GetLink() == "" {
+		r.add(Warning, both, "", "link", "the show has no website link", "call SetLink")
+	}
+	switch lang := f.GetLanguage(); {
+	case lang == "":
+		r.add(Error, both, "", "language", "the show has no language", `call SetLanguage with an ISO 639 code such as "en" or "en-us"`)
+	case !languagePattern.MatchString(lang):
+		r.add(Error, both, "", "language", fmt.Sprintf("%q is not an ISO 639 language code", lang), `use a code such as "en" or "en-us"`)
+	}
+
+	it := f.GetITunes()
+	if it == nil {
+		it = &feed.ITunesChannel{}
+	}
+	switch {
+	case it.Image == nil || it.Image.Href == "":
+		r.add(Error, both, "", "itunes:image", "the show has no artwork", "set ITunesChannel.Image to the URL of a square JPEG or PNG, 1400 to 3000 pixels wide")
+	default:
+		if ext := strings.ToLower(path.Ext(urlPath(it.Image.Href))); ext != ".jpg" && ext != ".jpeg" && ext != ".png" {
+			r.add(Warning, []string{Apple}, "", "itunes:image", fmt.Sprintf("the artwork URL %q does not end in .jpg or .png", it.Image.Href), "serve the artwork from a URL ending in .jpg, .jpeg or .png")
+		}
+		if !isHTTP(it.Image.Href) && !f.GetResolveURLs() {
+			r.add(Error, both, "", "itunes:image", fmt.Sprintf("the artwork URL %q is not an absolute http(s) URL", it.Image.Href), "use an absolute URL, or call SetResolveURLs(true)")
+		}
+	}
+	if it.Explicit == nil {
+		r.add(Error, []string{Apple}, "", "itunes:explicit", "the show does not say whether it is explicit", "set ITunesChannel.Explicit to true or false")
+	}
+	if it.Author == "" {
+		r.add(Warning, both, "", "itunes:author", "the show has no author", "set ITunesChannel.Author to the name shown under the show title")
+	}
+	if it.Owner == nil || it.Owner.Email == "" {
+		r.add(Error, []string{Spotify}, "", "itunes:owner", "the show has no owner email, which Spotify uses to verify ownership", "set ITunesChannel.Owner with a reachable Email")
+	}
+	c.checkCategories(r, it.Categories)
+	if len(f.GetItems()) == 0 {
+		r.add(Error, both, "", "item", "the show has no episodes", "publish at least one episode before submitting")
+	}
+}
+
+func (c *Checker) checkCategories(r *Report, categories []feed.ITunesCategory) {
+	if len(categories) == 0 {
+		r.add(Error, both, "", "itunes:category", "the show has no category", "add a category from Categories() to ITunesChannel.Categories")
+		return
+	}
+	for _, cat := range categories {
+		exact, parent, ok := findCategory(cat.Text)
+		switch {
+		case !ok:
+			fix := "choose a category from Categories()"
+			if replacement, retired := legacyCategories[cat.Text]; retired {
+				fix = fmt.Sprintf("the category was retired, use %q", replacement)
+			}
+			r.add(Error, both, "", "itunes:category", fmt.Sprintf("%q is not an Apple podcast category", cat.Text), fix)
+			continue
+		case parent != "":
+			r.add(Error, both, "", "itunes:category", fmt.Sprintf("%q is a subcategory", cat.Text), fmt.Sprintf("nest it under %q", parent))
+			continue
+		case exact != cat.Text:
+			r.add(Error, both, "", "itunes:category", fmt.Sprintf("%q does not match the taxonomy spelling", cat.Text), fmt.Sprintf("use %q", exact))
+		}
+		for _, sub := range cat.Subcategories {
+			subExact, subParent, ok := findCategory(sub.Text)
+			switch {
+			case !ok || subParent == "":
+				r.add(Error, both, "", "itunes:category", fmt.Sprintf("%q is not a subcategory of %q", sub.Text, exact), fmt.Sprintf("choose one of the %q subcategories from Categories()", exact))
+			case subParent != exact:
+				r.add(Error, both, "", "itunes:category", fmt.Sprintf("%q is not a subcategory of %q", sub.Text, exact), fmt.Sprintf("nest it under %q", subParent))
+			case subExact != sub.Text:
+				r.add(Error, both, "", "itunes:category", fmt.Sprintf("%q does not match the taxonomy spelling", sub.Text), fmt.Sprintf("use %q", subExact))
+			}
+		}
+	}
+}
+
+// Artwork limits, in pixels
+const (
+	minArtwork = 1400
+	maxArtwork = 3000
+)
+
+func (c *Checker) checkArtwork(r *Report) {
+	art := c.artwork
+	if art == nil {
+		return
+	}
+	if art.Format != "jpeg" && art.Format != "png" {
+		r.add(Error, both, "", "itunes:image", fmt.Sprintf("the artwork is a %s image", art.Format), "convert it to JPEG or PNG")
+	}
+	if art.Width != art.Height {
+		r.add(Error, both, "", "itunes:image", fmt.Sprintf("the artwork is %dx%d, not square", art.Width, art.Height), "crop it to a square")
+	}
+	if size := max(art.Width, art.Height); size < minArtwork || size > maxArtwork {
+		r.add(Error, []string{Apple}, "", "itunes:image", fmt.Sprintf("the artwork is %dx%d pixels", art.Width, art.Height), fmt.Sprintf("resize it to between %dx%d and %dx%d; %dx%d is recommended", minArtwork, minArtwork, maxArtwork, maxArtwork, maxArtwork, maxArtwork))
+	}
+	if art.Color != "RGB" {
+		r.add(Error, []string{Apple}, "", "itunes:image", fmt.Sprintf("the artwork uses the %s color space", art.Color), "convert it to RGB")
+	}
+}
+
+// enclosureTypes lists the enclosure MIME types Apple accepts, with
+// whether Spotify accepts them as well
+var enclosureTypes = map[string]bool{
+	"audio/mpeg":      true,
+	"audio/x-m4a":     true,
+	"video/mp4":       false,
+	"video/quicktime": false,
+	"video/x-m4v":     false,
+}
+
+// enclosureTypeFixes maps common misspellings to the accepted MIME type
+var enclosureTypeFixes = map[string]string{
+	"audio/mp3":   "audio/mpeg",
+	"audio/mpeg3": "audio/mpeg",
+	"audio/x-mp3": "audio/mpeg",
+	"audio/mpg":   "audio/mpeg",
+	"audio/m4a":   "audio/x-m4a",
+	"audio/mp4":   "audio/x-m4a",
+	"audio/aac":   "audio/x-m4a",
+	"video/m4v":   "video/x-m4v",
+	"video/mov":   "video/quicktime",
+}
+
+var durationPattern = regexp.MustCompile(`^(\d+|\d{1,2}:[0-5]\d|\d+:[0-5]\d:[0-5]\d)$`)
+
+func (c *Checker) checkEpisodes(r *Report, f *feed.Feed) {
+	// Episodes of the previous version by enclosure URL, for GUID stability
+	previous := map[string]string{}
+	if c.previous != nil {
+		for _, item := range c.previous.GetItems() {
+			if item.Enclosure != nil && item.GUID != "" {
+				previous[item.Enclosure.URL] = item.GUID
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, item := range f.GetItems() {
+		name := item.GUID
+		if name == "" {
+			name = item.Title
+		}
+
+		if item.Title == "" {
+			r.add(Error, both, name, "title", "the episode has no title", "set Item.Title")
+		}
+		if len(item.Description) > maxDescription {
+			r.add(Error, []string{Apple}, name, "description", fmt.Sprintf("the description is %d bytes long", len(item.Description)), fmt.Sprintf("shorten it to %d bytes, and put the full notes in Item.Content", maxDescription))
+		}
+		if item.PubDate.IsZero() {
+			r.add(Warning, both, name, "pubDate", "the episode has no publication date", "set Item.PubDate")
+		}
+
+		switch {
+		case item.GUID == "":
+			r.add(Warning, both, name, "guid", "the episode has no GUID, so directories identify it by its enclosure URL", "set Item.GUID to a permanent, unique value and never change it")
+		case seen[item.GUID]:
+			r.add(Error, both, name, "guid", "the GUID is used by more than one episode", "give each episode its own GUID")
+		}
+		seen[item.GUID] = true
+		if item.Enclosure != nil && item.GUID != "" {
+			if old, ok := previous[item.Enclosure.URL]; ok && old != item.GUID {
+				r.add(Error, both, name, "guid", fmt.Sprintf("the GUID changed from %q, so listeners get the episode again", old), fmt.Sprintf("restore the GUID %q", old))
+			}
+		}
+
+		checkEnclosure(r, name, item.Enclosure, f.GetResolveURLs())
+
+		switch d := item.ITunesDuration; {
+		case d == "":
+			r.add(Warning, both, name, "itunes:duration", "the episode has no duration", "set Item.ITunesDuration, for example with the audio package")
+		case !durationPattern.MatchString(d):
+			r.add(Error, []string{Apple}, name, "itunes:duration", fmt.Sprintf("%q is not a duration", d), "use seconds or HH:MM:SS")
+		}
+
+		switch item.ITunesEpisodeType {
+		case "", feed.ITunesFull, feed.ITunesTrailer, feed.ITunesBonus:
+		default:
+			r.add(Error, []string{Apple}, name, "itunes:episodeType", fmt.Sprintf("%q is not an episode type", item.ITunesEpisodeType), `use "full", "trailer" or "bonus"`)
+		}
+		if item.ITunesEpisode < 0 || item.ITunesSeason < 0 {
+			r.add(Error, []string{Apple}, name, "itunes:episode", "episode and season numbers must be positive", "remove or correct the number")
+		}
+	}
+}
+
+// checkEnclosure checks an episode's media file. Relative URLs pass when
+// the feed resolves them on render.
+func checkEnclosure(r *Report, name string, enc *feed.Enclosure, resolve bool) {
+	if enc == nil || enc.URL == "" {
+		r.add(Error, both, name, "enclosure", "the episode has no media file", "set Item.Enclosure, for example with the audio package")
+		return
+	}
+	switch {
+	case !isHTTP(enc.URL) && !resolve:
+		r.add(Error, both, name, "enclosure", fmt.Sprintf("the URL %q is not an absolute http(s) URL", enc.URL), "use an absolute URL, or call SetResolveURLs(true)")
+	case strings.ContainsAny(enc.URL, " \t"):
+		r.add(Error, both, name, "enclosure", "the URL contains spaces", "percent-encode spaces as %20 or rename the file")
+	}
+
+	if n, err := strconv.ParseInt(enc.Length, 10, 64); err != nil || n <= 0 {
+		r.add(Error, both, name, "enclosure", fmt.Sprintf("the length %q is not a positive byte count", enc.Length), "set Enclosure.Length to the file size in bytes")
+	}
+
+	typ := strings.ToLower(strings.TrimSpace(enc.Type))
+	spotify, ok := enclosureTypes[typ]
+	switch {
+	case ok && !spotify:
+		r.add(Warning, []string{Spotify}, name, "enclosure", fmt.Sprintf("Spotify does not play %s enclosures from RSS", typ), "publish an audio version as audio/mpeg for Spotify")
+	case ok:
+	case enclosureTypeFixes[typ] != "":
+		r.add(Error, both, name, "enclosure", fmt.Sprintf("%q is not an accepted MIME type", enc.Type), fmt.Sprintf("use %q", enclosureTypeFixes[typ]))
+	default:
+		r.add(Error, both, name, "enclosure", fmt.Sprintf("%q is not an accepted MIME type", enc.Type), "encode the episode as MP3 (audio/mpeg) or AAC (audio/x-m4a)")
+	}
+}
+
+// urlPath returns the path of a URL, without its query
+func urlPath(s string) string {
+	if u, err := url.Parse(s); err == nil {
+		return u.Path
+	}
+	return s
+}
+
+// isHTTP reports whether s is an absolute http or https URL
+func isHTTP(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
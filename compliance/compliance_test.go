@@ -0,0 +1,320 @@
+package compliance
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"go.rumenx.com/feed"
+)
+
+func compliantFeed() *feed.Feed {
+	explicit := false
+	return feed.New().
+		SetTitle("Hiking Treks").
+		SetDescription("Love to get outdoors and discover nature's treasures?").
+		SetLink("https://example.com/hiking").
+		SetLanguage("en-us").
+		SetITunes(feed.ITunesChannel{
+			Image:      &feed.ITunesImage{Href: "https://example.com/hiking/artwork.jpg"},
+			Categories: []feed.ITunesCategory{{Text: "Sports", Subcategories: []feed.ITunesCategory{{Text: "Wilderness"}}}},
+			Explicit:   &explicit,
+			Author:     "The Sunset Explorers",
+			Owner:      &feed.ITunesOwner{Name: "Sunset Explorers", Email: "mountainscape@example.com"},
+		}).
+		AddItem(feed.Item{
+			Title:          "Hiking Treks Trailer",
+			GUID:           "D03EEC9B-B1B4-475B-92C8-54F853FA2A22",
+			PubDate:        time.Date(2024, 1, 8, 12, 0, 0, 0, time.UTC),
+			Enclosure:      &feed.Enclosure{URL: "https://example.com/hiking/trailer.m4a", Length: "498537", Type: "audio/x-m4a"},
+			ITunesDuration: "00:01:24",
+		})
+}
+
+func TestCheckCompliant(t *testing.T) {
+	report := Check(compliantFeed())
+	if !report.OK() || len(report.Issues) != 0 {
+		t.Errorf("Expected no issues, got:\n%s", report)
+	}
+}
+
+// firstIssue returns the first issue for the tag, failing when there is none
+func firstIssue(t *testing.T, r *Report, tag string) Issue {
+	t.Helper()
+	for _, issue := range r.Issues {
+		if issue.Tag == tag {
+			return issue
+		}
+	}
+	t.Fatalf("Expected an issue for %s, got:\n%s", tag, r)
+	return Issue{}
+}
+
+func TestCheckChannel(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(f *feed.Feed)
+		tag      string
+		severity Severity
+		fix      string
+	}{
+		{"no language", func(f *feed.Feed) { f.SetLanguage("") }, "language", Error, "ISO 639"},
+		{"bad language", func(f *feed.Feed) { f.SetLanguage("English") }, "language", Error, `"en"`},
+		{"no link", func(f *feed.Feed) { f.SetLink("") }, "link", Warning, "SetLink"},
+		{"long description", func(f *feed.Feed) { f.SetDescription(strings.Repeat("x", 4001)) }, "description", Error, "4000"},
+		{"no artwork", func(f *feed.Feed) { f.GetITunes().Image = nil }, "itunes:image", Error, "1400 to 3000"},
+		{"artwork extension", func(f *feed.Feed) { f.GetITunes().Image.Href = "https://example.com/art.webp" }, "itunes:image", Warning, ".jpg"},
+		{"relative artwork", func(f *feed.Feed) { f.GetITunes().Image.Href = "/art.jpg" }, "itunes:image", Error, "absolute"},
+		{"no explicit flag", func(f *feed.Feed) { f.GetITunes().Explicit = nil }, "itunes:explicit", Error, "true or false"},
+		{"no author", func(f *feed.Feed) { f.GetITunes().Author = "" }, "itunes:author", Warning, "Author"},
+		{"no owner email", func(f *feed.Feed) { f.GetITunes().Owner.Email = "" }, "itunes:owner", Error, "Email"},
+		{"no category", func(f *feed.Feed) { f.GetITunes().Categories = nil }, "itunes:category", Error, "Categories()"},
+		{"unknown category", func(f *feed.Feed) { f.GetITunes().Categories[0].Text = "Outdoors" }, "itunes:category", Error, "Categories()"},
+		{"retired category", func(f *feed.Feed) { f.GetITunes().Categories[0] = feed.ITunesCategory{Text: "Games & Hobbies"} }, "itunes:category", Error, `"Leisure"`},
+		{"misspelled category", func(f *feed.Feed) { f.GetITunes().Categories[0].Text = "sports" }, "itunes:category", Error, `"Sports"`},
+		{"subcategory at top level", func(f *feed.Feed) { f.GetITunes().Categories[0] = feed.ITunesCategory{Text: "Running"} }, "itunes:category", Error, `nest it under "Sports"`},
+		{"subcategory of another category", func(f *feed.Feed) {
+			f.GetITunes().Categories[0].Subcategories[0].Text = "Tech News"
+		}, "itunes:category", Error, `nest it under "News"`},
+		{"no episodes", func(f *feed.Feed) { f.RemoveItem("D03EEC9B-B1B4-475B-92C8-54F853FA2A22") }, "item", Error, "at least one episode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := compliantFeed()
+			tt.modify(f)
+			report := Check(f)
+			issue := firstIssue(t, report, tt.tag)
+			if issue.Severity != tt.severity {
+				t.Errorf("Expected %s, got %s", tt.severity, issue.Severity)
+			}
+			if issue.Episode != "" {
+				t.Errorf("Expected a channel issue, got episode %q", issue.Episode)
+			}
+			if !strings.Contains(issue.Fix, tt.fix) {
+				t.Errorf("Expected fix to mention %q, got %q", tt.fix, issue.Fix)
+			}
+			if len(report.Issues) != 1 {
+				t.Errorf("Expected exactly one issue, got:\n%s", report)
+			}
+		})
+	}
+}
+
+func TestCheckEpisodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(item *feed.Item)
+		tag      string
+		severity Severity
+		fix      string
+	}{
+		{"no title", func(item *feed.Item) { item.Title = "" }, "title", Error, "Item.Title"},
+		{"no date", func(item *feed.Item) { item.PubDate = time.Time{} }, "pubDate", Warning, "PubDate"},
+		{"no guid", func(item *feed.Item) { item.GUID = "" }, "guid", Warning, "permanent"},
+		{"no enclosure", func(item *feed.Item) { item.Enclosure = nil }, "enclosure", Error, "audio package"},
+		{"zero length", func(item *feed.Item) { item.Enclosure.Length = "0" }, "enclosure", Error, "bytes"},
+		{"relative enclosure", func(item *feed.Item) { item.Enclosure.URL = "trailer.m4a" }, "enclosure", Error, "absolute"},
+		{"enclosure with spaces", func(item *feed.Item) { item.Enclosure.URL = "https://example.com/hiking trailer.m4a" }, "enclosure", Error, "%20"},
+		{"misspelled type", func(item *feed.Item) { item.Enclosure.Type = "audio/mp3" }, "enclosure", Error, `"audio/mpeg"`},
+		{"unknown type", func(item *feed.Item) { item.Enclosure.Type = "audio/flac" }, "enclosure", Error, "MP3"},
+		{"video", func(item *feed.Item) { item.Enclosure.Type = "video/mp4" }, "enclosure", Warning, "audio version"},
+		{"no duration", func(item *feed.Item) { item.ITunesDuration = "" }, "itunes:duration", Warning, "audio package"},
+		{"bad duration", func(item *feed.Item) { item.ITunesDuration = "1h24m" }, "itunes:duration", Error, "HH:MM:SS"},
+		{"seconds over 59", func(item *feed.Item) { item.ITunesDuration = "01:75" }, "itunes:duration", Error, "HH:MM:SS"},
+		{"bad episode type", func(item *feed.Item) { item.ITunesEpisodeType = "preview" }, "itunes:episodeType", Error, "trailer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := compliantFeed()
+			item := f.GetItems()[0]
+			tt.modify(&item)
+			f.RemoveItem("D03EEC9B-B1B4-475B-92C8-54F853FA2A22")
+			f.AddItem(item)
+
+			report := Check(f)
+			issue := firstIssue(t, report, tt.tag)
+			if issue.Severity != tt.severity {
+				t.Errorf("Expected %s, got %s", tt.severity, issue.Severity)
+			}
+			if issue.Episode == "" {
+				t.Error("Expected an episode issue")
+			}
+			if !strings.Contains(issue.Fix, tt.fix) {
+				t.Errorf("Expected fix to mention %q, got %q", tt.fix, issue.Fix)
+			}
+		})
+	}
+
+	for _, d := range []string{"84", "01:24", "1:02:03", "100:00:00"} {
+		f := compliantFeed()
+		item := f.GetItems()[0]
+		item.ITunesDuration = d
+		f.RemoveItem(item.GUID)
+		f.AddItem(item)
+		if report := Check(f); len(report.Issues) != 0 {
+			t.Errorf("Expected duration %q to be accepted, got:\n%s", d, report)
+		}
+	}
+}
+
+func TestCheckGUIDStability(t *testing.T) {
+	previous := compliantFeed()
+
+	f := compliantFeed()
+	item := f.GetItems()[0]
+	f.RemoveItem(item.GUID)
+	item.GUID = "https://example.com/hiking/trailer"
+	f.AddItem(item)
+
+	report := NewChecker().SetPrevious(previous).Check(f)
+	issue := firstIssue(t, report, "guid")
+	if issue.Severity != Error || !strings.Contains(issue.Fix, "D03EEC9B-B1B4-475B-92C8-54F853FA2A22") {
+		t.Errorf("Expected the fix to restore the old GUID, got %+v", issue)
+	}
+
+	if report := NewChecker().SetPrevious(previous).Check(compliantFeed()); len(report.Issues) != 0 {
+		t.Errorf("Expected unchanged GUIDs to pass, got:\n%s", report)
+	}
+}
+
+func TestCheckResolvedURLs(t *testing.T) {
+	f := compliantFeed().SetResolveURLs(true)
+	f.GetITunes().Image.Href = "/hiking/artwork.jpg"
+	item := f.GetItems()[0]
+	item.Enclosure.URL = "/hiking/trailer.m4a"
+	if report := Check(f); len(report.Issues) != 0 {
+		t.Errorf("Expected relative URLs to pass when they are resolved, got:\n%s", report)
+	}
+}
+
+func TestCheckDuplicateGUIDs(t *testing.T) {
+	dup := compliantFeed()
+	second := dup.GetItems()[0]
+	second.Title = "Episode 1"
+	second.Enclosure = &feed.Enclosure{URL: "https://example.com/hiking/1.mp3", Length: "1000", Type: "audio/mpeg"}
+	dup.AddItem(second)
+	if issue := firstIssue(t, Check(dup), "guid"); issue.Severity != Error {
+		t.Errorf("Expected duplicate GUIDs to be an error, got %+v", issue)
+	}
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadArtwork(t *testing.T) {
+	art, err := ReadArtwork(bytes.NewReader(encodePNG(t, image.NewRGBA(image.Rect(0, 0, 1400, 1400)))))
+	if err != nil {
+		t.Fatalf("ReadArtwork failed: %v", err)
+	}
+	if *art != (Artwork{Format: "png", Width: 1400, Height: 1400, Color: "RGB"}) {
+		t.Errorf("Unexpected artwork: %+v", art)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 3000, 2000)), nil); err != nil {
+		t.Fatalf("Failed to encode JPEG: %v", err)
+	}
+	fsys := fstest.MapFS{"art.jpg": {Data: buf.Bytes()}}
+	art, err = ReadArtworkFile(fsys, "art.jpg")
+	if err != nil {
+		t.Fatalf("ReadArtworkFile failed: %v", err)
+	}
+	if *art != (Artwork{Format: "jpeg", Width: 3000, Height: 2000, Color: "Gray"}) {
+		t.Errorf("Unexpected artwork: %+v", art)
+	}
+
+	if _, err := ReadArtwork(strings.NewReader("GIF89a")); !errors.Is(err, ErrUnsupportedImage) {
+		t.Errorf("Expected ErrUnsupportedImage, got %v", err)
+	}
+	if _, err := ReadArtworkFile(fsys, "missing.png"); err == nil {
+		t.Error("Expected error for a missing file")
+	}
+}
+
+func TestCheckArtwork(t *testing.T) {
+	tests := []struct {
+		art     Artwork
+		issues  int
+		message string
+	}{
+		{Artwork{Format: "jpeg", Width: 3000, Height: 3000, Color: "RGB"}, 0, ""},
+		{Artwork{Format: "png", Width: 1400, Height: 1400, Color: "RGB"}, 0, ""},
+		{Artwork{Format: "png", Width: 1000, Height: 1000, Color: "RGB"}, 1, "1000x1000 pixels"},
+		{Artwork{Format: "png", Width: 4000, Height: 4000, Color: "RGB"}, 1, "4000x4000 pixels"},
+		{Artwork{Format: "jpeg", Width: 3000, Height: 2000, Color: "RGB"}, 1, "not square"},
+		{Artwork{Format: "jpeg", Width: 3000, Height: 3000, Color: "CMYK"}, 1, "CMYK"},
+		{Artwork{Format: "gif", Width: 3000, Height: 3000, Color: "RGB"}, 1, "gif image"},
+	}
+	for _, tt := range tests {
+		art := tt.art
+		report := NewChecker().SetArtwork(&art).Check(compliantFeed())
+		if len(report.Issues) != tt.issues {
+			t.Errorf("%+v: expected %d issues, got:\n%s", tt.art, tt.issues, report)
+			continue
+		}
+		if tt.issues > 0 && !strings.Contains(report.Issues[0].Message, tt.message) {
+			t.Errorf("%+v: expected message to mention %q, got %q", tt.art, tt.message, report.Issues[0].Message)
+		}
+	}
+}
+
+func TestReport(t *testing.T) {
+	f := compliantFeed()
+	f.GetITunes().Explicit = nil
+	f.GetITunes().Owner = nil
+	f.GetITunes().Author = ""
+	report := Check(f)
+
+	if report.OK() {
+		t.Error("Expected report not to be OK")
+	}
+	if len(report.Errors()) != 2 || len(report.Warnings()) != 1 {
+		t.Errorf("Expected 2 errors and 1 warning, got:\n%s", report)
+	}
+	if apple := report.For(Apple); len(apple) != 2 {
+		t.Errorf("Expected 2 Apple issues, got %+v", apple)
+	}
+	if spotify := report.For(Spotify); len(spotify) != 2 {
+		t.Errorf("Expected 2 Spotify issues, got %+v", spotify)
+	}
+
+	out := report.String()
+	for _, want := range []string{
+		"2 errors, 1 warning\n",
+		"error: channel itunes:explicit: the show does not say whether it is explicit (Apple Podcasts)",
+		"  fix: set ITunesChannel.Explicit to true or false",
+		"warning: channel itunes:author",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected report to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestCategories(t *testing.T) {
+	categories := Categories()
+	if len(categories) != 19 {
+		t.Errorf("Expected 19 top-level categories, got %d", len(categories))
+	}
+	categories[0].Text = "Changed"
+	if Categories()[0].Text != "Arts" {
+		t.Error("Expected Categories to return a copy")
+	}
+	for _, c := range categories {
+		if c.Text == "Leisure" && len(c.Subcategories) != 8 {
+			t.Errorf("Expected 8 Leisure subcategories, got %d", len(c.Subcategories))
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package feed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// VersionIndex remembers which items each served version of a feed held,
+// keyed by the feed and the version's ETag, so that clients using RFC 3229
+// delta encoding ("A-IM: feed") can be sent only the items added or changed
+// since the version they already have. The feed key, such as the URL path
+// and format, keeps the versions of different feeds apart even when one is
+// asked for with the ETag of another. It keeps a bounded number of versions
+// across all feeds and is safe for concurrent use.
+type VersionIndex struct {
+	mu       sync.Mutex
+	size     int
+	versions map[versionKey]map[string]string // version -> item identity -> fingerprint
+	order    []versionKey                     // oldest first
+}
+
+// versionKey identifies a version of a feed
+type versionKey struct {
+	feed string
+	etag string
+}
+
+// NewVersionIndex creates an index that remembers up to size versions,
+// forgetting the oldest first
+func NewVersionIndex(size int) *VersionIndex {
+	if size < 1 {
+		size = 1
+	}
+	return &VersionIndex{
+		size:     size,
+		versions: make(map[versionKey]map[string]string),
+	}
+}
+
+// Record remembers the items f serves as the version of the feed key with
+// the given ETag. Recording a known version again is a no-op.
+func (v *VersionIndex) Record(key, etag string, f *Feed) {
+	items := f.servedItems()
+	version := make(map[string]string, len(items))
+	for _, item := range items {
+		version[itemIdentity(item)] = itemFingerprint(item)
+	}
+
+	vk := versionKey{feed: key, etag: strings.TrimPrefix(etag, "W/")}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.versions[vk]; ok {
+		return
+	}
+	if len(v.order) >= v.size {
+		delete(v.versions, v.order[0])
+		v.order = v.order[1:]
+	}
+	v.versions[vk] = version
+	v.order = append(v.order, vk)
+}
+
+// Len returns the number of remembered versions
+func (v *VersionIndex) Len() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return len(v.order)
+}
+
+// Delta returns a copy of f holding only the items that are new or changed
+// since a version of the feed key, in feed order. ifNoneMatch is the
+// client's If-None-Match header value; the first listed ETag the index
+// knows for the feed is used. Delta reports false when none is known, in
+// which case the full feed must be sent.
+func (v *VersionIndex) Delta(key, ifNoneMatch string, f *Feed) (*Feed, bool) {
+	var version map[string]string
+	v.mu.Lock()
+	for _, etag := range ParseETags(ifNoneMatch) {
+		if known, ok := v.versions[versionKey{feed: key, etag: etag}]; ok {
+			version = known
+			break
+		}
+	}
+	v.mu.Unlock()
+	if version == nil {
+		return nil, false
+	}
+
+	delta := f.Clone()
+	delta.items = delta.servedItems()
+	delta.Filter(func(item Item) bool {
+		fp, seen := version[itemIdentity(item)]
+		return !seen || fp != itemFingerprint(item)
+	})
+	return delta, true
+}
+
+// DeltaResponse is a feed response negotiated with ETags and RFC 3229
+// delta encoding
+type DeltaResponse struct {
+	Status int         // 200 OK, 226 IM Used or 304 Not Modified
+	Header http.Header // the ETag, and IM and Cache-Control for deltas
+	Body   []byte      // empty for 304 Not Modified
+}
+
+// Respond renders f in format and answers a request carrying the given
+// If-None-Match and A-IM header values. The rendered version is recorded
+// under key, such as the request path, and format. Clients that already
+// have it get 304 Not Modified, and clients asking for delta encoding with
+// a known version get 226 IM Used with only the new and changed items.
+// Framework adapters use it to serve feeds without a registry.
+func (v *VersionIndex) Respond(key string, f *Feed, format Format, ifNoneMatch, aIM string) (DeltaResponse, error) {
+	data, err := f.Render(format)
+	if err != nil {
+		return DeltaResponse{}, err
+	}
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	key += "." + string(format)
+	v.Record(key, etag, f)
+
+	resp := DeltaResponse{Status: http.StatusOK, Header: http.Header{}, Body: data}
+	resp.Header.Set("ETag", etag)
+	if ifNoneMatch == "" {
+		return resp, nil
+	}
+	if ETagMatches(ifNoneMatch, etag) {
+		resp.Status = http.StatusNotModified
+		resp.Body = nil
+		return resp, nil
+	}
+	if !AcceptsFeedDelta(aIM) {
+		return resp, nil
+	}
+	delta, ok := v.Delta(key, ifNoneMatch, f)
+	if !ok {
+		return resp, nil
+	}
+	data, err = delta.Render(format)
+	if err != nil {
+		return DeltaResponse{}, err
+	}
+	// Deltas depend on the client's version, so they must not be cached by
+	// intermediaries that do not understand them
+	resp.Status = http.StatusIMUsed
+	resp.Header.Set("IM", "feed")
+	resp.Header.Set("Cache-Control", "no-store, im")
+	resp.Body = data
+	return resp, nil
+}
+
+// itemIdentity identifies an item across versions by its GUID, link or,
+// failing both, a fingerprint of its text
+func itemIdentity(item Item) string {
+	if key := item.key(); key != "" {
+		return key
+	}
+	return itemFingerprint(item)
+}
+
+// ParseETags returns the entity tags listed in an If-None-Match header
+// value, without their W/ weakness prefixes. A "*" value is returned as is
+// and malformed entries are skipped.
+func ParseETags(header string) []string {
+	var tags []string
+	for {
+		header = strings.TrimLeft(header, " \t,")
+		if header == "" {
+			return tags
+		}
+		if header[0] == '*' {
+			tags = append(tags, "*")
+			header = header[1:]
+			continue
+		}
+		header = strings.TrimPrefix(header, "W/")
+		if header[0] == '"' {
+			if end := strings.IndexByte(header[1:], '"'); end >= 0 {
+				tags = append(tags, header[:end+2])
+				header = header[end+2:]
+				continue
+			}
+		}
+		_, header, _ = strings.Cut(header, ",")
+	}
+}
+
+// ETagMatches reports whether an If-None-Match header value matches etag,
+// using the weak comparison RFC 9110 requires: any listed tag may match,
+// "*" matches every version and W/ prefixes are ignored
+func ETagMatches(ifNoneMatch, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range ParseETags(ifNoneMatch) {
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// AcceptsFeedDeltaRequest reports whether a request asks for RFC 3229 delta
+// encoding with the "feed" instance manipulation
+func AcceptsFeedDeltaRequest(r *http.Request) bool {
+	return AcceptsFeedDelta(r.Header.Get("A-IM"))
+}
+
+// AcceptsFeedDelta reports whether an A-IM header value lists the "feed"
+// instance manipulation without disabling it through q=0
+func AcceptsFeedDelta(aIM string) bool {
+	for _, im := range strings.Split(aIM, ",") {
+		name, params, _ := strings.Cut(im, ";")
+		if !strings.EqualFold(strings.TrimSpace(name), "feed") {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(param, "=")
+			if strings.EqualFold(strings.TrimSpace(key), "q") {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && q <= 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
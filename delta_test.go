@@ -0,0 +1,158 @@
+package feed
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestVersionIndexDelta(t *testing.T) {
+	f := New().AddItems([]Item{
+		{Title: "One", GUID: "1"},
+		{Title: "Two", Link: "https://example.com/2"},
+		{Title: "Three", Description: "No GUID or link"},
+	})
+	v := NewVersionIndex(2)
+	v.Record("feed.xml", `"v1"`, f)
+
+	if _, ok := v.Delta("feed.xml", `"unknown"`, f); ok {
+		t.Error("Expected no delta for an unknown version")
+	}
+	delta, ok := v.Delta("feed.xml", `"v1"`, f)
+	if !ok || len(delta.GetItems()) != 0 {
+		t.Errorf("Expected an empty delta for the same version, got %v", delta.GetItems())
+	}
+
+	f.items[1].Title = "Two (edited)"
+	f.AddItem(Item{Title: "Four", GUID: "4"})
+	delta, _ = v.Delta("feed.xml", `"v1"`, f)
+	var titles []string
+	for _, item := range delta.GetItems() {
+		titles = append(titles, item.Title)
+	}
+	if fmt.Sprint(titles) != "[Two (edited) Four]" {
+		t.Errorf("Expected edited and new items, got %v", titles)
+	}
+	if len(f.GetItems()) != 4 {
+		t.Errorf("Delta should not modify the feed, got %d items", len(f.GetItems()))
+	}
+
+	f.SetMaxItems(1)
+	if delta, _ := v.Delta("feed.xml", `"v1"`, f); len(delta.GetItems()) != 0 {
+		t.Errorf("Expected the delta to honour max items, got %v", delta.GetItems())
+	}
+
+	v.Record("feed.xml", `"v2"`, f)
+	v.Record("feed.xml", `"v2"`, f)
+	v.Record("feed.xml", `"v3"`, f)
+	if _, ok := v.Delta("feed.xml", `"v1"`, f); ok || v.Len() != 2 {
+		t.Errorf("Expected the oldest version to be forgotten, %d versions left", v.Len())
+	}
+	if _, ok := v.Delta("other.xml", `"v3"`, f); ok {
+		t.Error("Expected no delta against the version of another feed")
+	}
+	if _, ok := v.Delta("feed.xml", `"v1", W/"v2"`, f); !ok {
+		t.Error("Expected a delta against a known version in an If-None-Match list")
+	}
+}
+
+func TestParseETags(t *testing.T) {
+	tests := map[string]string{
+		``:                      `[]`,
+		`"a"`:                   `["a"]`,
+		`"a", "b"`:              `["a" "b"]`,
+		`W/"a",W/"b" , "c"`:     `["a" "b" "c"]`,
+		`*`:                     `[*]`,
+		`"a,b", "c"`:            `["a,b" "c"]`,
+		`bogus, "a", "unclosed`: `["a"]`,
+	}
+	for header, want := range tests {
+		if got := fmt.Sprint(ParseETags(header)); got != want {
+			t.Errorf("ParseETags(%q): expected %s, got %s", header, want, got)
+		}
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		etag   string
+		want   bool
+	}{
+		{`"a"`, `"a"`, true},
+		{`"b", "a"`, `"a"`, true},
+		{`W/"a"`, `"a"`, true},
+		{`"a"`, `W/"a"`, true},
+		{`*`, `"a"`, true},
+		{`"b"`, `"a"`, false},
+		{`a`, `"a"`, false},
+		{``, `"a"`, false},
+	}
+	for _, tt := range tests {
+		if got := ETagMatches(tt.header, tt.etag); got != tt.want {
+			t.Errorf("ETagMatches(%q, %q): expected %v, got %v", tt.header, tt.etag, tt.want, got)
+		}
+	}
+}
+
+func TestAcceptsFeedDelta(t *testing.T) {
+	tests := map[string]bool{
+		"":                false,
+		"feed":            true,
+		"FEED":            true,
+		"vcdiff, feed":    true,
+		"feed;q=0.5":      true,
+		"feed; q=0":       false,
+		"gzip":            false,
+		"feeds":           false,
+		"vcdiff;q=0,feed": true,
+	}
+	for header, want := range tests {
+		if got := AcceptsFeedDelta(header); got != want {
+			t.Errorf("AcceptsFeedDelta(%q): expected %v, got %v", header, want, got)
+		}
+	}
+}
+
+func TestVersionIndexRespond(t *testing.T) {
+	f := New().SetTitle("Feed").SetDescription("Items").SetLink("https://example.com")
+	f.AddItem(Item{Title: "First", GUID: "1", Link: "https://example.com/1"})
+	v := NewVersionIndex(4)
+
+	first, err := v.Respond("/feed", f, FormatRSS, "", "")
+	if err != nil {
+		t.Fatalf("Respond failed: %v", err)
+	}
+	etag := first.Header.Get("ETag")
+	if first.Status != http.StatusOK || etag == "" || !strings.Contains(string(first.Body), "<title>First</title>") {
+		t.Fatalf("Expected the full feed with an ETag, got %d %v", first.Status, first.Header)
+	}
+
+	if resp, _ := v.Respond("/feed", f, FormatRSS, etag, "feed"); resp.Status != http.StatusNotModified || len(resp.Body) != 0 {
+		t.Errorf("Expected 304 for an unchanged feed, got %d", resp.Status)
+	}
+
+	f.AddItem(Item{Title: "Second", GUID: "2", Link: "https://example.com/2"})
+	resp, _ := v.Respond("/feed", f, FormatRSS, etag, "feed")
+	if resp.Status != http.StatusIMUsed || resp.Header.Get("IM") != "feed" || resp.Header.Get("Cache-Control") != "no-store, im" {
+		t.Fatalf("Expected 226 IM Used, got %d %v", resp.Status, resp.Header)
+	}
+	if !strings.Contains(string(resp.Body), "<title>Second</title>") || strings.Contains(string(resp.Body), "<title>First</title>") {
+		t.Errorf("Expected only the new item, got:\n%s", resp.Body)
+	}
+
+	for _, tt := range []struct {
+		key    string
+		format Format
+		aIM    string
+	}{
+		{"/feed", FormatRSS, ""},
+		{"/other", FormatRSS, "feed"},
+		{"/feed", FormatAtom, "feed"},
+	} {
+		if resp, _ := v.Respond(tt.key, f, tt.format, etag, tt.aIM); resp.Status != http.StatusOK {
+			t.Errorf("%+v: expected the full feed, got %d", tt, resp.Status)
+		}
+	}
+}
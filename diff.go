@@ -0,0 +1,202 @@
+package feed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"time"
+)
+
+// DiffResult describes what changed between two versions of a feed
+type DiffResult struct {
+	Channel []FieldChange `json:"channel"`
+	Added   []ItemDiff    `json:"added"`
+	Removed []ItemDiff    `json:"removed"`
+	Changed []ItemDiff    `json:"changed"`
+}
+
+// ItemDiff describes an added, removed or changed item
+type ItemDiff struct {
+	Key     string        `json:"key"`     // the GUID, link or fingerprint identifying the item
+	MatchBy string        `json:"matchBy"` // "guid", "link" or "fingerprint"; for changed items, how the versions were matched
+	Title   string        `json:"title"`
+	Link    string        `json:"link,omitempty"`
+	Changes []FieldChange `json:"changes,omitempty"`
+
+	// Item is the new version of the item, or the old one if it was removed
+	Item Item `json:"-"`
+}
+
+// FieldChange describes a changed field with its old and new values
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// Empty reports whether the feeds are the same
+func (d DiffResult) Empty() bool {
+	return len(d.Channel) == 0 && len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Diff compares two versions of a feed. Items are matched by GUID, then by
+// link, then by a fingerprint of their title, description and content, and
+// matched items are compared field by field. Channel metadata is compared
+// too, except for the last build date, which changes on every build.
+func Diff(old, new *Feed) DiffResult {
+	d := DiffResult{
+		Channel: diffChannel(old, new),
+		Added:   []ItemDiff{},
+		Removed: []ItemDiff{},
+		Changed: []ItemDiff{},
+	}
+
+	oldItems, newItems := old.items, new.items
+	matches := make([]int, len(newItems)) // old index of each new item, or -1
+	matchBy := make([]string, len(newItems))
+	for i := range matches {
+		matches[i] = -1
+	}
+	matched := make([]bool, len(oldItems))
+
+	passes := []struct {
+		name string
+		key  func(Item) string
+	}{
+		{"guid", func(item Item) string { return item.GUID }},
+		{"link", func(item Item) string { return item.Link }},
+		{"fingerprint", itemFingerprint},
+	}
+	for _, pass := range passes {
+		// Queue unmatched old items by key, so duplicates pair up in order
+		byKey := make(map[string][]int)
+		for i, item := range oldItems {
+			if key := pass.key(item); !matched[i] && key != "" {
+				byKey[key] = append(byKey[key], i)
+			}
+		}
+		for i, item := range newItems {
+			key := pass.key(item)
+			if matches[i] >= 0 || key == "" || len(byKey[key]) == 0 {
+				continue
+			}
+			matches[i], matchBy[i] = byKey[key][0], pass.name
+			byKey[key] = byKey[key][1:]
+			matched[matches[i]] = true
+		}
+	}
+
+	for i, item := range newItems {
+		if matches[i] < 0 {
+			d.Added = append(d.Added, newItemDiff(item, ""))
+			continue
+		}
+		if changes := diffItem(oldItems[matches[i]], item); len(changes) > 0 {
+			diff := newItemDiff(item, matchBy[i])
+			diff.Changes = changes
+			d.Changed = append(d.Changed, diff)
+		}
+	}
+	for i, item := range oldItems {
+		if !matched[i] {
+			d.Removed = append(d.Removed, newItemDiff(item, ""))
+		}
+	}
+	return d
+}
+
+// newItemDiff identifies an item by its GUID, link or fingerprint
+func newItemDiff(item Item, matchBy string) ItemDiff {
+	diff := ItemDiff{Title: item.Title, Link: item.Link, Item: item}
+	switch {
+	case item.GUID != "":
+		diff.Key, diff.MatchBy = item.GUID, "guid"
+	case item.Link != "":
+		diff.Key, diff.MatchBy = item.Link, "link"
+	default:
+		diff.Key, diff.MatchBy = itemFingerprint(item), "fingerprint"
+	}
+	if matchBy != "" {
+		diff.MatchBy = matchBy
+	}
+	return diff
+}
+
+// itemFingerprint hashes the text of an item
+func itemFingerprint(item Item) string {
+	h := sha256.New()
+	for _, s := range []string{item.Title, item.Description, item.Content} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// diffChannel compares channel metadata
+func diffChannel(old, new *Feed) []FieldChange {
+	changes := []FieldChange{}
+	changes = appendChange(changes, "title", old.title, new.title)
+	changes = appendChange(changes, "description", old.description, new.description)
+	changes = appendChange(changes, "link", old.link, new.link)
+	changes = appendChange(changes, "language", old.language, new.language)
+	changes = appendChange(changes, "copyright", old.copyright, new.copyright)
+	changes = appendChange(changes, "managingEditor", old.managingEditor, new.managingEditor)
+	changes = appendChange(changes, "webmaster", old.webmaster, new.webmaster)
+	changes = appendChange(changes, "ttl", old.ttl, new.ttl)
+	changes = appendChange(changes, "image", old.image, new.image)
+	changes = appendChange(changes, "skipHours", old.skipHours, new.skipHours)
+	changes = appendChange(changes, "skipDays", old.skipDays, new.skipDays)
+	changes = appendChange(changes, "podcast", old.podcast, new.podcast)
+	changes = appendChange(changes, "media", old.media, new.media)
+	changes = appendChange(changes, "itunes", old.itunes, new.itunes)
+	return changes
+}
+
+// diffItem compares the fields of two versions of an item
+func diffItem(old, new Item) []FieldChange {
+	var changes []FieldChange
+	changes = appendChange(changes, "title", old.Title, new.Title)
+	changes = appendChange(changes, "description", old.Description, new.Description)
+	changes = appendChange(changes, "content", old.Content, new.Content)
+	changes = appendChange(changes, "link", old.Link, new.Link)
+	changes = appendChange(changes, "guid", old.GUID, new.GUID)
+	changes = appendChange(changes, "author", old.Author, new.Author)
+	changes = appendChange(changes, "pubDate", old.PubDate, new.PubDate)
+	changes = appendChange(changes, "categories", old.Categories, new.Categories)
+	changes = appendChange(changes, "comments", old.Comments, new.Comments)
+	changes = appendChange(changes, "enclosure", old.Enclosure, new.Enclosure)
+	changes = appendChange(changes, "source", old.Source, new.Source)
+	changes = appendChange(changes, "publishAt", old.PublishAt, new.PublishAt)
+	changes = appendChange(changes, "expireAt", old.ExpireAt, new.ExpireAt)
+	changes = appendChange(changes, "podcast", old.Podcast, new.Podcast)
+	changes = appendChange(changes, "media", old.Media, new.Media)
+	changes = appendChange(changes, "itunes", itunesItem(old), itunesItem(new))
+	return changes
+}
+
+// appendChange records a change when the values differ. Empty and nil
+// values are equal, and times are compared as instants.
+func appendChange[T any](changes []FieldChange, field string, old, new T) []FieldChange {
+	if equalValues(old, new) {
+		return changes
+	}
+	return append(changes, FieldChange{Field: field, Old: old, New: new})
+}
+
+func equalValues(a, b any) bool {
+	switch a := a.(type) {
+	case time.Time:
+		return a.Equal(b.(time.Time))
+	case *Source:
+		b := b.(*Source)
+		if a == nil || b == nil {
+			return a == b
+		}
+		return a.URL == b.URL && a.Value == b.Value && a.ID == b.ID && a.Updated.Equal(b.Updated) && a.IsFeedURL == b.IsFeedURL
+	}
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Kind() == reflect.Slice && va.Len() == 0 && vb.Len() == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,137 @@
+package feed
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func diffTestFeed() *Feed {
+	return New().
+		SetTitle("News").
+		SetDescription("Latest news").
+		SetLink("https://example.com").
+		AddItems([]Item{
+			{Title: "Kept", GUID: "1", Link: "https://example.com/1", PubDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+			{Title: "Edited", GUID: "2", Link: "https://example.com/2", Content: "<p>Old</p>"},
+			{Title: "Dropped", GUID: "3", Link: "https://example.com/3"},
+			{Title: "No GUID", Link: "https://example.com/4"},
+			{Title: "Bare", Description: "Only text"},
+		})
+}
+
+func TestDiffNoChanges(t *testing.T) {
+	d := Diff(diffTestFeed(), diffTestFeed().SetLastBuildDate(time.Now().Add(time.Hour)))
+	if !d.Empty() {
+		t.Errorf("Expected no differences, got %+v", d)
+	}
+}
+
+func TestDiffItems(t *testing.T) {
+	old := diffTestFeed()
+	new := New().
+		SetTitle("News").
+		SetDescription("Latest news").
+		SetLink("https://example.com").
+		AddItems([]Item{
+			{Title: "Fresh", GUID: "5", Link: "https://example.com/5"},
+			// Same instant in another zone is not a change
+			{Title: "Kept", GUID: "1", Link: "https://example.com/1", PubDate: time.Date(2024, 1, 1, 2, 0, 0, 0, time.FixedZone("EET", 2*3600))},
+			{Title: "Edited", GUID: "2", Link: "https://example.com/2", Content: "<p>New</p>", Enclosure: &Enclosure{URL: "https://example.com/2.mp3", Length: "1", Type: "audio/mpeg"}},
+			{Title: "No GUID", GUID: "4", Link: "https://example.com/4", Categories: []string{"go"}},
+			{Title: "Bare", Description: "Only text", Author: "jane@example.com"},
+		})
+
+	d := Diff(old, new)
+	if len(d.Channel) != 0 {
+		t.Errorf("Expected no channel changes, got %+v", d.Channel)
+	}
+	if len(d.Added) != 1 || d.Added[0].Key != "5" || d.Added[0].Item.Title != "Fresh" {
+		t.Errorf("Expected item 5 added, got %+v", d.Added)
+	}
+	if len(d.Removed) != 1 || d.Removed[0].Key != "3" || d.Removed[0].MatchBy != "guid" {
+		t.Errorf("Expected item 3 removed, got %+v", d.Removed)
+	}
+
+	if len(d.Changed) != 3 {
+		t.Fatalf("Expected 3 changed items, got %+v", d.Changed)
+	}
+	tests := []struct {
+		key, matchBy string
+		fields       []string
+	}{
+		{"2", "guid", []string{"content", "enclosure"}},
+		{"4", "link", []string{"guid", "categories"}},
+		{itemFingerprint(Item{Title: "Bare", Description: "Only text"}), "fingerprint", []string{"author"}},
+	}
+	for i, tt := range tests {
+		got := d.Changed[i]
+		if got.Key != tt.key || got.MatchBy != tt.matchBy {
+			t.Errorf("Changed %d: expected key %q matched by %s, got %q by %s", i, tt.key, tt.matchBy, got.Key, got.MatchBy)
+		}
+		var fields []string
+		for _, c := range got.Changes {
+			fields = append(fields, c.Field)
+		}
+		if strings.Join(fields, ",") != strings.Join(tt.fields, ",") {
+			t.Errorf("Changed %d: expected fields %v, got %v", i, tt.fields, fields)
+		}
+	}
+
+	change := d.Changed[0].Changes[0]
+	if change.Old != "<p>Old</p>" || change.New != "<p>New</p>" {
+		t.Errorf("Expected content change details, got %+v", change)
+	}
+}
+
+func TestDiffDuplicateKeys(t *testing.T) {
+	old := New().AddItems([]Item{{Title: "A", GUID: "dup"}, {Title: "B", GUID: "dup"}})
+	new := New().AddItems([]Item{{Title: "A", GUID: "dup"}, {Title: "B2", GUID: "dup"}, {Title: "C", GUID: "dup"}})
+
+	d := Diff(old, new)
+	if len(d.Changed) != 1 || d.Changed[0].Title != "B2" {
+		t.Errorf("Expected duplicates to pair up in order, got %+v", d.Changed)
+	}
+	if len(d.Added) != 1 || d.Added[0].Title != "C" || len(d.Removed) != 0 {
+		t.Errorf("Expected one added item, got %+v added, %+v removed", d.Added, d.Removed)
+	}
+}
+
+func TestDiffChannel(t *testing.T) {
+	old := diffTestFeed()
+	new := diffTestFeed().
+		SetTitle("Breaking News").
+		SetTTL(30).
+		SetImage(Image{URL: "https://example.com/logo.png"}).
+		SetSkipDays("Sunday")
+
+	d := Diff(old, new)
+	var fields []string
+	for _, c := range d.Channel {
+		fields = append(fields, c.Field)
+	}
+	if got := strings.Join(fields, ","); got != "title,ttl,image,skipDays" {
+		t.Errorf("Expected channel changes title,ttl,image,skipDays, got %s", got)
+	}
+	if d.Channel[0].Old != "News" || d.Channel[0].New != "Breaking News" {
+		t.Errorf("Unexpected title change %+v", d.Channel[0])
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	old := diffTestFeed()
+	new := diffTestFeed().SetTitle("Renamed")
+	new.items[1].Title = "Edited again"
+
+	data, err := json.Marshal(Diff(old, new))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := `{"channel":[{"field":"title","old":"News","new":"Renamed"}],"added":[],"removed":[],` +
+		`"changed":[{"key":"2","matchBy":"guid","title":"Edited again","link":"https://example.com/2",` +
+		`"changes":[{"field":"title","old":"Edited","new":"Edited again"}]}]}`
+	if string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+}
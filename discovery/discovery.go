@@ -0,0 +1,250 @@
+// Package discovery generates feed autodiscovery markup for HTML pages and
+// finds feeds advertised by, or linked from, existing pages.
+package discovery
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"net/url"
+	"strings"
+
+	"go.rumenx.com/feed"
+	"go.rumenx.com/feed/internal/htmltok"
+)
+
+// Alternate describes a feed advertised through a <link rel="alternate"> tag
+type Alternate struct {
+	Title  string
+	URL    string
+	Format feed.Format
+}
+
+// LinkTags renders autodiscovery <link> tags for the given feeds, one per line
+func LinkTags(alternates ...Alternate) template.HTML {
+	var b strings.Builder
+	for i, alt := range alternates {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, `<link rel="alternate" type="%s"`, html.EscapeString(alt.Format.MediaType()))
+		if alt.Title != "" {
+			fmt.Fprintf(&b, ` title="%s"`, html.EscapeString(alt.Title))
+		}
+		fmt.Fprintf(&b, ` href="%s">`, html.EscapeString(alt.URL))
+	}
+	// All dynamic values are escaped above
+	return template.HTML(b.String())
+}
+
+// FeedAlternates describes one feed published in several formats.
+// urls maps each format to the URL it is served at; formats are listed in
+// the order of feed.Formats. When more than one format is published the
+// format name is appended to the title so readers can tell them apart.
+func FeedAlternates(f *feed.Feed, urls map[feed.Format]string) []Alternate {
+	var alternates []Alternate
+	for _, format := range feed.Formats {
+		u, ok := urls[format]
+		if !ok {
+			continue
+		}
+		title := f.GetTitle()
+		if len(urls) > 1 {
+			title += " (" + formatName(format) + ")"
+		}
+		alternates = append(alternates, Alternate{Title: title, URL: u, Format: format})
+	}
+	return alternates
+}
+
+// formatName returns a human-readable format name
+func formatName(format feed.Format) string {
+	switch format {
+	case feed.FormatAtom:
+		return "Atom"
+	case feed.FormatRSS:
+		return "RSS"
+	}
+	return string(format)
+}
+
+// Origin tells how a Candidate was found
+type Origin int
+
+// Candidate origins, from most to least reliable
+const (
+	// FromLinkTag is a feed advertised by a <link rel="alternate"> tag
+	FromLinkTag Origin = iota
+	// FromAnchor is a feed-looking <a href> on the page
+	FromAnchor
+	// FromGenericLinkTag is a <link rel="alternate"> with a generic XML or
+	// JSON type, kept only when its URL looks like a feed
+	FromGenericLinkTag
+	// FromCommonPath is an unverified guess at a conventional feed location
+	FromCommonPath
+)
+
+// Candidate is a possible feed found on a page
+type Candidate struct {
+	URL    string
+	Title  string
+	Type   string
+	Origin Origin
+}
+
+// feedMediaTypes are the link types that identify feeds
+var feedMediaTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/feed+json": true,
+}
+
+// genericMediaTypes are link types feeds are sometimes advertised with, but
+// which also cover APIs and other documents, such as WordPress's
+// /wp-json/ links
+var genericMediaTypes = map[string]bool{
+	"application/rdf+xml": true,
+	"application/json":    true,
+	"application/xml":     true,
+	"text/xml":            true,
+}
+
+// CommonPaths are conventional feed locations tried as a last resort
+var CommonPaths = []string{
+	"/feed",
+	"/feed.xml",
+	"/rss",
+	"/rss.xml",
+	"/atom.xml",
+	"/index.xml",
+	"/feed.atom",
+}
+
+// Discover finds candidate feeds in an HTML document. Relative URLs are
+// resolved against baseURL, or the document's <base href> when present.
+// Candidates are returned in order of reliability: link tags first, then
+// feed-looking anchors and generically typed link tags, then common paths
+// on the page's host, which callers should verify before use.
+func Discover(r io.Reader, baseURL string) ([]Candidate, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil || !base.IsAbs() {
+		return nil, fmt.Errorf("invalid base URL %q", baseURL)
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HTML: %w", err)
+	}
+
+	page := base
+
+	var links, anchors, generic []Candidate
+	var anchor *Candidate
+	var anchorText strings.Builder
+
+	for _, tok := range htmltok.Tokenize(string(data)) {
+		switch {
+		case tok.Type != htmltok.TextToken && tok.Data == "base":
+			if href, ok := tok.GetAttr("href"); ok {
+				if u, err := base.Parse(strings.TrimSpace(href)); err == nil {
+					base = u
+				}
+			}
+
+		case tok.Type != htmltok.TextToken && tok.Data == "link":
+			rel, _ := tok.GetAttr("rel")
+			typ, _ := tok.GetAttr("type")
+			href, _ := tok.GetAttr("href")
+			typ = strings.ToLower(strings.TrimSpace(typ))
+			if href == "" || !hasToken(rel, "alternate") {
+				continue
+			}
+			title, _ := tok.GetAttr("title")
+			switch {
+			case feedMediaTypes[typ]:
+				links = append(links, Candidate{URL: href, Title: title, Type: typ, Origin: FromLinkTag})
+			case genericMediaTypes[typ] && looksLikeFeed(href, ""):
+				generic = append(generic, Candidate{URL: href, Title: title, Type: typ, Origin: FromGenericLinkTag})
+			}
+
+		case tok.Type == htmltok.StartTagToken && tok.Data == "a":
+			href, _ := tok.GetAttr("href")
+			anchor = &Candidate{URL: href, Origin: FromAnchor}
+			anchorText.Reset()
+
+		case tok.Type == htmltok.TextToken && anchor != nil:
+			anchorText.WriteString(tok.Data)
+
+		case tok.Type == htmltok.EndTagToken && tok.Data == "a" && anchor != nil:
+			anchor.Title = strings.Join(strings.Fields(html.UnescapeString(anchorText.String())), " ")
+			if anchor.URL != "" && looksLikeFeed(anchor.URL, anchor.Title) {
+				anchors = append(anchors, *anchor)
+			}
+			anchor = nil
+		}
+	}
+
+	var candidates []Candidate
+	seen := make(map[string]bool)
+	add := func(base *url.URL, c Candidate) {
+		u, err := base.Parse(strings.TrimSpace(c.URL))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return
+		}
+		u.Fragment = ""
+		c.URL = u.String()
+		if seen[c.URL] {
+			return
+		}
+		seen[c.URL] = true
+		candidates = append(candidates, c)
+	}
+
+	for _, c := range links {
+		add(base, c)
+	}
+	for _, c := range anchors {
+		add(base, c)
+	}
+	for _, c := range generic {
+		add(base, c)
+	}
+	for _, path := range CommonPaths {
+		add(page, Candidate{URL: path, Origin: FromCommonPath})
+	}
+
+	return candidates, nil
+}
+
+// looksLikeFeed applies simple heuristics to an anchor's URL and text. The
+// path must contain one of the feed words as a whole word, such as
+// /feed/, /comments/rss or /atom-feed.xml, so that /feedback does not count.
+func looksLikeFeed(href, text string) bool {
+	u, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+	words := strings.FieldsFunc(strings.ToLower(u.Path), func(r rune) bool {
+		return (r < 'a' || r > 'z') && (r < '0' || r > '9')
+	})
+	for _, word := range words {
+		switch strings.TrimRight(word, "0123456789") {
+		case "rss", "atom", "feed", "feeds", "xml":
+			return true
+		}
+	}
+	text = strings.ToLower(text)
+	return text == "rss" || text == "atom" || strings.Contains(text, "rss feed") ||
+		strings.Contains(text, "atom feed") || strings.Contains(text, "subscribe")
+}
+
+// hasToken reports whether a space separated attribute contains token
+func hasToken(attr, token string) bool {
+	for _, t := range strings.Fields(attr) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
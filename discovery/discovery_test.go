@@ -0,0 +1,167 @@
+package discovery
+
+import (
+	"strings"
+	"testing"
+
+	"go.rumenx.com/feed"
+)
+
+func TestLinkTags(t *testing.T) {
+	got := LinkTags(
+		Alternate{Title: `News & "Updates"`, URL: "https://example.com/feed.xml?a=1&b=2", Format: feed.FormatRSS},
+		Alternate{URL: "/feed.atom", Format: feed.FormatAtom},
+	)
+
+	want := `<link rel="alternate" type="application/rss+xml" title="News &amp; &#34;Updates&#34;" href="https://example.com/feed.xml?a=1&amp;b=2">` + "\n" +
+		`<link rel="alternate" type="application/atom+xml" href="/feed.atom">`
+	if string(got) != want {
+		t.Errorf("Unexpected link tags:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFeedAlternates(t *testing.T) {
+	f := feed.New().SetTitle("Blog")
+
+	alts := FeedAlternates(f, map[feed.Format]string{
+		feed.FormatAtom: "/feed.atom",
+		feed.FormatRSS:  "/feed.xml",
+	})
+	if len(alts) != 2 {
+		t.Fatalf("Expected 2 alternates, got %d", len(alts))
+	}
+	if alts[0].Format != feed.FormatRSS || alts[0].Title != "Blog (RSS)" || alts[0].URL != "/feed.xml" {
+		t.Errorf("Unexpected first alternate: %+v", alts[0])
+	}
+	if alts[1].Format != feed.FormatAtom || alts[1].Title != "Blog (Atom)" {
+		t.Errorf("Unexpected second alternate: %+v", alts[1])
+	}
+
+	single := FeedAlternates(f, map[feed.Format]string{feed.FormatRSS: "/feed.xml"})
+	if len(single) != 1 || single[0].Title != "Blog" {
+		t.Errorf("Single format should keep the plain title, got %+v", single)
+	}
+}
+
+const page = `<!DOCTYPE html>
+<html>
+<head>
+  <title>Blog</title>
+  <link rel="stylesheet" href="/style.css">
+  <link rel="alternate" type="application/rss+xml" title="Posts" href="/feed.xml">
+  <link rel="Alternate home" type="Application/Atom+XML" title="Posts (Atom)" href="feed.atom">
+  <link rel="alternate" type="text/html" hreflang="de" href="/de/">
+</head>
+<body>
+  <a href="/about">About</a>
+  <a href="/comments/rss">Comments <b>RSS</b></a>
+  <a href="https://other.example.com/subscribe">Subscribe</a>
+  <a href="javascript:alert(1)">RSS</a>
+  <a href="/feed.xml#top">Feed again</a>
+</body>
+</html>`
+
+func TestDiscover(t *testing.T) {
+	candidates, err := Discover(strings.NewReader(page), "https://example.com/blog/")
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+
+	want := []Candidate{
+		{URL: "https://example.com/feed.xml", Title: "Posts", Type: "application/rss+xml", Origin: FromLinkTag},
+		{URL: "https://example.com/blog/feed.atom", Title: "Posts (Atom)", Type: "application/atom+xml", Origin: FromLinkTag},
+		{URL: "https://example.com/comments/rss", Title: "Comments RSS", Origin: FromAnchor},
+		{URL: "https://other.example.com/subscribe", Title: "Subscribe", Origin: FromAnchor},
+	}
+	if len(candidates) < len(want) {
+		t.Fatalf("Expected at least %d candidates, got %+v", len(want), candidates)
+	}
+	for i, w := range want {
+		if candidates[i] != w {
+			t.Errorf("Candidate %d: expected %+v, got %+v", i, w, candidates[i])
+		}
+	}
+
+	// Common paths follow, without repeating already found URLs
+	rest := candidates[len(want):]
+	if len(rest) != len(CommonPaths)-1 {
+		t.Errorf("Expected %d common path guesses, got %d", len(CommonPaths)-1, len(rest))
+	}
+	for _, c := range rest {
+		if c.Origin != FromCommonPath || !strings.HasPrefix(c.URL, "https://example.com/") {
+			t.Errorf("Unexpected common path candidate: %+v", c)
+		}
+	}
+}
+
+func TestDiscoverBaseTag(t *testing.T) {
+	html := `<head><base href="https://cdn.example.com/site/"><link rel="alternate" type="application/atom+xml" href="atom.xml"></head>`
+
+	candidates, err := Discover(strings.NewReader(html), "https://example.com/")
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	if candidates[0].URL != "https://cdn.example.com/site/atom.xml" {
+		t.Errorf("Expected <base href> to be honoured, got %s", candidates[0].URL)
+	}
+	if candidates[1].URL != "https://example.com/feed" {
+		t.Errorf("Common paths should be guessed on the page host, got %s", candidates[1].URL)
+	}
+}
+
+func TestDiscoverInvalidBase(t *testing.T) {
+	if _, err := Discover(strings.NewReader(page), "/relative"); err == nil {
+		t.Error("Expected an error for a relative base URL")
+	}
+}
+
+func TestDiscoverFalsePositives(t *testing.T) {
+	html := `<head>
+  <link rel="alternate" type="application/json" href="/wp-json/wp/v2/pages/2">
+  <link rel="alternate" type="text/xml" href="/feeds/posts.xml" title="Posts">
+</head>
+<body>
+  <a href="/feedback">Feedback</a>
+  <a href="/atomic-habits">Book review</a>
+  <a href="/blog/rss2/">Posts</a>
+</body>`
+
+	candidates, err := Discover(strings.NewReader(html), "https://example.com/")
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	want := []Candidate{
+		{URL: "https://example.com/blog/rss2/", Title: "Posts", Origin: FromAnchor},
+		{URL: "https://example.com/feeds/posts.xml", Title: "Posts", Type: "text/xml", Origin: FromGenericLinkTag},
+	}
+	for i, w := range want {
+		if i >= len(candidates) || candidates[i] != w {
+			t.Fatalf("Expected %+v first, got %+v", want, candidates)
+		}
+	}
+	for _, c := range candidates[len(want):] {
+		if c.Origin != FromCommonPath {
+			t.Errorf("Unexpected candidate: %+v", c)
+		}
+	}
+}
+
+func TestLooksLikeFeed(t *testing.T) {
+	tests := map[string]bool{
+		"/feed":              true,
+		"/feed/":             true,
+		"/feed.xml":          true,
+		"/comments/rss":      true,
+		"/blog/atom-feed":    true,
+		"/index.rss2":        true,
+		"/feedback":          false,
+		"/atomic":            false,
+		"/news/crossfeeding": false,
+		"/wp-json/wp/v2":     false,
+	}
+	for href, want := range tests {
+		if got := looksLikeFeed(href, ""); got != want {
+			t.Errorf("looksLikeFeed(%q): expected %v, got %v", href, want, got)
+		}
+	}
+}
@@ -12,4 +12,14 @@ var (
 	ErrInvalidURL         = errors.New("invalid URL format")
 	ErrInvalidDate        = errors.New("invalid date format")
 	ErrEmptyFeed          = errors.New("feed contains no items")
+	ErrUnknownFormat      = errors.New("unknown feed format")
+	ErrNotSlice           = errors.New("value is not a slice")
+	ErrNotStruct          = errors.New("value is not a struct")
+	ErrUnknownTarget      = errors.New("unknown feed tag target")
+	ErrUnexportedField    = errors.New("field is not exported")
+	ErrUnsupportedType    = errors.New("unsupported field type")
+	ErrInvalidPodcast     = errors.New("invalid podcast tag")
+	ErrInvalidMedia       = errors.New("invalid media element")
+	ErrInvalidITunes      = errors.New("invalid itunes tag")
+	ErrUnsupportedCharset = errors.New("unsupported character encoding")
 )
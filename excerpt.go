@@ -0,0 +1,267 @@
+package feed
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go.rumenx.com/feed/internal/htmltok"
+)
+
+// Excerpter generates plain-text excerpts from HTML content.
+// Lengths are counted in user-perceived characters, so combining marks,
+// emoji sequences and conjuncts are never split.
+type Excerpter struct {
+	maxChars int
+	maxWords int
+	sentence bool
+	ellipsis string
+	readMore string
+}
+
+// NewExcerpter creates an Excerpter that cuts text on a word boundary at
+// 200 characters and ends it with an ellipsis
+func NewExcerpter() *Excerpter {
+	return &Excerpter{
+		maxChars: 200,
+		ellipsis: "…",
+	}
+}
+
+// SetMaxChars sets the maximum excerpt length in characters, not counting
+// the ellipsis. Zero disables the limit.
+func (e *Excerpter) SetMaxChars(n int) *Excerpter {
+	e.maxChars = n
+	return e
+}
+
+// SetMaxWords sets the maximum number of words. Zero disables the limit.
+func (e *Excerpter) SetMaxWords(n int) *Excerpter {
+	e.maxWords = n
+	return e
+}
+
+// SetSentenceBoundary makes excerpts end on the last complete sentence that
+// fits, when there is one, instead of the last complete word
+func (e *Excerpter) SetSentenceBoundary(sentence bool) *Excerpter {
+	e.sentence = sentence
+	return e
+}
+
+// SetEllipsis sets the text appended to truncated excerpts
+func (e *Excerpter) SetEllipsis(ellipsis string) *Excerpter {
+	e.ellipsis = ellipsis
+	return e
+}
+
+// SetReadMore sets the text of a link to the item appended to RSS
+// descriptions. An empty text disables the link.
+func (e *Excerpter) SetReadMore(text string) *Excerpter {
+	e.readMore = text
+	return e
+}
+
+// Excerpt returns a plain-text excerpt of an HTML fragment: markup is
+// stripped, entities decoded and whitespace collapsed before truncating
+func (e *Excerpter) Excerpt(s string) string {
+	text := plainText(s)
+
+	truncated := false
+	if e.maxWords > 0 {
+		words := strings.Fields(text)
+		if len(words) > e.maxWords {
+			text = strings.Join(words[:e.maxWords], " ")
+			truncated = true
+		}
+	}
+
+	if e.maxChars > 0 {
+		if chars := splitGraphemes(text); len(chars) > e.maxChars {
+			cut := strings.Join(chars[:e.maxChars], "")
+			// Back up to the last complete word, unless the cut already
+			// falls on a space or the text has no spaces (as in Chinese)
+			if next := chars[e.maxChars]; next != " " {
+				if i := strings.LastIndexByte(cut, ' '); i > 0 {
+					cut = cut[:i]
+				}
+			}
+			text = cut
+			truncated = true
+		}
+	}
+
+	if !truncated {
+		return text
+	}
+
+	if e.sentence {
+		if i := lastSentenceEnd(text); i > 0 {
+			return text[:i]
+		}
+	}
+	return strings.TrimRight(text, " ,;:-–—") + e.ellipsis
+}
+
+// SetExcerpter sets the excerpter used to generate item descriptions at
+// render time. Items with content but no description get an excerpt of the
+// content; items with only a description keep it as their content and get
+// an excerpt as their description. RSS descriptions end with a "read more"
+// link when configured; Atom summaries are plain text.
+func (f *Feed) SetExcerpter(e *Excerpter) *Feed {
+	f.excerpter = e
+	return f
+}
+
+// GetExcerpter returns the excerpter used at render time
+func (f *Feed) GetExcerpter() *Excerpter {
+	return f.excerpter
+}
+
+// excerptItem returns a copy of item with a generated description
+func (f *Feed) excerptItem(item Item, format Format) Item {
+	if f.excerpter == nil {
+		return item
+	}
+	switch {
+	case item.Content == "" && item.Description == "":
+		return item
+	case item.Content == "":
+		item.Content = item.Description
+	case item.Description != "":
+		return item
+	}
+
+	excerpt := f.excerpter.Excerpt(item.Content)
+	if format == FormatAtom {
+		// Atom summaries are text constructs
+		item.Description = excerpt
+		return item
+	}
+
+	item.Description = html.EscapeString(excerpt)
+	if f.excerpter.readMore != "" && item.Link != "" {
+		item.Description += ` <a href="` + html.EscapeString(item.Link) + `">` + html.EscapeString(f.excerpter.readMore) + `</a>`
+	}
+	return item
+}
+
+// blockElements separate words when markup is stripped
+var blockElements = map[string]bool{
+	"p": true, "br": true, "div": true, "li": true, "ul": true, "ol": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"blockquote": true, "pre": true, "tr": true, "td": true, "th": true,
+	"hr": true, "dt": true, "dd": true, "figure": true, "figcaption": true,
+	"section": true, "article": true, "header": true, "footer": true,
+}
+
+// hiddenElements have contents that are not text
+var hiddenElements = map[string]bool{
+	"script": true, "style": true, "template": true, "noscript": true,
+	"head": true, "title": true, "svg": true, "math": true,
+}
+
+// plainText strips markup, decodes entities and collapses whitespace
+func plainText(s string) string {
+	var b strings.Builder
+	hidden := ""
+	for _, tok := range htmltok.Tokenize(s) {
+		switch {
+		case hidden != "":
+			if tok.Type == htmltok.EndTagToken && tok.Data == hidden {
+				hidden = ""
+			}
+		case tok.Type == htmltok.TextToken:
+			b.WriteString(html.UnescapeString(tok.Data))
+		case tok.Type == htmltok.StartTagToken && hiddenElements[tok.Data]:
+			hidden = tok.Data
+		case blockElements[tok.Data]:
+			b.WriteByte(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// lastSentenceEnd returns the byte offset just past the last sentence
+// terminator in s that is followed by a space, or by nothing at all
+func lastSentenceEnd(s string) int {
+	for i := len(s); i > 0; {
+		r, size := utf8.DecodeLastRuneInString(s[:i])
+		if strings.ContainsRune(".!?。！？", r) && (i == len(s) || s[i] == ' ') {
+			return i
+		}
+		i -= size
+	}
+	return -1
+}
+
+// splitGraphemes splits s into approximate extended grapheme clusters, so
+// that truncation never separates a character from its combining marks,
+// emoji modifiers and ZWJ sequences, flag pairs, Hangul jamo or Indic
+// conjuncts
+func splitGraphemes(s string) []string {
+	var clusters []string
+	start := 0
+	prev := rune(-1)
+	regional := 0 // regional indicators in the current run
+	for i, r := range s {
+		if i > 0 && !extendsCluster(prev, r, regional) {
+			clusters = append(clusters, s[start:i])
+			start = i
+			regional = 0
+		}
+		if isRegionalIndicator(r) {
+			regional++
+		}
+		prev = r
+	}
+	if start < len(s) {
+		clusters = append(clusters, s[start:])
+	}
+	return clusters
+}
+
+// extendsCluster reports whether r continues the cluster ending with prev
+func extendsCluster(prev, r rune, regional int) bool {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc):
+		return true
+	case r == '\u200d' || prev == '\u200d':
+		return true
+	case r >= 0xfe00 && r <= 0xfe0f, r >= 0xe0100 && r <= 0xe01ef:
+		// Variation selectors
+		return true
+	case r >= 0x1f3fb && r <= 0x1f3ff, r >= 0xe0020 && r <= 0xe007f:
+		// Emoji modifiers and tags
+		return true
+	case isRegionalIndicator(r):
+		return isRegionalIndicator(prev) && regional%2 == 1
+	case r >= 0x1160 && r <= 0x11ff, r >= 0xd7b0 && r <= 0xd7fb:
+		// Hangul vowel and trailing consonant jamo
+		return isHangul(prev)
+	case prev >= 0x1100 && prev <= 0x115f:
+		// Hangul leading consonant jamo
+		return isHangul(r)
+	case isVirama(prev):
+		return unicode.IsLetter(r)
+	}
+	return false
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+func isHangul(r rune) bool {
+	return (r >= 0x1100 && r <= 0x11ff) || (r >= 0xa960 && r <= 0xa97f) ||
+		(r >= 0xac00 && r <= 0xd7a3) || (r >= 0xd7b0 && r <= 0xd7fb)
+}
+
+// isVirama reports whether r joins the consonants around it into a conjunct
+func isVirama(r rune) bool {
+	switch r {
+	case 0x094d, 0x09cd, 0x0acd, 0x0b4d, 0x0c4d, 0x0d4d:
+		return true
+	}
+	return false
+}
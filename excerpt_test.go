@@ -0,0 +1,75 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExcerpt(t *testing.T) {
+	tests := []struct {
+		name string
+		e    *Excerpter
+		in   string
+		want string
+	}{
+		{"short text is kept", NewExcerpter(), "<p>Hello <b>world</b></p>", "Hello world"},
+		{"markup and entities", NewExcerpter(), "<p>Fish&nbsp;&amp;&#32;chips</p><p>Peas</p><script>x()</script>", "Fish & chips Peas"},
+		{"word boundary", NewExcerpter().SetMaxChars(14), "The quick brown fox jumps", "The quick…"},
+		{"cut on a space", NewExcerpter().SetMaxChars(15), "The quick brown fox jumps", "The quick brown…"},
+		{"trailing punctuation", NewExcerpter().SetMaxChars(12).SetEllipsis("..."), "Hello there, old friend", "Hello there..."},
+		{"max words", NewExcerpter().SetMaxChars(0).SetMaxWords(3), "one  two\nthree four", "one two three…"},
+		{"sentence boundary", NewExcerpter().SetMaxChars(30).SetSentenceBoundary(true), "First sentence. Second one! Third sentence here.", "First sentence. Second one!"},
+		{"no sentence fits", NewExcerpter().SetMaxChars(10).SetSentenceBoundary(true), "A long first sentence.", "A long…"},
+		{"no spaces", NewExcerpter().SetMaxChars(4), "日本語のテキスト", "日本語の…"},
+		{"combining marks", NewExcerpter().SetMaxChars(3), "éééé", "ééé…"},
+		{"emoji sequences", NewExcerpter().SetMaxChars(2), "👩‍👩‍👧🇧🇬🇺🇸👍🏽", "👩‍👩‍👧🇧🇬…"},
+		{"conjuncts", NewExcerpter().SetMaxChars(2), "क्षत्रिय", "क्षत्रि…"},
+		{"hangul jamo", NewExcerpter().SetMaxChars(1), "한글", "한…"},
+	}
+	for _, tt := range tests {
+		if got := tt.e.Excerpt(tt.in); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestSetExcerpter(t *testing.T) {
+	f := New().
+		SetTitle("Blog").
+		SetDescription("Posts").
+		SetLink("https://example.com").
+		SetExcerpter(NewExcerpter().SetMaxWords(4).SetReadMore("Read more"))
+	f.AddItem(Item{Title: "Content", Link: "https://example.com/a?x=1&y=2", Content: "<p>One two <b>three</b> four five</p>"})
+	f.AddItem(Item{Title: "Description", Link: "https://example.com/b", Description: "<p>A &lt; b</p>"})
+	f.AddItem(Item{Title: "Both", Link: "https://example.com/c", Description: "Hand-written", Content: "<p>Full</p>"})
+
+	rss, err := f.RSS()
+	if err != nil {
+		t.Fatalf("RSS generation failed: %v", err)
+	}
+	for _, want := range []string{
+		`<description>One two three four… &lt;a href=&#34;https://example.com/a?x=1&amp;amp;y=2&#34;&gt;Read more&lt;/a&gt;</description>`,
+		`<description>A &amp;lt; b &lt;a href=&#34;https://example.com/b&#34;&gt;Read more&lt;/a&gt;</description>`,
+		`<content:encoded><![CDATA[<p>A &lt; b</p>]]></content:encoded>`,
+		`<description>Hand-written</description>`,
+	} {
+		if !strings.Contains(string(rss), want) {
+			t.Errorf("RSS should contain %s", want)
+		}
+	}
+
+	atom, err := f.Atom()
+	if err != nil {
+		t.Fatalf("Atom generation failed: %v", err)
+	}
+	if !strings.Contains(string(atom), "<summary>One two three four…</summary>") {
+		t.Error("Atom summary should be the plain-text excerpt")
+	}
+	if !strings.Contains(string(atom), "<summary>A &lt; b</summary>") {
+		t.Error("Atom summary should not be HTML-escaped twice")
+	}
+
+	if f.GetItems()[0].Description != "" {
+		t.Error("Excerpts should not modify stored items")
+	}
+}
@@ -14,8 +14,20 @@ type Feed struct {
 	managingEditor string
 	webmaster      string
 	ttl            int
+	maxItems       int
+	skipHours      []int
+	skipDays       []string
+	stylesheet     string
+	sanitizer      Sanitizer
+	excerpter      *Excerpter
+	resolveURLs    bool
+	atomXMLBase    bool
 	lastBuildDate  time.Time
+	clock          func() time.Time
 	image          *Image
+	podcast        *PodcastChannel
+	itunes         *ITunesChannel
+	media          *MediaMetadata
 	items          []Item
 	customElements map[string]interface{}
 	namespaces     map[string]string
@@ -25,6 +37,7 @@ type Feed struct {
 type Item struct {
 	Title       string      `xml:"title"`
 	Description string      `xml:"description"`
+	Content     string      `xml:"-"`
 	Link        string      `xml:"link"`
 	Author      string      `xml:"author,omitempty"`
 	PubDate     time.Time   `xml:"pubDate"`
@@ -36,6 +49,11 @@ type Item struct {
 	Images      []Image     `xml:"-"`
 	Source      *Source     `xml:"source,omitempty"`
 
+	// Scheduling: the item is rendered from PublishAt until ExpireAt.
+	// Zero values leave that end open.
+	PublishAt time.Time `xml:"-"`
+	ExpireAt  time.Time `xml:"-"`
+
 	// Custom elements for extensions
 	CustomElements map[string]interface{} `xml:"-"`
 
@@ -50,6 +68,12 @@ type Item struct {
 
 	// Dublin Core extensions
 	DCTerms *DCTerms `xml:"-"`
+
+	// Podcasting 2.0 extensions
+	Podcast *PodcastItem `xml:"-"`
+
+	// Media RSS extensions
+	Media *Media `xml:"-"`
 }
 
 // Enclosure represents a media file attached to an item
@@ -69,10 +93,17 @@ type Image struct {
 	Height      int    `xml:"height,omitempty"`
 }
 
-// Source represents the source of an item
+// Source represents the source of an item.
+// URL and Value (the source title) are rendered in RSS; Atom additionally
+// uses ID and Updated for the entry's <source> element. Atom links to URL as
+// the source's alternate page unless IsFeedURL says it is the source feed
+// document itself.
 type Source struct {
-	URL   string `xml:"url,attr"`
-	Value string `xml:",chardata"`
+	URL       string    `xml:"url,attr"`
+	Value     string    `xml:",chardata"`
+	ID        string    `xml:"-"`
+	Updated   time.Time `xml:"-"`
+	IsFeedURL bool      `xml:"-"`
 }
 
 // DCTerms represents Dublin Core Terms metadata
@@ -191,6 +222,28 @@ func (f *Feed) GetTTL() int {
 	return f.ttl
 }
 
+// SetSkipHours sets the hours (0-23, GMT) in which aggregators should not poll the feed
+func (f *Feed) SetSkipHours(hours ...int) *Feed {
+	f.skipHours = hours
+	return f
+}
+
+// GetSkipHours returns the hours in which aggregators should not poll the feed
+func (f *Feed) GetSkipHours() []int {
+	return f.skipHours
+}
+
+// SetSkipDays sets the days (e.g. "Saturday") on which aggregators should not poll the feed
+func (f *Feed) SetSkipDays(days ...string) *Feed {
+	f.skipDays = days
+	return f
+}
+
+// GetSkipDays returns the days on which aggregators should not poll the feed
+func (f *Feed) GetSkipDays() []string {
+	return f.skipDays
+}
+
 // SetImage sets the feed image
 func (f *Feed) SetImage(image Image) *Feed {
 	f.image = &image
@@ -265,5 +318,11 @@ func (f *Feed) Validate() error {
 	if f.link == "" {
 		return ErrMissingLink
 	}
-	return nil
+	if err := f.validatePodcast(); err != nil {
+		return err
+	}
+	if err := f.validateITunes(); err != nil {
+		return err
+	}
+	return f.validateMedia()
 }
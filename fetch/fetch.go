@@ -0,0 +1,404 @@
+// Package fetch retrieves remote RSS and Atom feeds politely: it sends
+// conditional requests, honours the publisher's polling hints, backs off on
+// errors and reports only the items it has not seen before.
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.rumenx.com/feed"
+)
+
+// Common errors
+var (
+	ErrBodyTooLarge     = errors.New("feed body exceeds size limit")
+	ErrTooManyRedirects = errors.New("too many redirects")
+)
+
+// StatusError is returned when the server answers with an unexpected status
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("fetching %s: unexpected status %d", e.URL, e.StatusCode)
+}
+
+// Result describes the outcome of a single fetch
+type Result struct {
+	// URL is the URL that was requested, after applying permanent redirects
+	URL string
+	// StatusCode is the HTTP status of the final response
+	StatusCode int
+	// NotModified is true when the server answered 304 Not Modified
+	NotModified bool
+	// Feed is the parsed feed; nil when NotModified is true
+	Feed *feed.Feed
+	// NewItems holds the items that were not seen in earlier fetches
+	NewItems []feed.Item
+	// NextFetch is the earliest time the feed should be polled again
+	NextFetch time.Time
+}
+
+// state holds what the fetcher remembers about a subscription
+type state struct {
+	url          string
+	etag         string
+	lastModified string
+	seen         map[string]bool
+	failures     int
+	nextFetch    time.Time
+
+	// Polling hints from the last successfully parsed feed
+	ttl       time.Duration
+	skipHours []int
+	skipDays  []string
+}
+
+// Fetcher fetches feeds and remembers per-URL caching state.
+// It is safe for concurrent use.
+type Fetcher struct {
+	client      *http.Client
+	userAgent   string
+	maxBodySize int64
+	interval    time.Duration
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	now         func() time.Time
+
+	mu     sync.Mutex
+	states map[string]*state
+}
+
+// New creates a Fetcher with sensible defaults: a one hour polling interval,
+// a 10MB body limit and exponential backoff between one minute and one day
+func New() *Fetcher {
+	return &Fetcher{
+		client:      http.DefaultClient,
+		userAgent:   "go-feed",
+		maxBodySize: 10 << 20,
+		interval:    time.Hour,
+		minBackoff:  time.Minute,
+		maxBackoff:  24 * time.Hour,
+		now:         time.Now,
+		states:      make(map[string]*state),
+	}
+}
+
+// SetClient sets the HTTP client used for requests
+func (f *Fetcher) SetClient(client *http.Client) *Fetcher {
+	f.client = client
+	return f
+}
+
+// SetUserAgent sets the User-Agent header sent with requests
+func (f *Fetcher) SetUserAgent(userAgent string) *Fetcher {
+	f.userAgent = userAgent
+	return f
+}
+
+// SetMaxBodySize sets the maximum accepted response body size in bytes
+func (f *Fetcher) SetMaxBodySize(n int64) *Fetcher {
+	f.maxBodySize = n
+	return f
+}
+
+// SetInterval sets the default polling interval. Publisher hints (ttl and
+// Cache-Control max-age) can only make polling less frequent.
+func (f *Fetcher) SetInterval(d time.Duration) *Fetcher {
+	f.interval = d
+	return f
+}
+
+// SetBackoff sets the minimum and maximum delay used after failed fetches
+func (f *Fetcher) SetBackoff(min, max time.Duration) *Fetcher {
+	f.minBackoff = min
+	f.maxBackoff = max
+	return f
+}
+
+// SetClock sets the function used to read the current time
+func (f *Fetcher) SetClock(now func() time.Time) *Fetcher {
+	f.now = now
+	return f
+}
+
+// NextFetch returns the earliest time url should be polled again.
+// The zero time means the feed may be fetched right away.
+func (f *Fetcher) NextFetch(url string) time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if st, ok := f.states[url]; ok {
+		return st.nextFetch
+	}
+	return time.Time{}
+}
+
+// Fetch retrieves the feed at url. Conditional request headers from earlier
+// fetches are sent automatically, and only items not seen before are
+// reported in Result.NewItems.
+func (f *Fetcher) Fetch(ctx context.Context, url string) (*Result, error) {
+	st := f.state(url)
+
+	f.mu.Lock()
+	target, etag, lastModified := st.url, st.etag, st.lastModified
+	f.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		f.fail(st, time.Time{})
+		return nil, fmt.Errorf("creating request for %s: %w", target, err)
+	}
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, */*;q=0.8")
+	if f.userAgent != "" {
+		req.Header.Set("User-Agent", f.userAgent)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	permanent := true
+	client := *f.client
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return ErrTooManyRedirects
+		}
+		if code := req.Response.StatusCode; code != http.StatusMovedPermanently && code != http.StatusPermanentRedirect {
+			permanent = false
+		}
+		return nil
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		f.fail(st, time.Time{})
+		return nil, fmt.Errorf("fetching %s: %w", target, err)
+	}
+	defer resp.Body.Close()
+
+	result := &Result{URL: target, StatusCode: resp.StatusCode}
+
+	// Remember permanent redirects so later fetches go straight to the new URL
+	if final := resp.Request.URL.String(); final != target && permanent {
+		result.URL = final
+		f.mu.Lock()
+		st.url = final
+		f.mu.Unlock()
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		result.NotModified = true
+		result.NextFetch = f.succeed(st, resp, nil)
+		return result, nil
+
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		result.NextFetch = f.fail(st, retryAfter(resp.Header.Get("Retry-After"), f.now()))
+		return result, &StatusError{URL: target, StatusCode: resp.StatusCode}
+
+	case resp.StatusCode != http.StatusOK:
+		result.NextFetch = f.fail(st, time.Time{})
+		return result, &StatusError{URL: target, StatusCode: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBodySize+1))
+	if err != nil {
+		result.NextFetch = f.fail(st, time.Time{})
+		return result, fmt.Errorf("reading %s: %w", target, err)
+	}
+	if int64(len(body)) > f.maxBodySize {
+		result.NextFetch = f.fail(st, time.Time{})
+		return result, ErrBodyTooLarge
+	}
+
+	parsed, err := feed.Parse(bytes.NewReader(body))
+	if err != nil {
+		result.NextFetch = f.fail(st, time.Time{})
+		return result, fmt.Errorf("parsing %s: %w", target, err)
+	}
+
+	result.Feed = parsed
+	result.NewItems = f.newItems(st, parsed.GetItems())
+	result.NextFetch = f.succeed(st, resp, parsed)
+	return result, nil
+}
+
+// state returns the state for url, creating it on first use
+func (f *Fetcher) state(url string) *state {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	st, ok := f.states[url]
+	if !ok {
+		st = &state{url: url, seen: make(map[string]bool)}
+		f.states[url] = st
+	}
+	return st
+}
+
+// newItems returns the items not seen before. Only the keys of the latest
+// document are remembered, so memory stays bounded by the feed size however
+// long a subscription is polled; an item that drops out of the feed and
+// later reappears is reported again.
+func (f *Fetcher) newItems(st *state, items []feed.Item) []feed.Item {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var fresh []feed.Item
+	current := make(map[string]bool, len(items))
+	for _, item := range items {
+		key := itemKey(item)
+		if key == "" || current[key] {
+			continue
+		}
+		current[key] = true
+		if !st.seen[key] {
+			fresh = append(fresh, item)
+		}
+	}
+	st.seen = current
+	return fresh
+}
+
+// succeed records a successful fetch and schedules the next one.
+// parsed is nil for 304 responses, in which case the polling hints of the
+// last parsed version are reused.
+func (f *Fetcher) succeed(st *state, resp *http.Response, parsed *feed.Feed) time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if parsed != nil {
+		st.ttl = time.Duration(parsed.GetTTL()) * time.Minute
+		st.skipHours = parsed.GetSkipHours()
+		st.skipDays = parsed.GetSkipDays()
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		st.etag = etag
+	}
+	if lastModified := resp.Header.Get("Last-Modified"); lastModified != "" {
+		st.lastModified = lastModified
+	}
+
+	interval := f.interval
+	if st.ttl > interval {
+		interval = st.ttl
+	}
+	if maxAge, ok := cacheMaxAge(resp.Header.Get("Cache-Control")); ok && maxAge > interval {
+		interval = maxAge
+	}
+
+	st.failures = 0
+	st.nextFetch = skipTimes(f.now().Add(interval), st.skipHours, st.skipDays)
+	return st.nextFetch
+}
+
+// fail records a failed fetch and schedules a retry using exponential
+// backoff, or the server supplied retry time when it is later
+func (f *Fetcher) fail(st *state, retry time.Time) time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	backoff := time.Duration(float64(f.minBackoff) * math.Pow(2, float64(st.failures)))
+	if backoff > f.maxBackoff || backoff <= 0 {
+		backoff = f.maxBackoff
+	}
+	st.failures++
+
+	next := f.now().Add(backoff)
+	if retry.After(next) {
+		next = retry
+	}
+	st.nextFetch = next
+	return next
+}
+
+// itemKey identifies an item across fetches
+func itemKey(item feed.Item) string {
+	if item.GUID != "" {
+		return "guid:" + item.GUID
+	}
+	if item.Link != "" {
+		return "link:" + item.Link
+	}
+	if item.Title != "" {
+		return "title:" + item.Title + "|" + item.PubDate.String()
+	}
+	return ""
+}
+
+// cacheMaxAge extracts max-age from a Cache-Control header
+func cacheMaxAge(header string) (time.Duration, bool) {
+	for _, directive := range strings.Split(header, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(directive), "=")
+		if !ok || !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+		if err != nil || seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	return 0, false
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(header string, now time.Time) time.Time {
+	if header == "" {
+		return time.Time{}
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return now.Add(time.Duration(seconds) * time.Second)
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+// skipTimes moves t forward until it falls outside the skipped hours and
+// days. Both are interpreted in GMT as required by the RSS specification.
+func skipTimes(t time.Time, hours []int, days []string) time.Time {
+	if len(hours) == 0 && len(days) == 0 {
+		return t
+	}
+
+	skipHour := make(map[int]bool, len(hours))
+	for _, h := range hours {
+		skipHour[h] = true
+	}
+	skipDay := make(map[string]bool, len(days))
+	for _, d := range days {
+		skipDay[strings.ToLower(strings.TrimSpace(d))] = true
+	}
+
+	// A week of hours is enough to find a free slot if one exists
+	for i := 0; i < 7*24; i++ {
+		utc := t.UTC()
+		if skipDay[strings.ToLower(utc.Weekday().String())] {
+			t = utc.Truncate(time.Hour).Add(time.Duration(24-utc.Hour()) * time.Hour)
+			continue
+		}
+		if skipHour[utc.Hour()] {
+			t = utc.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		return t
+	}
+	return t
+}
@@ -0,0 +1,320 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func rssBody(extra string, guids ...string) string {
+	var items strings.Builder
+	for _, guid := range guids {
+		fmt.Fprintf(&items, "<item><title>%s</title><link>https://example.com/%s</link><guid>%s</guid></item>", guid, guid, guid)
+	}
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel><title>Test</title><link>https://example.com</link><description>Test</description>` +
+		extra + items.String() + `</channel></rss>`
+}
+
+func fixedClock(t time.Time) func() time.Time {
+	return func() time.Time { return t }
+}
+
+func TestFetchConditionalRequests(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("If-None-Match") == `"v1"` && r.Header.Get("If-Modified-Since") == "Wed, 01 Jan 2025 00:00:00 GMT" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Wed, 01 Jan 2025 00:00:00 GMT")
+		fmt.Fprint(w, rssBody("", "a", "b"))
+	}))
+	defer server.Close()
+
+	f := New()
+
+	result, err := f.Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("First fetch failed: %v", err)
+	}
+	if result.NotModified || result.Feed == nil {
+		t.Fatal("First fetch should return a parsed feed")
+	}
+	if len(result.NewItems) != 2 {
+		t.Errorf("Expected 2 new items, got %d", len(result.NewItems))
+	}
+
+	result, err = f.Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Second fetch failed: %v", err)
+	}
+	if !result.NotModified || result.StatusCode != http.StatusNotModified {
+		t.Error("Second fetch should be answered with 304 Not Modified")
+	}
+	if result.Feed != nil || len(result.NewItems) != 0 {
+		t.Error("Not modified fetch should not report a feed or items")
+	}
+	if atomic.LoadInt32(&requests) != 2 {
+		t.Errorf("Expected 2 requests, got %d", requests)
+	}
+}
+
+func TestFetchReportsOnlyNewItems(t *testing.T) {
+	guids := []string{"a", "b"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, rssBody("", guids...))
+	}))
+	defer server.Close()
+
+	f := New()
+	if _, err := f.Fetch(context.Background(), server.URL); err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	guids = []string{"c", "a", "b"}
+	result, err := f.Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(result.NewItems) != 1 || result.NewItems[0].GUID != "c" {
+		t.Errorf("Expected only item c to be new, got %+v", result.NewItems)
+	}
+	if len(result.Feed.GetItems()) != 3 {
+		t.Errorf("Expected full feed with 3 items, got %d", len(result.Feed.GetItems()))
+	}
+}
+
+func TestFetchForgetsItemsDroppedFromFeed(t *testing.T) {
+	var guids []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, rssBody("", guids...))
+	}))
+	defer server.Close()
+
+	f := New()
+	for i := 0; i < 50; i++ {
+		guids = []string{fmt.Sprint(i), fmt.Sprint(i + 1)}
+		result, err := f.Fetch(context.Background(), server.URL)
+		if err != nil {
+			t.Fatalf("Fetch failed: %v", err)
+		}
+		if i > 0 && (len(result.NewItems) != 1 || result.NewItems[0].GUID != fmt.Sprint(i+1)) {
+			t.Fatalf("Expected only item %d to be new, got %+v", i+1, result.NewItems)
+		}
+	}
+
+	if n := len(f.state(server.URL).seen); n != 2 {
+		t.Errorf("Expected only the keys of the latest document to be kept, got %d", n)
+	}
+}
+
+func TestFetchSchedule(t *testing.T) {
+	// Thursday 2 January 2025, 10:00 GMT
+	now := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		extra        string
+		cacheControl string
+		want         time.Time
+	}{
+		{"default interval", "", "", now.Add(time.Hour)},
+		{"ttl", "<ttl>120</ttl>", "", now.Add(2 * time.Hour)},
+		{"cache control", "<ttl>120</ttl>", "public, max-age=10800", now.Add(3 * time.Hour)},
+		{"skip hours", "<skipHours><hour>11</hour><hour>12</hour></skipHours>", "", now.Add(3 * time.Hour)},
+		{"skip days", "<skipDays><day>Thursday</day><day>Friday</day></skipDays>", "", time.Date(2025, 1, 4, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.cacheControl != "" {
+					w.Header().Set("Cache-Control", tt.cacheControl)
+				}
+				fmt.Fprint(w, rssBody(tt.extra, "a"))
+			}))
+			defer server.Close()
+
+			f := New().SetClock(fixedClock(now))
+			result, err := f.Fetch(context.Background(), server.URL)
+			if err != nil {
+				t.Fatalf("Fetch failed: %v", err)
+			}
+			if !result.NextFetch.Equal(tt.want) {
+				t.Errorf("Expected next fetch %v, got %v", tt.want, result.NextFetch)
+			}
+			if !f.NextFetch(server.URL).Equal(tt.want) {
+				t.Errorf("NextFetch should match the result, got %v", f.NextFetch(server.URL))
+			}
+		})
+	}
+}
+
+func TestFetchNotModifiedKeepsTTL(t *testing.T) {
+	now := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, rssBody("<ttl>180</ttl>", "a"))
+	}))
+	defer server.Close()
+
+	f := New().SetClock(fixedClock(now))
+	f.Fetch(context.Background(), server.URL)
+
+	result, err := f.Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if !result.NextFetch.Equal(now.Add(3 * time.Hour)) {
+		t.Errorf("Expected ttl to apply after 304, got %v", result.NextFetch)
+	}
+}
+
+func TestFetchPermanentRedirect(t *testing.T) {
+	var oldHits int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&oldHits, 1)
+		http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/temp", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusFound)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, rssBody("", "a"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	f := New()
+
+	result, err := f.Fetch(context.Background(), server.URL+"/old")
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if result.URL != server.URL+"/new" {
+		t.Errorf("Expected permanent redirect to be recorded, got %s", result.URL)
+	}
+
+	if _, err := f.Fetch(context.Background(), server.URL+"/old"); err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if atomic.LoadInt32(&oldHits) != 1 {
+		t.Errorf("Expected the old URL to be requested once, got %d", oldHits)
+	}
+
+	result, err = f.Fetch(context.Background(), server.URL+"/temp")
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if result.URL != server.URL+"/temp" {
+		t.Errorf("Temporary redirects should not be recorded, got %s", result.URL)
+	}
+}
+
+func TestFetchBackoff(t *testing.T) {
+	now := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	status := http.StatusServiceUnavailable
+	retry := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if retry != "" {
+			w.Header().Set("Retry-After", retry)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	f := New().SetClock(fixedClock(now)).SetBackoff(time.Minute, 10*time.Minute)
+
+	for i, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute} {
+		result, err := f.Fetch(context.Background(), server.URL)
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != status {
+			t.Fatalf("Expected StatusError, got %v", err)
+		}
+		if !result.NextFetch.Equal(now.Add(want)) {
+			t.Errorf("Attempt %d: expected backoff %v, got %v", i, want, result.NextFetch.Sub(now))
+		}
+	}
+
+	// Retry-After wins when it asks for a longer pause
+	status = http.StatusTooManyRequests
+	retry = "3600"
+	result, _ := f.Fetch(context.Background(), server.URL)
+	if !result.NextFetch.Equal(now.Add(time.Hour)) {
+		t.Errorf("Expected Retry-After to be honoured, got %v", result.NextFetch.Sub(now))
+	}
+
+	// A successful fetch resets the backoff
+	status = http.StatusOK
+	retry = ""
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, rssBody("", "a"))
+	})
+	if _, err := f.Fetch(context.Background(), server.URL); err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	result, _ = f.Fetch(context.Background(), server.URL)
+	if !result.NextFetch.Equal(now.Add(time.Minute)) {
+		t.Errorf("Expected backoff to reset after success, got %v", result.NextFetch.Sub(now))
+	}
+}
+
+func TestFetchBodySizeLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, rssBody("", "a", "b", "c"))
+	}))
+	defer server.Close()
+
+	_, err := New().SetMaxBodySize(64).Fetch(context.Background(), server.URL)
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("Expected ErrBodyTooLarge, got %v", err)
+	}
+}
+
+func TestFetchInvalidFeed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><body>Not a feed</body></html>")
+	}))
+	defer server.Close()
+
+	if _, err := New().Fetch(context.Background(), server.URL); err == nil {
+		t.Error("Expected an error for a non-feed document")
+	}
+}
+
+func TestCacheMaxAge(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"max-age=60", time.Minute, true},
+		{"public, max-age=3600, must-revalidate", time.Hour, true},
+		{"no-cache", 0, false},
+		{"max-age=abc", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := cacheMaxAge(tt.header)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("cacheMaxAge(%q) = %v, %v; want %v, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package fetch
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Handler is called by the Poller after every fetch.
+// It is called from worker goroutines and must be safe for concurrent use.
+type Handler func(url string, result *Result, err error)
+
+// Poller periodically fetches many subscriptions using a pool of workers.
+// Each subscription is scheduled according to the Fetcher's NextFetch time,
+// spread out by a random jitter so requests to many feeds do not align.
+type Poller struct {
+	fetcher *Fetcher
+	handler Handler
+	workers int
+	jitter  time.Duration
+
+	mu    sync.Mutex
+	subs  map[string]bool
+	added []string
+	wake  chan struct{}
+}
+
+// NewPoller creates a Poller that fetches with f and reports to handler
+func NewPoller(f *Fetcher, handler Handler) *Poller {
+	return &Poller{
+		fetcher: f,
+		handler: handler,
+		workers: 4,
+		jitter:  time.Minute,
+		subs:    make(map[string]bool),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// SetWorkers sets the number of concurrent fetches
+func (p *Poller) SetWorkers(n int) *Poller {
+	if n > 0 {
+		p.workers = n
+	}
+	return p
+}
+
+// SetJitter sets the maximum random delay added to every scheduled fetch
+func (p *Poller) SetJitter(d time.Duration) *Poller {
+	p.jitter = d
+	return p
+}
+
+// Subscribe adds a feed URL to poll. It may be called while the poller runs.
+func (p *Poller) Subscribe(url string) *Poller {
+	p.mu.Lock()
+	if !p.subs[url] {
+		p.subs[url] = true
+		p.added = append(p.added, url)
+	}
+	p.mu.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+	return p
+}
+
+// Run polls the subscriptions until ctx is cancelled.
+// It waits for in-flight fetches to finish before returning ctx.Err().
+func (p *Poller) Run(ctx context.Context) error {
+	// Jobs are only queued while a worker is free, so sends never block
+	jobs := make(chan string, p.workers)
+	done := make(chan string)
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for url := range jobs {
+				result, err := p.fetcher.Fetch(ctx, url)
+				if ctx.Err() == nil && p.handler != nil {
+					p.handler(url, result, err)
+				}
+				select {
+				case done <- url:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+	defer func() {
+		close(jobs)
+		wg.Wait()
+	}()
+
+	due := make(map[string]time.Time)
+	inFlight := make(map[string]bool)
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		p.mu.Lock()
+		for _, url := range p.added {
+			due[url] = time.Now().Add(p.randomJitter())
+		}
+		p.added = nil
+		p.mu.Unlock()
+
+		// Dispatch everything that is due while workers are free. Anything
+		// left over is picked up when a worker reports back.
+		now := time.Now()
+		var next time.Time
+		for url, at := range due {
+			if inFlight[url] {
+				continue
+			}
+			if !at.After(now) {
+				if len(inFlight) < p.workers {
+					jobs <- url
+					inFlight[url] = true
+				}
+				continue
+			}
+			if next.IsZero() || at.Before(next) {
+				next = at
+			}
+		}
+
+		wait := time.Hour
+		if !next.IsZero() {
+			wait = time.Until(next)
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case url := <-done:
+			delete(inFlight, url)
+			due[url] = p.fetcher.NextFetch(url).Add(p.randomJitter())
+		case <-p.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// randomJitter returns a random duration in [0, jitter)
+func (p *Poller) randomJitter() time.Duration {
+	if p.jitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(p.jitter)))
+}
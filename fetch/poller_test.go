@@ -0,0 +1,110 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPollerFetchesAllSubscriptions(t *testing.T) {
+	var mu sync.Mutex
+	hits := make(map[string]int)
+
+	var servers []*httptest.Server
+	for i := 0; i < 3; i++ {
+		name := fmt.Sprintf("feed-%d", i)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			hits[name]++
+			mu.Unlock()
+			fmt.Fprint(w, rssBody("", name))
+		}))
+		defer server.Close()
+		servers = append(servers, server)
+	}
+
+	var resultsMu sync.Mutex
+	newItems := 0
+	fetches := 0
+
+	f := New().SetInterval(20 * time.Millisecond)
+	p := NewPoller(f, func(url string, result *Result, err error) {
+		if err != nil {
+			t.Errorf("Fetch of %s failed: %v", url, err)
+			return
+		}
+		resultsMu.Lock()
+		fetches++
+		newItems += len(result.NewItems)
+		resultsMu.Unlock()
+	}).SetWorkers(2).SetJitter(5 * time.Millisecond)
+
+	for _, server := range servers {
+		p.Subscribe(server.URL)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	if err := p.Run(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected Run to stop with the context error, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for name, n := range hits {
+		if n < 2 {
+			t.Errorf("Expected %s to be polled repeatedly, got %d fetches", name, n)
+		}
+	}
+	if len(hits) != 3 {
+		t.Errorf("Expected all 3 feeds to be polled, got %d", len(hits))
+	}
+
+	resultsMu.Lock()
+	defer resultsMu.Unlock()
+	if newItems != 3 {
+		t.Errorf("Expected each item to be reported as new once, got %d", newItems)
+	}
+	if fetches < 6 {
+		t.Errorf("Expected at least 6 fetches, got %d", fetches)
+	}
+}
+
+func TestPollerSubscribeWhileRunning(t *testing.T) {
+	fetched := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, rssBody("", "a"))
+	}))
+	defer server.Close()
+
+	p := NewPoller(New(), func(url string, result *Result, err error) {
+		fetched <- url
+	}).SetJitter(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- p.Run(ctx)
+	}()
+
+	p.Subscribe(server.URL)
+
+	select {
+	case url := <-fetched:
+		if url != server.URL {
+			t.Errorf("Unexpected URL %s", url)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Subscription added while running was not fetched")
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package feed
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Format identifies a feed output format
+type Format string
+
+// Supported output formats
+const (
+	FormatRSS  Format = "rss"
+	FormatAtom Format = "atom"
+)
+
+// Formats lists all supported output formats
+var Formats = []Format{FormatRSS, FormatAtom}
+
+// ParseFormat returns the format for a name or file extension such as
+// "atom" or ".xml". It returns false for unknown formats.
+func ParseFormat(s string) (Format, bool) {
+	switch strings.ToLower(strings.TrimPrefix(s, ".")) {
+	case "rss", "xml":
+		return FormatRSS, true
+	case "atom":
+		return FormatAtom, true
+	}
+	return "", false
+}
+
+// MediaType returns the registered media type of the format, as used for
+// autodiscovery links
+func (f Format) MediaType() string {
+	switch f {
+	case FormatAtom:
+		return "application/atom+xml"
+	default:
+		return "application/rss+xml"
+	}
+}
+
+// ContentType returns the HTTP Content-Type used when serving the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatAtom:
+		return "application/atom+xml; charset=utf-8"
+	default:
+		return "application/xml; charset=utf-8"
+	}
+}
+
+// Extension returns the conventional file extension for the format
+func (f Format) Extension() string {
+	switch f {
+	case FormatAtom:
+		return ".atom"
+	default:
+		return ".xml"
+	}
+}
+
+// Render generates the feed in the given format
+func (f *Feed) Render(format Format) ([]byte, error) {
+	switch format {
+	case FormatRSS:
+		return f.RSS()
+	case FormatAtom:
+		return f.Atom()
+	}
+	return nil, fmt.Errorf("unsupported feed format %q", format)
+}
@@ -0,0 +1,47 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := map[string]Format{
+		"rss":   FormatRSS,
+		".xml":  FormatRSS,
+		"Atom":  FormatAtom,
+		".atom": FormatAtom,
+	}
+	for in, want := range tests {
+		got, ok := ParseFormat(in)
+		if !ok || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+
+	if _, ok := ParseFormat("json"); ok {
+		t.Error("ParseFormat should reject unknown formats")
+	}
+}
+
+func TestRenderFormat(t *testing.T) {
+	f := newCollectionFeed()
+
+	rss, err := f.Render(FormatRSS)
+	if err != nil || !strings.Contains(string(rss), `<rss version="2.0">`) {
+		t.Errorf("Render(FormatRSS) should produce RSS, got error %v", err)
+	}
+
+	atom, err := f.Render(FormatAtom)
+	if err != nil || !strings.Contains(string(atom), "http://www.w3.org/2005/Atom") {
+		t.Errorf("Render(FormatAtom) should produce Atom, got error %v", err)
+	}
+
+	if _, err := f.Render("json"); err == nil {
+		t.Error("Render should fail for unsupported formats")
+	}
+
+	if FormatAtom.MediaType() != "application/atom+xml" || FormatRSS.Extension() != ".xml" {
+		t.Error("Unexpected format metadata")
+	}
+}
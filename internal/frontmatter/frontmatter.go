@@ -0,0 +1,617 @@
+// Package frontmatter decodes the YAML, TOML and JSON front matter of
+// Markdown files without external dependencies. Only the subset of each
+// language commonly used in front matter and small config files is
+// supported.
+package frontmatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalid is returned for malformed front matter
+var ErrInvalid = errors.New("invalid front matter")
+
+// Split separates the front matter of a Markdown file from its
+// body and decodes it. YAML front matter is fenced by "---" lines, TOML by
+// "+++" lines, and JSON is a single object at the start of the file.
+// Files without front matter return a nil map.
+func Split(src []byte) (map[string]any, []byte, error) {
+	src = bytes.TrimPrefix(src, []byte("\ufeff"))
+	src = bytes.ReplaceAll(src, []byte("\r\n"), []byte("\n"))
+
+	switch {
+	case bytes.HasPrefix(src, []byte("---\n")):
+		fm, body, err := fenced(src, "---", "...")
+		if err != nil {
+			return nil, nil, err
+		}
+		values, err := ParseYAML(fm)
+		return values, body, err
+	case bytes.HasPrefix(src, []byte("+++\n")):
+		fm, body, err := fenced(src, "+++", "")
+		if err != nil {
+			return nil, nil, err
+		}
+		values, err := ParseTOML(fm)
+		return values, body, err
+	case bytes.HasPrefix(src, []byte("{")):
+		dec := json.NewDecoder(bytes.NewReader(src))
+		var values map[string]any
+		if err := dec.Decode(&values); err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		return values, bytes.TrimLeft(src[dec.InputOffset():], "\n"), nil
+	}
+	return nil, src, nil
+}
+
+// fenced returns the lines between the opening fence and the closing fence
+// (or alternative closing fence), and the body after it
+func fenced(src []byte, fence, alt string) (string, []byte, error) {
+	rest := string(src[len(fence)+1:])
+	offset := 0
+	for offset <= len(rest) {
+		end := strings.IndexByte(rest[offset:], '\n')
+		line := rest[offset:]
+		if end >= 0 {
+			line = rest[offset : offset+end]
+		}
+		if trimmed := strings.TrimRight(line, " \t"); trimmed == fence || (alt != "" && trimmed == alt) {
+			body := ""
+			if end >= 0 {
+				body = rest[offset+end+1:]
+			}
+			return rest[:offset], []byte(body), nil
+		}
+		if end < 0 {
+			break
+		}
+		offset += end + 1
+	}
+	return "", nil, fmt.Errorf("%w: missing closing %q", ErrInvalid, fence)
+}
+
+// yamlLine is a line of YAML; blank and comment lines have indent -1
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+// ParseYAML decodes the subset of YAML used in front matter: mappings,
+// block and flow sequences, quoted and plain scalars, and literal (|) and
+// folded (>) block scalars. Anchors, tags and multi-document streams are
+// not supported.
+func ParseYAML(src string) (map[string]any, error) {
+	var lines []yamlLine
+	raw := strings.Split(src, "\n")
+	for i, text := range raw {
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			lines = append(lines, yamlLine{num: i + 1, indent: -1, text: ""})
+			continue
+		}
+		if strings.HasPrefix(text, "\t") {
+			return nil, fmt.Errorf("%w: line %d: tabs are not allowed for indentation", ErrInvalid, i+1)
+		}
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		lines = append(lines, yamlLine{num: i + 1, indent: indent, text: strings.TrimRight(text[indent:], " ")})
+	}
+
+	p := &yamlParser{lines: lines, raw: raw}
+	p.skipBlank()
+	if p.pos >= len(p.lines) {
+		return map[string]any{}, nil
+	}
+	values, err := p.mapping(p.lines[p.pos].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.skipBlank(); p.pos < len(p.lines) {
+		return nil, fmt.Errorf("%w: line %d: unexpected indentation", ErrInvalid, p.lines[p.pos].num)
+	}
+	return values, nil
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	raw   []string
+	pos   int
+}
+
+func (p *yamlParser) skipBlank() {
+	for p.pos < len(p.lines) && p.lines[p.pos].indent < 0 {
+		p.pos++
+	}
+}
+
+// mapping parses "key: value" lines at the given indentation
+func (p *yamlParser) mapping(indent int) (map[string]any, error) {
+	values := make(map[string]any)
+	for p.skipBlank(); p.pos < len(p.lines); p.skipBlank() {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("%w: line %d: unexpected indentation", ErrInvalid, line.num)
+		}
+		key, rest, ok := cutYAMLKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("%w: line %d: expected \"key: value\"", ErrInvalid, line.num)
+		}
+		p.pos++
+		value, err := p.value(rest, indent, line.num)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// sequence parses "- value" lines at the given indentation
+func (p *yamlParser) sequence(indent int) ([]any, error) {
+	var values []any
+	for p.skipBlank(); p.pos < len(p.lines); p.skipBlank() {
+		line := p.lines[p.pos]
+		if line.indent < indent || (line.text != "-" && !strings.HasPrefix(line.text, "- ")) {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("%w: line %d: unexpected indentation", ErrInvalid, line.num)
+		}
+		rest := strings.TrimSpace(strings.TrimPrefix(line.text, "-"))
+		if _, _, ok := cutYAMLKey(rest); ok && !strings.HasPrefix(rest, `"`) && !strings.HasPrefix(rest, "'") {
+			// A mapping item: re-read this line as the first key of a
+			// mapping indented past the dash
+			itemIndent := indent + (len(line.text) - len(rest))
+			p.lines[p.pos] = yamlLine{num: line.num, indent: itemIndent, text: rest}
+			item, err := p.mapping(itemIndent)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, item)
+			continue
+		}
+		p.pos++
+		value, err := p.value(rest, indent, line.num)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// value parses the value after a key or dash, which may continue on the
+// following, more indented lines
+func (p *yamlParser) value(rest string, indent, num int) (any, error) {
+	switch {
+	case rest == "" || strings.HasPrefix(rest, "#"):
+		p.skipBlank()
+		if p.pos >= len(p.lines) {
+			return nil, nil
+		}
+		next := p.lines[p.pos]
+		if next.text == "-" || strings.HasPrefix(next.text, "- ") {
+			// Sequences may be indented at the same level as their key
+			if next.indent >= indent {
+				return p.sequence(next.indent)
+			}
+		}
+		if next.indent > indent {
+			return p.mapping(next.indent)
+		}
+		return nil, nil
+	case rest[0] == '|' || rest[0] == '>':
+		return p.blockScalar(rest, indent), nil
+	}
+	return parseYAMLScalar(rest, num)
+}
+
+// blockScalar reads a literal or folded block scalar from the raw lines,
+// which keep comments and indentation
+func (p *yamlParser) blockScalar(header string, indent int) string {
+	folded := header[0] == '>'
+	chomp := strings.TrimSpace(strings.SplitN(header[1:], "#", 2)[0])
+
+	var lines []string
+	blockIndent := -1
+	for ; p.pos < len(p.lines); p.pos++ {
+		raw := p.raw[p.lines[p.pos].num-1]
+		if strings.TrimSpace(raw) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		lineIndent := len(raw) - len(strings.TrimLeft(raw, " "))
+		if blockIndent < 0 {
+			blockIndent = lineIndent
+		}
+		if lineIndent <= indent || lineIndent < blockIndent {
+			break
+		}
+		lines = append(lines, strings.TrimRight(raw[blockIndent:], " "))
+	}
+	// Trailing blank lines belong to the chomping, not the content
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var text string
+	if folded {
+		var b strings.Builder
+		for i, line := range lines {
+			switch {
+			case i == 0:
+			case line == "" || lines[i-1] == "":
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(' ')
+			}
+			b.WriteString(line)
+		}
+		text = b.String()
+	} else {
+		text = strings.Join(lines, "\n")
+	}
+	if chomp != "-" && text != "" {
+		text += "\n"
+	}
+	return text
+}
+
+// cutYAMLKey splits "key: value", honouring quoted keys
+func cutYAMLKey(s string) (key, rest string, ok bool) {
+	if strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'") {
+		end := strings.IndexByte(s[1:], s[0])
+		if end < 0 {
+			return "", "", false
+		}
+		key, rest = s[1:end+1], s[end+2:]
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", false
+		}
+		return key, strings.TrimSpace(rest[1:]), true
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] == ':' && (i+1 == len(s) || s[i+1] == ' ') {
+			key = strings.TrimSpace(s[:i])
+			if key == "" || strings.ContainsAny(key[:1], "[{-#") {
+				return "", "", false
+			}
+			return key, strings.TrimSpace(s[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// parseYAMLScalar parses a quoted or plain scalar, or a flow sequence
+func parseYAMLScalar(s string, num int) (any, error) {
+	switch s[0] {
+	case '"':
+		value, rest, err := unquoteDouble(s)
+		if err != nil || !isComment(rest) {
+			return nil, fmt.Errorf("%w: line %d: invalid quoted string", ErrInvalid, num)
+		}
+		return value, nil
+	case '\'':
+		value, rest, ok := unquoteSingle(s)
+		if !ok || !isComment(rest) {
+			return nil, fmt.Errorf("%w: line %d: invalid quoted string", ErrInvalid, num)
+		}
+		return value, nil
+	case '[':
+		values, rest, err := parseFlowSequence(s)
+		if err != nil || !isComment(rest) {
+			return nil, fmt.Errorf("%w: line %d: invalid sequence", ErrInvalid, num)
+		}
+		return values, nil
+	}
+
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	switch s {
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	case "null", "Null", "NULL", "~":
+		return nil, nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && strings.ContainsAny(s, "0123456789") {
+		return f, nil
+	}
+	return s, nil
+}
+
+// parseFlowSequence parses "[a, 'b', "c"]" and returns the rest of s
+func parseFlowSequence(s string) ([]any, string, error) {
+	values := []any{}
+	s = strings.TrimSpace(s[1:])
+	for {
+		if strings.HasPrefix(s, "]") {
+			return values, strings.TrimSpace(s[1:]), nil
+		}
+		if s == "" {
+			return nil, "", ErrInvalid
+		}
+
+		var value any
+		switch s[0] {
+		case '"':
+			str, rest, err := unquoteDouble(s)
+			if err != nil {
+				return nil, "", err
+			}
+			value, s = str, rest
+		case '\'':
+			str, rest, ok := unquoteSingle(s)
+			if !ok {
+				return nil, "", ErrInvalid
+			}
+			value, s = str, rest
+		default:
+			end := strings.IndexAny(s, ",]")
+			if end < 0 {
+				return nil, "", ErrInvalid
+			}
+			plain := strings.TrimSpace(s[:end])
+			s = s[end:]
+			value, _ = parseYAMLScalar(plain, 0)
+		}
+		values = append(values, value)
+
+		s = strings.TrimSpace(s)
+		if strings.HasPrefix(s, ",") {
+			s = strings.TrimSpace(s[1:])
+		} else if !strings.HasPrefix(s, "]") {
+			return nil, "", ErrInvalid
+		}
+	}
+}
+
+// unquoteDouble reads a double-quoted string with backslash escapes and
+// returns the rest of s
+func unquoteDouble(s string) (string, string, error) {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			value, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", "", ErrInvalid
+			}
+			return value, strings.TrimSpace(s[i+1:]), nil
+		}
+	}
+	return "", "", ErrInvalid
+}
+
+// unquoteSingle reads a single-quoted string, in which a doubled quote
+// stands for a single one, and returns the rest of s
+func unquoteSingle(s string) (string, string, bool) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		if s[i] == '\'' {
+			if i+1 < len(s) && s[i+1] == '\'' {
+				b.WriteByte('\'')
+				i++
+				continue
+			}
+			return b.String(), strings.TrimSpace(s[i+1:]), true
+		}
+		b.WriteByte(s[i])
+	}
+	return "", "", false
+}
+
+func isComment(s string) bool {
+	return s == "" || strings.HasPrefix(s, "#")
+}
+
+// ParseTOML decodes the subset of TOML used in front matter: key/value
+// pairs, [tables], strings, numbers, booleans, dates and arrays
+func ParseTOML(src string) (map[string]any, error) {
+	values := make(map[string]any)
+	table := values
+
+	lines := strings.Split(src, "\n")
+	for i := 0; i < len(lines); i++ {
+		num := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			name, rest, ok := strings.Cut(line[1:], "]")
+			if !ok || strings.HasPrefix(name, "[") || !isComment(strings.TrimSpace(rest)) {
+				return nil, fmt.Errorf("%w: line %d: invalid table header", ErrInvalid, num)
+			}
+			table = values
+			for _, part := range strings.Split(name, ".") {
+				part = strings.Trim(strings.TrimSpace(part), `"`)
+				sub, ok := table[part].(map[string]any)
+				if !ok {
+					sub = make(map[string]any)
+					table[part] = sub
+				}
+				table = sub
+			}
+			continue
+		}
+
+		key, rest, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: line %d: expected \"key = value\"", ErrInvalid, num)
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"'`)
+		rest = strings.TrimSpace(rest)
+
+		// Multi-line strings and arrays continue on the following lines
+		for (strings.HasPrefix(rest, `"""`) || strings.HasPrefix(rest, "'''")) && strings.Count(rest, rest[:3]) < 2 && i+1 < len(lines) {
+			i++
+			rest += "\n" + lines[i]
+		}
+		for strings.HasPrefix(rest, "[") && !balancedArray(rest) && i+1 < len(lines) {
+			i++
+			rest += "\n" + strings.TrimSpace(lines[i])
+		}
+
+		value, after, err := parseTOMLValue(rest)
+		if err != nil || !isComment(strings.TrimSpace(after)) {
+			return nil, fmt.Errorf("%w: line %d: invalid value for %q", ErrInvalid, num, key)
+		}
+		table[key] = value
+	}
+	return values, nil
+}
+
+// balancedArray reports whether the brackets of an array outside strings
+// and comments are balanced
+func balancedArray(s string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			// Skip the comment up to the end of the line
+			if end := strings.IndexByte(s[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(s)
+			}
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseTOMLValue parses a single value and returns the rest of s
+func parseTOMLValue(s string) (any, string, error) {
+	switch {
+	case s == "":
+		return nil, "", ErrInvalid
+	case strings.HasPrefix(s, `"""`), strings.HasPrefix(s, "'''"):
+		delim := s[:3]
+		end := strings.Index(s[3:], delim)
+		if end < 0 {
+			return nil, "", ErrInvalid
+		}
+		// Multi-line strings are taken literally
+		return strings.TrimPrefix(s[3:3+end], "\n"), s[3+end+3:], nil
+	case s[0] == '"':
+		text, rest, err := unquoteDouble(s)
+		return text, rest, err
+	case s[0] == '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return nil, "", ErrInvalid
+		}
+		return s[1 : end+1], s[end+2:], nil
+	case s[0] == '[':
+		values := []any{}
+		s = s[1:]
+		for {
+			s = skipTOMLSpace(s)
+			if strings.HasPrefix(s, "]") {
+				return values, s[1:], nil
+			}
+			value, rest, err := parseTOMLValue(s)
+			if err != nil {
+				return nil, "", err
+			}
+			values = append(values, value)
+			s = skipTOMLSpace(rest)
+			if strings.HasPrefix(s, ",") {
+				s = s[1:]
+			} else if !strings.HasPrefix(s, "]") {
+				return nil, "", ErrInvalid
+			}
+		}
+	}
+
+	end := strings.IndexAny(s, ",]#\n")
+	if end < 0 {
+		end = len(s)
+	}
+	token, rest := strings.TrimSpace(s[:end]), s[end:]
+	switch token {
+	case "true":
+		return true, rest, nil
+	case "false":
+		return false, rest, nil
+	}
+	if n, err := strconv.ParseInt(strings.ReplaceAll(token, "_", ""), 10, 64); err == nil {
+		return n, rest, nil
+	}
+	if f, err := strconv.ParseFloat(strings.ReplaceAll(token, "_", ""), 64); err == nil {
+		return f, rest, nil
+	}
+	if t, ok := ParseDate(token); ok {
+		return t, rest, nil
+	}
+	return nil, "", ErrInvalid
+}
+
+// skipTOMLSpace skips whitespace, newlines and comments inside arrays
+func skipTOMLSpace(s string) string {
+	for {
+		s = strings.TrimLeft(s, " \t\n")
+		if !strings.HasPrefix(s, "#") {
+			return s
+		}
+		end := strings.IndexByte(s, '\n')
+		if end < 0 {
+			return ""
+		}
+		s = s[end:]
+	}
+}
+
+// dateLayouts are the date formats accepted in front matter
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseDate parses a front matter date; dates without a zone are UTC
+func ParseDate(s string) (time.Time, bool) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
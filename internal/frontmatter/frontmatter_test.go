@@ -0,0 +1,154 @@
+package frontmatter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSplitYAML(t *testing.T) {
+	src := "---\n" +
+		"title: \"Hello: world\"\n" +
+		"date: 2024-03-01\n" +
+		"draft: false\n" +
+		"weight: 3 # comment\n" +
+		"tags: [go, 'feeds', \"rss\"]\n" +
+		"authors:\n" +
+		"  - Jane\n" +
+		"  - name: John\n" +
+		"    email: john@example.com\n" +
+		"params:\n" +
+		"  nested: yes\n" +
+		"summary: |\n" +
+		"  First line\n" +
+		"  # not a comment\n" +
+		"\n" +
+		"  Last line\n" +
+		"folded: >-\n" +
+		"  one\n" +
+		"  two\n" +
+		"---\n" +
+		"Body\n"
+
+	values, body, err := Split([]byte(src))
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if string(body) != "Body\n" {
+		t.Errorf("Expected body %q, got %q", "Body\n", body)
+	}
+
+	want := map[string]any{
+		"title":   "Hello: world",
+		"date":    "2024-03-01",
+		"draft":   false,
+		"weight":  int64(3),
+		"tags":    []any{"go", "feeds", "rss"},
+		"authors": []any{"Jane", map[string]any{"name": "John", "email": "john@example.com"}},
+		"params":  map[string]any{"nested": "yes"},
+		"summary": "First line\n# not a comment\n\nLast line\n",
+		"folded":  "one two",
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("Expected %#v, got %#v", want, values)
+	}
+}
+
+func TestSplitTOML(t *testing.T) {
+	src := "+++\n" +
+		"title = \"Release \\\"2.0\\\"\"\n" +
+		"date = 2024-03-01T10:00:00Z\n" +
+		"draft = true\n" +
+		"tags = [\n" +
+		"  \"go\", # language\n" +
+		"  'feeds',\n" +
+		"]\n" +
+		"description = '''\n" +
+		"Multi\n" +
+		"line'''\n" +
+		"[params]\n" +
+		"weight = 1_000\n" +
+		"+++\n" +
+		"Body"
+
+	values, body, err := Split([]byte(src))
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if string(body) != "Body" {
+		t.Errorf("Expected body %q, got %q", "Body", body)
+	}
+
+	want := map[string]any{
+		"title":       `Release "2.0"`,
+		"date":        time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		"draft":       true,
+		"tags":        []any{"go", "feeds"},
+		"description": "Multi\nline",
+		"params":      map[string]any{"weight": int64(1000)},
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("Expected %#v, got %#v", want, values)
+	}
+}
+
+func TestSplitJSON(t *testing.T) {
+	values, body, err := Split([]byte("{\"title\": \"JSON\", \"tags\": [\"a\"]}\n\nBody"))
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if values["title"] != "JSON" || !reflect.DeepEqual(values["tags"], []any{"a"}) {
+		t.Errorf("Unexpected values %#v", values)
+	}
+	if string(body) != "Body" {
+		t.Errorf("Expected body %q, got %q", "Body", body)
+	}
+}
+
+func TestSplitNone(t *testing.T) {
+	values, body, err := Split([]byte("# Just Markdown\r\n"))
+	if err != nil || values != nil {
+		t.Errorf("Expected no front matter, got %v, %v", values, err)
+	}
+	if string(body) != "# Just Markdown\n" {
+		t.Errorf("Expected normalized body, got %q", body)
+	}
+}
+
+func TestSplitErrors(t *testing.T) {
+	tests := map[string]string{
+		"unclosed yaml":  "---\ntitle: x\n",
+		"bad yaml line":  "---\njust text\n---\n",
+		"bad indent":     "---\ntitle: x\n  extra: y\n---\n",
+		"tab indent":     "---\nparams:\n\tkey: x\n---\n",
+		"bad quote":      "---\ntitle: \"open\n---\n",
+		"unclosed toml":  "+++\ntitle = 'x'\n",
+		"bad toml value": "+++\ntitle = nope\n+++\n",
+		"bad toml line":  "+++\ntitle\n+++\n",
+		"bad json":       "{\"title\": }\n",
+	}
+	for name, src := range tests {
+		if _, _, err := Split([]byte(src)); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: expected ErrInvalid, got %v", name, err)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := map[string]time.Time{
+		"2024-03-01":                time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		"2024-03-01 09:30":          time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+		"2024-03-01T09:30:00":       time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+		"2024-03-01T09:30:00+02:00": time.Date(2024, 3, 1, 7, 30, 0, 0, time.UTC),
+	}
+	for s, want := range tests {
+		got, ok := ParseDate(s)
+		if !ok || !got.Equal(want) {
+			t.Errorf("ParseDate(%q): expected %v, got %v", s, want, got)
+		}
+	}
+	if _, ok := ParseDate("March 1st"); ok {
+		t.Error("Expected invalid date to fail")
+	}
+}
@@ -0,0 +1,331 @@
+// Package htmltok is a small, forgiving HTML tokenizer used to inspect and
+// rewrite HTML fragments without external dependencies. It does not build
+// a tree; callers work on the flat token stream.
+package htmltok
+
+import (
+	"html"
+	"strings"
+)
+
+// TokenType identifies the kind of a token
+type TokenType int
+
+const (
+	// TextToken is character data. Data holds the raw, still escaped text.
+	TextToken TokenType = iota
+	// StartTagToken is an opening tag such as <a href="x">
+	StartTagToken
+	// EndTagToken is a closing tag such as </a>
+	EndTagToken
+	// SelfClosingTagToken is a tag closed with a slash such as <br/>
+	SelfClosingTagToken
+	// CommentToken is a comment; Data holds its contents
+	CommentToken
+	// DoctypeToken is a doctype, processing instruction or other <!...> markup
+	DoctypeToken
+)
+
+// Attr is a tag attribute. Key is lowercased and Val is unescaped.
+type Attr struct {
+	Key string
+	Val string
+}
+
+// Token is a single piece of an HTML document
+type Token struct {
+	Type TokenType
+	// Data is the lowercased tag name for tags and the raw text otherwise
+	Data string
+	Attr []Attr
+}
+
+// rawTextElements hold text that is not parsed as markup
+var rawTextElements = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"textarea": true,
+	"title":    true,
+	"xmp":      true,
+	"noembed":  true,
+	"noframes": true,
+}
+
+// voidElements never have content or an end tag
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"source": true, "track": true, "wbr": true,
+}
+
+// IsVoid reports whether the named element never has an end tag
+func IsVoid(name string) bool {
+	return voidElements[name]
+}
+
+// Tokenizer splits an HTML document into tokens
+type Tokenizer struct {
+	s   string
+	pos int
+	raw string // name of the raw text element being read, if any
+}
+
+// New returns a Tokenizer for s
+func New(s string) *Tokenizer {
+	return &Tokenizer{s: s}
+}
+
+// Tokenize splits s into tokens
+func Tokenize(s string) []Token {
+	var tokens []Token
+	t := New(s)
+	for {
+		tok, ok := t.Next()
+		if !ok {
+			return tokens
+		}
+		tokens = append(tokens, tok)
+	}
+}
+
+// Next returns the next token, or false at the end of the input.
+// A tag left unterminated at the end of the input is dropped.
+func (t *Tokenizer) Next() (Token, bool) {
+	if t.pos >= len(t.s) {
+		return Token{}, false
+	}
+
+	if t.raw != "" {
+		if tok, ok := t.rawText(); ok {
+			return tok, true
+		}
+	}
+
+	rest := t.s[t.pos:]
+	if rest[0] != '<' {
+		return t.text(), true
+	}
+
+	switch {
+	case strings.HasPrefix(rest, "<!--"):
+		end := strings.Index(rest[4:], "-->")
+		if end < 0 {
+			t.pos = len(t.s)
+			return Token{Type: CommentToken, Data: rest[4:]}, true
+		}
+		t.pos += 4 + end + 3
+		return Token{Type: CommentToken, Data: rest[4 : 4+end]}, true
+
+	case strings.HasPrefix(rest, "<!") || strings.HasPrefix(rest, "<?"):
+		end := strings.IndexByte(rest, '>')
+		if end < 0 {
+			t.pos = len(t.s)
+			return Token{Type: DoctypeToken, Data: rest[2:]}, true
+		}
+		t.pos += end + 1
+		return Token{Type: DoctypeToken, Data: rest[2:end]}, true
+
+	case strings.HasPrefix(rest, "</") && len(rest) > 2 && isLetter(rest[2]):
+		return t.tag(true)
+
+	case len(rest) > 1 && isLetter(rest[1]):
+		return t.tag(false)
+	}
+
+	// A lone '<' is just text
+	return t.text(), true
+}
+
+// text reads character data up to the next '<' that starts markup
+func (t *Tokenizer) text() Token {
+	start := t.pos
+	i := t.pos + 1
+	for i < len(t.s) {
+		if t.s[i] == '<' && i+1 < len(t.s) {
+			c := t.s[i+1]
+			if isLetter(c) || c == '/' || c == '!' || c == '?' {
+				break
+			}
+		}
+		i++
+	}
+	t.pos = i
+	return Token{Type: TextToken, Data: t.s[start:i]}
+}
+
+// rawText reads the contents of a raw text element up to its end tag.
+// It returns false when the element is empty.
+func (t *Tokenizer) rawText() (Token, bool) {
+	rest := t.s[t.pos:]
+	end := indexFold(rest, "</"+t.raw)
+	t.raw = ""
+	if end < 0 {
+		end = len(rest)
+	}
+	if end == 0 {
+		return Token{}, false
+	}
+	t.pos += end
+	return Token{Type: TextToken, Data: rest[:end]}, true
+}
+
+// tag reads a start or end tag, including its attributes
+func (t *Tokenizer) tag(end bool) (Token, bool) {
+	i := t.pos + 1
+	if end {
+		i++
+	}
+
+	nameStart := i
+	for i < len(t.s) && !isSpace(t.s[i]) && t.s[i] != '/' && t.s[i] != '>' {
+		i++
+	}
+	tok := Token{Type: StartTagToken, Data: strings.ToLower(t.s[nameStart:i])}
+	if end {
+		tok.Type = EndTagToken
+	}
+
+	for {
+		for i < len(t.s) && (isSpace(t.s[i]) || t.s[i] == '/') {
+			if t.s[i] == '/' && i+1 < len(t.s) && t.s[i+1] == '>' && !end {
+				tok.Type = SelfClosingTagToken
+			}
+			i++
+		}
+		if i >= len(t.s) {
+			// Unterminated tag: drop it
+			t.pos = len(t.s)
+			return Token{}, false
+		}
+		if t.s[i] == '>' {
+			i++
+			break
+		}
+
+		keyStart := i
+		for i < len(t.s) && !isSpace(t.s[i]) && t.s[i] != '=' && t.s[i] != '>' && t.s[i] != '/' {
+			i++
+		}
+		key := strings.ToLower(t.s[keyStart:i])
+
+		for i < len(t.s) && isSpace(t.s[i]) {
+			i++
+		}
+		val := ""
+		if i < len(t.s) && t.s[i] == '=' {
+			i++
+			for i < len(t.s) && isSpace(t.s[i]) {
+				i++
+			}
+			if i < len(t.s) && (t.s[i] == '"' || t.s[i] == '\'') {
+				quote := t.s[i]
+				i++
+				valStart := i
+				for i < len(t.s) && t.s[i] != quote {
+					i++
+				}
+				val = t.s[valStart:i]
+				if i < len(t.s) {
+					i++
+				}
+			} else {
+				valStart := i
+				for i < len(t.s) && !isSpace(t.s[i]) && t.s[i] != '>' {
+					i++
+				}
+				val = t.s[valStart:i]
+			}
+		}
+
+		if !end && key != "" && !hasAttr(tok.Attr, key) {
+			tok.Attr = append(tok.Attr, Attr{Key: key, Val: html.UnescapeString(val)})
+		}
+	}
+
+	t.pos = i
+	if tok.Type == StartTagToken && rawTextElements[tok.Data] {
+		t.raw = tok.Data
+	}
+	return tok, true
+}
+
+// GetAttr returns the value of the named attribute
+func (tok Token) GetAttr(key string) (string, bool) {
+	for _, a := range tok.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// String renders the token back to HTML, escaping attribute values
+func (tok Token) String() string {
+	switch tok.Type {
+	case TextToken:
+		return tok.Data
+	case CommentToken:
+		return "<!--" + tok.Data + "-->"
+	case DoctypeToken:
+		return "<!" + tok.Data + ">"
+	case EndTagToken:
+		return "</" + tok.Data + ">"
+	}
+
+	var b strings.Builder
+	b.WriteByte('<')
+	b.WriteString(tok.Data)
+	for _, a := range tok.Attr {
+		b.WriteByte(' ')
+		b.WriteString(a.Key)
+		b.WriteString(`="`)
+		b.WriteString(html.EscapeString(a.Val))
+		b.WriteByte('"')
+	}
+	if tok.Type == SelfClosingTagToken {
+		b.WriteString(" /")
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// hasAttr reports whether attrs already contains key; like browsers, the
+// first occurrence of a duplicated attribute wins
+func hasAttr(attrs []Attr, key string) bool {
+	for _, a := range attrs {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// indexFold is strings.Index with ASCII case folding. Unlike lowercasing the
+// whole string first, it keeps byte offsets valid for non-ASCII input.
+func indexFold(s, substr string) int {
+outer:
+	for i := 0; i+len(substr) <= len(s); i++ {
+		for j := 0; j < len(substr); j++ {
+			if lower(s[i+j]) != lower(substr[j]) {
+				continue outer
+			}
+		}
+		return i
+	}
+	return -1
+}
+
+func lower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
@@ -0,0 +1,134 @@
+package htmltok
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tokens := Tokenize(`<!DOCTYPE html><p class=intro ID='x'>Hi &amp; <b>bye</b><br/><!-- note --></p>`)
+
+	want := []struct {
+		typ  TokenType
+		data string
+	}{
+		{DoctypeToken, "DOCTYPE html"},
+		{StartTagToken, "p"},
+		{TextToken, "Hi &amp; "},
+		{StartTagToken, "b"},
+		{TextToken, "bye"},
+		{EndTagToken, "b"},
+		{SelfClosingTagToken, "br"},
+		{CommentToken, " note "},
+		{EndTagToken, "p"},
+	}
+
+	if len(tokens) != len(want) {
+		t.Fatalf("Expected %d tokens, got %d: %+v", len(want), len(tokens), tokens)
+	}
+	for i, w := range want {
+		if tokens[i].Type != w.typ || tokens[i].Data != w.data {
+			t.Errorf("Token %d: expected %v %q, got %v %q", i, w.typ, w.data, tokens[i].Type, tokens[i].Data)
+		}
+	}
+
+	if v, _ := tokens[1].GetAttr("class"); v != "intro" {
+		t.Errorf("Expected unquoted attribute value 'intro', got %q", v)
+	}
+	if v, _ := tokens[1].GetAttr("id"); v != "x" {
+		t.Errorf("Expected lowercased single-quoted attribute 'id', got %q", v)
+	}
+}
+
+func TestTokenizeAttributes(t *testing.T) {
+	tokens := Tokenize(`<a href="/a?x=1&amp;y=2" disabled title = "t" href="/dup">`)
+	if len(tokens) != 1 {
+		t.Fatalf("Expected 1 token, got %d", len(tokens))
+	}
+
+	tok := tokens[0]
+	if v, _ := tok.GetAttr("href"); v != "/a?x=1&y=2" {
+		t.Errorf("Expected unescaped href with the first value winning, got %q", v)
+	}
+	if _, ok := tok.GetAttr("disabled"); !ok {
+		t.Error("Expected boolean attribute to be present")
+	}
+	if v, _ := tok.GetAttr("title"); v != "t" {
+		t.Errorf("Expected spaced attribute value 't', got %q", v)
+	}
+	if len(tok.Attr) != 3 {
+		t.Errorf("Expected 3 attributes, got %d", len(tok.Attr))
+	}
+}
+
+func TestTokenizeRawText(t *testing.T) {
+	tokens := Tokenize(`<script>if (a < b && "</p>") {}</SCRIPT><style></style>x`)
+
+	if len(tokens) != 6 {
+		t.Fatalf("Expected 6 tokens, got %d: %+v", len(tokens), tokens)
+	}
+	if tokens[1].Type != TextToken || tokens[1].Data != `if (a < b && "</p>") {}` {
+		t.Errorf("Script contents should be raw text, got %q", tokens[1].Data)
+	}
+	if tokens[2].Type != EndTagToken || tokens[2].Data != "script" {
+		t.Errorf("Expected script end tag, got %+v", tokens[2])
+	}
+	if tokens[4].Type != EndTagToken || tokens[4].Data != "style" {
+		t.Errorf("Expected empty style element, got %+v", tokens[4])
+	}
+}
+
+func TestTokenizeEdgeCases(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"1 < 2", "1 < 2"},
+		{"a <3 b", "a <3 b"},
+		{"text <a href='x'", "text "},
+		{"<!-- unterminated", "<!-- unterminated-->"},
+		{"</ p>", "</ p>"},
+		{"ünïcödé <b>ok</b>", "ünïcödé <b>ok</b>"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		for _, tok := range Tokenize(tt.in) {
+			b.WriteString(tok.String())
+		}
+		if b.String() != tt.want {
+			t.Errorf("Tokenize(%q) rendered %q, want %q", tt.in, b.String(), tt.want)
+		}
+	}
+}
+
+func TestTokenString(t *testing.T) {
+	tok := Token{
+		Type: SelfClosingTagToken,
+		Data: "img",
+		Attr: []Attr{{Key: "src", Val: `a.png?x="1"&y`}},
+	}
+	if got := tok.String(); got != `<img src="a.png?x=&#34;1&#34;&amp;y" />` {
+		t.Errorf("Unexpected rendering: %s", got)
+	}
+}
+
+func FuzzTokenize(f *testing.F) {
+	for _, seed := range []string{
+		`<p>Hello</p>`,
+		`<a href="x" onclick='y'>z</a>`,
+		`<script>alert(1)</script>`,
+		`<!-- c --><!DOCTYPE html>`,
+		`<img src=x onerror=alert(1)//>`,
+		`<<>><</`,
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		for _, tok := range Tokenize(s) {
+			if (tok.Type == StartTagToken || tok.Type == EndTagToken || tok.Type == SelfClosingTagToken) && tok.Data == "" {
+				t.Fatalf("Tag token without a name for input %q", s)
+			}
+		}
+	})
+}
@@ -0,0 +1,280 @@
+package feed
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// ItemSource is a cursor over feed items that renderers pull from one at a
+// time, so that large feeds never have to be held in memory.
+// Next returns io.EOF once the source is exhausted.
+type ItemSource interface {
+	Next(ctx context.Context) (Item, error)
+	Close() error
+}
+
+// sliceSource iterates over an in-memory slice
+type sliceSource struct {
+	items []Item
+}
+
+// NewSliceSource returns an ItemSource over a slice of items
+func NewSliceSource(items []Item) ItemSource {
+	return &sliceSource{items: items}
+}
+
+func (s *sliceSource) Next(ctx context.Context) (Item, error) {
+	if err := ctx.Err(); err != nil {
+		return Item{}, err
+	}
+	if len(s.items) == 0 {
+		return Item{}, io.EOF
+	}
+	item := s.items[0]
+	s.items = s.items[1:]
+	return item, nil
+}
+
+func (s *sliceSource) Close() error {
+	s.items = nil
+	return nil
+}
+
+// chanSource receives items from a channel
+type chanSource struct {
+	ch <-chan Item
+}
+
+// NewChanSource returns an ItemSource that receives items from ch until it
+// is closed. The producer should stop sending when the context of the
+// consuming renderer is done.
+func NewChanSource(ch <-chan Item) ItemSource {
+	return &chanSource{ch: ch}
+}
+
+func (s *chanSource) Next(ctx context.Context) (Item, error) {
+	select {
+	case item, ok := <-s.ch:
+		if !ok {
+			return Item{}, io.EOF
+		}
+		return item, nil
+	case <-ctx.Done():
+		return Item{}, ctx.Err()
+	}
+}
+
+func (s *chanSource) Close() error {
+	return nil
+}
+
+// rowsSource scans items from database rows
+type rowsSource struct {
+	rows *sql.Rows
+	scan func(*sql.Rows) (Item, error)
+}
+
+// NewRowsSource returns an ItemSource that converts each of rows into an
+// item with scan. Closing the source closes the rows.
+//
+//	rows, err := db.QueryContext(ctx, "SELECT title, url, published FROM posts ORDER BY published DESC")
+//	if err != nil {
+//		return err
+//	}
+//	src := feed.NewRowsSource(rows, func(rows *sql.Rows) (feed.Item, error) {
+//		var item feed.Item
+//		err := rows.Scan(&item.Title, &item.Link, &item.PubDate)
+//		return item, err
+//	})
+func NewRowsSource(rows *sql.Rows, scan func(*sql.Rows) (Item, error)) ItemSource {
+	return &rowsSource{rows: rows, scan: scan}
+}
+
+func (s *rowsSource) Next(ctx context.Context) (Item, error) {
+	if err := ctx.Err(); err != nil {
+		return Item{}, err
+	}
+	if !s.rows.Next() {
+		if err := s.rows.Err(); err != nil {
+			return Item{}, fmt.Errorf("failed to read rows: %w", err)
+		}
+		return Item{}, io.EOF
+	}
+	item, err := s.scan(s.rows)
+	if err != nil {
+		return Item{}, fmt.Errorf("failed to scan row: %w", err)
+	}
+	return item, nil
+}
+
+func (s *rowsSource) Close() error {
+	return s.rows.Close()
+}
+
+// AddFrom adds the items of src to the feed until src is exhausted or the
+// feed holds its maximum number of items, then closes src
+func (f *Feed) AddFrom(ctx context.Context, src ItemSource) (err error) {
+	defer func() {
+		if cerr := src.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	for f.maxItems <= 0 || len(f.items) < f.maxItems {
+		item, err := src.Next(ctx)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		f.items = append(f.items, item)
+	}
+	return nil
+}
+
+// Write streams the feed in the given format to w, pulling its items from
+// src instead of the feed's own items. A nil src streams the feed's items.
+// Rendering stops after the maximum number of items and src is closed.
+func (f *Feed) Write(ctx context.Context, w io.Writer, format Format, src ItemSource) error {
+	switch format {
+	case FormatRSS:
+		return f.WriteRSS(ctx, w, src)
+	case FormatAtom:
+		return f.WriteAtom(ctx, w, src)
+	}
+	return fmt.Errorf("unsupported feed format %q", format)
+}
+
+// rssStream is an RSS document whose items are encoded as they are pulled
+type rssStream struct {
+	XMLName      xml.Name   `xml:"rss"`
+	Version      string     `xml:"version,attr"`
+	XMLNSContent string     `xml:"xmlns:content,attr,omitempty"`
+	XMLNSPodcast string     `xml:"xmlns:podcast,attr,omitempty"`
+	XMLNSMedia   string     `xml:"xmlns:media,attr,omitempty"`
+	XMLNSITunes  string     `xml:"xmlns:itunes,attr,omitempty"`
+	Channel      rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Channel
+	Items xml.Marshaler `xml:"item"`
+}
+
+// WriteRSS streams the feed as RSS 2.0 to w, pulling its items from src.
+// The content namespace is always declared, since whether any item has
+// full content is not known up front. The podcast, media and itunes
+// namespaces are declared when the feed itself has such elements,
+// so set them on the channel when streaming such items from another source.
+func (f *Feed) WriteRSS(ctx context.Context, w io.Writer, src ItemSource) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+
+	if src == nil {
+		src = NewSliceSource(f.items)
+	}
+	defer src.Close()
+
+	rss := f.rss()
+	stream := rssStream{
+		Version:      rss.Version,
+		XMLNSContent: contentNamespace,
+		XMLNSPodcast: rss.XMLNSPodcast,
+		XMLNSMedia:   rss.XMLNSMedia,
+		XMLNSITunes:  rss.XMLNSITunes,
+		Channel: rssChannel{
+			Channel: rss.Channel,
+			Items: &itemStream{ctx: ctx, feed: f, src: src, format: FormatRSS, encode: func(e *xml.Encoder, item Item, start xml.StartElement) error {
+				return e.EncodeElement(rssItem(item), start)
+			}},
+		},
+	}
+
+	if err := f.encodeStream(w, stream); err != nil {
+		return fmt.Errorf("failed to write RSS XML: %w", err)
+	}
+	return nil
+}
+
+// atomStream is an Atom document whose entries are encoded as they are pulled
+type atomStream struct {
+	AtomFeed
+	Entries xml.Marshaler `xml:"entry"`
+}
+
+// WriteAtom streams the feed as Atom 1.0 to w, pulling its entries from src
+func (f *Feed) WriteAtom(ctx context.Context, w io.Writer, src ItemSource) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+
+	if src == nil {
+		src = NewSliceSource(f.items)
+	}
+	defer src.Close()
+
+	base := f.baseURL()
+	stream := atomStream{
+		AtomFeed: f.atomFeed(),
+		Entries: &itemStream{ctx: ctx, feed: f, src: src, format: FormatAtom, encode: func(e *xml.Encoder, item Item, start xml.StartElement) error {
+			return e.EncodeElement(f.atomEntry(item, base), start)
+		}},
+	}
+
+	if err := f.encodeStream(w, stream); err != nil {
+		return fmt.Errorf("failed to write Atom XML: %w", err)
+	}
+	return nil
+}
+
+// encodeStream writes the preamble and the indented document
+func (f *Feed) encodeStream(w io.Writer, v any) error {
+	if _, err := w.Write(f.xmlPreamble()); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// itemStream encodes items pulled from a source as repeated elements,
+// skipping items that are not live and applying the render-time transforms
+// and the max items policy
+type itemStream struct {
+	ctx    context.Context
+	feed   *Feed
+	src    ItemSource
+	format Format
+	encode func(e *xml.Encoder, item Item, start xml.StartElement) error
+}
+
+func (s *itemStream) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	base := s.feed.baseURL()
+	now := s.feed.now()
+	for n := 0; s.feed.maxItems <= 0 || n < s.feed.maxItems; {
+		item, err := s.src.Next(s.ctx)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !item.IsLive(now) {
+			continue
+		}
+		n++
+		item = s.feed.renderItem(item, base, s.format)
+		if err := s.encode(e, item, start); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func streamTestFeed() *Feed {
+	f := New().
+		SetTitle("Stream").
+		SetDescription("Streamed feed").
+		SetLink("https://example.com").
+		SetLastBuildDate(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	for _, title := range []string{"One", "Two", "Three"} {
+		f.AddItem(Item{
+			Title:       title,
+			Description: title + " description",
+			Content:     "<p>" + title + "</p>",
+			Link:        "https://example.com/" + strings.ToLower(title),
+			PubDate:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		})
+	}
+	return f
+}
+
+func TestWriteMatchesRender(t *testing.T) {
+	f := streamTestFeed()
+	for _, format := range Formats {
+		want, err := f.Render(format)
+		if err != nil {
+			t.Fatalf("Render(%s) failed: %v", format, err)
+		}
+		var buf bytes.Buffer
+		if err := f.Write(context.Background(), &buf, format, NewSliceSource(f.GetItems())); err != nil {
+			t.Fatalf("Write(%s) failed: %v", format, err)
+		}
+		if buf.String() != string(want) {
+			t.Errorf("Expected streamed %s to match rendered output, got:\n%s\nwant:\n%s", format, buf.String(), want)
+		}
+	}
+}
+
+func TestWriteNilSourceUsesFeedItems(t *testing.T) {
+	f := streamTestFeed()
+	var buf bytes.Buffer
+	if err := f.WriteAtom(context.Background(), &buf, nil); err != nil {
+		t.Fatalf("WriteAtom failed: %v", err)
+	}
+	if got := strings.Count(buf.String(), "<entry>"); got != 3 {
+		t.Errorf("Expected 3 entries, got %d", got)
+	}
+}
+
+// countingSource counts how many items were pulled
+type countingSource struct {
+	pulled int
+	closed bool
+}
+
+func (s *countingSource) Next(ctx context.Context) (Item, error) {
+	s.pulled++
+	return Item{Title: "Item", Link: "https://example.com/item"}, nil
+}
+
+func (s *countingSource) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestWriteStopsAfterMaxItems(t *testing.T) {
+	f := streamTestFeed().SetMaxItems(2)
+	src := &countingSource{}
+
+	var buf bytes.Buffer
+	if err := f.WriteRSS(context.Background(), &buf, src); err != nil {
+		t.Fatalf("WriteRSS failed: %v", err)
+	}
+	if src.pulled != 2 {
+		t.Errorf("Expected 2 items pulled, got %d", src.pulled)
+	}
+	if !src.closed {
+		t.Error("Expected source to be closed")
+	}
+	if got := strings.Count(buf.String(), "<item>"); got != 2 {
+		t.Errorf("Expected 2 items, got %d", got)
+	}
+}
+
+func TestWriteAppliesTransforms(t *testing.T) {
+	f := streamTestFeed().SetResolveURLs(true)
+	src := NewSliceSource([]Item{{Title: "Relative", Link: "/relative"}})
+
+	var buf bytes.Buffer
+	if err := f.WriteRSS(context.Background(), &buf, src); err != nil {
+		t.Fatalf("WriteRSS failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "<link>https://example.com/relative</link>") {
+		t.Errorf("Expected resolved item link, got %s", buf.String())
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	var buf bytes.Buffer
+	if err := New().WriteRSS(context.Background(), &buf, nil); !errors.Is(err, ErrMissingTitle) {
+		t.Errorf("Expected ErrMissingTitle, got %v", err)
+	}
+
+	f := streamTestFeed()
+	if err := f.Write(context.Background(), &buf, Format("json"), nil); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := f.WriteAtom(ctx, &buf, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestChanSource(t *testing.T) {
+	ch := make(chan Item)
+	go func() {
+		defer close(ch)
+		for _, title := range []string{"A", "B"} {
+			ch <- Item{Title: title}
+		}
+	}()
+
+	f := New()
+	if err := f.AddFrom(context.Background(), NewChanSource(ch)); err != nil {
+		t.Fatalf("AddFrom failed: %v", err)
+	}
+	if len(f.GetItems()) != 2 || f.GetItems()[1].Title != "B" {
+		t.Errorf("Expected items A and B, got %v", f.GetItems())
+	}
+}
+
+func TestChanSourceCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := NewChanSource(make(chan Item)).Next(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestAddFromHonoursMaxItems(t *testing.T) {
+	f := New().SetMaxItems(3)
+	f.AddItem(Item{Title: "Existing"})
+	src := &countingSource{}
+	if err := f.AddFrom(context.Background(), src); err != nil {
+		t.Fatalf("AddFrom failed: %v", err)
+	}
+	if len(f.GetItems()) != 3 {
+		t.Errorf("Expected 3 items, got %d", len(f.GetItems()))
+	}
+	if src.pulled != 2 || !src.closed {
+		t.Errorf("Expected 2 items pulled and source closed, got %d pulled, closed %v", src.pulled, src.closed)
+	}
+}
+
+func TestSliceSource(t *testing.T) {
+	src := NewSliceSource([]Item{{Title: "Only"}})
+	item, err := src.Next(context.Background())
+	if err != nil || item.Title != "Only" {
+		t.Errorf("Expected first item, got %v, %v", item, err)
+	}
+	if _, err := src.Next(context.Background()); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+// postsDriver is a minimal database/sql driver serving a fixed posts table
+type postsDriver struct{}
+
+func (postsDriver) Open(name string) (driver.Conn, error) { return postsConn{}, nil }
+
+type postsConn struct{}
+
+func (postsConn) Prepare(query string) (driver.Stmt, error) { return postsStmt{}, nil }
+func (postsConn) Close() error                              { return nil }
+func (postsConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+type postsStmt struct{}
+
+func (postsStmt) Close() error                                    { return nil }
+func (postsStmt) NumInput() int                                   { return 0 }
+func (postsStmt) Exec(args []driver.Value) (driver.Result, error) { return nil, driver.ErrSkip }
+func (postsStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return &postsRows{}, nil
+}
+
+type postsRows struct {
+	n int
+}
+
+func (r *postsRows) Columns() []string { return []string{"title", "url"} }
+func (r *postsRows) Close() error      { return nil }
+func (r *postsRows) Next(dest []driver.Value) error {
+	if r.n == 3 {
+		return io.EOF
+	}
+	r.n++
+	dest[0] = "Post " + string(rune('0'+r.n))
+	dest[1] = "https://example.com/posts/" + string(rune('0'+r.n))
+	return nil
+}
+
+func init() {
+	sql.Register("feedtest-posts", postsDriver{})
+}
+
+func TestRowsSource(t *testing.T) {
+	db, err := sql.Open("feedtest-posts", "")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(context.Background(), "SELECT title, url FROM posts")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	src := NewRowsSource(rows, func(rows *sql.Rows) (Item, error) {
+		var item Item
+		err := rows.Scan(&item.Title, &item.Link)
+		return item, err
+	})
+
+	f := streamTestFeed().SetMaxItems(2)
+	var buf bytes.Buffer
+	if err := f.WriteRSS(context.Background(), &buf, src); err != nil {
+		t.Fatalf("WriteRSS failed: %v", err)
+	}
+	output := buf.String()
+	if !strings.Contains(output, "<title>Post 1</title>") || !strings.Contains(output, "<link>https://example.com/posts/2</link>") {
+		t.Errorf("Expected scanned rows in output, got %s", output)
+	}
+	if strings.Contains(output, "Post 3") {
+		t.Error("Expected streaming to stop after max items")
+	}
+}
+
+func TestRowsSourceScanError(t *testing.T) {
+	db, err := sql.Open("feedtest-posts", "")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT title, url FROM posts")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	scanErr := errors.New("bad row")
+	src := NewRowsSource(rows, func(*sql.Rows) (Item, error) {
+		return Item{}, scanErr
+	})
+	if err := New().AddFrom(context.Background(), src); !errors.Is(err, scanErr) {
+		t.Errorf("Expected scan error, got %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+)
+
+// itunesNamespace is the namespace of Apple's podcast tags
+const itunesNamespace = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+
+// Show types
+const (
+	ITunesEpisodic = "episodic"
+	ITunesSerial   = "serial"
+)
+
+// Episode types
+const (
+	ITunesFull    = "full"
+	ITunesTrailer = "trailer"
+	ITunesBonus   = "bonus"
+)
+
+// ITunesChannel holds the channel-level tags of Apple's podcast namespace,
+// rendered in RSS with the itunes: prefix. Item-level tags come from the
+// ITunes fields of Item.
+type ITunesChannel struct {
+	Image      *ITunesImage     `xml:"itunes:image,omitempty"`
+	Categories []ITunesCategory `xml:"itunes:category,omitempty"`
+	Explicit   *bool            `xml:"itunes:explicit,omitempty"`
+	Author     string           `xml:"itunes:author,omitempty"`
+	Owner      *ITunesOwner     `xml:"itunes:owner,omitempty"`
+	Title      string           `xml:"itunes:title,omitempty"`
+	Type       string           `xml:"itunes:type,omitempty"` // ITunesEpisodic or ITunesSerial
+	NewFeedURL string           `xml:"itunes:new-feed-url,omitempty"`
+}
+
+// ITunesImage links to the show artwork
+type ITunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+// ITunesCategory is a category of Apple's taxonomy, with its subcategories
+type ITunesCategory struct {
+	Text          string           `xml:"text,attr"`
+	Subcategories []ITunesCategory `xml:"itunes:category,omitempty"`
+}
+
+// ITunesOwner is the contact for the show, used by directories to verify
+// ownership
+type ITunesOwner struct {
+	Name  string `xml:"itunes:name,omitempty"`
+	Email string `xml:"itunes:email,omitempty"`
+}
+
+// ITunesItem holds the item-level tags of Apple's podcast namespace
+type ITunesItem struct {
+	Author      string `xml:"itunes:author,omitempty"`
+	Subtitle    string `xml:"itunes:subtitle,omitempty"`
+	Summary     string `xml:"itunes:summary,omitempty"`
+	Duration    string `xml:"itunes:duration,omitempty"`
+	Episode     int    `xml:"itunes:episode,omitempty"`
+	Season      int    `xml:"itunes:season,omitempty"`
+	EpisodeType string `xml:"itunes:episodeType,omitempty"`
+}
+
+// SetITunes sets the iTunes channel tags
+func (f *Feed) SetITunes(itunes ITunesChannel) *Feed {
+	f.itunes = &itunes
+	return f
+}
+
+// GetITunes returns the iTunes channel tags
+func (f *Feed) GetITunes() *ITunesChannel {
+	return f.itunes
+}
+
+// itunesItem collects the iTunes fields of an item, or returns nil when
+// none is set
+func itunesItem(item Item) *ITunesItem {
+	it := ITunesItem{
+		Author:      item.ITunesAuthor,
+		Subtitle:    item.ITunesSubtitle,
+		Summary:     item.ITunesSummary,
+		Duration:    item.ITunesDuration,
+		Episode:     item.ITunesEpisode,
+		Season:      item.ITunesSeason,
+		EpisodeType: item.ITunesEpisodeType,
+	}
+	if it == (ITunesItem{}) {
+		return nil
+	}
+	return &it
+}
+
+// usesITunes reports whether the feed has any iTunes tags
+func (f *Feed) usesITunes() bool {
+	if f.itunes != nil {
+		return true
+	}
+	for _, item := range f.items {
+		if itunesItem(item) != nil {
+			return true
+		}
+	}
+	if f.podcast != nil {
+		for _, live := range f.podcast.LiveItems {
+			if itunesItem(live.Item) != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateITunes checks the channel tags. Directory rules beyond the shape
+// of the tags are left to the compliance package.
+func (f *Feed) validateITunes() error {
+	it := f.itunes
+	if it == nil {
+		return nil
+	}
+	var errs []error
+	if it.Image != nil && it.Image.Href == "" {
+		errs = append(errs, itunesError("image", "requires an href"))
+	}
+	errs = append(errs, validateITunesCategories(it.Categories)...)
+	switch it.Type {
+	case "", ITunesEpisodic, ITunesSerial:
+	default:
+		errs = append(errs, itunesError("type", "must be episodic or serial, got %q", it.Type))
+	}
+	return errors.Join(errs...)
+}
+
+func validateITunesCategories(categories []ITunesCategory) []error {
+	var errs []error
+	for _, c := range categories {
+		if c.Text == "" {
+			errs = append(errs, itunesError("category", "requires text"))
+		}
+		errs = append(errs, validateITunesCategories(c.Subcategories)...)
+	}
+	return errs
+}
+
+func itunesError(tag, format string, args ...any) error {
+	return fmt.Errorf("%w: itunes:%s %s", ErrInvalidITunes, tag, fmt.Sprintf(format, args...))
+}
+
+// clone returns a deep copy of the channel tags
+func (it *ITunesChannel) clone() *ITunesChannel {
+	clone := *it
+	if it.Image != nil {
+		image := *it.Image
+		clone.Image = &image
+	}
+	clone.Categories = cloneITunesCategories(it.Categories)
+	if it.Explicit != nil {
+		explicit := *it.Explicit
+		clone.Explicit = &explicit
+	}
+	if it.Owner != nil {
+		owner := *it.Owner
+		clone.Owner = &owner
+	}
+	return &clone
+}
+
+func cloneITunesCategories(categories []ITunesCategory) []ITunesCategory {
+	if categories == nil {
+		return nil
+	}
+	clone := make([]ITunesCategory, len(categories))
+	for i, c := range categories {
+		c.Subcategories = cloneITunesCategories(c.Subcategories)
+		clone[i] = c
+	}
+	return clone
+}
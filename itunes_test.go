@@ -0,0 +1,148 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func itunesTestFeed() *Feed {
+	explicit := false
+	return New().
+		SetTitle("Hiking Treks").
+		SetDescription("Love to get outdoors and discover nature's treasures?").
+		SetLink("https://www.apple.com/itunes/podcasts/").
+		SetITunes(ITunesChannel{
+			Image:      &ITunesImage{Href: "https://applehosted.podcasts/hiking/artwork.png"},
+			Categories: []ITunesCategory{{Text: "Sports", Subcategories: []ITunesCategory{{Text: "Wilderness"}}}},
+			Explicit:   &explicit,
+			Author:     "The Sunset Explorers",
+			Owner:      &ITunesOwner{Name: "Sunset Explorers", Email: "mountainscape@icloud.com"},
+			Type:       ITunesSerial,
+		}).
+		AddItem(Item{
+			Title:             "Hiking Treks Trailer",
+			Link:              "https://www.apple.com/itunes/podcasts/trailer",
+			GUID:              "D03EEC9B-B1B4-475B-92C8-54F853FA2A22",
+			Enclosure:         &Enclosure{URL: "https://applehosted.podcasts/hiking/trailer.m4a", Length: "498537", Type: "audio/x-m4a"},
+			ITunesDuration:    "00:01:24",
+			ITunesEpisode:     1,
+			ITunesSeason:      2,
+			ITunesEpisodeType: ITunesTrailer,
+		})
+}
+
+func TestITunesRSS(t *testing.T) {
+	rss, err := itunesTestFeed().RSS()
+	if err != nil {
+		t.Fatalf("RSS failed: %v", err)
+	}
+	out := string(rss)
+
+	for _, want := range []string{
+		`xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"`,
+		`<itunes:image href="https://applehosted.podcasts/hiking/artwork.png"></itunes:image>`,
+		`<itunes:category text="Sports">`,
+		`<itunes:category text="Wilderness"></itunes:category>`,
+		`<itunes:explicit>false</itunes:explicit>`,
+		`<itunes:author>The Sunset Explorers</itunes:author>`,
+		`<itunes:email>mountainscape@icloud.com</itunes:email>`,
+		`<itunes:type>serial</itunes:type>`,
+		`<itunes:duration>00:01:24</itunes:duration>`,
+		`<itunes:episode>1</itunes:episode>`,
+		`<itunes:season>2</itunes:season>`,
+		`<itunes:episodeType>trailer</itunes:episodeType>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected RSS to contain %s", want)
+		}
+	}
+}
+
+func TestITunesNamespaceOnlyWhenUsed(t *testing.T) {
+	f := New().SetTitle("T").SetDescription("D").SetLink("https://example.com").
+		AddItem(Item{Title: "Post", Link: "https://example.com/post"})
+	rss, err := f.RSS()
+	if err != nil {
+		t.Fatalf("RSS failed: %v", err)
+	}
+	if strings.Contains(string(rss), "itunes") {
+		t.Errorf("Expected no itunes namespace, got %s", rss)
+	}
+
+	// Item fields alone declare the namespace
+	f.AddItem(Item{Title: "Episode", Link: "https://example.com/episode", ITunesDuration: "42"})
+	rss, err = f.RSS()
+	if err != nil {
+		t.Fatalf("RSS failed: %v", err)
+	}
+	if !strings.Contains(string(rss), `xmlns:itunes=`) {
+		t.Error("Expected itunes namespace for item fields")
+	}
+}
+
+func TestITunesStream(t *testing.T) {
+	var buf bytes.Buffer
+	if err := itunesTestFeed().WriteRSS(context.Background(), &buf, nil); err != nil {
+		t.Fatalf("WriteRSS failed: %v", err)
+	}
+	rendered, err := itunesTestFeed().RSS()
+	if err != nil {
+		t.Fatalf("RSS failed: %v", err)
+	}
+	for _, want := range []string{`xmlns:itunes=`, `<itunes:type>serial</itunes:type>`, `<itunes:episodeType>trailer</itunes:episodeType>`} {
+		if !strings.Contains(buf.String(), want) || !strings.Contains(string(rendered), want) {
+			t.Errorf("Expected streamed and rendered RSS to contain %s", want)
+		}
+	}
+}
+
+func TestITunesResolveImage(t *testing.T) {
+	f := itunesTestFeed().SetLink("https://example.com/podcast/").SetResolveURLs(true)
+	f.GetITunes().Image.Href = "artwork.jpg"
+	rss, err := f.RSS()
+	if err != nil {
+		t.Fatalf("RSS failed: %v", err)
+	}
+	if !strings.Contains(string(rss), `<itunes:image href="https://example.com/podcast/artwork.jpg">`) {
+		t.Errorf("Expected resolved artwork URL, got %s", rss)
+	}
+	if f.GetITunes().Image.Href != "artwork.jpg" {
+		t.Errorf("Expected rendering to leave the feed unchanged, got %q", f.GetITunes().Image.Href)
+	}
+}
+
+func TestITunesValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		itunes ITunesChannel
+	}{
+		{"image without href", ITunesChannel{Image: &ITunesImage{}}},
+		{"category without text", ITunesChannel{Categories: []ITunesCategory{{Text: "Arts", Subcategories: []ITunesCategory{{}}}}}},
+		{"unknown type", ITunesChannel{Type: "weekly"}},
+	}
+	for _, tt := range tests {
+		f := New().SetTitle("T").SetDescription("D").SetLink("https://example.com").SetITunes(tt.itunes)
+		if err := f.Validate(); !errors.Is(err, ErrInvalidITunes) {
+			t.Errorf("%s: expected ErrInvalidITunes, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestITunesClone(t *testing.T) {
+	f := itunesTestFeed()
+	clone := f.Clone()
+	clone.GetITunes().Categories[0].Subcategories[0].Text = "Running"
+	*clone.GetITunes().Explicit = true
+	clone.GetITunes().Owner.Email = "other@example.com"
+
+	it := f.GetITunes()
+	if it.Categories[0].Subcategories[0].Text != "Wilderness" || *it.Explicit || it.Owner.Email != "mountainscape@icloud.com" {
+		t.Errorf("Expected clone to be independent, got %+v", it)
+	}
+	if d := Diff(f, clone); len(d.Channel) != 1 || d.Channel[0].Field != "itunes" {
+		t.Errorf("Expected an itunes channel change, got %+v", d.Channel)
+	}
+}
@@ -0,0 +1,415 @@
+package feed
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mapping targets recognised in `feed:"..."` struct tags
+var mappingTargets = map[string]bool{
+	"title":            true,
+	"description":      true,
+	"content":          true,
+	"link":             true,
+	"author":           true,
+	"pubDate":          true,
+	"guid":             true,
+	"category":         true,
+	"comments":         true,
+	"enclosure.url":    true,
+	"enclosure.length": true,
+	"enclosure.type":   true,
+	"source.url":       true,
+	"source.title":     true,
+}
+
+// MappingError reports a struct field that could not be mapped to an item
+type MappingError struct {
+	Type   string // struct type name
+	Field  string // dotted field path, such as Meta.PublishedAt
+	Target string // tag target, such as pubDate
+	Err    error
+}
+
+func (e *MappingError) Error() string {
+	return fmt.Sprintf("failed to map %s.%s to %q: %v", e.Type, e.Field, e.Target, e.Err)
+}
+
+func (e *MappingError) Unwrap() error {
+	return e.Err
+}
+
+// MapOption configures ItemsFrom and ItemFrom
+type MapOption func(*mapConfig)
+
+// mapConfig holds the options of one mapping call
+type mapConfig struct {
+	converters map[string]func(any) (any, error)
+}
+
+// WithConverter converts the value of every field tagged with target before
+// it is mapped. The converter returns a string, time.Time, fmt.Stringer,
+// number or slice, as appropriate for the target.
+func WithConverter(target string, fn func(value any) (any, error)) MapOption {
+	return func(c *mapConfig) {
+		if c.converters == nil {
+			c.converters = make(map[string]func(any) (any, error))
+		}
+		c.converters[target] = fn
+	}
+}
+
+// mapField is a tagged field of a struct type
+type mapField struct {
+	path   []int  // field indexes, dereferencing pointers on the way
+	name   string // dotted field path for errors
+	target string
+}
+
+// mapPlan is the cached mapping of a struct type
+type mapPlan struct {
+	typeName string
+	fields   []mapField
+	err      error
+}
+
+// mapPlans caches a *mapPlan per struct type
+var mapPlans sync.Map
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// ItemsFrom converts a slice of structs, or pointers to structs, into items
+// using `feed:"..."` struct tags:
+//
+//	type Post struct {
+//		Title     string    `feed:"title"`
+//		URL       string    `feed:"link"`
+//		Published time.Time `feed:"pubDate"`
+//		Tags      []string  `feed:"category"`
+//		AudioURL  string    `feed:"enclosure.url"`
+//	}
+//
+// Untagged struct fields are searched for tags as well. Values may be
+// strings, numbers, time.Time or fmt.Stringer, and pointers to them; nil
+// pointers are skipped. When several fields map to the same target the
+// first non-empty value wins, except for categories, which are combined.
+func ItemsFrom(slice any, opts ...MapOption) ([]Item, error) {
+	v := reflect.ValueOf(slice)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("%w: got %T", ErrNotSlice, slice)
+	}
+
+	config := newMapConfig(opts)
+	items := make([]Item, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		elem := v.Index(i)
+		for elem.Kind() == reflect.Pointer || elem.Kind() == reflect.Interface {
+			elem = elem.Elem()
+		}
+		if !elem.IsValid() {
+			continue
+		}
+		item, err := mapItem(elem, config)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// ItemFrom converts a single struct, or pointer to a struct, into an item
+// using the same struct tags as ItemsFrom
+func ItemFrom(v any, opts ...MapOption) (Item, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return Item{}, fmt.Errorf("%w: got %T", ErrNotStruct, v)
+	}
+	return mapItem(rv, newMapConfig(opts))
+}
+
+func newMapConfig(opts []MapOption) *mapConfig {
+	config := &mapConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return config
+}
+
+// mapItem maps one struct value
+func mapItem(v reflect.Value, config *mapConfig) (Item, error) {
+	if v.Kind() != reflect.Struct {
+		return Item{}, fmt.Errorf("%w: got %s", ErrNotStruct, v.Type())
+	}
+
+	plan := planFor(v.Type())
+	if plan.err != nil {
+		return Item{}, plan.err
+	}
+
+	var item Item
+	for _, field := range plan.fields {
+		fv, ok := fieldByPath(v, field.path)
+		if !ok {
+			continue
+		}
+		if err := setTarget(&item, field.target, fv, config); err != nil {
+			return Item{}, &MappingError{Type: plan.typeName, Field: field.name, Target: field.target, Err: err}
+		}
+	}
+	return item, nil
+}
+
+// planFor returns the cached mapping plan of a struct type
+func planFor(t reflect.Type) *mapPlan {
+	if plan, ok := mapPlans.Load(t); ok {
+		return plan.(*mapPlan)
+	}
+	plan := &mapPlan{typeName: t.Name()}
+	if plan.typeName == "" {
+		plan.typeName = t.String()
+	}
+	plan.err = plan.collect(t, nil, "", map[reflect.Type]bool{t: true})
+	actual, _ := mapPlans.LoadOrStore(t, plan)
+	return actual.(*mapPlan)
+}
+
+// collect adds the tagged fields of t, recursing into untagged structs.
+// visiting guards against recursive types.
+func (p *mapPlan) collect(t reflect.Type, path []int, prefix string, visiting map[reflect.Type]bool) error {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := prefix + sf.Name
+		fieldPath := append(append([]int(nil), path...), i)
+
+		tag, tagged := sf.Tag.Lookup("feed")
+		if tag == "-" {
+			continue
+		}
+		if tagged {
+			target, _, _ := strings.Cut(tag, ",")
+			if !mappingTargets[target] {
+				return &MappingError{Type: p.typeName, Field: name, Target: target, Err: ErrUnknownTarget}
+			}
+			if !sf.IsExported() {
+				return &MappingError{Type: p.typeName, Field: name, Target: target, Err: ErrUnexportedField}
+			}
+			p.fields = append(p.fields, mapField{path: fieldPath, name: name, target: target})
+			continue
+		}
+
+		ft := sf.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if ft.Kind() != reflect.Struct || ft == timeType || visiting[ft] || !sf.IsExported() {
+			continue
+		}
+		visiting[ft] = true
+		if err := p.collect(ft, fieldPath, name+".", visiting); err != nil {
+			return err
+		}
+		delete(visiting, ft)
+	}
+	return nil
+}
+
+// fieldByPath follows field indexes, reporting false on nil pointers
+func fieldByPath(v reflect.Value, path []int) (reflect.Value, bool) {
+	for _, i := range path {
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v, true
+}
+
+// setTarget maps a field value onto the item
+func setTarget(item *Item, target string, v reflect.Value, config *mapConfig) error {
+	if conv := config.converters[target]; conv != nil {
+		if !v.CanInterface() {
+			return ErrUnexportedField
+		}
+		converted, err := conv(v.Interface())
+		if err != nil {
+			return err
+		}
+		v = reflect.ValueOf(converted)
+	}
+
+	switch target {
+	case "pubDate":
+		t, err := mapTime(v)
+		if err != nil {
+			return err
+		}
+		if item.PubDate.IsZero() {
+			item.PubDate = t
+		}
+		return nil
+	case "category":
+		categories, err := mapStrings(v)
+		if err != nil {
+			return err
+		}
+		item.Categories = append(item.Categories, categories...)
+		return nil
+	}
+
+	s, err := mapString(v)
+	if err != nil || s == "" {
+		return err
+	}
+
+	var dst *string
+	switch target {
+	case "title":
+		dst = &item.Title
+	case "description":
+		dst = &item.Description
+	case "content":
+		dst = &item.Content
+	case "link":
+		dst = &item.Link
+	case "author":
+		dst = &item.Author
+	case "guid":
+		dst = &item.GUID
+	case "comments":
+		dst = &item.Comments
+	case "enclosure.url", "enclosure.length", "enclosure.type":
+		if item.Enclosure == nil {
+			item.Enclosure = &Enclosure{}
+		}
+		switch target {
+		case "enclosure.url":
+			dst = &item.Enclosure.URL
+		case "enclosure.length":
+			dst = &item.Enclosure.Length
+		default:
+			dst = &item.Enclosure.Type
+		}
+	case "source.url", "source.title":
+		if item.Source == nil {
+			item.Source = &Source{}
+		}
+		dst = &item.Source.URL
+		if target == "source.title" {
+			dst = &item.Source.Value
+		}
+	}
+	if *dst == "" {
+		*dst = s
+	}
+	return nil
+}
+
+// mapString converts a value to a string
+func mapString(v reflect.Value) (string, error) {
+	if !v.IsValid() {
+		return "", nil
+	}
+	if v.Type().Implements(stringerType) && v.CanInterface() {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return "", nil
+		}
+		return v.Interface().(fmt.Stringer).String(), nil
+	}
+	if v.CanAddr() && reflect.PointerTo(v.Type()).Implements(stringerType) && v.Addr().CanInterface() {
+		return v.Addr().Interface().(fmt.Stringer).String(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return "", nil
+		}
+		return mapString(v.Elem())
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	}
+	return "", fmt.Errorf("%w %s", ErrUnsupportedType, v.Type())
+}
+
+// mapStrings converts a slice or a single value to a list of strings
+func mapStrings(v reflect.Value) ([]string, error) {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		s, err := mapString(v)
+		if err != nil || s == "" {
+			return nil, err
+		}
+		return []string{s}, nil
+	}
+
+	var values []string
+	for i := 0; i < v.Len(); i++ {
+		s, err := mapString(v.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		if s != "" {
+			values = append(values, s)
+		}
+	}
+	return values, nil
+}
+
+// mapTime converts a time.Time, or a date string in a common feed format
+func mapTime(v reflect.Value) (time.Time, error) {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return time.Time{}, nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return time.Time{}, nil
+	}
+
+	switch {
+	case v.Type() == timeType:
+		return v.Interface().(time.Time), nil
+	case v.Kind() == reflect.String:
+		if v.String() == "" {
+			return time.Time{}, nil
+		}
+		t := parseDate(v.String())
+		if t.IsZero() {
+			return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidDate, v.String())
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%w %s", ErrUnsupportedType, v.Type())
+}
@@ -0,0 +1,182 @@
+package feed
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mappingStatus int
+
+func (s mappingStatus) String() string {
+	return [...]string{"draft", "published"}[s]
+}
+
+type mappingTag struct {
+	Name string
+}
+
+func (t *mappingTag) String() string {
+	return "#" + t.Name
+}
+
+type mappingAuthor struct {
+	Email string `feed:"author"`
+}
+
+type mappingMedia struct {
+	URL    string `feed:"enclosure.url"`
+	Size   int64  `feed:"enclosure.length"`
+	Format string `feed:"enclosure.type"`
+}
+
+type mappingPost struct {
+	ID        int           `feed:"guid"`
+	Title     string        `feed:"title"`
+	Summary   string        `feed:"description"`
+	Body      *string       `feed:"content"`
+	URL       string        `feed:"link"`
+	Published time.Time     `feed:"pubDate"`
+	Updated   *time.Time    `feed:"pubDate"`
+	Tags      []*mappingTag `feed:"category"`
+	Status    mappingStatus `feed:"category"`
+	Author    mappingAuthor
+	Media     *mappingMedia
+	Internal  string `feed:"-"`
+	Next      *mappingPost
+}
+
+func TestItemsFrom(t *testing.T) {
+	body := "<p>Body</p>"
+	published := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	posts := []*mappingPost{
+		{
+			ID:        42,
+			Title:     "First",
+			Summary:   "Summary",
+			Body:      &body,
+			URL:       "https://example.com/first",
+			Published: published,
+			Tags:      []*mappingTag{{Name: "go"}, nil, {Name: "feeds"}},
+			Status:    1,
+			Author:    mappingAuthor{Email: "jane@example.com (Jane)"},
+			Media:     &mappingMedia{URL: "https://example.com/a.mp3", Size: 1024, Format: "audio/mpeg"},
+		},
+		nil,
+		{Title: "Second", URL: "https://example.com/second"},
+	}
+
+	items, err := ItemsFrom(posts)
+	if err != nil {
+		t.Fatalf("ItemsFrom failed: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("Expected nil elements to be skipped, got %d items", len(items))
+	}
+
+	first := items[0]
+	if first.GUID != "42" || first.Title != "First" || first.Description != "Summary" || first.Content != body || first.Link != "https://example.com/first" {
+		t.Errorf("Unexpected item fields: %+v", first)
+	}
+	if !first.PubDate.Equal(published) {
+		t.Errorf("Expected pubDate %v, got %v", published, first.PubDate)
+	}
+	if strings.Join(first.Categories, ",") != "#go,#feeds,published" {
+		t.Errorf("Unexpected categories: %v", first.Categories)
+	}
+	if first.Author != "jane@example.com (Jane)" {
+		t.Errorf("Nested struct fields should be mapped, got author %q", first.Author)
+	}
+	if first.Enclosure == nil || first.Enclosure.URL != "https://example.com/a.mp3" || first.Enclosure.Length != "1024" || first.Enclosure.Type != "audio/mpeg" {
+		t.Errorf("Unexpected enclosure: %+v", first.Enclosure)
+	}
+
+	second := items[1]
+	if second.Enclosure != nil || second.Content != "" || !second.PubDate.IsZero() {
+		t.Errorf("Nil pointers should be skipped: %+v", second)
+	}
+	if second.Categories[0] != "draft" {
+		t.Errorf("Expected Stringer category, got %v", second.Categories)
+	}
+}
+
+func TestItemsFromConverter(t *testing.T) {
+	type event struct {
+		Name string `feed:"title"`
+		At   int64  `feed:"pubDate"`
+	}
+
+	items, err := ItemsFrom([]event{{Name: "Launch", At: 1735689600}},
+		WithConverter("pubDate", func(v any) (any, error) {
+			return time.Unix(v.(int64), 0).UTC(), nil
+		}),
+		WithConverter("title", func(v any) (any, error) {
+			return strings.ToUpper(v.(string)), nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("ItemsFrom failed: %v", err)
+	}
+	if items[0].Title != "LAUNCH" || !items[0].PubDate.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Converters should be applied: %+v", items[0])
+	}
+
+	// Without the converter the field type is not supported
+	_, err = ItemsFrom([]event{{Name: "Launch", At: 1}})
+	var mappingErr *MappingError
+	if !errors.As(err, &mappingErr) || !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("Expected an unsupported type MappingError, got %v", err)
+	}
+	if mappingErr.Field != "At" || mappingErr.Target != "pubDate" || !strings.Contains(err.Error(), "item 0") {
+		t.Errorf("Error should name the item and field, got %v", err)
+	}
+}
+
+func TestItemsFromErrors(t *testing.T) {
+	if _, err := ItemsFrom(mappingPost{}); !errors.Is(err, ErrNotSlice) {
+		t.Errorf("Expected ErrNotSlice, got %v", err)
+	}
+	if _, err := ItemsFrom([]string{"x"}); !errors.Is(err, ErrNotStruct) {
+		t.Errorf("Expected ErrNotStruct, got %v", err)
+	}
+
+	type badTarget struct {
+		Nested struct {
+			Heading string `feed:"headline"`
+		}
+	}
+	_, err := ItemsFrom([]badTarget{{}})
+	if !errors.Is(err, ErrUnknownTarget) || !strings.Contains(err.Error(), "badTarget.Nested.Heading") {
+		t.Errorf("Expected ErrUnknownTarget naming the field, got %v", err)
+	}
+
+	type badDate struct {
+		Date string `feed:"pubDate"`
+	}
+	if _, err := ItemsFrom([]badDate{{Date: "yesterday"}}); !errors.Is(err, ErrInvalidDate) {
+		t.Errorf("Expected ErrInvalidDate, got %v", err)
+	}
+
+	type unexported struct {
+		title string `feed:"title"`
+	}
+	if _, err := ItemFrom(unexported{title: "x"}); !errors.Is(err, ErrUnexportedField) {
+		t.Errorf("Expected ErrUnexportedField, got %v", err)
+	}
+}
+
+func TestItemFrom(t *testing.T) {
+	item, err := ItemFrom(&mappingPost{Title: "Single", URL: "https://example.com/single"})
+	if err != nil {
+		t.Fatalf("ItemFrom failed: %v", err)
+	}
+	if item.Title != "Single" || item.Link != "https://example.com/single" {
+		t.Errorf("Unexpected item: %+v", item)
+	}
+
+	if _, ok := mapPlans.Load(reflect.TypeOf(mappingPost{})); !ok {
+		t.Error("Expected the mapping plan to be cached")
+	}
+}
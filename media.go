@@ -0,0 +1,327 @@
+package feed
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// mediaNamespace is the namespace of the Media RSS module
+const mediaNamespace = "http://search.yahoo.com/mrss/"
+
+// Media holds the Media RSS (mrss) elements of an item, rendered in RSS
+// and Atom with the media: prefix
+type Media struct {
+	Groups   []MediaGroup   `xml:"media:group,omitempty"`
+	Contents []MediaContent `xml:"media:content,omitempty"`
+	MediaMetadata
+}
+
+// MediaGroup groups media contents that are versions of the same work,
+// e.g. the same video in several resolutions
+type MediaGroup struct {
+	Contents []MediaContent `xml:"media:content"`
+	MediaMetadata
+}
+
+// MediaContent describes a media object. Duration is in seconds, Bitrate
+// in kilobits per second and SamplingRate in kilohertz.
+type MediaContent struct {
+	URL          string  `xml:"url,attr,omitempty"`
+	FileSize     int64   `xml:"fileSize,attr,omitempty"`
+	Type         string  `xml:"type,attr,omitempty"`
+	Medium       string  `xml:"medium,attr,omitempty"` // image, audio, video, document or executable
+	IsDefault    bool    `xml:"isDefault,attr,omitempty"`
+	Expression   string  `xml:"expression,attr,omitempty"` // sample, full or nonstop
+	Bitrate      int     `xml:"bitrate,attr,omitempty"`
+	Framerate    float64 `xml:"framerate,attr,omitempty"`
+	SamplingRate float64 `xml:"samplingrate,attr,omitempty"`
+	Channels     int     `xml:"channels,attr,omitempty"`
+	Duration     int     `xml:"duration,attr,omitempty"`
+	Height       int     `xml:"height,attr,omitempty"`
+	Width        int     `xml:"width,attr,omitempty"`
+	Lang         string  `xml:"lang,attr,omitempty"`
+	MediaMetadata
+}
+
+// MediaMetadata holds the optional elements that describe media. They may
+// be set on the channel, an item, a group or a single content.
+type MediaMetadata struct {
+	Title        *MediaText         `xml:"media:title,omitempty"`
+	Description  *MediaText         `xml:"media:description,omitempty"`
+	Keywords     string             `xml:"media:keywords,omitempty"` // comma separated
+	Thumbnails   []MediaThumbnail   `xml:"media:thumbnail,omitempty"`
+	Credits      []MediaCredit      `xml:"media:credit,omitempty"`
+	Ratings      []MediaRating      `xml:"media:rating,omitempty"`
+	Restrictions []MediaRestriction `xml:"media:restriction,omitempty"`
+	Player       *MediaPlayer       `xml:"media:player,omitempty"`
+	Embed        *MediaEmbed        `xml:"media:embed,omitempty"`
+}
+
+// MediaText is a media title or description
+type MediaText struct {
+	Type string `xml:"type,attr,omitempty"` // plain (the default) or html
+	Text string `xml:",chardata"`
+}
+
+// MediaThumbnail is a still image representing the media. Time is the
+// offset into the media the thumbnail was taken at, if any.
+type MediaThumbnail struct {
+	URL    string
+	Width  int
+	Height int
+	Time   time.Duration
+}
+
+// MarshalXML renders the thumbnail with its time offset in Normal Play Time
+func (t MediaThumbnail) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "url"}, Value: t.URL})
+	if t.Width > 0 {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "width"}, Value: strconv.Itoa(t.Width)})
+	}
+	if t.Height > 0 {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "height"}, Value: strconv.Itoa(t.Height)})
+	}
+	if t.Time > 0 {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "time"}, Value: formatNPT(t.Time)})
+	}
+	return e.EncodeElement("", start)
+}
+
+// MediaCredit credits an entity, e.g. a director or performer
+type MediaCredit struct {
+	Name   string `xml:",chardata"`
+	Role   string `xml:"role,attr,omitempty"`
+	Scheme string `xml:"scheme,attr,omitempty"` // urn:ebu by default
+}
+
+// MediaRating gives the audience rating of the media
+type MediaRating struct {
+	Value  string `xml:",chardata"`             // e.g. "adult", "nonadult" or "pg-13"
+	Scheme string `xml:"scheme,attr,omitempty"` // urn:simple by default, or e.g. urn:mpaa
+}
+
+// MediaRestriction allows or denies the media to countries, URIs or
+// sharing. Value lists country codes or URIs separated by spaces, or is
+// "all" or "none".
+type MediaRestriction struct {
+	Relationship string `xml:"relationship,attr"` // allow or deny
+	Type         string `xml:"type,attr,omitempty"`
+	Value        string `xml:",chardata"`
+}
+
+// MediaPlayer links to a web page where the media can be played
+type MediaPlayer struct {
+	URL    string `xml:"url,attr"`
+	Width  int    `xml:"width,attr,omitempty"`
+	Height int    `xml:"height,attr,omitempty"`
+}
+
+// MediaEmbed describes how to embed a player for the media
+type MediaEmbed struct {
+	URL    string       `xml:"url,attr"`
+	Width  int          `xml:"width,attr,omitempty"`
+	Height int          `xml:"height,attr,omitempty"`
+	Params []MediaParam `xml:"media:param,omitempty"`
+}
+
+// MediaParam is a parameter of an embedded player
+type MediaParam struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
+}
+
+// SetMedia sets the Media RSS elements of the channel
+func (f *Feed) SetMedia(media MediaMetadata) *Feed {
+	f.media = &media
+	return f
+}
+
+// GetMedia returns the Media RSS elements of the channel
+func (f *Feed) GetMedia() *MediaMetadata {
+	return f.media
+}
+
+// channelMedia returns the channel media for rendering, with relative URLs
+// resolved when URL resolution is enabled
+func (f *Feed) channelMedia() *MediaMetadata {
+	if base := f.baseURL(); f.media != nil && f.resolveURLs && base != nil {
+		media := f.media.clone()
+		media.resolve(base)
+		return media
+	}
+	return f.media
+}
+
+// formatNPT formats a time offset in Normal Play Time, e.g. "12:05:01.123"
+func formatNPT(d time.Duration) string {
+	h := d / time.Hour
+	m := d % time.Hour / time.Minute
+	s := d % time.Minute / time.Second
+	ms := d % time.Second / time.Millisecond
+	if ms > 0 {
+		return fmt.Sprintf("%02d:%02d:%02d.%03d", h, m, s, ms)
+	}
+	return fmt.Sprintf("%02d:%02d:%02d", h, m, s)
+}
+
+// usesMedia reports whether the feed has any Media RSS elements
+func (f *Feed) usesMedia() bool {
+	if f.media != nil {
+		return true
+	}
+	for _, item := range f.items {
+		if item.Media != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// validateMedia checks the attributes Media RSS requires
+func (f *Feed) validateMedia() error {
+	var errs []error
+	if f.media != nil {
+		errs = append(errs, f.media.validate()...)
+	}
+	for _, item := range f.items {
+		if m := item.Media; m != nil {
+			for _, g := range m.Groups {
+				if len(g.Contents) == 0 {
+					errs = append(errs, mediaError("group", "requires at least one content"))
+				}
+				errs = append(errs, validateMediaContents(g.Contents)...)
+				errs = append(errs, g.MediaMetadata.validate()...)
+			}
+			errs = append(errs, validateMediaContents(m.Contents)...)
+			errs = append(errs, m.MediaMetadata.validate()...)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validateMediaContents(contents []MediaContent) []error {
+	var errs []error
+	for _, c := range contents {
+		if c.URL == "" && c.Player == nil {
+			errs = append(errs, mediaError("content", "requires a url or a player"))
+		}
+		errs = append(errs, c.MediaMetadata.validate()...)
+	}
+	return errs
+}
+
+func (m *MediaMetadata) validate() []error {
+	var errs []error
+	for _, t := range m.Thumbnails {
+		if t.URL == "" {
+			errs = append(errs, mediaError("thumbnail", "requires a url"))
+		}
+	}
+	for _, r := range m.Restrictions {
+		if r.Relationship != "allow" && r.Relationship != "deny" {
+			errs = append(errs, mediaError("restriction", "relationship must be allow or deny, got %q", r.Relationship))
+		}
+	}
+	if m.Player != nil && m.Player.URL == "" {
+		errs = append(errs, mediaError("player", "requires a url"))
+	}
+	if m.Embed != nil && m.Embed.URL == "" {
+		errs = append(errs, mediaError("embed", "requires a url"))
+	}
+	return errs
+}
+
+func mediaError(tag, format string, args ...any) error {
+	return fmt.Errorf("%w: media:%s %s", ErrInvalidMedia, tag, fmt.Sprintf(format, args...))
+}
+
+// clone returns a deep copy of the item media
+func (m *Media) clone() *Media {
+	clone := *m
+	if m.Groups != nil {
+		clone.Groups = make([]MediaGroup, len(m.Groups))
+		for i, g := range m.Groups {
+			g.Contents = cloneMediaContents(g.Contents)
+			g.MediaMetadata = *g.MediaMetadata.clone()
+			clone.Groups[i] = g
+		}
+	}
+	clone.Contents = cloneMediaContents(m.Contents)
+	clone.MediaMetadata = *m.MediaMetadata.clone()
+	return &clone
+}
+
+func cloneMediaContents(contents []MediaContent) []MediaContent {
+	if contents == nil {
+		return nil
+	}
+	clone := make([]MediaContent, len(contents))
+	for i, c := range contents {
+		c.MediaMetadata = *c.MediaMetadata.clone()
+		clone[i] = c
+	}
+	return clone
+}
+
+// clone returns a deep copy of the metadata
+func (m *MediaMetadata) clone() *MediaMetadata {
+	clone := *m
+	if m.Title != nil {
+		title := *m.Title
+		clone.Title = &title
+	}
+	if m.Description != nil {
+		description := *m.Description
+		clone.Description = &description
+	}
+	clone.Thumbnails = append([]MediaThumbnail(nil), m.Thumbnails...)
+	clone.Credits = append([]MediaCredit(nil), m.Credits...)
+	clone.Ratings = append([]MediaRating(nil), m.Ratings...)
+	clone.Restrictions = append([]MediaRestriction(nil), m.Restrictions...)
+	if m.Player != nil {
+		player := *m.Player
+		clone.Player = &player
+	}
+	if m.Embed != nil {
+		embed := *m.Embed
+		embed.Params = append([]MediaParam(nil), m.Embed.Params...)
+		clone.Embed = &embed
+	}
+	return &clone
+}
+
+// resolveMedia returns a copy of the media with relative URLs made absolute
+func resolveMedia(base *url.URL, m *Media) *Media {
+	m = m.clone()
+	for i := range m.Groups {
+		resolveMediaContents(base, m.Groups[i].Contents)
+		m.Groups[i].MediaMetadata.resolve(base)
+	}
+	resolveMediaContents(base, m.Contents)
+	m.MediaMetadata.resolve(base)
+	return m
+}
+
+func resolveMediaContents(base *url.URL, contents []MediaContent) {
+	for i := range contents {
+		contents[i].URL = resolveURL(base, contents[i].URL)
+		contents[i].MediaMetadata.resolve(base)
+	}
+}
+
+// resolve makes the metadata's URLs absolute in place
+func (m *MediaMetadata) resolve(base *url.URL) {
+	for i := range m.Thumbnails {
+		m.Thumbnails[i].URL = resolveURL(base, m.Thumbnails[i].URL)
+	}
+	if m.Player != nil {
+		m.Player.URL = resolveURL(base, m.Player.URL)
+	}
+	if m.Embed != nil {
+		m.Embed.URL = resolveURL(base, m.Embed.URL)
+	}
+}
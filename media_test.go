@@ -0,0 +1,178 @@
+package feed
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mediaTestFeed() *Feed {
+	return New().
+		SetTitle("Videos").
+		SetDescription("Latest videos").
+		SetLink("https://example.com").
+		SetMedia(MediaMetadata{
+			Thumbnails: []MediaThumbnail{{URL: "https://example.com/channel.jpg"}},
+			Ratings:    []MediaRating{{Value: "nonadult"}},
+		}).
+		AddItem(Item{
+			Title: "Launch video",
+			Link:  "https://example.com/videos/launch",
+			GUID:  "launch",
+			Media: &Media{
+				Groups: []MediaGroup{{
+					Contents: []MediaContent{
+						{URL: "https://example.com/launch-1080.mp4", Type: "video/mp4", Medium: "video", IsDefault: true, Expression: "full", Bitrate: 4000, Framerate: 29.97, Duration: 185, Width: 1920, Height: 1080, Lang: "en"},
+						{URL: "https://example.com/launch-480.mp4", Type: "video/mp4", Medium: "video", Bitrate: 800, Duration: 185, Width: 854, Height: 480, Lang: "en"},
+					},
+					MediaMetadata: MediaMetadata{
+						Title:        &MediaText{Text: "The launch"},
+						Description:  &MediaText{Type: "html", Text: "<p>Watch the <b>launch</b>.</p>"},
+						Keywords:     "launch, rocket",
+						Thumbnails:   []MediaThumbnail{{URL: "https://example.com/launch-1.jpg", Width: 320, Height: 180, Time: 12*time.Minute + 5*time.Second + 123*time.Millisecond}},
+						Credits:      []MediaCredit{{Name: "Jane Doe", Role: "director", Scheme: "urn:ebu"}},
+						Ratings:      []MediaRating{{Value: "pg", Scheme: "urn:mpaa"}},
+						Restrictions: []MediaRestriction{{Relationship: "allow", Type: "country", Value: "au us"}},
+						Player:       &MediaPlayer{URL: "https://example.com/player?id=launch", Width: 640, Height: 360},
+						Embed: &MediaEmbed{URL: "https://example.com/embed.swf", Width: 640, Height: 360, Params: []MediaParam{
+							{Name: "autoplay", Value: "false"},
+						}},
+					},
+				}},
+			},
+		})
+}
+
+func TestMediaRSS(t *testing.T) {
+	rss, err := mediaTestFeed().RSS()
+	if err != nil {
+		t.Fatalf("RSS failed: %v", err)
+	}
+	out := string(rss)
+	for _, want := range []string{
+		`<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/">`,
+		`<media:thumbnail url="https://example.com/channel.jpg"></media:thumbnail>`,
+		`<media:rating>nonadult</media:rating>`,
+		`<media:group>`,
+		`<media:content url="https://example.com/launch-1080.mp4" type="video/mp4" medium="video" isDefault="true" expression="full" bitrate="4000" framerate="29.97" duration="185" height="1080" width="1920" lang="en"></media:content>`,
+		`<media:title>The launch</media:title>`,
+		`<media:description type="html">&lt;p&gt;Watch the &lt;b&gt;launch&lt;/b&gt;.&lt;/p&gt;</media:description>`,
+		`<media:keywords>launch, rocket</media:keywords>`,
+		`<media:thumbnail url="https://example.com/launch-1.jpg" width="320" height="180" time="00:12:05.123"></media:thumbnail>`,
+		`<media:credit role="director" scheme="urn:ebu">Jane Doe</media:credit>`,
+		`<media:rating scheme="urn:mpaa">pg</media:rating>`,
+		`<media:restriction relationship="allow" type="country">au us</media:restriction>`,
+		`<media:player url="https://example.com/player?id=launch" width="640" height="360"></media:player>`,
+		`<media:param name="autoplay">false</media:param>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected RSS to contain %s", want)
+		}
+	}
+}
+
+func TestMediaAtom(t *testing.T) {
+	atom, err := mediaTestFeed().Atom()
+	if err != nil {
+		t.Fatalf("Atom failed: %v", err)
+	}
+	out := string(atom)
+	for _, want := range []string{
+		`<feed xmlns="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/">`,
+		`<media:rating>nonadult</media:rating>`,
+		`<media:content url="https://example.com/launch-480.mp4"`,
+		`<media:credit role="director" scheme="urn:ebu">Jane Doe</media:credit>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected Atom to contain %s, got:\n%s", want, out)
+		}
+	}
+	if entry := out[strings.Index(out, "<entry>"):]; !strings.Contains(entry, "<media:group>") {
+		t.Error("Expected the media group inside the entry")
+	}
+}
+
+func TestMediaResolveURLs(t *testing.T) {
+	f := New().SetTitle("Videos").SetDescription("Latest videos").SetLink("https://example.com/").
+		SetResolveURLs(true).
+		AddItem(Item{Title: "Clip", Link: "/videos/clip/", Media: &Media{
+			Contents: []MediaContent{{URL: "clip.mp4", MediaMetadata: MediaMetadata{
+				Thumbnails: []MediaThumbnail{{URL: "clip.jpg"}},
+			}}},
+			MediaMetadata: MediaMetadata{Player: &MediaPlayer{URL: "/player"}},
+		}})
+
+	rss, err := f.RSS()
+	if err != nil {
+		t.Fatalf("RSS failed: %v", err)
+	}
+	for _, want := range []string{
+		`url="https://example.com/videos/clip/clip.mp4"`,
+		`url="https://example.com/videos/clip/clip.jpg"`,
+		`url="https://example.com/player"`,
+	} {
+		if !strings.Contains(string(rss), want) {
+			t.Errorf("Expected RSS to contain %s", want)
+		}
+	}
+	if f.items[0].Media.Contents[0].URL != "clip.mp4" {
+		t.Error("Resolving URLs should not modify the feed")
+	}
+}
+
+func TestMediaValidation(t *testing.T) {
+	if err := mediaTestFeed().Validate(); err != nil {
+		t.Errorf("Expected a valid feed, got %v", err)
+	}
+
+	f := mediaTestFeed()
+	g := &f.items[0].Media.Groups[0]
+	g.Contents[1].URL = ""
+	g.Thumbnails[0].URL = ""
+	g.Restrictions[0].Relationship = "maybe"
+	f.items[0].Media.Groups = append(f.items[0].Media.Groups, MediaGroup{})
+
+	err := f.Validate()
+	if !errors.Is(err, ErrInvalidMedia) {
+		t.Fatalf("Expected ErrInvalidMedia, got %v", err)
+	}
+	for _, want := range []string{
+		"media:content requires a url or a player",
+		"media:thumbnail requires a url",
+		`media:restriction relationship must be allow or deny, got "maybe"`,
+		"media:group requires at least one content",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %q, got:\n%v", want, err)
+		}
+	}
+}
+
+func TestMediaClone(t *testing.T) {
+	f := mediaTestFeed()
+	clone := f.Clone()
+	clone.media.Ratings[0].Value = "adult"
+	g := clone.items[0].Media.Groups[0]
+	g.Contents[0].URL = "https://example.com/other.mp4"
+	g.Thumbnails[0].URL = "https://example.com/other.jpg"
+	g.Embed.Params[0].Value = "true"
+
+	orig := f.items[0].Media.Groups[0]
+	if f.media.Ratings[0].Value != "nonadult" || orig.Contents[0].URL != "https://example.com/launch-1080.mp4" ||
+		orig.Thumbnails[0].URL != "https://example.com/launch-1.jpg" || orig.Embed.Params[0].Value != "false" {
+		t.Error("Cloning should deep copy media elements")
+	}
+}
+
+func TestFormatNPT(t *testing.T) {
+	tests := map[time.Duration]string{
+		5 * time.Second: "00:00:05",
+		12*time.Hour + 5*time.Minute + time.Second + 123*time.Millisecond: "12:05:01.123",
+	}
+	for d, want := range tests {
+		if got := formatNPT(d); got != want {
+			t.Errorf("formatNPT(%s): expected %s, got %s", d, want, got)
+		}
+	}
+}
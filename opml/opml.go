@@ -0,0 +1,292 @@
+// Package opml reads and writes OPML 2.0 subscription lists.
+package opml
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"go.rumenx.com/feed"
+	"go.rumenx.com/feed/fetch"
+)
+
+// ErrNotOPML is returned when a document's root element is not <opml>
+var ErrNotOPML = errors.New("document is not OPML")
+
+// Document represents an OPML document
+type Document struct {
+	Title        string
+	DateCreated  time.Time
+	DateModified time.Time
+	OwnerName    string
+	OwnerEmail   string
+	OwnerID      string
+	Docs         string
+	Outlines     []Outline
+}
+
+// Outline represents an OPML outline. Subscription outlines have Type "rss"
+// and an XMLURL; other outlines usually group nested outlines.
+type Outline struct {
+	Text        string
+	Title       string
+	Type        string
+	XMLURL      string
+	HTMLURL     string
+	Description string
+	Language    string
+	Categories  []string
+	Created     time.Time
+	Outlines    []Outline
+}
+
+// xmlDocument is the on-the-wire OPML structure
+type xmlDocument struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    xmlHead  `xml:"head"`
+	Body    xmlBody  `xml:"body"`
+}
+
+type xmlHead struct {
+	Title        string `xml:"title,omitempty"`
+	DateCreated  string `xml:"dateCreated,omitempty"`
+	DateModified string `xml:"dateModified,omitempty"`
+	OwnerName    string `xml:"ownerName,omitempty"`
+	OwnerEmail   string `xml:"ownerEmail,omitempty"`
+	OwnerID      string `xml:"ownerId,omitempty"`
+	Docs         string `xml:"docs,omitempty"`
+}
+
+type xmlBody struct {
+	Outlines []xmlOutline `xml:"outline"`
+}
+
+type xmlOutline struct {
+	Text        string       `xml:"text,attr"`
+	Title       string       `xml:"title,attr,omitempty"`
+	Type        string       `xml:"type,attr,omitempty"`
+	XMLURL      string       `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL     string       `xml:"htmlUrl,attr,omitempty"`
+	Description string       `xml:"description,attr,omitempty"`
+	Language    string       `xml:"language,attr,omitempty"`
+	Category    string       `xml:"category,attr,omitempty"`
+	Created     string       `xml:"created,attr,omitempty"`
+	Outlines    []xmlOutline `xml:"outline"`
+}
+
+// Parse reads an OPML document. Versions 1.0, 1.1 and 2.0 are accepted.
+func Parse(r io.Reader) (*Document, error) {
+	var doc xmlDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		var unexpected xml.UnmarshalError
+		if errors.As(err, &unexpected) {
+			return nil, ErrNotOPML
+		}
+		return nil, fmt.Errorf("failed to parse OPML: %w", err)
+	}
+
+	d := &Document{
+		Title:        doc.Head.Title,
+		DateCreated:  parseDate(doc.Head.DateCreated),
+		DateModified: parseDate(doc.Head.DateModified),
+		OwnerName:    doc.Head.OwnerName,
+		OwnerEmail:   doc.Head.OwnerEmail,
+		OwnerID:      doc.Head.OwnerID,
+		Docs:         doc.Head.Docs,
+		Outlines:     fromXMLOutlines(doc.Body.Outlines),
+	}
+	return d, nil
+}
+
+// XML generates the OPML 2.0 document
+func (d *Document) XML() ([]byte, error) {
+	doc := xmlDocument{
+		Version: "2.0",
+		Head: xmlHead{
+			Title:        d.Title,
+			DateCreated:  formatDate(d.DateCreated),
+			DateModified: formatDate(d.DateModified),
+			OwnerName:    d.OwnerName,
+			OwnerEmail:   d.OwnerEmail,
+			OwnerID:      d.OwnerID,
+			Docs:         d.Docs,
+		},
+		Body: xmlBody{Outlines: toXMLOutlines(d.Outlines)},
+	}
+
+	xmlData, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal OPML XML: %w", err)
+	}
+
+	return append([]byte(xml.Header), xmlData...), nil
+}
+
+// Subscriptions returns every outline with an XMLURL, including nested ones,
+// in document order
+func (d *Document) Subscriptions() []Outline {
+	var subs []Outline
+	var walk func([]Outline)
+	walk = func(outlines []Outline) {
+		for _, o := range outlines {
+			if o.XMLURL != "" {
+				subs = append(subs, o)
+			}
+			walk(o.Outlines)
+		}
+	}
+	walk(d.Outlines)
+	return subs
+}
+
+// Subscribe adds every subscription in the document to the poller and
+// returns the subscribed feed URLs
+func (d *Document) Subscribe(p *fetch.Poller) []string {
+	var urls []string
+	for _, sub := range d.Subscriptions() {
+		p.Subscribe(sub.XMLURL)
+		urls = append(urls, sub.XMLURL)
+	}
+	return urls
+}
+
+// FeedRef describes a published feed for FromFeeds
+type FeedRef struct {
+	// URL is where the feed is published
+	URL string
+	// Feed provides the title, description, site link and language
+	Feed *feed.Feed
+	// Type is the outline type; it defaults to "rss"
+	Type string
+	// Group, when set, nests the outline under a group outline of that name
+	Group string
+	// Categories are written to the outline's category attribute
+	Categories []string
+}
+
+// FromFeeds builds an OPML document listing the given feeds
+func FromFeeds(title string, refs []FeedRef) *Document {
+	d := &Document{
+		Title:       title,
+		DateCreated: time.Now(),
+	}
+
+	groups := make(map[string]int)
+	for _, ref := range refs {
+		if ref.Feed == nil {
+			continue
+		}
+
+		outline := Outline{
+			Text:        ref.Feed.GetTitle(),
+			Title:       ref.Feed.GetTitle(),
+			Type:        ref.Type,
+			XMLURL:      ref.URL,
+			HTMLURL:     ref.Feed.GetLink(),
+			Description: ref.Feed.GetDescription(),
+			Language:    ref.Feed.GetLanguage(),
+			Categories:  ref.Categories,
+		}
+		if outline.Type == "" {
+			outline.Type = "rss"
+		}
+
+		if ref.Group == "" {
+			d.Outlines = append(d.Outlines, outline)
+			continue
+		}
+		i, ok := groups[ref.Group]
+		if !ok {
+			i = len(d.Outlines)
+			groups[ref.Group] = i
+			d.Outlines = append(d.Outlines, Outline{Text: ref.Group, Title: ref.Group})
+		}
+		d.Outlines[i].Outlines = append(d.Outlines[i].Outlines, outline)
+	}
+
+	return d
+}
+
+func fromXMLOutlines(in []xmlOutline) []Outline {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make([]Outline, len(in))
+	for i, o := range in {
+		out[i] = Outline{
+			Text:        o.Text,
+			Title:       o.Title,
+			Type:        o.Type,
+			XMLURL:      strings.TrimSpace(o.XMLURL),
+			HTMLURL:     strings.TrimSpace(o.HTMLURL),
+			Description: o.Description,
+			Language:    o.Language,
+			Categories:  splitCategories(o.Category),
+			Created:     parseDate(o.Created),
+			Outlines:    fromXMLOutlines(o.Outlines),
+		}
+	}
+	return out
+}
+
+func toXMLOutlines(in []Outline) []xmlOutline {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make([]xmlOutline, len(in))
+	for i, o := range in {
+		text := o.Text
+		if text == "" {
+			// text is required by OPML 2.0
+			text = o.Title
+		}
+		out[i] = xmlOutline{
+			Text:        text,
+			Title:       o.Title,
+			Type:        o.Type,
+			XMLURL:      o.XMLURL,
+			HTMLURL:     o.HTMLURL,
+			Description: o.Description,
+			Language:    o.Language,
+			Category:    strings.Join(o.Categories, ","),
+			Created:     formatDate(o.Created),
+			Outlines:    toXMLOutlines(o.Outlines),
+		}
+	}
+	return out
+}
+
+// splitCategories splits a comma separated category attribute
+func splitCategories(s string) []string {
+	var categories []string
+	for _, c := range strings.Split(s, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			categories = append(categories, c)
+		}
+	}
+	return categories
+}
+
+// formatDate formats a time as an RFC 822 date, as required by OPML
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC1123Z)
+}
+
+// parseDate parses an OPML date, returning the zero time when it is
+// empty or not in a recognised format
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC1123Z, time.RFC1123, time.RFC822Z, time.RFC822, time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
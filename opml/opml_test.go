@@ -0,0 +1,158 @@
+package opml
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.rumenx.com/feed"
+	"go.rumenx.com/feed/fetch"
+)
+
+const sample = `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+  <head>
+    <title>Team subscriptions</title>
+    <dateCreated>Mon, 06 Jan 2025 10:00:00 +0000</dateCreated>
+    <ownerName>Jane</ownerName>
+  </head>
+  <body>
+    <outline text="Go">
+      <outline text="The Go Blog" type="rss" xmlUrl="https://go.dev/blog/feed.atom" htmlUrl="https://go.dev/blog" category="/go,/news"/>
+      <outline text="Nested">
+        <outline text="Deep" type="rss" xmlUrl="https://deep.example.com/feed.xml"/>
+      </outline>
+    </outline>
+    <outline text="Example" type="rss" xmlUrl=" https://example.com/feed.xml "/>
+    <outline text="Just a note"/>
+  </body>
+</opml>`
+
+func TestParse(t *testing.T) {
+	doc, err := Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if doc.Title != "Team subscriptions" || doc.OwnerName != "Jane" {
+		t.Errorf("Unexpected head: %q %q", doc.Title, doc.OwnerName)
+	}
+	if !doc.DateCreated.Equal(time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected dateCreated: %v", doc.DateCreated)
+	}
+	if len(doc.Outlines) != 3 || len(doc.Outlines[0].Outlines) != 2 {
+		t.Fatalf("Unexpected outline structure: %+v", doc.Outlines)
+	}
+
+	blog := doc.Outlines[0].Outlines[0]
+	if blog.XMLURL != "https://go.dev/blog/feed.atom" || blog.HTMLURL != "https://go.dev/blog" {
+		t.Errorf("Unexpected outline URLs: %+v", blog)
+	}
+	if len(blog.Categories) != 2 || blog.Categories[1] != "/news" {
+		t.Errorf("Unexpected categories: %v", blog.Categories)
+	}
+
+	subs := doc.Subscriptions()
+	var urls []string
+	for _, s := range subs {
+		urls = append(urls, s.XMLURL)
+	}
+	want := "https://go.dev/blog/feed.atom,https://deep.example.com/feed.xml,https://example.com/feed.xml"
+	if strings.Join(urls, ",") != want {
+		t.Errorf("Expected subscriptions %s, got %v", want, urls)
+	}
+}
+
+func TestParseNotOPML(t *testing.T) {
+	_, err := Parse(strings.NewReader(`<rss version="2.0"></rss>`))
+	if !errors.Is(err, ErrNotOPML) {
+		t.Errorf("Expected ErrNotOPML, got %v", err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	doc, err := Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	data, err := doc.XML()
+	if err != nil {
+		t.Fatalf("XML generation failed: %v", err)
+	}
+
+	out := string(data)
+	for _, want := range []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<opml version="2.0">`,
+		`<dateCreated>Mon, 06 Jan 2025 10:00:00 +0000</dateCreated>`,
+		`xmlUrl="https://go.dev/blog/feed.atom"`,
+		`category="/go,/news"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("OPML output should contain %s", want)
+		}
+	}
+
+	again, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Parsing generated OPML failed: %v", err)
+	}
+	if len(again.Subscriptions()) != 3 {
+		t.Errorf("Expected 3 subscriptions after round trip, got %d", len(again.Subscriptions()))
+	}
+}
+
+func TestFromFeeds(t *testing.T) {
+	news := feed.New().
+		SetTitle("News").
+		SetDescription("Latest news").
+		SetLink("https://example.com/news").
+		SetLanguage("en")
+	golang := feed.New().
+		SetTitle("Go").
+		SetDescription("Go posts").
+		SetLink("https://example.com/tags/go")
+
+	doc := FromFeeds("All feeds", []FeedRef{
+		{URL: "https://example.com/news.xml", Feed: news},
+		{URL: "https://example.com/tags/go.xml", Feed: golang, Group: "Tags", Categories: []string{"go"}},
+		{URL: "https://example.com/missing.xml"},
+	})
+
+	if doc.Title != "All feeds" || doc.DateCreated.IsZero() {
+		t.Error("Expected title and creation date to be set")
+	}
+	if len(doc.Outlines) != 2 {
+		t.Fatalf("Expected 2 top-level outlines, got %d", len(doc.Outlines))
+	}
+
+	top := doc.Outlines[0]
+	if top.Text != "News" || top.Type != "rss" || top.HTMLURL != "https://example.com/news" || top.Language != "en" {
+		t.Errorf("Unexpected outline: %+v", top)
+	}
+
+	group := doc.Outlines[1]
+	if group.Text != "Tags" || len(group.Outlines) != 1 || group.Outlines[0].XMLURL != "https://example.com/tags/go.xml" {
+		t.Errorf("Unexpected group outline: %+v", group)
+	}
+
+	if _, err := doc.XML(); err != nil {
+		t.Fatalf("XML generation failed: %v", err)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	doc, err := Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	p := fetch.NewPoller(fetch.New(), nil)
+	urls := doc.Subscribe(p)
+	if len(urls) != 3 {
+		t.Errorf("Expected 3 subscriptions, got %v", urls)
+	}
+}
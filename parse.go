@@ -0,0 +1,372 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parse reads an RSS 2.0 or Atom 1.0 document and returns it as a Feed
+func Parse(r io.Reader) (*Feed, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed: %w", err)
+	}
+
+	root, err := rootElement(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case root.Local == "rss":
+		return parseRSS(data)
+	case root.Local == "feed" && root.Space == "http://www.w3.org/2005/Atom":
+		return parseAtom(data)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// rootElement returns the name of the document's root element
+func rootElement(data []byte) (xml.Name, error) {
+	decoder := newDecoder(data)
+	decoder.Strict = false
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			return xml.Name{}, ErrUnknownFormat
+		}
+		if err != nil {
+			return xml.Name{}, fmt.Errorf("failed to parse feed XML: %w", err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name, nil
+		}
+	}
+}
+
+// rssDocument is the parse-side view of an RSS 2.0 document. Extensions
+// reuse the local names of RSS elements (atom:link, itunes:author,
+// media:title, ...) and encoding/xml matches fields by local name alone, so
+// such elements are collected with their names and the RSS ones picked
+// afterwards.
+type rssDocument struct {
+	XMLName xml.Name
+	Channel struct {
+		Title          []rssElement    `xml:"title"`
+		Description    []rssElement    `xml:"description"`
+		Link           []rssElement    `xml:"link"`
+		Language       []rssElement    `xml:"language"`
+		Copyright      []rssElement    `xml:"copyright"`
+		ManagingEditor []rssElement    `xml:"managingEditor"`
+		Webmaster      []rssElement    `xml:"webMaster"`
+		PubDate        []rssElement    `xml:"pubDate"`
+		LastBuildDate  []rssElement    `xml:"lastBuildDate"`
+		TTL            []rssElement    `xml:"ttl"`
+		Image          []rssParseImage `xml:"image"`
+		SkipHours      *RSSSkipHours   `xml:"skipHours"`
+		SkipDays       *RSSSkipDays    `xml:"skipDays"`
+		Items          []rssParseItem  `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssParseItem struct {
+	Title       []rssElement  `xml:"title"`
+	Description []rssElement  `xml:"description"`
+	Link        []rssElement  `xml:"link"`
+	Author      []rssElement  `xml:"author"`
+	Category    []rssElement  `xml:"category"`
+	Comments    []rssElement  `xml:"comments"`
+	GUID        []rssElement  `xml:"guid"`
+	PubDate     []rssElement  `xml:"pubDate"`
+	Enclosure   *RSSEnclosure `xml:"enclosure"`
+	Source      *RSSSource    `xml:"source"`
+	Encoded     string        `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+}
+
+type rssParseImage struct {
+	XMLName xml.Name
+	RSSImage
+}
+
+// rssElement is a text element together with its name
+type rssElement struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+// rssValue returns the value of the first element in the RSS namespace,
+// which is none or that of the root element
+func rssValue(elements []rssElement, space string) string {
+	for _, e := range elements {
+		if e.XMLName.Space == space {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+// rssValues returns the values of all elements in the RSS namespace
+func rssValues(elements []rssElement, space string) []string {
+	var values []string
+	for _, e := range elements {
+		if e.XMLName.Space == space {
+			values = append(values, e.Value)
+		}
+	}
+	return values
+}
+
+// parseRSS converts an RSS 2.0 document into a Feed
+func parseRSS(data []byte) (*Feed, error) {
+	var rss rssDocument
+	if err := newDecoder(data).Decode(&rss); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal RSS XML: %w", err)
+	}
+
+	ch := rss.Channel
+	space := rss.XMLName.Space
+	f := New()
+	f.title = strings.TrimSpace(rssValue(ch.Title, space))
+	f.description = strings.TrimSpace(rssValue(ch.Description, space))
+	f.link = strings.TrimSpace(rssValue(ch.Link, space))
+	f.language = rssValue(ch.Language, space)
+	f.copyright = rssValue(ch.Copyright, space)
+	f.managingEditor = rssValue(ch.ManagingEditor, space)
+	f.webmaster = rssValue(ch.Webmaster, space)
+	f.ttl, _ = strconv.Atoi(strings.TrimSpace(rssValue(ch.TTL, space)))
+	f.lastBuildDate = parseDate(rssValue(ch.LastBuildDate, space))
+	if f.lastBuildDate.IsZero() {
+		f.lastBuildDate = parseDate(rssValue(ch.PubDate, space))
+	}
+
+	for _, img := range ch.Image {
+		if img.XMLName.Space != space {
+			continue
+		}
+		f.image = &Image{
+			URL:    img.URL,
+			Title:  img.Title,
+			Link:   img.Link,
+			Width:  img.Width,
+			Height: img.Height,
+		}
+		break
+	}
+	if ch.SkipHours != nil {
+		f.skipHours = ch.SkipHours.Hours
+	}
+	if ch.SkipDays != nil {
+		f.skipDays = ch.SkipDays.Days
+	}
+
+	for _, ri := range ch.Items {
+		item := Item{
+			Title:       strings.TrimSpace(rssValue(ri.Title, space)),
+			Description: rssValue(ri.Description, space),
+			Content:     ri.Encoded,
+			Link:        strings.TrimSpace(rssValue(ri.Link, space)),
+			Author:      rssValue(ri.Author, space),
+			PubDate:     parseDate(rssValue(ri.PubDate, space)),
+			GUID:        strings.TrimSpace(rssValue(ri.GUID, space)),
+			Categories:  rssValues(ri.Category, space),
+			Comments:    rssValue(ri.Comments, space),
+		}
+		if ri.Enclosure != nil {
+			item.Enclosure = &Enclosure{
+				URL:    ri.Enclosure.URL,
+				Length: ri.Enclosure.Length,
+				Type:   ri.Enclosure.Type,
+			}
+		}
+		if ri.Source != nil {
+			// RSS source URLs point at the source feed document
+			item.Source = &Source{
+				URL:       ri.Source.URL,
+				Value:     ri.Source.Value,
+				IsFeedURL: true,
+			}
+		}
+		f.items = append(f.items, item)
+	}
+
+	return f, nil
+}
+
+// atomDocument is the parse-side view of an Atom 1.0 document. Its fields
+// are qualified with the Atom namespace so that extension elements sharing
+// their local names, such as media:title or media:content, are ignored.
+type atomDocument struct {
+	Title    string           `xml:"http://www.w3.org/2005/Atom title"`
+	Subtitle string           `xml:"http://www.w3.org/2005/Atom subtitle"`
+	Link     []atomParseLink  `xml:"http://www.w3.org/2005/Atom link"`
+	Updated  string           `xml:"http://www.w3.org/2005/Atom updated"`
+	Rights   string           `xml:"http://www.w3.org/2005/Atom rights"`
+	Author   *atomParseAuthor `xml:"http://www.w3.org/2005/Atom author"`
+	Entries  []atomParseEntry `xml:"http://www.w3.org/2005/Atom entry"`
+}
+
+type atomParseEntry struct {
+	Title     string           `xml:"http://www.w3.org/2005/Atom title"`
+	ID        string           `xml:"http://www.w3.org/2005/Atom id"`
+	Link      []atomParseLink  `xml:"http://www.w3.org/2005/Atom link"`
+	Updated   string           `xml:"http://www.w3.org/2005/Atom updated"`
+	Published string           `xml:"http://www.w3.org/2005/Atom published"`
+	Summary   string           `xml:"http://www.w3.org/2005/Atom summary"`
+	Content   *AtomContent     `xml:"http://www.w3.org/2005/Atom content"`
+	Author    *atomParseAuthor `xml:"http://www.w3.org/2005/Atom author"`
+	Category  []AtomCategory   `xml:"http://www.w3.org/2005/Atom category"`
+	Source    *atomParseSource `xml:"http://www.w3.org/2005/Atom source"`
+}
+
+type atomParseSource struct {
+	ID      string          `xml:"http://www.w3.org/2005/Atom id"`
+	Title   string          `xml:"http://www.w3.org/2005/Atom title"`
+	Link    []atomParseLink `xml:"http://www.w3.org/2005/Atom link"`
+	Updated string          `xml:"http://www.w3.org/2005/Atom updated"`
+}
+
+type atomParseAuthor struct {
+	Name  string `xml:"http://www.w3.org/2005/Atom name"`
+	Email string `xml:"http://www.w3.org/2005/Atom email"`
+}
+
+type atomParseLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+// parseAtom converts an Atom 1.0 document into a Feed
+func parseAtom(data []byte) (*Feed, error) {
+	var atom atomDocument
+	if err := newDecoder(data).Decode(&atom); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Atom XML: %w", err)
+	}
+
+	f := New()
+	f.title = strings.TrimSpace(atom.Title)
+	f.description = strings.TrimSpace(atom.Subtitle)
+	f.link = alternateLink(atom.Link)
+	f.copyright = atom.Rights
+	f.lastBuildDate = parseDate(atom.Updated)
+	if atom.Author != nil {
+		f.managingEditor = formatAuthor(atom.Author)
+	}
+
+	for _, entry := range atom.Entries {
+		item := Item{
+			Title:       strings.TrimSpace(entry.Title),
+			Description: entry.Summary,
+			Link:        alternateLink(entry.Link),
+			PubDate:     parseDate(entry.Published),
+			GUID:        strings.TrimSpace(entry.ID),
+		}
+		if item.PubDate.IsZero() {
+			item.PubDate = parseDate(entry.Updated)
+		}
+		if entry.Content != nil {
+			if item.Description == "" {
+				item.Description = entry.Content.Text
+			} else if entry.Content.Text != item.Description {
+				item.Content = entry.Content.Text
+			}
+		}
+		if entry.Author != nil {
+			item.Author = formatAuthor(entry.Author)
+		}
+		for _, cat := range entry.Category {
+			item.Categories = append(item.Categories, cat.Term)
+		}
+		if entry.Source != nil {
+			link, isFeedURL := sourceLink(entry.Source.Link)
+			item.Source = &Source{
+				URL:       link,
+				Value:     entry.Source.Title,
+				ID:        entry.Source.ID,
+				Updated:   parseDate(entry.Source.Updated),
+				IsFeedURL: isFeedURL,
+			}
+		}
+		f.items = append(f.items, item)
+	}
+
+	return f, nil
+}
+
+// alternateLink picks the alternate link from a list of Atom links,
+// falling back to the first link
+func alternateLink(links []atomParseLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	if len(links) > 0 {
+		return links[0].Href
+	}
+	return ""
+}
+
+// sourceLink picks the link of an Atom source like alternateLink, and
+// reports whether the link picked is the rel="self" link of the source feed
+func sourceLink(links []atomParseLink) (string, bool) {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href, false
+		}
+	}
+	for _, link := range links {
+		if link.Rel == "self" {
+			return link.Href, true
+		}
+	}
+	return alternateLink(links), false
+}
+
+// formatAuthor turns an Atom author into the RSS "email (name)" form
+func formatAuthor(author *atomParseAuthor) string {
+	switch {
+	case author.Email != "" && author.Name != "":
+		return author.Email + " (" + author.Name + ")"
+	case author.Email != "":
+		return author.Email
+	default:
+		return author.Name
+	}
+}
+
+// dateLayouts are the date formats accepted when parsing feeds
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339Nano,
+	time.RFC3339,
+	time.RFC822Z,
+	time.RFC822,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// parseDate parses an RSS or Atom date, returning the zero time when the
+// value is empty or not in a recognised format
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,314 @@
+package feed
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRSSRoundTrip(t *testing.T) {
+	pub := time.Date(2025, 1, 2, 15, 4, 0, 0, time.UTC)
+
+	f := New()
+	f.SetTitle("Test Feed").
+		SetDescription("Test Description").
+		SetLink("https://example.com").
+		SetTTL(30).
+		SetSkipHours(0, 1, 2).
+		SetSkipDays("Saturday", "Sunday").
+		SetImage(Image{URL: "https://example.com/logo.png", Title: "Logo", Link: "https://example.com"})
+	f.AddItem(Item{
+		Title:       "Item",
+		Description: "<p>Body</p>",
+		Link:        "https://example.com/item",
+		GUID:        "item-1",
+		PubDate:     pub,
+		Categories:  []string{"go", "news"},
+		Enclosure:   &Enclosure{URL: "https://example.com/a.mp3", Length: "1024", Type: "audio/mpeg"},
+		Source:      &Source{URL: "https://other.example.com/feed.xml", Value: "Other"},
+	})
+
+	rss, err := f.RSS()
+	if err != nil {
+		t.Fatalf("RSS generation failed: %v", err)
+	}
+
+	parsed, err := Parse(bytes.NewReader(rss))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if parsed.GetTitle() != "Test Feed" || parsed.GetLink() != "https://example.com" || parsed.GetTTL() != 30 {
+		t.Errorf("Unexpected channel metadata: %q %q %d", parsed.GetTitle(), parsed.GetLink(), parsed.GetTTL())
+	}
+	if len(parsed.GetSkipHours()) != 3 || len(parsed.GetSkipDays()) != 2 {
+		t.Errorf("Expected skip hours and days, got %v %v", parsed.GetSkipHours(), parsed.GetSkipDays())
+	}
+	if parsed.GetImage() == nil || parsed.GetImage().URL != "https://example.com/logo.png" {
+		t.Error("Expected channel image to be parsed")
+	}
+
+	items := parsed.GetItems()
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+	item := items[0]
+	if item.Title != "Item" || item.Description != "<p>Body</p>" || item.GUID != "item-1" {
+		t.Errorf("Unexpected item: %+v", item)
+	}
+	if !item.PubDate.Equal(pub) {
+		t.Errorf("Expected pubDate %v, got %v", pub, item.PubDate)
+	}
+	if len(item.Categories) != 2 {
+		t.Errorf("Expected 2 categories, got %v", item.Categories)
+	}
+	if item.Enclosure == nil || item.Enclosure.Type != "audio/mpeg" {
+		t.Error("Expected enclosure to be parsed")
+	}
+	if item.Source == nil || item.Source.Value != "Other" {
+		t.Error("Expected source to be parsed")
+	}
+}
+
+func TestParseAtomRoundTrip(t *testing.T) {
+	pub := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	f := New()
+	f.SetTitle("Test Feed").
+		SetDescription("Test Description").
+		SetLink("https://example.com").
+		SetManagingEditor("editor@example.com (Editor)")
+	f.AddItem(Item{
+		Title:       "Entry",
+		Description: "Summary",
+		Link:        "https://example.com/entry",
+		GUID:        "urn:entry:1",
+		Author:      "Jane Doe",
+		PubDate:     pub,
+		Categories:  []string{"go"},
+	})
+
+	atom, err := f.Atom()
+	if err != nil {
+		t.Fatalf("Atom generation failed: %v", err)
+	}
+
+	parsed, err := Parse(bytes.NewReader(atom))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if parsed.GetTitle() != "Test Feed" || parsed.GetDescription() != "Test Description" {
+		t.Errorf("Unexpected feed metadata: %q %q", parsed.GetTitle(), parsed.GetDescription())
+	}
+	if parsed.GetLink() != "https://example.com" {
+		t.Errorf("Expected alternate link, got %q", parsed.GetLink())
+	}
+	if parsed.GetManagingEditor() != "editor@example.com (Editor)" {
+		t.Errorf("Unexpected author: %q", parsed.GetManagingEditor())
+	}
+
+	items := parsed.GetItems()
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+	item := items[0]
+	if item.Title != "Entry" || item.Link != "https://example.com/entry" || item.GUID != "urn:entry:1" {
+		t.Errorf("Unexpected entry: %+v", item)
+	}
+	if item.Author != "Jane Doe" || !item.PubDate.Equal(pub) {
+		t.Errorf("Unexpected entry author or date: %q %v", item.Author, item.PubDate)
+	}
+}
+
+func TestParseAtomWithMedia(t *testing.T) {
+	f := New().
+		SetTitle("Channel").
+		SetDescription("Posts").
+		SetLink("https://example.com/").
+		SetMedia(MediaMetadata{Title: &MediaText{Text: "chan media title"}})
+	f.AddItem(Item{
+		Title:       "Entry",
+		Description: "Summary",
+		Content:     "<p>Body</p>",
+		Link:        "https://example.com/entry",
+		PubDate:     time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC),
+		Media: &Media{
+			Contents:      []MediaContent{{URL: "https://example.com/video.mp4", Type: "video/mp4"}},
+			MediaMetadata: MediaMetadata{Title: &MediaText{Text: "item media title"}},
+		},
+	})
+
+	atom, err := f.Atom()
+	if err != nil {
+		t.Fatalf("Atom generation failed: %v", err)
+	}
+	parsed, err := Parse(bytes.NewReader(atom))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if parsed.GetTitle() != "Channel" {
+		t.Errorf("Expected the Atom feed title, got %q", parsed.GetTitle())
+	}
+	item := parsed.GetItems()[0]
+	if item.Title != "Entry" || item.Link != "https://example.com/entry" {
+		t.Errorf("Expected the Atom entry title and link, got %q and %q", item.Title, item.Link)
+	}
+	if item.Description != "Summary" || item.Content != "<p>Body</p>" {
+		t.Errorf("Expected the Atom summary and content, got %q and %q", item.Description, item.Content)
+	}
+}
+
+func TestParseAtomSourceRoundTrip(t *testing.T) {
+	f := New().SetTitle("Planet").SetDescription("All the blogs").SetLink("https://planet.example.com")
+	f.AddItem(Item{
+		Title:   "Feed source",
+		Link:    "https://example.com/one",
+		PubDate: time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC),
+		Source:  &Source{URL: "https://alice.example.com/feed.xml", Value: "Alice", IsFeedURL: true},
+	})
+	f.AddItem(Item{
+		Title:   "Site source",
+		Link:    "https://example.com/two",
+		PubDate: time.Date(2025, 1, 1, 15, 4, 5, 0, time.UTC),
+		Source:  &Source{URL: "https://bob.example.com", Value: "Bob"},
+	})
+
+	atom, err := f.Atom()
+	if err != nil {
+		t.Fatalf("Atom generation failed: %v", err)
+	}
+	parsed, err := Parse(bytes.NewReader(atom))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	items := parsed.GetItems()
+	if src := items[0].Source; src == nil || src.URL != "https://alice.example.com/feed.xml" || !src.IsFeedURL {
+		t.Errorf("Expected a rel=self source to be parsed as the feed URL, got %+v", src)
+	}
+	if src := items[1].Source; src == nil || src.URL != "https://bob.example.com" || src.IsFeedURL {
+		t.Errorf("Expected a rel=alternate source not to be parsed as the feed URL, got %+v", src)
+	}
+
+	again, err := parsed.Atom()
+	if err != nil {
+		t.Fatalf("Atom generation failed: %v", err)
+	}
+	if !strings.Contains(string(again), `<link href="https://alice.example.com/feed.xml" rel="self"></link>`) {
+		t.Errorf("Expected the source feed URL to be rendered as self again, got:\n%s", again)
+	}
+}
+
+func TestParseRSSExtensionElements(t *testing.T) {
+	data := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd" xmlns:media="http://search.yahoo.com/mrss/">
+  <channel>
+    <itunes:title>Show</itunes:title>
+    <title>Example</title>
+    <link>https://example.com/</link>
+    <atom:link href="https://example.com/feed.xml" rel="self" type="application/rss+xml"/>
+    <description>Posts</description>
+    <itunes:image href="https://example.com/artwork.jpg"/>
+    <image>
+      <url>https://example.com/logo.png</url>
+      <title>Example</title>
+      <link>https://example.com/</link>
+    </image>
+    <item>
+      <title>Post</title>
+      <media:title>Media title</media:title>
+      <link>https://example.com/post</link>
+      <atom:link href="https://example.com/post.json" rel="alternate"/>
+      <itunes:author>Jane</itunes:author>
+      <author>jane@example.com (Jane Doe)</author>
+      <category>go</category>
+      <media:category>tech</media:category>
+    </item>
+  </channel>
+</rss>`
+
+	f, err := Parse(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if f.GetTitle() != "Example" || f.GetLink() != "https://example.com/" {
+		t.Errorf("Expected the RSS title and link, got %q and %q", f.GetTitle(), f.GetLink())
+	}
+	if f.GetImage() == nil || f.GetImage().URL != "https://example.com/logo.png" {
+		t.Errorf("Expected the RSS image, got %+v", f.GetImage())
+	}
+
+	item := f.GetItems()[0]
+	if item.Title != "Post" || item.Link != "https://example.com/post" || item.Author != "jane@example.com (Jane Doe)" {
+		t.Errorf("Expected the RSS item elements, got %+v", item)
+	}
+	if len(item.Categories) != 1 || item.Categories[0] != "go" {
+		t.Errorf("Expected only RSS categories, got %v", item.Categories)
+	}
+}
+
+func TestParseLegacyCharsets(t *testing.T) {
+	rss := "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n" +
+		"<rss version=\"2.0\"><channel><title>Caf\xe9</title><link>https://example.com/</link><description>d</description>" +
+		"<item><title>\x93Quoted\x94 \x80 10</title></item></channel></rss>"
+	f, err := Parse(strings.NewReader(rss))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if f.GetTitle() != "Café" {
+		t.Errorf("Expected Latin-1 title to be decoded, got %q", f.GetTitle())
+	}
+	if got := f.GetItems()[0].Title; got != "\u201cQuoted\u201d \u20ac 10" {
+		t.Errorf("Expected windows-1252 characters to be decoded, got %q", got)
+	}
+
+	atom := "<?xml version=\"1.0\" encoding=\"windows-1252\"?>\n" +
+		"<feed xmlns=\"http://www.w3.org/2005/Atom\"><title>Na\xefve \x96 notes</title></feed>"
+	f, err = Parse(strings.NewReader(atom))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if f.GetTitle() != "Naïve \u2013 notes" {
+		t.Errorf("Expected windows-1252 title to be decoded, got %q", f.GetTitle())
+	}
+
+	_, err = Parse(strings.NewReader(`<?xml version="1.0" encoding="KOI8-R"?><rss version="2.0"><channel></channel></rss>`))
+	if !errors.Is(err, ErrUnsupportedCharset) {
+		t.Errorf("Expected ErrUnsupportedCharset, got %v", err)
+	}
+}
+
+func TestParseUnknownFormat(t *testing.T) {
+	_, err := Parse(strings.NewReader(`<?xml version="1.0"?><html></html>`))
+	if !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Expected ErrUnknownFormat, got %v", err)
+	}
+
+	_, err = Parse(strings.NewReader(""))
+	if !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Expected ErrUnknownFormat for empty input, got %v", err)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	want := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := []string{
+		"Thu, 02 Jan 2025 15:04:05 +0000",
+		"Thu, 02 Jan 2025 15:04:05 UTC",
+		"Thu, 2 Jan 2025 15:04:05 +0000",
+		"2025-01-02T15:04:05Z",
+		"2025-01-02T15:04:05",
+	}
+	for _, s := range tests {
+		if got := parseDate(s); !got.Equal(want) {
+			t.Errorf("parseDate(%q) = %v, want %v", s, got, want)
+		}
+	}
+
+	if !parseDate("not a date").IsZero() {
+		t.Error("Invalid dates should parse to the zero time")
+	}
+}
@@ -0,0 +1,439 @@
+package feed
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// podcastNamespace is the namespace of the Podcasting 2.0 tags
+const podcastNamespace = "https://podcastindex.org/namespace/1.0"
+
+// podcastGUIDNamespace is the UUID namespace for podcast:guid values
+var podcastGUIDNamespace = [16]byte{0xea, 0xd4, 0xc2, 0x36, 0xbf, 0x58, 0x58, 0xc6, 0xa2, 0xc6, 0xa6, 0xb2, 0x8d, 0x12, 0x8c, 0xb6}
+
+// Live item statuses
+const (
+	PodcastLivePending = "pending"
+	PodcastLiveLive    = "live"
+	PodcastLiveEnded   = "ended"
+)
+
+// PodcastChannel holds the channel-level tags of the Podcasting 2.0
+// namespace (podcastindex.org), rendered in RSS with the podcast: prefix
+type PodcastChannel struct {
+	GUID      string            `xml:"podcast:guid,omitempty"`
+	Locked    *PodcastLocked    `xml:"podcast:locked,omitempty"`
+	Funding   []PodcastFunding  `xml:"podcast:funding,omitempty"`
+	Persons   []PodcastPerson   `xml:"podcast:person,omitempty"`
+	Location  *PodcastLocation  `xml:"podcast:location,omitempty"`
+	Value     *PodcastValue     `xml:"podcast:value,omitempty"`
+	LiveItems []PodcastLiveItem `xml:"podcast:liveItem,omitempty"`
+}
+
+// PodcastItem holds the item-level tags of the Podcasting 2.0 namespace
+type PodcastItem struct {
+	Transcripts         []PodcastTranscript         `xml:"podcast:transcript,omitempty"`
+	Chapters            *PodcastChapters            `xml:"podcast:chapters,omitempty"`
+	Season              *PodcastSeason              `xml:"podcast:season,omitempty"`
+	Episode             *PodcastEpisode             `xml:"podcast:episode,omitempty"`
+	Soundbites          []PodcastSoundbite          `xml:"podcast:soundbite,omitempty"`
+	Persons             []PodcastPerson             `xml:"podcast:person,omitempty"`
+	Location            *PodcastLocation            `xml:"podcast:location,omitempty"`
+	Value               *PodcastValue               `xml:"podcast:value,omitempty"`
+	AlternateEnclosures []PodcastAlternateEnclosure `xml:"podcast:alternateEnclosure,omitempty"`
+}
+
+// PodcastTranscript links to a transcript or closed captions file
+type PodcastTranscript struct {
+	URL      string `xml:"url,attr"`
+	Type     string `xml:"type,attr"`
+	Language string `xml:"language,attr,omitempty"`
+	Rel      string `xml:"rel,attr,omitempty"` // "captions" for closed captions
+}
+
+// PodcastChapters links to a chapters file
+type PodcastChapters struct {
+	URL  string `xml:"url,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// PodcastLocked tells podcast platforms whether they may import the feed
+type PodcastLocked struct {
+	Owner  string // email address of the owner, for verifying moves
+	Locked bool
+}
+
+// MarshalXML renders the locked flag as "yes" or "no"
+func (l PodcastLocked) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if l.Owner != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "owner"}, Value: l.Owner})
+	}
+	value := "no"
+	if l.Locked {
+		value = "yes"
+	}
+	return e.EncodeElement(value, start)
+}
+
+// PodcastFunding links to a donation or support page
+type PodcastFunding struct {
+	URL  string `xml:"url,attr"`
+	Text string `xml:",chardata"`
+}
+
+// PodcastPerson credits a person of interest to the podcast or episode
+type PodcastPerson struct {
+	Name  string `xml:",chardata"`
+	Role  string `xml:"role,attr,omitempty"`
+	Group string `xml:"group,attr,omitempty"`
+	Img   string `xml:"img,attr,omitempty"`
+	Href  string `xml:"href,attr,omitempty"`
+}
+
+// PodcastLocation describes the location the podcast or episode is about
+type PodcastLocation struct {
+	Name string `xml:",chardata"`
+	Geo  string `xml:"geo,attr,omitempty"` // geo URI, e.g. "geo:30.2672,97.7431"
+	OSM  string `xml:"osm,attr,omitempty"` // OpenStreetMap type and ID, e.g. "R113314"
+}
+
+// PodcastSeason numbers and optionally names the season of an episode
+type PodcastSeason struct {
+	Number int    `xml:",chardata"`
+	Name   string `xml:"name,attr,omitempty"`
+}
+
+// PodcastEpisode numbers an episode, optionally with a display label
+type PodcastEpisode struct {
+	Number  float64 `xml:",chardata"`
+	Display string  `xml:"display,attr,omitempty"`
+}
+
+// PodcastSoundbite points to a short, shareable part of an episode.
+// Times are in seconds.
+type PodcastSoundbite struct {
+	StartTime float64 `xml:"startTime,attr"`
+	Duration  float64 `xml:"duration,attr"`
+	Title     string  `xml:",chardata"`
+}
+
+// PodcastValue describes how listeners can send value, e.g. over Lightning
+type PodcastValue struct {
+	Type       string                  `xml:"type,attr"`
+	Method     string                  `xml:"method,attr"`
+	Suggested  string                  `xml:"suggested,attr,omitempty"`
+	Recipients []PodcastValueRecipient `xml:"podcast:valueRecipient"`
+}
+
+// PodcastValueRecipient receives a share of the value sent
+type PodcastValueRecipient struct {
+	Name        string `xml:"name,attr,omitempty"`
+	CustomKey   string `xml:"customKey,attr,omitempty"`
+	CustomValue string `xml:"customValue,attr,omitempty"`
+	Type        string `xml:"type,attr"`
+	Address     string `xml:"address,attr"`
+	Split       int    `xml:"split,attr"`
+	Fee         bool   `xml:"fee,attr,omitempty"`
+}
+
+// PodcastAlternateEnclosure offers another version of the episode media,
+// e.g. another bitrate, language or a video version
+type PodcastAlternateEnclosure struct {
+	Type      string            `xml:"type,attr"`
+	Length    int64             `xml:"length,attr,omitempty"`
+	Bitrate   float64           `xml:"bitrate,attr,omitempty"`
+	Height    int               `xml:"height,attr,omitempty"`
+	Lang      string            `xml:"lang,attr,omitempty"`
+	Title     string            `xml:"title,attr,omitempty"`
+	Rel       string            `xml:"rel,attr,omitempty"`
+	Codecs    string            `xml:"codecs,attr,omitempty"`
+	Default   bool              `xml:"default,attr,omitempty"`
+	Sources   []PodcastSource   `xml:"podcast:source"`
+	Integrity *PodcastIntegrity `xml:"podcast:integrity,omitempty"`
+}
+
+// PodcastSource is a URI the alternate enclosure can be fetched from
+type PodcastSource struct {
+	URI         string `xml:"uri,attr"`
+	ContentType string `xml:"contentType,attr,omitempty"`
+}
+
+// PodcastIntegrity lets clients verify an alternate enclosure's media
+type PodcastIntegrity struct {
+	Type  string `xml:"type,attr"` // "sri" or "pgp-signature"
+	Value string `xml:"value,attr"`
+}
+
+// PodcastLiveItem announces a live stream. The item is rendered like a
+// regular RSS item, including its podcast tags.
+type PodcastLiveItem struct {
+	Status string // PodcastLivePending, PodcastLiveLive or PodcastLiveEnded
+	Start  time.Time
+	End    time.Time
+	Item   Item
+}
+
+// MarshalXML renders the live item with its status and times as attributes
+func (l PodcastLiveItem) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Attr = append(start.Attr,
+		xml.Attr{Name: xml.Name{Local: "status"}, Value: l.Status},
+		xml.Attr{Name: xml.Name{Local: "start"}, Value: formatRFC3339Date(l.Start)},
+	)
+	if !l.End.IsZero() {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "end"}, Value: formatRFC3339Date(l.End)})
+	}
+	return e.EncodeElement(rssItem(l.Item), start)
+}
+
+// SetPodcast sets the Podcasting 2.0 channel tags
+func (f *Feed) SetPodcast(podcast PodcastChannel) *Feed {
+	f.podcast = &podcast
+	return f
+}
+
+// GetPodcast returns the Podcasting 2.0 channel tags
+func (f *Feed) GetPodcast() *PodcastChannel {
+	return f.podcast
+}
+
+// PodcastGUID returns the podcast:guid for a feed URL: a version 5 UUID of
+// the URL without its scheme and trailing slashes, as the namespace specifies
+func PodcastGUID(feedURL string) string {
+	if _, rest, ok := strings.Cut(feedURL, "://"); ok {
+		feedURL = rest
+	}
+	feedURL = strings.TrimRight(feedURL, "/")
+
+	h := sha1.New()
+	h.Write(podcastGUIDNamespace[:])
+	h.Write([]byte(feedURL))
+	sum := h.Sum(nil)
+	sum[6] = sum[6]&0x0f | 0x50 // version 5
+	sum[8] = sum[8]&0x3f | 0x80 // RFC 4122 variant
+
+	s := hex.EncodeToString(sum[:16])
+	return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+// usesPodcast reports whether the feed has any Podcasting 2.0 tags
+func (f *Feed) usesPodcast() bool {
+	if f.podcast != nil {
+		return true
+	}
+	for _, item := range f.items {
+		if item.Podcast != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePodcast checks the attributes the Podcasting 2.0 namespace requires
+func (f *Feed) validatePodcast() error {
+	var errs []error
+	if p := f.podcast; p != nil {
+		if p.GUID != "" && !isUUID(p.GUID) {
+			errs = append(errs, podcastError("guid", "must be a UUID, got %q", p.GUID))
+		}
+		for _, funding := range p.Funding {
+			if funding.URL == "" {
+				errs = append(errs, podcastError("funding", "requires a url"))
+			}
+		}
+		errs = append(errs, validatePersons(p.Persons)...)
+		errs = append(errs, validateLocation(p.Location)...)
+		errs = append(errs, validateValue(p.Value)...)
+		for _, live := range p.LiveItems {
+			switch live.Status {
+			case PodcastLivePending, PodcastLiveLive, PodcastLiveEnded:
+			default:
+				errs = append(errs, podcastError("liveItem", "status must be pending, live or ended, got %q", live.Status))
+			}
+			if live.Start.IsZero() || live.End.IsZero() {
+				errs = append(errs, podcastError("liveItem", "requires start and end"))
+			} else if live.End.Before(live.Start) {
+				errs = append(errs, podcastError("liveItem", "ends before it starts"))
+			}
+			errs = append(errs, validatePodcastItem(live.Item.Podcast)...)
+		}
+	}
+	for _, item := range f.items {
+		errs = append(errs, validatePodcastItem(item.Podcast)...)
+	}
+	return errors.Join(errs...)
+}
+
+func validatePodcastItem(p *PodcastItem) []error {
+	if p == nil {
+		return nil
+	}
+	var errs []error
+	for _, t := range p.Transcripts {
+		if t.URL == "" || t.Type == "" {
+			errs = append(errs, podcastError("transcript", "requires url and type"))
+		}
+	}
+	if p.Chapters != nil && (p.Chapters.URL == "" || p.Chapters.Type == "") {
+		errs = append(errs, podcastError("chapters", "requires url and type"))
+	}
+	for _, s := range p.Soundbites {
+		if s.StartTime < 0 || s.Duration <= 0 {
+			errs = append(errs, podcastError("soundbite", "requires a startTime and a positive duration"))
+		}
+	}
+	errs = append(errs, validatePersons(p.Persons)...)
+	errs = append(errs, validateLocation(p.Location)...)
+	errs = append(errs, validateValue(p.Value)...)
+	for _, alt := range p.AlternateEnclosures {
+		if alt.Type == "" {
+			errs = append(errs, podcastError("alternateEnclosure", "requires a type"))
+		}
+		if len(alt.Sources) == 0 {
+			errs = append(errs, podcastError("alternateEnclosure", "requires at least one source"))
+		}
+		for _, src := range alt.Sources {
+			if src.URI == "" {
+				errs = append(errs, podcastError("source", "requires a uri"))
+			}
+		}
+		if i := alt.Integrity; i != nil && (i.Type != "sri" && i.Type != "pgp-signature" || i.Value == "") {
+			errs = append(errs, podcastError("integrity", "requires a type of sri or pgp-signature and a value"))
+		}
+	}
+	return errs
+}
+
+func validatePersons(persons []PodcastPerson) []error {
+	var errs []error
+	for _, p := range persons {
+		if strings.TrimSpace(p.Name) == "" {
+			errs = append(errs, podcastError("person", "requires a name"))
+		}
+	}
+	return errs
+}
+
+func validateLocation(l *PodcastLocation) []error {
+	if l != nil && strings.TrimSpace(l.Name) == "" {
+		return []error{podcastError("location", "requires a name")}
+	}
+	return nil
+}
+
+func validateValue(v *PodcastValue) []error {
+	if v == nil {
+		return nil
+	}
+	var errs []error
+	if v.Type == "" || v.Method == "" {
+		errs = append(errs, podcastError("value", "requires type and method"))
+	}
+	if len(v.Recipients) == 0 {
+		errs = append(errs, podcastError("value", "requires at least one valueRecipient"))
+	}
+	for _, r := range v.Recipients {
+		if r.Type == "" || r.Address == "" || r.Split <= 0 {
+			errs = append(errs, podcastError("valueRecipient", "requires type, address and a positive split"))
+		}
+	}
+	return errs
+}
+
+func podcastError(tag, format string, args ...any) error {
+	return fmt.Errorf("%w: podcast:%s %s", ErrInvalidPodcast, tag, fmt.Sprintf(format, args...))
+}
+
+// isUUID reports whether s is a UUID in its canonical textual form
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, c := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// clone returns a deep copy of the channel tags
+func (p *PodcastChannel) clone() *PodcastChannel {
+	clone := *p
+	if p.Locked != nil {
+		locked := *p.Locked
+		clone.Locked = &locked
+	}
+	clone.Funding = append([]PodcastFunding(nil), p.Funding...)
+	clone.Persons = append([]PodcastPerson(nil), p.Persons...)
+	if p.Location != nil {
+		location := *p.Location
+		clone.Location = &location
+	}
+	clone.Value = p.Value.clone()
+	if p.LiveItems != nil {
+		clone.LiveItems = make([]PodcastLiveItem, len(p.LiveItems))
+		for i, live := range p.LiveItems {
+			live.Item = live.Item.Clone()
+			clone.LiveItems[i] = live
+		}
+	}
+	return &clone
+}
+
+// clone returns a deep copy of the item tags
+func (p *PodcastItem) clone() *PodcastItem {
+	clone := *p
+	clone.Transcripts = append([]PodcastTranscript(nil), p.Transcripts...)
+	if p.Chapters != nil {
+		chapters := *p.Chapters
+		clone.Chapters = &chapters
+	}
+	if p.Season != nil {
+		season := *p.Season
+		clone.Season = &season
+	}
+	if p.Episode != nil {
+		episode := *p.Episode
+		clone.Episode = &episode
+	}
+	clone.Soundbites = append([]PodcastSoundbite(nil), p.Soundbites...)
+	clone.Persons = append([]PodcastPerson(nil), p.Persons...)
+	if p.Location != nil {
+		location := *p.Location
+		clone.Location = &location
+	}
+	clone.Value = p.Value.clone()
+	if p.AlternateEnclosures != nil {
+		clone.AlternateEnclosures = make([]PodcastAlternateEnclosure, len(p.AlternateEnclosures))
+		for i, alt := range p.AlternateEnclosures {
+			alt.Sources = append([]PodcastSource(nil), alt.Sources...)
+			if alt.Integrity != nil {
+				integrity := *alt.Integrity
+				alt.Integrity = &integrity
+			}
+			clone.AlternateEnclosures[i] = alt
+		}
+	}
+	return &clone
+}
+
+func (v *PodcastValue) clone() *PodcastValue {
+	if v == nil {
+		return nil
+	}
+	clone := *v
+	clone.Recipients = append([]PodcastValueRecipient(nil), v.Recipients...)
+	return &clone
+}
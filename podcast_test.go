@@ -0,0 +1,265 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.rumenx.com/feed/sanitize"
+)
+
+// podcastTestFeed uses the examples from the Podcasting 2.0 namespace spec
+func podcastTestFeed() *Feed {
+	return New().
+		SetTitle("Podcasting 2.0 Namespace Example").
+		SetDescription("This is a fake show that exists only as an example of the podcast namespace tag usage.").
+		SetLink("http://example.com/podcast").
+		SetPodcast(PodcastChannel{
+			GUID:     PodcastGUID("https://mp3s.nashownotes.com/pc20rss.xml"),
+			Locked:   &PodcastLocked{Owner: "podcastowner@example.com", Locked: true},
+			Funding:  []PodcastFunding{{URL: "https://www.example.com/donations", Text: "Support the show!"}},
+			Persons:  []PodcastPerson{{Name: "Adam Curry", Role: "host", Href: "https://example.com/adamcurry", Img: "http://example.com/images/adamcurry.jpg"}},
+			Location: &PodcastLocation{Name: "Austin, TX", Geo: "geo:30.2672,97.7431", OSM: "R113314"},
+			Value: &PodcastValue{
+				Type:   "lightning",
+				Method: "keysend",
+				Recipients: []PodcastValueRecipient{
+					{Name: "Alice (Podcaster)", Type: "node", Address: "02d5c1bf8b940dc9cadca86d1b0a3c37fbe39cee4c7e839e33bef9174531d27f52", Split: 40},
+					{Name: "Hosting Provider", Type: "node", Address: "03ae9f91a0cb8ff43840e3c322c4c61f019d8c1c3cea15a25cfc425ac605e61a4a", Split: 5, Fee: true},
+				},
+			},
+			LiveItems: []PodcastLiveItem{{
+				Status: PodcastLiveLive,
+				Start:  time.Date(2021, 9, 26, 7, 30, 0, 0, time.FixedZone("", -6*3600)),
+				End:    time.Date(2021, 9, 26, 9, 30, 0, 0, time.FixedZone("", -6*3600)),
+				Item:   Item{Title: "Podcasting 2.0 Live Show", GUID: "e32b4890-983b-4ce5-8b46-f2d6bc1d8819"},
+			}},
+		}).
+		AddItem(Item{
+			Title: "Episode 3 - The Future",
+			Link:  "http://example.com/podcast-1/episode-3",
+			GUID:  "hicb9a7s",
+			Enclosure: &Enclosure{
+				URL:    "https://example.com/file-03.mp3",
+				Length: "43200000",
+				Type:   "audio/mpeg",
+			},
+			Podcast: &PodcastItem{
+				Transcripts: []PodcastTranscript{{URL: "https://example.com/episode1/transcript.srt", Type: "application/srt", Rel: "captions"}},
+				Chapters:    &PodcastChapters{URL: "https://example.com/episode1/chapters.json", Type: "application/json+chapters"},
+				Season:      &PodcastSeason{Number: 1, Name: "Race for the Whitehouse 2020"},
+				Episode:     &PodcastEpisode{Number: 315.5, Display: "Ch.3"},
+				Soundbites:  []PodcastSoundbite{{StartTime: 73, Duration: 60}, {StartTime: 1234.5, Duration: 42.25, Title: "Why the Podcast Namespace Matters"}},
+				AlternateEnclosures: []PodcastAlternateEnclosure{{
+					Type:    "audio/opus",
+					Length:  32400000,
+					Bitrate: 96000,
+					Title:   "High quality",
+					Sources: []PodcastSource{
+						{URI: "https://example.com/file-high.opus"},
+						{URI: "ipfs://QmdwGqd3d2gFPGeJNLLCshdiPert45fMu84552Y4XHTy4y"},
+					},
+					Integrity: &PodcastIntegrity{Type: "sri", Value: "sha384-ExVqijgYHm15PqQqdXfW95x+Rs6C+d6E/ICxyQOeFevnxNLR/wtJNrNYTjIysUBo"},
+				}},
+			},
+		})
+}
+
+func TestPodcastRSS(t *testing.T) {
+	rss, err := podcastTestFeed().RSS()
+	if err != nil {
+		t.Fatalf("RSS failed: %v", err)
+	}
+	out := string(rss)
+
+	for _, want := range []string{
+		`<rss version="2.0" xmlns:podcast="https://podcastindex.org/namespace/1.0">`,
+		`<podcast:guid>917393e3-1b1e-5cef-ace4-edaa54e1f810</podcast:guid>`,
+		`<podcast:locked owner="podcastowner@example.com">yes</podcast:locked>`,
+		`<podcast:funding url="https://www.example.com/donations">Support the show!</podcast:funding>`,
+		`<podcast:person role="host" img="http://example.com/images/adamcurry.jpg" href="https://example.com/adamcurry">Adam Curry</podcast:person>`,
+		`<podcast:location geo="geo:30.2672,97.7431" osm="R113314">Austin, TX</podcast:location>`,
+		`<podcast:value type="lightning" method="keysend">`,
+		`<podcast:valueRecipient name="Hosting Provider" type="node" address="03ae9f91a0cb8ff43840e3c322c4c61f019d8c1c3cea15a25cfc425ac605e61a4a" split="5" fee="true"></podcast:valueRecipient>`,
+		`<podcast:liveItem status="live" start="2021-09-26T07:30:00-06:00" end="2021-09-26T09:30:00-06:00">`,
+		`<title>Podcasting 2.0 Live Show</title>`,
+		`<podcast:transcript url="https://example.com/episode1/transcript.srt" type="application/srt" rel="captions"></podcast:transcript>`,
+		`<podcast:chapters url="https://example.com/episode1/chapters.json" type="application/json+chapters"></podcast:chapters>`,
+		`<podcast:season name="Race for the Whitehouse 2020">1</podcast:season>`,
+		`<podcast:episode display="Ch.3">315.5</podcast:episode>`,
+		`<podcast:soundbite startTime="73" duration="60"></podcast:soundbite>`,
+		`<podcast:soundbite startTime="1234.5" duration="42.25">Why the Podcast Namespace Matters</podcast:soundbite>`,
+		`<podcast:alternateEnclosure type="audio/opus" length="32400000" bitrate="96000" title="High quality">`,
+		`<podcast:source uri="ipfs://QmdwGqd3d2gFPGeJNLLCshdiPert45fMu84552Y4XHTy4y"></podcast:source>`,
+		`<podcast:integrity type="sri" value="sha384-ExVqijgYHm15PqQqdXfW95x+Rs6C+d6E/ICxyQOeFevnxNLR/wtJNrNYTjIysUBo"></podcast:integrity>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected RSS to contain %s", want)
+		}
+	}
+	if strings.Index(out, "<podcast:guid>") > strings.Index(out, "<item>") {
+		t.Error("Expected channel podcast tags before the items")
+	}
+
+	plain, _ := New().SetTitle("Plain").SetDescription("No podcast").SetLink("https://example.com").RSS()
+	if strings.Contains(string(plain), "xmlns:podcast") {
+		t.Error("Expected no podcast namespace without podcast tags")
+	}
+}
+
+func TestPodcastWriteRSS(t *testing.T) {
+	var buf bytes.Buffer
+	if err := podcastTestFeed().WriteRSS(context.Background(), &buf, nil); err != nil {
+		t.Fatalf("WriteRSS failed: %v", err)
+	}
+	rss, _ := podcastTestFeed().RSS()
+	if buf.String() != strings.Replace(string(rss), `version="2.0"`, `version="2.0" xmlns:content="`+contentNamespace+`"`, 1) {
+		t.Errorf("Expected streamed output to match RSS, got:\n%s", buf.String())
+	}
+}
+
+func TestPodcastGUID(t *testing.T) {
+	tests := map[string]string{
+		"https://mp3s.nashownotes.com/pc20rss.xml":  "917393e3-1b1e-5cef-ace4-edaa54e1f810",
+		"http://mp3s.nashownotes.com/pc20rss.xml/":  "917393e3-1b1e-5cef-ace4-edaa54e1f810",
+		"podcastindex.org/namespace/1.0":            "0b20eef2-824a-5f6a-bc6b-68f611056e0c",
+		"https://podcastindex.org/namespace/1.0///": "0b20eef2-824a-5f6a-bc6b-68f611056e0c",
+	}
+	for url, want := range tests {
+		if got := PodcastGUID(url); got != want {
+			t.Errorf("PodcastGUID(%q): expected %s, got %s", url, want, got)
+		}
+	}
+}
+
+func TestPodcastValidation(t *testing.T) {
+	if err := podcastTestFeed().Validate(); err != nil {
+		t.Errorf("Expected the spec examples to be valid, got %v", err)
+	}
+
+	f := podcastTestFeed()
+	f.podcast.GUID = "not-a-uuid"
+	f.podcast.Funding[0].URL = ""
+	f.podcast.LiveItems[0].Status = "streaming"
+	f.podcast.Value.Recipients[0].Split = 0
+	p := f.items[0].Podcast
+	p.Transcripts[0].Type = ""
+	p.Chapters.URL = ""
+	p.Soundbites[0].Duration = 0
+	p.Persons = []PodcastPerson{{Role: "guest"}}
+	p.AlternateEnclosures[0].Sources = nil
+	p.AlternateEnclosures[0].Integrity.Type = "md5"
+
+	err := f.Validate()
+	if !errors.Is(err, ErrInvalidPodcast) {
+		t.Fatalf("Expected ErrInvalidPodcast, got %v", err)
+	}
+	for _, want := range []string{
+		`podcast:guid must be a UUID, got "not-a-uuid"`,
+		"podcast:funding requires a url",
+		`podcast:liveItem status must be pending, live or ended, got "streaming"`,
+		"podcast:valueRecipient requires type, address and a positive split",
+		"podcast:transcript requires url and type",
+		"podcast:chapters requires url and type",
+		"podcast:soundbite requires a startTime and a positive duration",
+		"podcast:person requires a name",
+		"podcast:alternateEnclosure requires at least one source",
+		"podcast:integrity requires a type of sri or pgp-signature and a value",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %q, got:\n%v", want, err)
+		}
+	}
+	if _, err := f.RSS(); !errors.Is(err, ErrInvalidPodcast) {
+		t.Errorf("Expected RSS to refuse invalid podcast tags, got %v", err)
+	}
+}
+
+func TestPodcastClone(t *testing.T) {
+	f := podcastTestFeed()
+	clone := f.Clone()
+	clone.podcast.Persons[0].Name = "Dave Jones"
+	clone.podcast.LiveItems[0].Item.Title = "Changed"
+	clone.items[0].Podcast.AlternateEnclosures[0].Sources[0].URI = "https://example.com/other.opus"
+	clone.items[0].Podcast.Season.Number = 2
+
+	if f.podcast.Persons[0].Name != "Adam Curry" || f.podcast.LiveItems[0].Item.Title != "Podcasting 2.0 Live Show" {
+		t.Error("Cloning should deep copy the channel podcast tags")
+	}
+	p := f.items[0].Podcast
+	if p.AlternateEnclosures[0].Sources[0].URI != "https://example.com/file-high.opus" || p.Season.Number != 1 {
+		t.Error("Cloning should deep copy the item podcast tags")
+	}
+}
+
+func TestPodcastLiveItemRenderTransforms(t *testing.T) {
+	f := New().
+		SetTitle("Live").
+		SetDescription("Live shows").
+		SetLink("https://example.com/").
+		SetResolveURLs(true).
+		SetSanitizer(sanitize.Strict()).
+		SetPodcast(PodcastChannel{LiveItems: []PodcastLiveItem{{
+			Status: PodcastLiveLive,
+			Start:  time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+			End:    time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC),
+			Item: Item{
+				Title:       "Live show",
+				Link:        "/live",
+				Description: `Tune in <img src=x onerror=alert(1)>`,
+				Enclosure:   &Enclosure{URL: "/stream.mp3", Type: "audio/mpeg"},
+			},
+		}}})
+	f.AddItem(Item{Title: "Episode", Link: "https://example.com/1", Description: "<b>Hi</b>"})
+
+	rss, err := f.RSS()
+	if err != nil {
+		t.Fatalf("RSS generation failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := f.WriteRSS(context.Background(), &buf, nil); err != nil {
+		t.Fatalf("WriteRSS failed: %v", err)
+	}
+
+	for name, out := range map[string]string{"RSS": string(rss), "WriteRSS": buf.String()} {
+		if strings.Contains(out, "onerror") || strings.Contains(out, "&lt;img") {
+			t.Errorf("%s should sanitize live items, got:\n%s", name, out)
+		}
+		for _, want := range []string{
+			"<link>https://example.com/live</link>",
+			`<enclosure url="https://example.com/stream.mp3"`,
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s should resolve live item URLs, missing %s", name, want)
+			}
+		}
+	}
+	if f.podcast.LiveItems[0].Item.Link != "/live" {
+		t.Error("Rendering should not modify the stored live item")
+	}
+}
+
+func TestPodcastLiveItemContentNamespace(t *testing.T) {
+	f := New().
+		SetTitle("Live").
+		SetDescription("Live shows").
+		SetLink("https://example.com/").
+		SetSanitizer(sanitize.Strict()).
+		SetPodcast(PodcastChannel{LiveItems: []PodcastLiveItem{{
+			Status: PodcastLiveLive,
+			Start:  time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+			End:    time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC),
+			Item:   Item{Title: "Live show", Link: "https://example.com/live", Content: "<script>alert(1)</script>"},
+		}}})
+
+	rss, err := f.RSS()
+	if err != nil {
+		t.Fatalf("RSS generation failed: %v", err)
+	}
+	if strings.Contains(string(rss), "xmlns:content") {
+		t.Errorf("Expected no content namespace once sanitizing empties the live item content, got:\n%s", rss)
+	}
+}
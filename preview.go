@@ -0,0 +1,137 @@
+package feed
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+//go:embed assets/feed.xsl
+var defaultStylesheet []byte
+
+//go:embed assets/preview.html
+var previewHTML string
+
+var previewTemplate = template.Must(template.New("preview").Parse(previewHTML))
+
+// StylesheetContentType is the Content-Type used to serve XSL stylesheets
+const StylesheetContentType = "text/xsl; charset=utf-8"
+
+// DefaultStylesheet returns the built-in XSL stylesheet, which renders RSS
+// and Atom feeds as a readable page with subscribe instructions
+func DefaultStylesheet() []byte {
+	return append([]byte(nil), defaultStylesheet...)
+}
+
+// SetStylesheet sets the URL of an XSL stylesheet referenced from RSS and
+// Atom output through an xml-stylesheet processing instruction, so browsers
+// show a readable page instead of raw XML. Serve DefaultStylesheet at that
+// URL, or your own stylesheet.
+func (f *Feed) SetStylesheet(href string) *Feed {
+	f.stylesheet = href
+	return f
+}
+
+// GetStylesheet returns the URL of the XSL stylesheet
+func (f *Feed) GetStylesheet() string {
+	return f.stylesheet
+}
+
+// xmlPreamble returns the XML declaration followed by the stylesheet
+// processing instruction, when a stylesheet is set
+func (f *Feed) xmlPreamble() []byte {
+	preamble := []byte(xml.Header)
+	if f.stylesheet != "" {
+		preamble = append(preamble, fmt.Sprintf(`<?xml-stylesheet type="text/xsl" href="%s"?>`+"\n", html.EscapeString(f.stylesheet))...)
+	}
+	return preamble
+}
+
+// HTML renders the feed as a standalone HTML page for people who open the
+// feed URL in a browser. feedURL is shown in the subscribe instructions and
+// may be empty.
+func (f *Feed) HTML(feedURL string) ([]byte, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	data := struct {
+		Title       string
+		Description string
+		Link        string
+		Language    string
+		FeedURL     string
+		Items       []Item
+	}{
+		Title:       f.title,
+		Description: f.description,
+		Link:        f.link,
+		Language:    f.language,
+		FeedURL:     feedURL,
+		Items:       f.renderItems(""),
+	}
+
+	var buf bytes.Buffer
+	if err := previewTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render HTML preview: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// HTML renders the snapshot as a standalone HTML page
+func (s *Snapshot) HTML(feedURL string) ([]byte, error) {
+	return s.feed.HTML(feedURL)
+}
+
+// RequestURL returns the absolute URL of a request, such as the feed URL the
+// preview page tells readers to subscribe to. The X-Forwarded-Proto and
+// X-Forwarded-Host headers set by a reverse proxy take precedence over the
+// scheme and host the server received.
+func RequestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := strings.ToLower(forwardedValue(r.Header.Get("X-Forwarded-Proto"))); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	host := r.Host
+	if h := forwardedValue(r.Header.Get("X-Forwarded-Host")); h != "" {
+		host = h
+	}
+	return scheme + "://" + host + r.URL.RequestURI()
+}
+
+// forwardedValue returns the first value of a forwarded header, the one set
+// by the proxy closest to the client
+func forwardedValue(header string) string {
+	first, _, _ := strings.Cut(header, ",")
+	return strings.TrimSpace(first)
+}
+
+// IsBrowserRequest reports whether a request clearly comes from a web
+// browser navigating to the feed, rather than from a feed reader
+func IsBrowserRequest(r *http.Request) bool {
+	return IsBrowser(r.Header.Get("Accept"), r.Header.Get("User-Agent"))
+}
+
+// IsBrowser reports whether the Accept and User-Agent headers clearly
+// identify a web browser: the client must accept HTML, must not ask for a
+// feed media type by name and must identify itself as a browser.
+func IsBrowser(accept, userAgent string) bool {
+	accept = strings.ToLower(accept)
+	if !strings.Contains(accept, "text/html") {
+		return false
+	}
+	for _, t := range []string{"rss", "atom", "application/feed+json"} {
+		if strings.Contains(accept, t) {
+			return false
+		}
+	}
+	return strings.Contains(userAgent, "Mozilla/")
+}
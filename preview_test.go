@@ -0,0 +1,142 @@
+package feed
+
+import (
+	"encoding/xml"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStylesheetInstruction(t *testing.T) {
+	f := newCollectionFeed()
+
+	rss, _ := f.RSS()
+	if strings.Contains(string(rss), "xml-stylesheet") {
+		t.Error("RSS should not reference a stylesheet by default")
+	}
+
+	f.SetStylesheet("/feed.xsl?v=1&x=2")
+	if f.GetStylesheet() != "/feed.xsl?v=1&x=2" {
+		t.Errorf("Unexpected stylesheet: %s", f.GetStylesheet())
+	}
+
+	want := xml.Header + `<?xml-stylesheet type="text/xsl" href="/feed.xsl?v=1&amp;x=2"?>` + "\n"
+
+	rss, err := f.RSS()
+	if err != nil {
+		t.Fatalf("RSS generation failed: %v", err)
+	}
+	if !strings.HasPrefix(string(rss), want) {
+		t.Errorf("RSS should start with the stylesheet instruction, got:\n%s", rss[:120])
+	}
+
+	atom, err := f.Atom()
+	if err != nil {
+		t.Fatalf("Atom generation failed: %v", err)
+	}
+	if !strings.HasPrefix(string(atom), want) {
+		t.Error("Atom should start with the stylesheet instruction")
+	}
+
+	// The output must remain well-formed and parseable
+	if _, err := Parse(strings.NewReader(string(atom))); err != nil {
+		t.Errorf("Output with stylesheet should still parse: %v", err)
+	}
+}
+
+func TestDefaultStylesheet(t *testing.T) {
+	xsl := DefaultStylesheet()
+	if !strings.Contains(string(xsl), "<xsl:stylesheet") {
+		t.Fatal("Default stylesheet should be an XSL document")
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(string(xsl)))
+	for {
+		if _, err := decoder.Token(); err != nil {
+			if err.Error() != "EOF" {
+				t.Fatalf("Default stylesheet is not well-formed XML: %v", err)
+			}
+			break
+		}
+	}
+
+	// Callers get a copy
+	xsl[0] = 'X'
+	if DefaultStylesheet()[0] == 'X' {
+		t.Error("DefaultStylesheet should return a copy")
+	}
+}
+
+func TestHTMLPreview(t *testing.T) {
+	f := newCollectionFeed()
+	f.AddItem(Item{Title: `<script>alert("x")</script>`, Link: "javascript:alert(1)"})
+
+	page, err := f.HTML("https://example.com/feed.xml")
+	if err != nil {
+		t.Fatalf("HTML generation failed: %v", err)
+	}
+
+	out := string(page)
+	for _, want := range []string{
+		"<title>Test Feed (feed)</title>",
+		"<code>https://example.com/feed.xml</code>",
+		`<a href="https://example.com/new">New</a>`,
+		`<time datetime="2025-01-03T12:00:00Z">3 January 2025</time>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("HTML preview should contain %s", want)
+		}
+	}
+	if strings.Contains(out, "<script>") || strings.Contains(out, "javascript:") {
+		t.Error("HTML preview must escape item content")
+	}
+
+	if _, err := New().HTML(""); err == nil {
+		t.Error("HTML preview should validate the feed")
+	}
+}
+
+func TestIsBrowserRequest(t *testing.T) {
+	const firefox = "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"
+
+	tests := []struct {
+		name      string
+		accept    string
+		userAgent string
+		want      bool
+	}{
+		{"browser", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", firefox, true},
+		{"feed reader", "application/rss+xml, application/atom+xml;q=0.9, */*;q=0.1", "NetNewsWire", false},
+		{"feed reader with browser UA", "application/atom+xml,text/html;q=0.5", firefox, false},
+		{"no html", "*/*", firefox, false},
+		{"curl", "text/html", "curl/8.0", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/feed.xml", nil)
+		req.Header.Set("Accept", tt.accept)
+		req.Header.Set("User-Agent", tt.userAgent)
+		if got := IsBrowserRequest(req); got != tt.want {
+			t.Errorf("%s: IsBrowserRequest = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRequestURL(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://example.com/feed.xml?format=atom", nil)
+	if got := RequestURL(req); got != "http://example.com/feed.xml?format=atom" {
+		t.Errorf("Expected the request URL, got %s", got)
+	}
+
+	req = httptest.NewRequest("GET", "/feed.xml", nil)
+	req.Host = "internal:8080"
+	req.Header.Set("X-Forwarded-Proto", "https, http")
+	req.Header.Set("X-Forwarded-Host", "blog.example.com")
+	if got := RequestURL(req); got != "https://blog.example.com/feed.xml" {
+		t.Errorf("Expected the forwarded scheme and host, got %s", got)
+	}
+
+	req.Header.Set("X-Forwarded-Proto", "gopher")
+	if got := RequestURL(req); got != "http://blog.example.com/feed.xml" {
+		t.Errorf("Expected an unknown forwarded scheme to be ignored, got %s", got)
+	}
+}
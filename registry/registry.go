@@ -0,0 +1,533 @@
+// Package registry serves many named feeds from a single mount point.
+//
+// Feeds are registered with a path pattern such as "tags/{tag}" and are
+// served as <prefix>/<path>.<ext>, e.g. /feeds/tags/go.xml and
+// /feeds/tags/go.atom. The registry also serves an index of all feeds at
+// <prefix>/index.opml and <prefix>/index.json, and caches rendered output
+// until it expires or is invalidated by name. Clients that send
+// "A-IM: feed" with the ETag of a version they already have receive only
+// the new and changed items, as described in RFC 3229.
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.rumenx.com/feed"
+	"go.rumenx.com/feed/opml"
+)
+
+// Common errors
+var (
+	// ErrNotFound may be returned by a Generator when the requested
+	// parameters do not name an existing feed; it is served as 404
+	ErrNotFound       = errors.New("feed not found")
+	ErrDuplicateName  = errors.New("feed name already registered")
+	ErrInvalidPattern = errors.New("invalid path pattern")
+)
+
+// Params holds the values captured by a path pattern's {placeholders}
+type Params map[string]string
+
+// Generator builds a feed for the captured path parameters
+type Generator func(params Params) (*feed.Feed, error)
+
+// Entry describes a registered feed
+type Entry struct {
+	Name    string
+	Pattern string
+	Formats []feed.Format
+
+	generator Generator
+	segments  []string
+}
+
+// static reports whether the entry's pattern has no placeholders
+func (e *Entry) static() bool {
+	for _, seg := range e.segments {
+		if isParam(seg) {
+			return false
+		}
+	}
+	return true
+}
+
+// allows reports whether the entry is served in the given format
+func (e *Entry) allows(format feed.Format) bool {
+	for _, f := range e.Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// cached is a rendered response kept in the registry cache
+type cached struct {
+	data        []byte
+	etag        string
+	contentType string
+	expires     time.Time
+
+	feed   *feed.Feed // the generated feed, for rendering deltas
+	format feed.Format
+}
+
+// Registry routes requests to registered feeds. It is safe for concurrent use.
+type Registry struct {
+	prefix   string
+	title    string
+	baseURL  string
+	cacheTTL time.Duration
+	now      func() time.Time
+	versions *feed.VersionIndex
+
+	mu      sync.RWMutex
+	entries []*Entry
+	byName  map[string]*Entry
+	cache   map[string]map[string]cached // name -> cache key -> response
+}
+
+// New creates a Registry mounted at prefix, e.g. "/feeds"
+func New(prefix string) *Registry {
+	return &Registry{
+		prefix:   "/" + strings.Trim(prefix, "/"),
+		title:    "Feeds",
+		cacheTTL: 5 * time.Minute,
+		now:      time.Now,
+		versions: feed.NewVersionIndex(64),
+		byName:   make(map[string]*Entry),
+		cache:    make(map[string]map[string]cached),
+	}
+}
+
+// SetTitle sets the title of the OPML index
+func (r *Registry) SetTitle(title string) *Registry {
+	r.title = title
+	return r
+}
+
+// SetBaseURL sets the absolute URL of the site, e.g. "https://example.com",
+// used for links in the index. By default it is derived from the request.
+func (r *Registry) SetBaseURL(baseURL string) *Registry {
+	r.baseURL = strings.TrimRight(baseURL, "/")
+	return r
+}
+
+// SetCacheTTL sets how long rendered feeds are cached; zero disables caching.
+// Output is cached no longer than until the next scheduled item is published
+// or expires.
+func (r *Registry) SetCacheTTL(ttl time.Duration) *Registry {
+	r.cacheTTL = ttl
+	return r
+}
+
+// SetDeltaVersions sets how many past versions of all feeds are remembered
+// for RFC 3229 delta encoding; zero disables it. The default is 64.
+func (r *Registry) SetDeltaVersions(n int) *Registry {
+	r.versions = nil
+	if n > 0 {
+		r.versions = feed.NewVersionIndex(n)
+	}
+	return r
+}
+
+// SetClock sets the function used to read the current time
+func (r *Registry) SetClock(now func() time.Time) *Registry {
+	r.now = now
+	return r
+}
+
+// Register adds a feed under name. pattern is the path below the prefix,
+// without extension, and may contain {placeholders} passed to the generator;
+// an empty pattern defaults to the name. Without formats, all supported
+// formats are served.
+func (r *Registry) Register(name, pattern string, generator Generator, formats ...feed.Format) error {
+	if pattern == "" {
+		pattern = name
+	}
+	segments := strings.Split(strings.Trim(pattern, "/"), "/")
+	for _, seg := range segments {
+		if seg == "" || strings.Contains(seg, ".") || (strings.ContainsAny(seg, "{}") && !isParam(seg)) {
+			return fmt.Errorf("%w: %q", ErrInvalidPattern, pattern)
+		}
+	}
+	if len(formats) == 0 {
+		formats = feed.Formats
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.byName[name]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicateName, name)
+	}
+
+	e := &Entry{
+		Name:      name,
+		Pattern:   strings.Trim(pattern, "/"),
+		Formats:   formats,
+		generator: generator,
+		segments:  segments,
+	}
+	r.entries = append(r.entries, e)
+	r.byName[name] = e
+	return nil
+}
+
+// Entries returns the registered feeds in registration order
+func (r *Registry) Entries() []Entry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := make([]Entry, len(r.entries))
+	for i, e := range r.entries {
+		entries[i] = *e
+	}
+	return entries
+}
+
+// Invalidate drops all cached output of the named feed
+func (r *Registry) Invalidate(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.cache, name)
+}
+
+// InvalidateAll drops all cached output
+func (r *Registry) InvalidateAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cache = make(map[string]map[string]cached)
+}
+
+// URL returns the path of a feed in the given format, relative to the site
+// root, filling in the pattern's placeholders from params
+func (r *Registry) URL(name string, format feed.Format, params Params) (string, error) {
+	r.mu.RLock()
+	e, ok := r.byName[name]
+	r.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrNotFound, name)
+	}
+
+	parts := make([]string, len(e.segments))
+	for i, seg := range e.segments {
+		if isParam(seg) {
+			v, ok := params[seg[1:len(seg)-1]]
+			if !ok || v == "" {
+				return "", fmt.Errorf("missing parameter %s for feed %q", seg, name)
+			}
+			seg = v
+		}
+		parts[i] = seg
+	}
+	return strings.TrimRight(r.prefix, "/") + "/" + strings.Join(parts, "/") + format.Extension(), nil
+}
+
+// ServeHTTP routes <prefix>/<path>.<ext> to the matching feed
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	rest, ok := strings.CutPrefix(req.URL.Path, strings.TrimRight(r.prefix, "/")+"/")
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	switch rest {
+	case "index.opml":
+		r.serveOPML(w, req)
+		return
+	case "index.json":
+		r.serveJSON(w, req)
+		return
+	}
+
+	dot := strings.LastIndexByte(rest, '.')
+	if dot <= 0 {
+		http.NotFound(w, req)
+		return
+	}
+	format, ok := feed.ParseFormat(rest[dot:])
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	e, params := r.match(strings.Split(rest[:dot], "/"))
+	if e == nil || !e.allows(format) {
+		http.NotFound(w, req)
+		return
+	}
+
+	resp, err := r.render(e, params, format, rest)
+	if errors.Is(err, ErrNotFound) {
+		http.NotFound(w, req)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", resp.contentType)
+	w.Header().Set("ETag", resp.etag)
+	if r.cacheTTL > 0 {
+		// Scheduled items may shorten the cache lifetime below the TTL
+		maxAge := resp.expires.Sub(r.now())
+		w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(maxAge.Seconds())))
+	}
+	match := req.Header.Get("If-None-Match")
+	if match != "" && feed.ETagMatches(match, resp.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if match != "" && r.versions != nil && feed.AcceptsFeedDeltaRequest(req) {
+		if delta, ok := r.versions.Delta(e.Name+":"+rest, match, resp.feed); ok {
+			r.serveDelta(w, req, delta, resp.format)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+	if req.Method != http.MethodHead {
+		w.Write(resp.data)
+	}
+}
+
+// serveDelta responds 226 IM Used with only the items the client has not
+// seen. Deltas depend on the client's version, so they must not be cached
+// by intermediaries that do not understand them.
+func (r *Registry) serveDelta(w http.ResponseWriter, req *http.Request, delta *feed.Feed, format feed.Format) {
+	data, err := delta.Render(format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("IM", "feed")
+	w.Header().Set("Cache-Control", "no-store, im")
+	w.WriteHeader(http.StatusIMUsed)
+	if req.Method != http.MethodHead {
+		w.Write(data)
+	}
+}
+
+// match finds the entry whose pattern matches the path segments
+func (r *Registry) match(segments []string) (*Entry, Params) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Prefer patterns with more literal segments, so "tags/popular" wins
+	// over "tags/{tag}" regardless of registration order
+	var best *Entry
+	var bestParams Params
+	bestLiterals := -1
+
+	for _, e := range r.entries {
+		if len(e.segments) != len(segments) {
+			continue
+		}
+		params := Params{}
+		literals := 0
+		matched := true
+		for i, seg := range e.segments {
+			switch {
+			case isParam(seg):
+				if segments[i] == "" {
+					matched = false
+				}
+				params[seg[1:len(seg)-1]] = segments[i]
+			case seg == segments[i]:
+				literals++
+			default:
+				matched = false
+			}
+			if !matched {
+				break
+			}
+		}
+		if matched && literals > bestLiterals {
+			best, bestParams, bestLiterals = e, params, literals
+		}
+	}
+	return best, bestParams
+}
+
+// render returns the cached response for key, rendering it when needed
+func (r *Registry) render(e *Entry, params Params, format feed.Format, key string) (cached, error) {
+	now := r.now()
+
+	if r.cacheTTL > 0 {
+		r.mu.RLock()
+		resp, ok := r.cache[e.Name][key]
+		r.mu.RUnlock()
+		if ok && now.Before(resp.expires) {
+			return resp, nil
+		}
+	}
+
+	f, err := e.generator(params)
+	if err != nil {
+		return cached{}, err
+	}
+	if f == nil {
+		return cached{}, fmt.Errorf("failed to generate feed %q", e.Name)
+	}
+
+	data, err := f.Render(format)
+	if err != nil {
+		return cached{}, err
+	}
+
+	sum := sha256.Sum256(data)
+	resp := cached{
+		data:        data,
+		etag:        `"` + hex.EncodeToString(sum[:16]) + `"`,
+		contentType: format.ContentType(),
+		expires:     now.Add(f.MaxAgeAt(now, r.cacheTTL)),
+		feed:        f,
+		format:      format,
+	}
+	if r.versions != nil {
+		r.versions.Record(e.Name+":"+key, resp.etag, f)
+	}
+
+	if r.cacheTTL > 0 {
+		r.mu.Lock()
+		if r.cache[e.Name] == nil {
+			r.cache[e.Name] = make(map[string]cached)
+		}
+		r.cache[e.Name][key] = resp
+		r.mu.Unlock()
+	}
+	return resp, nil
+}
+
+// IndexEntry describes a feed in the JSON index
+type IndexEntry struct {
+	Name        string            `json:"name"`
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Pattern     string            `json:"pattern"`
+	URLs        map[string]string `json:"urls,omitempty"`
+}
+
+// index describes every registered feed. The titles of static feeds come
+// from their cached output, generated when needed; parameterised feeds are
+// listed by pattern only.
+func (r *Registry) index(req *http.Request) ([]IndexEntry, []opml.FeedRef) {
+	base := r.baseURL
+	if base == "" {
+		scheme := "http"
+		if req.TLS != nil {
+			scheme = "https"
+		}
+		base = scheme + "://" + req.Host
+	}
+
+	var entries []IndexEntry
+	var refs []opml.FeedRef
+	for _, e := range r.Entries() {
+		ie := IndexEntry{Name: e.Name, Pattern: e.Pattern}
+		if !e.static() {
+			entries = append(entries, ie)
+			continue
+		}
+
+		f, err := r.staticFeed(&e)
+		if err != nil {
+			continue
+		}
+		ie.Title = f.GetTitle()
+		ie.Description = f.GetDescription()
+		ie.URLs = make(map[string]string)
+		for _, format := range e.Formats {
+			path, err := r.URL(e.Name, format, nil)
+			if err != nil {
+				continue
+			}
+			ie.URLs[string(format)] = base + path
+			refs = append(refs, opml.FeedRef{URL: base + path, Feed: f, Type: string(format)})
+		}
+		entries = append(entries, ie)
+	}
+
+	sort.SliceStable(refs, func(i, j int) bool {
+		return refs[i].Feed.GetTitle() < refs[j].Feed.GetTitle()
+	})
+	return entries, refs
+}
+
+// staticFeed returns the generated feed of a static entry from the cached
+// output of any of its formats, rendering and caching the first format when
+// none is cached, so index requests don't regenerate every feed
+func (r *Registry) staticFeed(e *Entry) (*feed.Feed, error) {
+	keys := make([]string, len(e.Formats))
+	for i, format := range e.Formats {
+		path, err := r.URL(e.Name, format, nil)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = strings.TrimPrefix(path, strings.TrimRight(r.prefix, "/")+"/")
+	}
+
+	if r.cacheTTL > 0 {
+		now := r.now()
+		r.mu.RLock()
+		for _, key := range keys {
+			if resp, ok := r.cache[e.Name][key]; ok && now.Before(resp.expires) {
+				r.mu.RUnlock()
+				return resp.feed, nil
+			}
+		}
+		r.mu.RUnlock()
+	}
+
+	resp, err := r.render(e, Params{}, e.Formats[0], keys[0])
+	if err != nil {
+		return nil, err
+	}
+	return resp.feed, nil
+}
+
+func (r *Registry) serveJSON(w http.ResponseWriter, req *http.Request) {
+	entries, _ := r.index(req)
+	data, err := json.MarshalIndent(map[string]interface{}{"feeds": entries}, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (r *Registry) serveOPML(w http.ResponseWriter, req *http.Request) {
+	_, refs := r.index(req)
+	data, err := opml.FromFeeds(r.title, refs).XML()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/x-opml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// isParam reports whether a pattern segment is a {placeholder}
+func isParam(seg string) bool {
+	return len(seg) > 2 && seg[0] == '{' && seg[len(seg)-1] == '}' && !strings.ContainsAny(seg[1:len(seg)-1], "{}")
+}
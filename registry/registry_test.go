@@ -0,0 +1,401 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.rumenx.com/feed"
+	"go.rumenx.com/feed/opml"
+)
+
+func newFeed(title string) *feed.Feed {
+	f := feed.New()
+	f.SetTitle(title).
+		SetDescription(title + " description").
+		SetLink("https://example.com/" + strings.ToLower(title))
+	f.AddItem(feed.Item{Title: title + " item", Link: "https://example.com/item"})
+	return f
+}
+
+func newRegistry(t *testing.T) (*Registry, map[string]int) {
+	t.Helper()
+
+	calls := make(map[string]int)
+	r := New("/feeds").SetBaseURL("https://example.com")
+
+	mustRegister := func(name, pattern string, gen Generator, formats ...feed.Format) {
+		if err := r.Register(name, pattern, gen, formats...); err != nil {
+			t.Fatalf("Register(%q) failed: %v", name, err)
+		}
+	}
+
+	mustRegister("main", "", func(Params) (*feed.Feed, error) {
+		calls["main"]++
+		return newFeed("Main"), nil
+	})
+	mustRegister("tag", "tags/{tag}", func(p Params) (*feed.Feed, error) {
+		calls["tag"]++
+		if p["tag"] == "missing" {
+			return nil, ErrNotFound
+		}
+		return newFeed("Tag " + p["tag"]), nil
+	}, feed.FormatRSS)
+	mustRegister("popular", "tags/popular", func(Params) (*feed.Feed, error) {
+		calls["popular"]++
+		return newFeed("Popular"), nil
+	})
+
+	return r, calls
+}
+
+func get(r http.Handler, path string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRegistryRouting(t *testing.T) {
+	r, _ := newRegistry(t)
+
+	tests := []struct {
+		path        string
+		status      int
+		contentType string
+		contains    string
+	}{
+		{"/feeds/main.xml", http.StatusOK, "application/xml; charset=utf-8", "<title>Main</title>"},
+		{"/feeds/main.rss", http.StatusOK, "application/xml; charset=utf-8", "<rss"},
+		{"/feeds/main.atom", http.StatusOK, "application/atom+xml; charset=utf-8", "<title>Main</title>"},
+		{"/feeds/tags/go.xml", http.StatusOK, "application/xml; charset=utf-8", "<title>Tag go</title>"},
+		{"/feeds/tags/popular.xml", http.StatusOK, "application/xml; charset=utf-8", "<title>Popular</title>"},
+		{"/feeds/tags/go.atom", http.StatusNotFound, "", ""},
+		{"/feeds/tags/missing.xml", http.StatusNotFound, "", ""},
+		{"/feeds/unknown.xml", http.StatusNotFound, "", ""},
+		{"/feeds/main.json", http.StatusNotFound, "", ""},
+		{"/feeds/main", http.StatusNotFound, "", ""},
+		{"/other/main.xml", http.StatusNotFound, "", ""},
+	}
+
+	for _, tt := range tests {
+		rec := get(r, tt.path)
+		if rec.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.status, rec.Code)
+			continue
+		}
+		if tt.contentType != "" && rec.Header().Get("Content-Type") != tt.contentType {
+			t.Errorf("%s: expected content type %s, got %s", tt.path, tt.contentType, rec.Header().Get("Content-Type"))
+		}
+		if tt.contains != "" && !strings.Contains(rec.Body.String(), tt.contains) {
+			t.Errorf("%s: body should contain %s", tt.path, tt.contains)
+		}
+	}
+}
+
+func TestRegistryCacheAndInvalidate(t *testing.T) {
+	r, calls := newRegistry(t)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.SetClock(func() time.Time { return now }).SetCacheTTL(time.Minute)
+
+	first := get(r, "/feeds/main.xml")
+	get(r, "/feeds/main.xml")
+	get(r, "/feeds/tags/go.xml")
+	if calls["main"] != 1 {
+		t.Errorf("Expected cached output to be reused, generator called %d times", calls["main"])
+	}
+	if first.Header().Get("Cache-Control") != "public, max-age=60" {
+		t.Errorf("Unexpected Cache-Control: %s", first.Header().Get("Cache-Control"))
+	}
+
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("Expected an ETag")
+	}
+	if rec := get(r, "/feeds/main.xml", "If-None-Match", etag); rec.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for matching ETag, got %d", rec.Code)
+	}
+
+	r.Invalidate("main")
+	get(r, "/feeds/main.xml")
+	get(r, "/feeds/tags/go.xml")
+	if calls["main"] != 2 {
+		t.Errorf("Expected regeneration after invalidation, generator called %d times", calls["main"])
+	}
+	if calls["tag"] != 1 {
+		t.Errorf("Invalidating one feed should keep others cached, tag generator called %d times", calls["tag"])
+	}
+
+	now = now.Add(2 * time.Minute)
+	get(r, "/feeds/tags/go.xml")
+	if calls["tag"] != 2 {
+		t.Errorf("Expected regeneration after expiry, tag generator called %d times", calls["tag"])
+	}
+}
+
+func TestRegistryJSONIndex(t *testing.T) {
+	r, _ := newRegistry(t)
+
+	rec := get(r, "/feeds/index.json")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+
+	var index struct {
+		Feeds []IndexEntry `json:"feeds"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &index); err != nil {
+		t.Fatalf("Invalid JSON index: %v", err)
+	}
+	if len(index.Feeds) != 3 {
+		t.Fatalf("Expected 3 index entries, got %d", len(index.Feeds))
+	}
+
+	main := index.Feeds[0]
+	if main.Title != "Main" || main.URLs["rss"] != "https://example.com/feeds/main.xml" || main.URLs["atom"] != "https://example.com/feeds/main.atom" {
+		t.Errorf("Unexpected index entry: %+v", main)
+	}
+	if tag := index.Feeds[1]; tag.Pattern != "tags/{tag}" || tag.URLs != nil {
+		t.Errorf("Parameterised feeds should be listed by pattern only: %+v", tag)
+	}
+}
+
+func TestRegistryOPMLIndex(t *testing.T) {
+	r, _ := newRegistry(t)
+	r.SetTitle("All feeds")
+
+	rec := get(r, "/feeds/index.opml")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+
+	doc, err := opml.Parse(rec.Body)
+	if err != nil {
+		t.Fatalf("Invalid OPML index: %v", err)
+	}
+	if doc.Title != "All feeds" {
+		t.Errorf("Unexpected OPML title: %q", doc.Title)
+	}
+	if subs := doc.Subscriptions(); len(subs) != 4 {
+		t.Errorf("Expected 4 subscriptions (2 feeds x 2 formats), got %d", len(subs))
+	}
+}
+
+func TestRegistryIndexUsesCache(t *testing.T) {
+	r, calls := newRegistry(t)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.SetClock(func() time.Time { return now }).SetCacheTTL(time.Minute)
+
+	get(r, "/feeds/main.atom")
+	get(r, "/feeds/index.json")
+	get(r, "/feeds/index.opml")
+	get(r, "/feeds/index.json")
+	get(r, "/feeds/tags/popular.xml")
+	if calls["main"] != 1 || calls["popular"] != 1 {
+		t.Errorf("Expected index requests to share the feed cache, generators called %d and %d times", calls["main"], calls["popular"])
+	}
+
+	now = now.Add(2 * time.Minute)
+	get(r, "/feeds/index.json")
+	if calls["main"] != 2 || calls["popular"] != 2 {
+		t.Errorf("Expected regeneration after expiry, generators called %d and %d times", calls["main"], calls["popular"])
+	}
+}
+
+func TestRegistryURL(t *testing.T) {
+	r, _ := newRegistry(t)
+
+	if u, err := r.URL("tag", feed.FormatRSS, Params{"tag": "go"}); err != nil || u != "/feeds/tags/go.xml" {
+		t.Errorf("Unexpected URL %q, %v", u, err)
+	}
+	if _, err := r.URL("tag", feed.FormatRSS, nil); err == nil {
+		t.Error("Expected an error for a missing parameter")
+	}
+	if _, err := r.URL("nope", feed.FormatRSS, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestRegisterErrors(t *testing.T) {
+	r := New("feeds")
+	gen := func(Params) (*feed.Feed, error) { return newFeed("x"), nil }
+
+	if err := r.Register("a", "", gen); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := r.Register("a", "other", gen); !errors.Is(err, ErrDuplicateName) {
+		t.Errorf("Expected ErrDuplicateName, got %v", err)
+	}
+	for _, pattern := range []string{"a//b", "a.xml", "tags/{tag", "x{y}"} {
+		if err := r.Register("p"+pattern, pattern, gen); !errors.Is(err, ErrInvalidPattern) {
+			t.Errorf("Expected ErrInvalidPattern for %q, got %v", pattern, err)
+		}
+	}
+}
+
+func TestRegistryMethodNotAllowed(t *testing.T) {
+	r, _ := newRegistry(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/feeds/main.xml", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405, got %d", rec.Code)
+	}
+}
+
+func TestRegistryDeltaEncoding(t *testing.T) {
+	items := []feed.Item{
+		{Title: "First", GUID: "1", Link: "https://example.com/1"},
+		{Title: "Second", GUID: "2", Link: "https://example.com/2"},
+	}
+	r := New("/feeds").SetCacheTTL(0)
+	err := r.Register("main", "", func(Params) (*feed.Feed, error) {
+		f := newFeed("Main")
+		f.AddItems(items)
+		return f, nil
+	})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	fetch := func(header ...string) (*http.Response, string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/feeds/main.xml", nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	first, _ := fetch()
+	etag := first.Header.Get("ETag")
+
+	for _, match := range []string{etag, `"other", ` + etag, "W/" + etag, "*"} {
+		if resp, _ := fetch("A-IM", "feed", "If-None-Match", match); resp.StatusCode != http.StatusNotModified {
+			t.Errorf("%s: expected 304 for an unchanged feed, got %d", match, resp.StatusCode)
+		}
+	}
+
+	items = append([]feed.Item{{Title: "Third", GUID: "3", Link: "https://example.com/3"}}, items...)
+	items[2].Title = "Second (edited)"
+
+	resp, body := fetch("A-IM", "feed", "If-None-Match", `"unknown", W/`+etag)
+	if resp.StatusCode != http.StatusIMUsed {
+		t.Fatalf("Expected 226 IM Used, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("IM") != "feed" || resp.Header.Get("Cache-Control") != "no-store, im" {
+		t.Errorf("Unexpected delta headers %v", resp.Header)
+	}
+	if resp.Header.Get("ETag") == etag {
+		t.Error("Expected the delta to carry the ETag of the current version")
+	}
+	for _, want := range []string{"<title>Third</title>", "<title>Second (edited)</title>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected delta to contain %s, got:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "<title>First</title>") || strings.Contains(body, "<title>Main item</title>") {
+		t.Errorf("Expected delta to omit unchanged items, got:\n%s", body)
+	}
+
+	// Clients without delta support and unknown versions get the full feed
+	for _, header := range [][]string{
+		{"If-None-Match", etag},
+		{"A-IM", "feed", "If-None-Match", `"unknown"`},
+		{"A-IM", "feed;q=0", "If-None-Match", etag},
+	} {
+		resp, body := fetch(header...)
+		if resp.StatusCode != http.StatusOK || !strings.Contains(body, "<title>First</title>") {
+			t.Errorf("%v: expected the full feed, got %d", header, resp.StatusCode)
+		}
+	}
+
+	r.SetDeltaVersions(0)
+	if resp, _ := fetch("A-IM", "feed", "If-None-Match", etag); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected delta encoding to be disabled, got %d", resp.StatusCode)
+	}
+}
+
+func TestRegistryDeltaAcrossFeeds(t *testing.T) {
+	r := New("/feeds")
+	for _, name := range []string{"a", "b"} {
+		title := strings.ToUpper(name)
+		if err := r.Register(name, "", func(Params) (*feed.Feed, error) { return newFeed(title), nil }); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+
+	etag := get(r, "/feeds/a.xml").Header().Get("ETag")
+	get(r, "/feeds/b.xml")
+	get(r, "/feeds/a.atom")
+
+	for _, path := range []string{"/feeds/b.xml", "/feeds/a.atom"} {
+		rec := get(r, path, "A-IM", "feed", "If-None-Match", etag)
+		if rec.Code != http.StatusOK || rec.Header().Get("IM") != "" {
+			t.Errorf("%s: expected the full feed for the ETag of another feed, got %d", path, rec.Code)
+		}
+	}
+}
+
+func TestRegistryScheduledItems(t *testing.T) {
+	now := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	launch := now.Add(10 * time.Minute)
+	clock := func() time.Time { return now }
+	r := New("/feeds").SetClock(clock).SetCacheTTL(time.Hour)
+	err := r.Register("main", "", func(Params) (*feed.Feed, error) {
+		f := newFeed("Main").SetClock(clock)
+		f.AddItem(feed.Item{Title: "Launch", Link: "https://example.com/launch", PublishAt: launch})
+		return f, nil
+	})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	rec := get(r, "/feeds/main.xml")
+	if strings.Contains(rec.Body.String(), "Launch") {
+		t.Error("Expected the embargoed item to be hidden")
+	}
+	if rec.Header().Get("Cache-Control") != "public, max-age=600" {
+		t.Errorf("Expected the cache lifetime to end at the embargo, got %s", rec.Header().Get("Cache-Control"))
+	}
+
+	now = launch
+	if rec := get(r, "/feeds/main.xml"); !strings.Contains(rec.Body.String(), "Launch") {
+		t.Error("Expected the item to be served once the embargo lifts")
+	}
+}
+
+func TestRegistryScheduledItemsRegistryClock(t *testing.T) {
+	now := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	r := New("/feeds").SetClock(func() time.Time { return now }).SetCacheTTL(time.Hour)
+	err := r.Register("main", "", func(Params) (*feed.Feed, error) {
+		f := newFeed("Main").SetClock(func() time.Time { return now.Add(-24 * time.Hour) })
+		f.AddItem(feed.Item{Title: "Launch", Link: "https://example.com/launch", PublishAt: now.Add(10 * time.Minute)})
+		f.AddItem(feed.Item{Title: "Promo", Link: "https://example.com/promo", ExpireAt: now.Add(-time.Hour)})
+		return f, nil
+	})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	if rec := get(r, "/feeds/main.xml"); rec.Header().Get("Cache-Control") != "public, max-age=600" {
+		t.Errorf("Expected the cache lifetime to be measured with the registry clock, got %s", rec.Header().Get("Cache-Control"))
+	}
+}
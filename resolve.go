@@ -0,0 +1,235 @@
+package feed
+
+import (
+	"net/url"
+	"strings"
+
+	"go.rumenx.com/feed/internal/htmltok"
+)
+
+// htmlURLAttrs are HTML attributes holding a single URL
+var htmlURLAttrs = map[string]bool{
+	"href":       true,
+	"src":        true,
+	"cite":       true,
+	"poster":     true,
+	"action":     true,
+	"formaction": true,
+	"background": true,
+	"longdesc":   true,
+	"usemap":     true,
+}
+
+// SetResolveURLs enables rewriting relative URLs to absolute ones at render
+// time. Item links are resolved against the feed link; comments, enclosure,
+// image and source URLs and URLs inside item descriptions and content
+// (including srcset) are resolved against the item link, or the feed link
+// when the item has none. The stored items are left untouched.
+func (f *Feed) SetResolveURLs(resolve bool) *Feed {
+	f.resolveURLs = resolve
+	return f
+}
+
+// GetResolveURLs reports whether relative URLs are resolved at render time
+func (f *Feed) GetResolveURLs() bool {
+	return f.resolveURLs
+}
+
+// SetAtomXMLBase enables xml:base attributes in Atom output: the feed link
+// on the feed and the item link on each entry. Readers then resolve relative
+// URLs in entry content themselves, so the content HTML is not rewritten
+// even when SetResolveURLs is enabled.
+func (f *Feed) SetAtomXMLBase(enabled bool) *Feed {
+	f.atomXMLBase = enabled
+	return f
+}
+
+// GetAtomXMLBase reports whether Atom output declares xml:base
+func (f *Feed) GetAtomXMLBase() bool {
+	return f.atomXMLBase
+}
+
+// baseURL returns the feed link as a base URL, or nil when it is not absolute
+func (f *Feed) baseURL() *url.URL {
+	base, err := url.Parse(f.link)
+	if err != nil || !base.IsAbs() {
+		return nil
+	}
+	return base
+}
+
+// itemBaseURL returns the base URL for an item's relative URLs
+func itemBaseURL(feedBase *url.URL, item Item) *url.URL {
+	if item.Link == "" {
+		return feedBase
+	}
+	var base *url.URL
+	var err error
+	if feedBase != nil {
+		base, err = feedBase.Parse(strings.TrimSpace(item.Link))
+	} else {
+		base, err = url.Parse(strings.TrimSpace(item.Link))
+	}
+	if err != nil || !base.IsAbs() {
+		return feedBase
+	}
+	return base
+}
+
+// resolveItem returns a copy of item with relative URLs made absolute.
+// HTML in the description and content is only rewritten when rewriteHTML
+// is set.
+func (f *Feed) resolveItem(item Item, feedBase *url.URL, rewriteHTML bool) Item {
+	base := itemBaseURL(feedBase, item)
+	if base == nil {
+		return item
+	}
+
+	if feedBase != nil {
+		item.Link = resolveURL(feedBase, item.Link)
+	}
+	item.Comments = resolveURL(base, item.Comments)
+
+	if item.Enclosure != nil {
+		enclosure := *item.Enclosure
+		enclosure.URL = resolveURL(base, enclosure.URL)
+		item.Enclosure = &enclosure
+	}
+	if item.Enclosures != nil {
+		enclosures := make([]Enclosure, len(item.Enclosures))
+		for i, e := range item.Enclosures {
+			e.URL = resolveURL(base, e.URL)
+			enclosures[i] = e
+		}
+		item.Enclosures = enclosures
+	}
+	if item.Images != nil {
+		images := make([]Image, len(item.Images))
+		for i, img := range item.Images {
+			img.URL = resolveURL(base, img.URL)
+			img.Link = resolveURL(base, img.Link)
+			images[i] = img
+		}
+		item.Images = images
+	}
+	if item.Source != nil {
+		source := *item.Source
+		source.URL = resolveURL(base, source.URL)
+		item.Source = &source
+	}
+	if item.Media != nil {
+		item.Media = resolveMedia(base, item.Media)
+	}
+
+	if rewriteHTML {
+		item.Description = resolveHTML(base, item.Description)
+		item.Content = resolveHTML(base, item.Content)
+	}
+	return item
+}
+
+// resolveURL resolves ref against base, leaving empty, absolute and
+// unparseable references unchanged
+func resolveURL(base *url.URL, ref string) string {
+	trimmed := strings.TrimSpace(ref)
+	if trimmed == "" {
+		return ref
+	}
+	u, err := url.Parse(trimmed)
+	if err != nil || u.IsAbs() {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
+
+// resolveHTML resolves the URL attributes of an HTML fragment against base.
+// The fragment is returned unchanged when it has no relative URLs.
+func resolveHTML(base *url.URL, s string) string {
+	if !strings.Contains(s, "<") {
+		return s
+	}
+
+	tokens := htmltok.Tokenize(s)
+	changed := false
+	for i, tok := range tokens {
+		if tok.Type != htmltok.StartTagToken && tok.Type != htmltok.SelfClosingTagToken {
+			continue
+		}
+		for j, a := range tok.Attr {
+			val := a.Val
+			switch {
+			case htmlURLAttrs[a.Key]:
+				val = resolveURL(base, a.Val)
+			case a.Key == "srcset":
+				val = resolveSrcset(base, a.Val)
+			}
+			if val != a.Val {
+				tokens[i].Attr[j].Val = val
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return s
+	}
+
+	var b strings.Builder
+	for _, tok := range tokens {
+		b.WriteString(tok.String())
+	}
+	return b.String()
+}
+
+// resolveSrcset resolves each image candidate URL of a srcset attribute,
+// following the HTML parsing rules so that commas inside URLs survive
+func resolveSrcset(base *url.URL, srcset string) string {
+	var candidates []string
+	changed := false
+	s := srcset
+	for {
+		s = strings.TrimLeft(s, " \t\n\r\f,")
+		if s == "" {
+			break
+		}
+
+		end := strings.IndexAny(s, " \t\n\r\f")
+		if end < 0 {
+			end = len(s)
+		}
+		ref := s[:end]
+		s = s[end:]
+
+		descriptors := ""
+		if trimmed := strings.TrimRight(ref, ","); trimmed != ref {
+			// A trailing comma ends a candidate without descriptors
+			ref = trimmed
+		} else {
+			depth, i := 0, 0
+			for ; i < len(s); i++ {
+				if s[i] == '(' {
+					depth++
+				} else if s[i] == ')' && depth > 0 {
+					depth--
+				} else if s[i] == ',' && depth == 0 {
+					break
+				}
+			}
+			descriptors = strings.TrimSpace(s[:i])
+			s = s[i:]
+		}
+
+		candidate := resolveURL(base, ref)
+		if candidate != ref {
+			changed = true
+		}
+		if descriptors != "" {
+			candidate += " " + descriptors
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	if !changed {
+		return srcset
+	}
+	return strings.Join(candidates, ", ")
+}
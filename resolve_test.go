@@ -0,0 +1,111 @@
+package feed
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestResolveHTML(t *testing.T) {
+	base, _ := url.Parse("https://example.com/blog/post/")
+
+	tests := []struct {
+		in, want string
+	}{
+		{"plain text", "plain text"},
+		{`<a href="https://other.com/">x</a>`, `<a href="https://other.com/">x</a>`},
+		{`<p class=x>Unchanged <b>markup</b></p>`, `<p class=x>Unchanged <b>markup</b></p>`},
+		{`<img src="/images/a.png" alt="A">`, `<img src="https://example.com/images/a.png" alt="A">`},
+		{`<a href="../other?a=1&amp;b=2#top">x</a>`, `<a href="https://example.com/blog/other?a=1&amp;b=2#top">x</a>`},
+		{`<a href="mailto:me@example.com">x</a>`, `<a href="mailto:me@example.com">x</a>`},
+		{`<video poster="p.jpg" src="//cdn.example.com/v.mp4"></video>`, `<video poster="https://example.com/blog/post/p.jpg" src="https://cdn.example.com/v.mp4"></video>`},
+		{
+			`<img srcset="a.png 1x, /b.png 2x,data:image/png;base64,AA== 3x">`,
+			`<img srcset="https://example.com/blog/post/a.png 1x, https://example.com/b.png 2x, data:image/png;base64,AA== 3x">`,
+		},
+		{`<source srcset="a.png, b.png 640w">`, `<source srcset="https://example.com/blog/post/a.png, https://example.com/blog/post/b.png 640w">`},
+	}
+	for _, tt := range tests {
+		if got := resolveHTML(base, tt.in); got != tt.want {
+			t.Errorf("resolveHTML(%q) =\n%q, want\n%q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func newRelativeFeed() *Feed {
+	f := New().
+		SetTitle("Blog").
+		SetDescription("Posts").
+		SetLink("https://example.com/blog/").
+		SetImage(Image{URL: "/logo.png", Title: "Blog", Link: "/"})
+	f.AddItem(Item{
+		Title:       "Post",
+		Link:        "posts/first",
+		Description: `<img src="/images/a.png">`,
+		Content:     `<a href="second">Next</a>`,
+		Comments:    "#comments",
+		Enclosure:   &Enclosure{URL: "/audio/a.mp3", Length: "1", Type: "audio/mpeg"},
+	})
+	f.AddItem(Item{Title: "No link", Link: "", Description: `<img src="b.png">`})
+	return f
+}
+
+func TestSetResolveURLs(t *testing.T) {
+	f := newRelativeFeed()
+	if f.GetResolveURLs() {
+		t.Error("URL resolution should be disabled by default")
+	}
+	f.SetResolveURLs(true)
+
+	rss, err := f.RSS()
+	if err != nil {
+		t.Fatalf("RSS generation failed: %v", err)
+	}
+	for _, want := range []string{
+		"<link>https://example.com/blog/posts/first</link>",
+		`&lt;img src=&#34;https://example.com/images/a.png&#34;&gt;`,
+		`<a href="https://example.com/blog/posts/second">Next</a>`,
+		"<comments>https://example.com/blog/posts/first#comments</comments>",
+		`url="https://example.com/audio/a.mp3"`,
+		"<url>https://example.com/logo.png</url>",
+		`&lt;img src=&#34;https://example.com/blog/b.png&#34;&gt;`,
+	} {
+		if !strings.Contains(string(rss), want) {
+			t.Errorf("RSS should contain %s", want)
+		}
+	}
+
+	// The stored items are left untouched
+	if item := f.GetItems()[0]; item.Link != "posts/first" || item.Enclosure.URL != "/audio/a.mp3" {
+		t.Errorf("Resolving should not modify stored items: %+v", item)
+	}
+}
+
+func TestSetAtomXMLBase(t *testing.T) {
+	f := newRelativeFeed().SetResolveURLs(true).SetAtomXMLBase(true)
+	if !f.GetAtomXMLBase() {
+		t.Error("Expected xml:base to be enabled")
+	}
+
+	atom, err := f.Atom()
+	if err != nil {
+		t.Fatalf("Atom generation failed: %v", err)
+	}
+	for _, want := range []string{
+		`<feed xmlns="http://www.w3.org/2005/Atom" xml:base="https://example.com/blog/">`,
+		`<entry xml:base="https://example.com/blog/posts/first">`,
+		`<link href="https://example.com/blog/posts/first" rel="alternate"`,
+		// Content is left for the reader to resolve
+		`&lt;a href=&#34;second&#34;&gt;Next&lt;/a&gt;`,
+	} {
+		if !strings.Contains(string(atom), want) {
+			t.Errorf("Atom should contain %s", want)
+		}
+	}
+
+	// RSS has no xml:base, so its content is still rewritten
+	rss, _ := f.RSS()
+	if !strings.Contains(string(rss), `<a href="https://example.com/blog/posts/second">`) {
+		t.Error("RSS content should be resolved even with Atom xml:base enabled")
+	}
+}
@@ -8,25 +8,44 @@ import (
 
 // RSS represents the RSS 2.0 feed structure
 type RSS struct {
-	XMLName xml.Name `xml:"rss"`
-	Version string   `xml:"version,attr"`
-	Channel Channel  `xml:"channel"`
+	XMLName      xml.Name `xml:"rss"`
+	Version      string   `xml:"version,attr"`
+	XMLNSContent string   `xml:"xmlns:content,attr,omitempty"`
+	XMLNSPodcast string   `xml:"xmlns:podcast,attr,omitempty"`
+	XMLNSMedia   string   `xml:"xmlns:media,attr,omitempty"`
+	XMLNSITunes  string   `xml:"xmlns:itunes,attr,omitempty"`
+	Channel      Channel  `xml:"channel"`
 }
 
 // Channel represents the RSS channel
 type Channel struct {
-	Title          string    `xml:"title"`
-	Description    string    `xml:"description"`
-	Link           string    `xml:"link"`
-	Language       string    `xml:"language,omitempty"`
-	Copyright      string    `xml:"copyright,omitempty"`
-	ManagingEditor string    `xml:"managingEditor,omitempty"`
-	Webmaster      string    `xml:"webMaster,omitempty"`
-	PubDate        string    `xml:"pubDate,omitempty"`
-	LastBuildDate  string    `xml:"lastBuildDate,omitempty"`
-	TTL            int       `xml:"ttl,omitempty"`
-	Image          *RSSImage `xml:"image,omitempty"`
-	Items          []RSSItem `xml:"item"`
+	Title          string        `xml:"title"`
+	Description    string        `xml:"description"`
+	Link           string        `xml:"link"`
+	Language       string        `xml:"language,omitempty"`
+	Copyright      string        `xml:"copyright,omitempty"`
+	ManagingEditor string        `xml:"managingEditor,omitempty"`
+	Webmaster      string        `xml:"webMaster,omitempty"`
+	PubDate        string        `xml:"pubDate,omitempty"`
+	LastBuildDate  string        `xml:"lastBuildDate,omitempty"`
+	TTL            int           `xml:"ttl,omitempty"`
+	Image          *RSSImage     `xml:"image,omitempty"`
+	SkipHours      *RSSSkipHours `xml:"skipHours,omitempty"`
+	SkipDays       *RSSSkipDays  `xml:"skipDays,omitempty"`
+	*PodcastChannel
+	*MediaMetadata
+	*ITunesChannel
+	Items []RSSItem `xml:"item"`
+}
+
+// RSSSkipHours lists the hours in which aggregators should not poll the feed
+type RSSSkipHours struct {
+	Hours []int `xml:"hour"`
+}
+
+// RSSSkipDays lists the days on which aggregators should not poll the feed
+type RSSSkipDays struct {
+	Days []string `xml:"day"`
 }
 
 // RSSImage represents an RSS image
@@ -50,8 +69,20 @@ type RSSItem struct {
 	GUID        string        `xml:"guid,omitempty"`
 	PubDate     string        `xml:"pubDate,omitempty"`
 	Source      *RSSSource    `xml:"source,omitempty"`
+	Content     *RSSContent   `xml:"content:encoded,omitempty"`
+	*PodcastItem
+	*Media
+	*ITunesItem
 }
 
+// RSSContent holds the full item content of the content module
+type RSSContent struct {
+	Text string `xml:",cdata"`
+}
+
+// contentNamespace is the namespace of the RSS content module
+const contentNamespace = "http://purl.org/rss/1.0/modules/content/"
+
 // RSSEnclosure represents an RSS enclosure
 type RSSEnclosure struct {
 	URL    string `xml:"url,attr"`
@@ -71,6 +102,37 @@ func (f *Feed) RSS() ([]byte, error) {
 		return nil, err
 	}
 
+	rss := f.rss()
+	if podcast := rss.Channel.PodcastChannel; podcast != nil {
+		// Decide on the rendered live items, whose content may have been
+		// emptied by sanitizing or excerpts
+		for _, live := range podcast.LiveItems {
+			if live.Item.Content != "" {
+				rss.XMLNSContent = contentNamespace
+			}
+		}
+	}
+
+	// Convert items
+	for _, item := range f.renderItems(FormatRSS) {
+		if item.Content != "" {
+			rss.XMLNSContent = contentNamespace
+		}
+		rss.Channel.Items = append(rss.Channel.Items, rssItem(item))
+	}
+
+	// Generate XML with header
+	xmlData, err := xml.MarshalIndent(rss, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal RSS XML: %w", err)
+	}
+
+	// Add XML declaration and stylesheet instruction
+	return append(f.xmlPreamble(), xmlData...), nil
+}
+
+// rss builds the RSS document without its items
+func (f *Feed) rss() RSS {
 	rss := RSS{
 		Version: "2.0",
 		Channel: Channel{
@@ -85,6 +147,32 @@ func (f *Feed) RSS() ([]byte, error) {
 			TTL:            f.ttl,
 		},
 	}
+	rss.Channel.PodcastChannel = f.podcast
+	if f.podcast != nil && len(f.podcast.LiveItems) > 0 {
+		// Live items get the same render-time transforms as regular items
+		podcast := f.podcast.clone()
+		base := f.baseURL()
+		for i, live := range podcast.LiveItems {
+			podcast.LiveItems[i].Item = f.renderItem(live.Item, base, FormatRSS)
+		}
+		rss.Channel.PodcastChannel = podcast
+	}
+	if f.usesPodcast() {
+		rss.XMLNSPodcast = podcastNamespace
+	}
+	rss.Channel.MediaMetadata = f.channelMedia()
+	if f.usesMedia() {
+		rss.XMLNSMedia = mediaNamespace
+	}
+	if f.itunes != nil {
+		rss.Channel.ITunesChannel = f.itunes.clone()
+		if base := f.baseURL(); f.resolveURLs && base != nil && f.itunes.Image != nil {
+			rss.Channel.ITunesChannel.Image.Href = resolveURL(base, f.itunes.Image.Href)
+		}
+	}
+	if f.usesITunes() {
+		rss.XMLNSITunes = itunesNamespace
+	}
 
 	// Add feed image if present
 	if f.image != nil {
@@ -95,50 +183,61 @@ func (f *Feed) RSS() ([]byte, error) {
 			Width:  f.image.Width,
 			Height: f.image.Height,
 		}
+		if base := f.baseURL(); f.resolveURLs && base != nil {
+			rss.Channel.Image.URL = resolveURL(base, f.image.URL)
+			rss.Channel.Image.Link = resolveURL(base, f.image.Link)
+		}
 	}
 
-	// Convert items
-	for _, item := range f.items {
-		rssItem := RSSItem{
-			Title:       item.Title,
-			Description: item.Description,
-			Link:        item.Link,
-			Author:      item.Author,
-			Category:    item.Categories,
-			Comments:    item.Comments,
-			GUID:        item.GUID,
-			PubDate:     formatRFC822Date(item.PubDate),
-		}
+	if len(f.skipHours) > 0 {
+		rss.Channel.SkipHours = &RSSSkipHours{Hours: f.skipHours}
+	}
+	if len(f.skipDays) > 0 {
+		rss.Channel.SkipDays = &RSSSkipDays{Days: f.skipDays}
+	}
 
-		// Add enclosure if present
-		if item.Enclosure != nil {
-			rssItem.Enclosure = &RSSEnclosure{
-				URL:    item.Enclosure.URL,
-				Length: item.Enclosure.Length,
-				Type:   item.Enclosure.Type,
-			}
-		}
+	return rss
+}
 
-		// Add source if present
-		if item.Source != nil {
-			rssItem.Source = &RSSSource{
-				URL:   item.Source.URL,
-				Value: item.Source.Value,
-			}
-		}
+// rssItem converts a rendered item into an RSS item
+func rssItem(item Item) RSSItem {
+	rssItem := RSSItem{
+		Title:       item.Title,
+		Description: item.Description,
+		Link:        item.Link,
+		Author:      item.Author,
+		Category:    item.Categories,
+		Comments:    item.Comments,
+		GUID:        item.GUID,
+		PubDate:     formatRFC822Date(item.PubDate),
+		PodcastItem: item.Podcast,
+		Media:       item.Media,
+		ITunesItem:  itunesItem(item),
+	}
 
-		rss.Channel.Items = append(rss.Channel.Items, rssItem)
+	// Add full content if present
+	if item.Content != "" {
+		rssItem.Content = &RSSContent{Text: item.Content}
 	}
 
-	// Generate XML with header
-	xmlData, err := xml.MarshalIndent(rss, "", "  ")
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal RSS XML: %w", err)
+	// Add enclosure if present
+	if item.Enclosure != nil {
+		rssItem.Enclosure = &RSSEnclosure{
+			URL:    item.Enclosure.URL,
+			Length: item.Enclosure.Length,
+			Type:   item.Enclosure.Type,
+		}
+	}
+
+	// Add source if present
+	if item.Source != nil {
+		rssItem.Source = &RSSSource{
+			URL:   item.Source.URL,
+			Value: item.Source.Value,
+		}
 	}
 
-	// Add XML declaration
-	xmlHeader := []byte(xml.Header)
-	return append(xmlHeader, xmlData...), nil
+	return rssItem
 }
 
 // formatRFC822Date formats a time.Time as RFC 822 date string (required for RSS)
@@ -0,0 +1,83 @@
+package feed
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// SafeFeed wraps a Feed for concurrent use.
+// Mutations are serialised by a mutex, while readers work on an immutable
+// Snapshot that is rebuilt lazily after each change, so rendering never
+// takes the lock once the snapshot is current.
+type SafeFeed struct {
+	mu       sync.RWMutex
+	feed     *Feed
+	snapshot atomic.Pointer[Snapshot]
+}
+
+// NewSafeFeed wraps f for concurrent use. The caller must not use f directly
+// afterwards. A nil feed is replaced by New().
+func NewSafeFeed(f *Feed) *SafeFeed {
+	if f == nil {
+		f = New()
+	}
+	return &SafeFeed{feed: f}
+}
+
+// Update calls fn with exclusive access to the underlying feed.
+// fn must not retain the feed after it returns.
+func (s *SafeFeed) Update(fn func(f *Feed)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fn(s.feed)
+	s.snapshot.Store(nil)
+}
+
+// AddItem adds an item to the feed
+func (s *SafeFeed) AddItem(item Item) {
+	s.Update(func(f *Feed) {
+		f.AddItem(item)
+	})
+}
+
+// AddItems adds multiple items to the feed
+func (s *SafeFeed) AddItems(items []Item) {
+	s.Update(func(f *Feed) {
+		f.AddItems(items)
+	})
+}
+
+// Snapshot returns an immutable view of the current feed state
+func (s *SafeFeed) Snapshot() *Snapshot {
+	if snap := s.snapshot.Load(); snap != nil {
+		return snap
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Another reader may have rebuilt the snapshot while we waited
+	if snap := s.snapshot.Load(); snap != nil {
+		return snap
+	}
+
+	snap := s.feed.Snapshot()
+	s.snapshot.Store(snap)
+	return snap
+}
+
+// RSS generates RSS 2.0 XML output from the current snapshot
+func (s *SafeFeed) RSS() ([]byte, error) {
+	return s.Snapshot().RSS()
+}
+
+// Atom generates Atom 1.0 XML output from the current snapshot
+func (s *SafeFeed) Atom() ([]byte, error) {
+	return s.Snapshot().Atom()
+}
+
+// Render generates the current snapshot in the given format
+func (s *SafeFeed) Render(format Format) ([]byte, error) {
+	return s.Snapshot().Render(format)
+}
@@ -0,0 +1,31 @@
+package feed
+
+// Sanitizer cleans untrusted HTML. The sanitize package provides
+// configurable policies that implement it.
+type Sanitizer interface {
+	Sanitize(html string) string
+}
+
+// SetSanitizer sets the sanitizer applied to item descriptions and content
+// when the feed is rendered. The stored items are left untouched.
+func (f *Feed) SetSanitizer(s Sanitizer) *Feed {
+	f.sanitizer = s
+	return f
+}
+
+// GetSanitizer returns the sanitizer applied at render time
+func (f *Feed) GetSanitizer() Sanitizer {
+	return f.sanitizer
+}
+
+// sanitizeItem returns a copy of item with its HTML fields sanitized
+func (f *Feed) sanitizeItem(item Item) Item {
+	if f.sanitizer == nil {
+		return item
+	}
+	item.Description = f.sanitizer.Sanitize(item.Description)
+	if item.Content != "" {
+		item.Content = f.sanitizer.Sanitize(item.Content)
+	}
+	return item
+}
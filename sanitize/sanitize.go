@@ -0,0 +1,310 @@
+// Package sanitize cleans untrusted HTML in feed item descriptions and
+// content using an allowlist of elements, attributes and URL schemes.
+//
+// Policies implement feed.Sanitizer:
+//
+//	f.SetSanitizer(sanitize.Basic())
+package sanitize
+
+import (
+	"html"
+	"strings"
+
+	"go.rumenx.com/feed/internal/htmltok"
+)
+
+// Policy is an allowlist of elements, attributes and URL schemes.
+// Anything not explicitly allowed is removed: disallowed elements are
+// unwrapped, keeping their text, except for elements such as <script> and
+// <style> whose contents are dropped as well. Comments are removed, event
+// handler and style attributes are always stripped, and the output is
+// balanced so a fragment can never close markup around it.
+type Policy struct {
+	elements    map[string]map[string]bool
+	globalAttrs map[string]bool
+	schemes     map[string]bool
+	nofollow    bool
+}
+
+// dropContent lists elements removed together with their contents
+var dropContent = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"template": true,
+	"noscript": true,
+	"iframe":   true,
+	"frame":    true,
+	"frameset": true,
+	"object":   true,
+	"applet":   true,
+	"svg":      true,
+	"math":     true,
+	"head":     true,
+	"title":    true,
+	"textarea": true,
+	"select":   true,
+	"xmp":      true,
+	"noembed":  true,
+	"noframes": true,
+}
+
+// urlAttrs are attributes holding a single URL
+var urlAttrs = map[string]bool{
+	"href":       true,
+	"src":        true,
+	"cite":       true,
+	"poster":     true,
+	"action":     true,
+	"formaction": true,
+	"background": true,
+	"longdesc":   true,
+	"usemap":     true,
+}
+
+// NewPolicy returns an empty policy, which strips all markup and keeps text
+func NewPolicy() *Policy {
+	return &Policy{
+		elements:    make(map[string]map[string]bool),
+		globalAttrs: make(map[string]bool),
+		schemes:     make(map[string]bool),
+	}
+}
+
+// Strict returns a policy that strips all markup, leaving plain text
+func Strict() *Policy {
+	return NewPolicy()
+}
+
+// Basic returns a policy for text formatting: paragraphs, emphasis, lists,
+// quotes, code, headings and links to http, https and mailto URLs.
+// Links are marked rel="nofollow".
+func Basic() *Policy {
+	return NewPolicy().
+		AllowElements("p", "br", "hr", "div", "span",
+			"b", "strong", "i", "em", "u", "s", "strike", "del", "ins",
+			"sub", "sup", "small", "mark", "abbr", "cite", "dfn",
+			"code", "pre", "kbd", "samp", "var",
+			"blockquote", "q", "ul", "ol", "li", "dl", "dt", "dd",
+			"h1", "h2", "h3", "h4", "h5", "h6", "a").
+		AllowAttrs("*", "title", "lang", "dir").
+		AllowAttrs("a", "href").
+		AllowAttrs("blockquote", "cite").
+		AllowAttrs("q", "cite").
+		AllowAttrs("del", "cite", "datetime").
+		AllowAttrs("ins", "cite", "datetime").
+		AllowAttrs("ol", "start", "reversed", "type").
+		AllowSchemes("http", "https", "mailto").
+		RequireNofollow(true)
+}
+
+// Rich returns the Basic policy extended with images, audio, video,
+// figures, tables and disclosure widgets
+func Rich() *Policy {
+	return Basic().
+		AllowElements("img", "picture", "source", "figure", "figcaption",
+			"audio", "video", "track",
+			"table", "caption", "colgroup", "col", "thead", "tbody", "tfoot", "tr", "th", "td",
+			"details", "summary", "time").
+		AllowAttrs("img", "src", "srcset", "sizes", "alt", "width", "height", "loading").
+		AllowAttrs("source", "src", "srcset", "sizes", "type", "media").
+		AllowAttrs("audio", "src", "controls", "loop", "muted", "preload").
+		AllowAttrs("video", "src", "poster", "controls", "loop", "muted", "preload", "width", "height").
+		AllowAttrs("track", "src", "kind", "srclang", "label", "default").
+		AllowAttrs("col", "span").
+		AllowAttrs("colgroup", "span").
+		AllowAttrs("th", "colspan", "rowspan", "scope").
+		AllowAttrs("td", "colspan", "rowspan").
+		AllowAttrs("details", "open").
+		AllowAttrs("time", "datetime")
+}
+
+// AllowElements allows the named elements, without attributes
+func (p *Policy) AllowElements(names ...string) *Policy {
+	for _, name := range names {
+		name = strings.ToLower(name)
+		if p.elements[name] == nil {
+			p.elements[name] = make(map[string]bool)
+		}
+	}
+	return p
+}
+
+// AllowAttrs allows attributes on an element, which is allowed as well.
+// The element "*" allows the attributes on every allowed element.
+// Event handler (on*) and style attributes are never allowed.
+func (p *Policy) AllowAttrs(element string, attrs ...string) *Policy {
+	element = strings.ToLower(element)
+	target := p.globalAttrs
+	if element != "*" {
+		p.AllowElements(element)
+		target = p.elements[element]
+	}
+	for _, attr := range attrs {
+		target[strings.ToLower(attr)] = true
+	}
+	return p
+}
+
+// AllowSchemes allows URL schemes in href, src and other URL attributes.
+// Relative URLs are always allowed.
+func (p *Policy) AllowSchemes(schemes ...string) *Policy {
+	for _, scheme := range schemes {
+		p.schemes[strings.ToLower(scheme)] = true
+	}
+	return p
+}
+
+// RequireNofollow adds rel="nofollow" to every link when enabled
+func (p *Policy) RequireNofollow(nofollow bool) *Policy {
+	p.nofollow = nofollow
+	return p
+}
+
+// Sanitize returns s with everything not allowed by the policy removed
+func (p *Policy) Sanitize(s string) string {
+	if s == "" {
+		return s
+	}
+
+	var b strings.Builder
+	var open []string // allowed elements currently open
+	skip, skipDepth := "", 0
+
+	t := htmltok.New(s)
+	for {
+		tok, ok := t.Next()
+		if !ok {
+			break
+		}
+
+		// Inside a dropped element, only track nesting of the same element
+		if skip != "" {
+			switch {
+			case tok.Type == htmltok.StartTagToken && tok.Data == skip:
+				skipDepth++
+			case tok.Type == htmltok.EndTagToken && tok.Data == skip:
+				skipDepth--
+				if skipDepth == 0 {
+					skip = ""
+				}
+			}
+			continue
+		}
+
+		switch tok.Type {
+		case htmltok.TextToken:
+			b.WriteString(html.EscapeString(html.UnescapeString(tok.Data)))
+
+		case htmltok.StartTagToken, htmltok.SelfClosingTagToken:
+			if dropContent[tok.Data] {
+				if tok.Type == htmltok.StartTagToken && !htmltok.IsVoid(tok.Data) {
+					skip, skipDepth = tok.Data, 1
+				}
+				continue
+			}
+			allowed, ok := p.elements[tok.Data]
+			if !ok {
+				continue
+			}
+			tok.Attr = p.attrs(tok.Data, tok.Attr, allowed)
+			if htmltok.IsVoid(tok.Data) {
+				tok.Type = htmltok.StartTagToken
+				b.WriteString(tok.String())
+				continue
+			}
+			if tok.Type == htmltok.SelfClosingTagToken {
+				// <p/> is an open tag in HTML; render it as an empty element
+				tok.Type = htmltok.StartTagToken
+				b.WriteString(tok.String() + "</" + tok.Data + ">")
+				continue
+			}
+			b.WriteString(tok.String())
+			open = append(open, tok.Data)
+
+		case htmltok.EndTagToken:
+			// Close the innermost matching element and anything opened after it
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != tok.Data {
+					continue
+				}
+				for len(open) > i {
+					b.WriteString("</" + open[len(open)-1] + ">")
+					open = open[:len(open)-1]
+				}
+				break
+			}
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+	return b.String()
+}
+
+// attrs filters the attributes of an allowed element
+func (p *Policy) attrs(element string, attrs []htmltok.Attr, allowed map[string]bool) []htmltok.Attr {
+	var kept []htmltok.Attr
+	for _, a := range attrs {
+		if strings.HasPrefix(a.Key, "on") || a.Key == "style" {
+			continue
+		}
+		if a.Key == "rel" && p.nofollow && element == "a" {
+			// Replaced by rel="nofollow" below
+			continue
+		}
+		if !allowed[a.Key] && !p.globalAttrs[a.Key] {
+			continue
+		}
+		if urlAttrs[a.Key] && !p.allowedURL(a.Val) {
+			continue
+		}
+		if a.Key == "srcset" && !p.allowedSrcset(a.Val) {
+			continue
+		}
+		kept = append(kept, a)
+	}
+
+	if p.nofollow && element == "a" {
+		for _, a := range kept {
+			if a.Key == "href" {
+				kept = append(kept, htmltok.Attr{Key: "rel", Val: "nofollow"})
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// allowedURL reports whether u is relative or uses an allowed scheme
+func (p *Policy) allowedURL(u string) bool {
+	// Browsers ignore whitespace and control characters inside URLs,
+	// so "java\tscript:" must be treated as "javascript:"
+	clean := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, u)
+
+	colon := strings.IndexByte(clean, ':')
+	if colon < 0 {
+		return true
+	}
+	if slash := strings.IndexAny(clean, "/?#"); slash >= 0 && slash < colon {
+		// The colon belongs to the path or query of a relative URL
+		return true
+	}
+	return p.schemes[strings.ToLower(clean[:colon])]
+}
+
+// allowedSrcset reports whether every URL in a srcset attribute is allowed
+func (p *Policy) allowedSrcset(srcset string) bool {
+	for _, candidate := range strings.Split(srcset, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) > 0 && !p.allowedURL(fields[0]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,167 @@
+package sanitize
+
+import (
+	"strings"
+	"testing"
+
+	"go.rumenx.com/feed/internal/htmltok"
+)
+
+func TestStrict(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"plain text", "plain text"},
+		{"<p>Hello <b>world</b></p>", "Hello world"},
+		{"a < b & c", "a &lt; b &amp; c"},
+		{"Tom &amp; Jerry", "Tom &amp; Jerry"},
+		{"<script>alert(1)</script>after", "after"},
+		{"<style>p{color:red}</style>text", "text"},
+		{"<!-- comment -->text", "text"},
+		{"<svg><script>alert(1)</script><svg></svg></svg>kept", "kept"},
+		{"<img src=x onerror=alert(1)>", ""},
+	}
+	for _, tt := range tests {
+		if got := Strict().Sanitize(tt.in); got != tt.want {
+			t.Errorf("Strict(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBasic(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"<p>Hello <b>world</b></p>", "<p>Hello <b>world</b></p>"},
+		{`<p style="color:red" class="x" onclick="evil()">Hi</p>`, "<p>Hi</p>"},
+		{`<a href="https://example.com/a?x=1&amp;y=2">link</a>`, `<a href="https://example.com/a?x=1&amp;y=2" rel="nofollow">link</a>`},
+		{`<a href="/relative" rel="author">link</a>`, `<a href="/relative" rel="nofollow">link</a>`},
+		{`<a href="javascript:alert(1)">x</a>`, "<a>x</a>"},
+		{`<a href=" JaVa&#x09;ScRiPt:alert(1)">x</a>`, "<a>x</a>"},
+		{`<a href="data:text/html,<script>">x</a>`, "<a>x</a>"},
+		{`<a href="mailto:me@example.com">mail</a>`, `<a href="mailto:me@example.com" rel="nofollow">mail</a>`},
+		{`<a href="page?next=a:b">x</a>`, `<a href="page?next=a:b" rel="nofollow">x</a>`},
+		{"<img src=x.png><br/>", "<br>"},
+		{"<b><i>unclosed", "<b><i>unclosed</i></b>"},
+		{"<b>bold</i></b></b></div>", "<b>bold</b>"},
+		{"<b><i>cross</b></i>", "<b><i>cross</i></b>"},
+		{"<p/>", "<p></p>"},
+		{"<iframe src=x>fallback</iframe>after", "after"},
+		{`<span title="a&quot;b">x</span>`, `<span title="a&#34;b">x</span>`},
+	}
+	for _, tt := range tests {
+		if got := Basic().Sanitize(tt.in); got != tt.want {
+			t.Errorf("Basic(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRich(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{
+			`<img src="/a.png" srcset="/a-2x.png 2x, https://cdn.example.com/a-3x.png 3x" alt="A" onload="x()">`,
+			`<img src="/a.png" srcset="/a-2x.png 2x, https://cdn.example.com/a-3x.png 3x" alt="A">`,
+		},
+		{`<img src="a.png" srcset="a.png 1x, javascript:alert(1) 2x">`, `<img src="a.png">`},
+		{`<video src="v.mp4" controls autoplay><track src="s.vtt" kind="subtitles"></video>`, `<video src="v.mp4" controls=""><track src="s.vtt" kind="subtitles"></video>`},
+		{"<table><tr><td colspan=2 bgcolor=red>x</td></tr></table>", `<table><tr><td colspan="2">x</td></tr></table>`},
+		{"<figure><img src=a.png><figcaption>A</figcaption></figure>", `<figure><img src="a.png"><figcaption>A</figcaption></figure>`},
+	}
+	for _, tt := range tests {
+		if got := Rich().Sanitize(tt.in); got != tt.want {
+			t.Errorf("Rich(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCustomPolicy(t *testing.T) {
+	p := NewPolicy().
+		AllowAttrs("a", "href", "rel", "style", "onclick").
+		AllowSchemes("HTTPS")
+
+	got := p.Sanitize(`<a href="https://example.com" rel="me" style="x" onclick="y"><em>x</em></a>`)
+	want := `<a href="https://example.com" rel="me">x</a>`
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	if got := p.Sanitize(`<a href="http://example.com">x</a>`); got != "<a>x</a>" {
+		t.Errorf("Scheme not in the allowlist should be removed, got %q", got)
+	}
+}
+
+// checkSafe verifies that sanitized output only contains allowed markup
+func checkSafe(t *testing.T, p *Policy, out string) {
+	t.Helper()
+
+	depth := 0
+	for _, tok := range htmltok.Tokenize(out) {
+		switch tok.Type {
+		case htmltok.TextToken:
+			if strings.Contains(tok.Data, "<") {
+				t.Fatalf("Unescaped '<' in text of %q", out)
+			}
+			continue
+		case htmltok.CommentToken, htmltok.DoctypeToken, htmltok.SelfClosingTagToken:
+			t.Fatalf("Unexpected token %q in %q", tok.String(), out)
+		case htmltok.EndTagToken:
+			depth--
+			if depth < 0 {
+				t.Fatalf("Unbalanced end tag in %q", out)
+			}
+			continue
+		}
+
+		allowed, ok := p.elements[tok.Data]
+		if !ok {
+			t.Fatalf("Disallowed element <%s> in %q", tok.Data, out)
+		}
+		if !htmltok.IsVoid(tok.Data) {
+			depth++
+		}
+		for _, a := range tok.Attr {
+			if !allowed[a.Key] && !p.globalAttrs[a.Key] && a.Key != "rel" {
+				t.Fatalf("Disallowed attribute %s in %q", a.Key, out)
+			}
+			if urlAttrs[a.Key] && !p.allowedURL(a.Val) {
+				t.Fatalf("Disallowed URL %q in %q", a.Val, out)
+			}
+		}
+	}
+	if depth != 0 {
+		t.Fatalf("Unbalanced output %q", out)
+	}
+}
+
+func FuzzSanitize(f *testing.F) {
+	seeds := []string{
+		"<p>Hello <b>world</b></p>",
+		`<a href="javascript:alert(1)" onclick="x">x</a>`,
+		"<script>alert(1)</script>",
+		"<img src=x onerror=alert(1)//>",
+		"<svg><style><img src=x onerror=alert(1)></style></svg>",
+		`<a href="java&#0009;script:alert(1)">x</a>`,
+		"<b><i>cross</b></i><p/>",
+		"<!--<script>-->",
+		"<title><a href=x></title>",
+		"a < b && c > d",
+		`<img srcset="a.png 1x, javascript:x 2x">`,
+	}
+	for _, s := range seeds {
+		f.Add(s)
+	}
+
+	policies := []*Policy{Strict(), Basic(), Rich()}
+	f.Fuzz(func(t *testing.T, s string) {
+		for _, p := range policies {
+			out := p.Sanitize(s)
+			checkSafe(t, p, out)
+			if again := p.Sanitize(out); again != out {
+				t.Fatalf("Sanitize is not idempotent:\n in: %q\nout: %q\nagain: %q", s, out, again)
+			}
+		}
+	})
+}
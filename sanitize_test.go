@@ -0,0 +1,92 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+
+	"go.rumenx.com/feed/sanitize"
+)
+
+func TestSanitizerAppliedAtRender(t *testing.T) {
+	f := New().
+		SetTitle("Test Feed").
+		SetDescription("Test Description").
+		SetLink("https://example.com").
+		SetSanitizer(sanitize.Basic())
+	f.AddItem(Item{
+		Title:       "Item",
+		Link:        "https://example.com/item",
+		Description: `<p onclick="evil()">Hi<script>alert(1)</script></p>`,
+		Content:     `<img src=x onerror=alert(1)><a href="javascript:alert(1)">link</a>`,
+	})
+
+	rss, err := f.RSS()
+	if err != nil {
+		t.Fatalf("RSS generation failed: %v", err)
+	}
+	atom, err := f.Atom()
+	if err != nil {
+		t.Fatalf("Atom generation failed: %v", err)
+	}
+
+	for name, out := range map[string]string{"RSS": string(rss), "Atom": string(atom)} {
+		for _, bad := range []string{"<script", "onclick", "onerror", "javascript:"} {
+			if strings.Contains(out, bad) {
+				t.Errorf("%s output should not contain %q", name, bad)
+			}
+		}
+	}
+	if !strings.Contains(string(rss), "<![CDATA[<a>link</a>]]>") {
+		t.Error("RSS should render sanitized content in content:encoded")
+	}
+
+	// The stored items are left untouched
+	if !strings.Contains(f.GetItems()[0].Description, "<script>") {
+		t.Error("Sanitizing should not modify stored items")
+	}
+}
+
+func TestItemContent(t *testing.T) {
+	f := newCollectionFeed()
+	f.AddItem(Item{
+		Title:       "Full",
+		Link:        "https://example.com/full",
+		Description: "Summary",
+		Content:     "<p>Full content</p>",
+	})
+
+	rss, err := f.RSS()
+	if err != nil {
+		t.Fatalf("RSS generation failed: %v", err)
+	}
+	if !strings.Contains(string(rss), `xmlns:content="http://purl.org/rss/1.0/modules/content/"`) {
+		t.Error("RSS should declare the content namespace")
+	}
+	if !strings.Contains(string(rss), "<content:encoded><![CDATA[<p>Full content</p>]]></content:encoded>") {
+		t.Error("RSS should render content:encoded")
+	}
+
+	atom, err := f.Atom()
+	if err != nil {
+		t.Fatalf("Atom generation failed: %v", err)
+	}
+	if !strings.Contains(string(atom), `<content type="html">&lt;p&gt;Full content&lt;/p&gt;</content>`) {
+		t.Error("Atom content should use the item content")
+	}
+
+	for name, data := range map[string][]byte{"RSS": rss, "Atom": atom} {
+		parsed, err := Parse(strings.NewReader(string(data)))
+		if err != nil {
+			t.Fatalf("%s: parse failed: %v", name, err)
+		}
+		for _, item := range parsed.GetItems() {
+			want := ""
+			if item.Title == "Full" {
+				want = "<p>Full content</p>"
+			}
+			if item.Content != want {
+				t.Errorf("%s: item %q content = %q, want %q", name, item.Title, item.Content, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package feed
+
+import (
+	"time"
+)
+
+// SetClock sets the function used to read the current time when deciding
+// which scheduled items are live. It defaults to time.Now.
+func (f *Feed) SetClock(now func() time.Time) *Feed {
+	f.clock = now
+	return f
+}
+
+// now returns the current time according to the feed's clock
+func (f *Feed) now() time.Time {
+	if f.clock != nil {
+		return f.clock()
+	}
+	return time.Now()
+}
+
+// IsLive reports whether the item is published and not yet expired at the
+// given time
+func (i Item) IsLive(now time.Time) bool {
+	if !i.PublishAt.IsZero() && now.Before(i.PublishAt) {
+		return false
+	}
+	return i.ExpireAt.IsZero() || now.Before(i.ExpireAt)
+}
+
+// scheduled reports whether any item has a publish or expiry time
+func (f *Feed) scheduled() bool {
+	for _, item := range f.items {
+		if !item.PublishAt.IsZero() || !item.ExpireAt.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// NextChange returns the earliest time after now at which a scheduled item
+// is published or expires, changing the rendered output, or the zero time
+// when nothing is scheduled
+func (f *Feed) NextChange() time.Time {
+	return f.nextChange(f.now())
+}
+
+func (f *Feed) nextChange(now time.Time) time.Time {
+	var next time.Time
+	for _, item := range f.items {
+		for _, t := range []time.Time{item.PublishAt, item.ExpireAt} {
+			if t.After(now) && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+	}
+	return next
+}
+
+// MaxAge caps a cache lifetime so that cached output expires no later than
+// the next scheduled change, e.g. for a Cache-Control max-age
+func (f *Feed) MaxAge(ttl time.Duration) time.Duration {
+	return f.MaxAgeAt(f.now(), ttl)
+}
+
+// MaxAgeAt is like MaxAge but measures the lifetime from now instead of the
+// feed's clock, for callers such as caches that keep time with their own
+func (f *Feed) MaxAgeAt(now time.Time, ttl time.Duration) time.Duration {
+	next := f.nextChange(now)
+	if next.IsZero() {
+		return ttl
+	}
+	if until := next.Sub(now); until < ttl {
+		return until
+	}
+	return ttl
+}
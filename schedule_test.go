@@ -0,0 +1,121 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func scheduledFeed(now *time.Time) *Feed {
+	at := func(h int) time.Time { return time.Date(2025, 1, 1, h, 0, 0, 0, time.UTC) }
+	return New().
+		SetTitle("Schedule").
+		SetDescription("Scheduled items").
+		SetLink("https://example.com").
+		SetClock(func() time.Time { return *now }).
+		AddItems([]Item{
+			{Title: "Always", Link: "https://example.com/always"},
+			{Title: "Embargoed", Link: "https://example.com/embargoed", PublishAt: at(12)},
+			{Title: "Promo", Link: "https://example.com/promo", PublishAt: at(9), ExpireAt: at(18)},
+		})
+}
+
+func TestItemIsLive(t *testing.T) {
+	publish := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	item := Item{PublishAt: publish, ExpireAt: publish.Add(time.Hour)}
+
+	tests := []struct {
+		now  time.Time
+		want bool
+	}{
+		{publish.Add(-time.Second), false},
+		{publish, true},
+		{publish.Add(59 * time.Minute), true},
+		{publish.Add(time.Hour), false},
+	}
+	for _, tt := range tests {
+		if got := item.IsLive(tt.now); got != tt.want {
+			t.Errorf("IsLive(%s): expected %v, got %v", tt.now.Format(time.Kitchen), tt.want, got)
+		}
+	}
+	if !(Item{}).IsLive(publish) {
+		t.Error("Expected an unscheduled item to be live")
+	}
+}
+
+func TestScheduledRendering(t *testing.T) {
+	now := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	f := scheduledFeed(&now)
+
+	tests := []struct {
+		hour int
+		want []string
+	}{
+		{8, []string{"Always"}},
+		{10, []string{"Always", "Promo"}},
+		{12, []string{"Always", "Embargoed", "Promo"}},
+		{18, []string{"Always", "Embargoed"}},
+	}
+	for _, tt := range tests {
+		now = time.Date(2025, 1, 1, tt.hour, 0, 0, 0, time.UTC)
+		rss, err := f.RSS()
+		if err != nil {
+			t.Fatalf("RSS failed: %v", err)
+		}
+		var buf bytes.Buffer
+		if err := f.WriteAtom(context.Background(), &buf, nil); err != nil {
+			t.Fatalf("WriteAtom failed: %v", err)
+		}
+		for _, title := range []string{"Always", "Embargoed", "Promo"} {
+			want := slices.Contains(tt.want, title)
+			tag := "<title>" + title + "</title>"
+			if strings.Contains(string(rss), tag) != want || strings.Contains(buf.String(), tag) != want {
+				t.Errorf("%02d:00: expected %s to be rendered: %v", tt.hour, title, want)
+			}
+		}
+	}
+	if len(f.GetItems()) != 3 {
+		t.Errorf("Rendering should not drop scheduled items, got %d", len(f.GetItems()))
+	}
+}
+
+func TestScheduledMaxItems(t *testing.T) {
+	now := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	f := New().SetClock(func() time.Time { return now }).SetMaxItems(1).AddItems([]Item{
+		{Title: "Embargoed", PublishAt: now.Add(time.Hour)},
+		{Title: "Live"},
+	})
+	if items := f.servedItems(); len(items) != 1 || items[0].Title != "Live" {
+		t.Errorf("Expected embargoed items not to count toward max items, got %+v", items)
+	}
+}
+
+func TestNextChangeAndMaxAge(t *testing.T) {
+	now := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	f := scheduledFeed(&now)
+
+	if next := f.NextChange(); !next.Equal(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the promo to be the next change, got %s", next)
+	}
+	if got := f.MaxAge(time.Hour); got != time.Hour {
+		t.Errorf("Expected the TTL to be kept, got %s", got)
+	}
+	if got := f.Snapshot().MaxAge(2 * time.Hour); got != time.Hour {
+		t.Errorf("Expected the TTL to shrink to the next change, got %s", got)
+	}
+
+	if got := f.MaxAgeAt(time.Date(2025, 1, 1, 8, 30, 0, 0, time.UTC), 2*time.Hour); got != 30*time.Minute {
+		t.Errorf("Expected the lifetime to be measured from the given time, got %s", got)
+	}
+
+	now = time.Date(2025, 1, 1, 18, 0, 0, 0, time.UTC)
+	if next := f.NextChange(); !next.IsZero() {
+		t.Errorf("Expected nothing left to change, got %s", next)
+	}
+	if got := New().MaxAge(time.Minute); got != time.Minute {
+		t.Errorf("Expected an unscheduled feed to keep its TTL, got %s", got)
+	}
+}
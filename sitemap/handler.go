@@ -0,0 +1,113 @@
+package sitemap
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"go.rumenx.com/feed"
+)
+
+// contentType is the Content-Type sitemaps are served with
+const contentType = "application/xml; charset=utf-8"
+
+// Handler returns an http.Handler serving the sitemap of the feed returned
+// by generator, after passing the Builder to the configure functions. When
+// the URLs do not fit in one file it serves a sitemap index instead, whose
+// entries point back at the same URL with a page query parameter.
+func Handler(generator func() *feed.Feed, configure ...func(*Builder)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, ok := build(w, generator, configure)
+		if !ok {
+			return
+		}
+
+		files, err := b.Split()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var data []byte
+		switch page := req.URL.Query().Get("page"); {
+		case page != "":
+			n, err := strconv.Atoi(page)
+			if err != nil || n < 1 || n > len(files) {
+				http.NotFound(w, req)
+				return
+			}
+			data = files[n-1]
+		case len(files) == 1:
+			data = files[0]
+		default:
+			self := requestURL(req)
+			data, err = b.Index(func(n int) string {
+				q := self.Query()
+				q.Set("page", strconv.Itoa(n))
+				u := *self
+				u.RawQuery = q.Encode()
+				return u.String()
+			})
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		serve(w, req, data)
+	})
+}
+
+// NewsHandler returns an http.Handler serving the Google News sitemap of
+// the feed returned by generator
+func NewsHandler(generator func() *feed.Feed, configure ...func(*Builder)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, ok := build(w, generator, configure)
+		if !ok {
+			return
+		}
+		data, err := b.News()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		serve(w, req, data)
+	})
+}
+
+// build creates and configures the Builder for a request, writing an error
+// response when the feed cannot be generated
+func build(w http.ResponseWriter, generator func() *feed.Feed, configure []func(*Builder)) (*Builder, bool) {
+	f := generator()
+	if f == nil {
+		http.Error(w, "Failed to generate feed", http.StatusInternalServerError)
+		return nil, false
+	}
+	b := NewBuilder(f)
+	for _, fn := range configure {
+		fn(b)
+	}
+	return b, true
+}
+
+func serve(w http.ResponseWriter, req *http.Request, data []byte) {
+	w.Header().Set("Content-Type", contentType)
+	if req.Method == http.MethodHead {
+		return
+	}
+	_, _ = w.Write(data)
+}
+
+// requestURL returns the absolute URL of the request
+func requestURL(req *http.Request) *url.URL {
+	u := *req.URL
+	if u.Scheme == "" {
+		u.Scheme = "http"
+		if req.TLS != nil {
+			u.Scheme = "https"
+		}
+	}
+	if u.Host == "" {
+		u.Host = req.Host
+	}
+	return &u
+}
@@ -0,0 +1,98 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// newsWindow is how far back Google News reads a news sitemap
+const newsWindow = 48 * time.Hour
+
+type xmlNews struct {
+	Publication     xmlPublication `xml:"news:publication"`
+	PublicationDate string         `xml:"news:publication_date"`
+	Title           string         `xml:"news:title"`
+}
+
+type xmlPublication struct {
+	Name     string `xml:"news:name"`
+	Language string `xml:"news:language"`
+}
+
+// SetPublication sets the publication name and language of the news
+// sitemap. By default they are the title and language of the first feed.
+func (b *Builder) SetPublication(name, language string) *Builder {
+	b.name, b.language = name, language
+	return b
+}
+
+// News renders a Google News sitemap of the items published in the last
+// 48 hours, newest first and at most MaxNewsURLs of them
+func (b *Builder) News() ([]byte, error) {
+	name, language := b.name, b.language
+	if len(b.feeds) > 0 {
+		if name == "" {
+			name = b.feeds[0].GetTitle()
+		}
+		if language == "" {
+			language = b.feeds[0].GetLanguage()
+		}
+	}
+	language = newsLanguage(language)
+	if name == "" || language == "" {
+		return nil, ErrMissingPublication
+	}
+
+	var buf strings.Builder
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<urlset xmlns="` + sitemapNamespace + `" xmlns:news="` + newsNamespace + "\">\n")
+
+	now := b.now()
+	seen := map[string]bool{}
+	count := 0
+	for _, entry := range b.entries(now) {
+		pub := entry.item.PubDate
+		if count == MaxNewsURLs || pub.IsZero() || pub.Before(now.Add(-newsWindow)) {
+			break
+		}
+		if pub.After(now) || seen[entry.loc] {
+			continue
+		}
+		seen[entry.loc] = true
+		count++
+
+		data, err := xml.MarshalIndent(xmlURL{
+			Loc: entry.loc,
+			News: &xmlNews{
+				Publication:     xmlPublication{Name: name, Language: language},
+				PublicationDate: formatDate(pub),
+				Title:           entry.item.Title,
+			},
+		}, "  ", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal news sitemap URL: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	buf.WriteString("</urlset>\n")
+	return []byte(buf.String()), nil
+}
+
+// newsLanguage converts a language tag to the ISO 639 code Google News
+// expects: the language alone, except for Chinese, which keeps its script
+// as zh-cn or zh-tw
+func newsLanguage(tag string) string {
+	tag = strings.ToLower(strings.ReplaceAll(tag, "_", "-"))
+	lang, region, _ := strings.Cut(tag, "-")
+	if lang != "zh" {
+		return lang
+	}
+	switch region {
+	case "tw", "hk", "mo", "hant":
+		return "zh-tw"
+	}
+	return "zh-cn"
+}
@@ -0,0 +1,533 @@
+// Package sitemap renders the items of feeds as XML sitemaps
+// (sitemaps.org), with Google's image, video and news extensions.
+//
+// A Builder collects the live items of one or more feeds, one URL per item
+// link. Sitemap renders a single file; Split and Index break large sites
+// into files of at most 50,000 URLs and 50MB, listed by a sitemap index.
+// News renders a Google News sitemap of the items published in the last
+// two days.
+package sitemap
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.rumenx.com/feed"
+)
+
+// Common errors
+var (
+	ErrTooLarge           = errors.New("sitemap exceeds its limits")
+	ErrInvalidPriority    = errors.New("priority must be between 0 and 1")
+	ErrMissingPublication = errors.New("news sitemap requires a publication name and language")
+)
+
+// Limits of a single sitemap file set by the sitemaps.org protocol
+const (
+	MaxURLs  = 50000
+	MaxBytes = 50 * 1024 * 1024
+
+	// MaxNewsURLs is the most URLs Google reads from a news sitemap
+	MaxNewsURLs = 1000
+)
+
+// Change frequencies
+const (
+	Always  = "always"
+	Hourly  = "hourly"
+	Daily   = "daily"
+	Weekly  = "weekly"
+	Monthly = "monthly"
+	Yearly  = "yearly"
+	Never   = "never"
+)
+
+// Namespaces of the sitemap protocol and its extensions
+const (
+	sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	imageNamespace   = "http://www.google.com/schemas/sitemap-image/1.1"
+	videoNamespace   = "http://www.google.com/schemas/sitemap-video/1.1"
+	newsNamespace    = "http://www.google.com/schemas/sitemap-news/0.9"
+)
+
+// URL is a page listed in a sitemap
+type URL struct {
+	Loc        string
+	LastMod    time.Time
+	ChangeFreq string  // one of the change frequency constants
+	Priority   float64 // between 0 and 1; zero leaves it out
+	Images     []string
+	Videos     []Video
+}
+
+// Video describes a video on a page. Google requires the thumbnail, title,
+// description and either the content or the player URL.
+type Video struct {
+	ThumbnailURL    string
+	Title           string
+	Description     string
+	ContentURL      string
+	PlayerURL       string
+	Duration        time.Duration
+	PublicationDate time.Time
+}
+
+// xmlURL is the on-the-wire <url> element
+type xmlURL struct {
+	XMLName    xml.Name   `xml:"url"`
+	Loc        string     `xml:"loc"`
+	LastMod    string     `xml:"lastmod,omitempty"`
+	ChangeFreq string     `xml:"changefreq,omitempty"`
+	Priority   string     `xml:"priority,omitempty"`
+	Images     []xmlImage `xml:"image:image,omitempty"`
+	Videos     []xmlVideo `xml:"video:video,omitempty"`
+	News       *xmlNews   `xml:"news:news,omitempty"`
+}
+
+type xmlImage struct {
+	Loc string `xml:"image:loc"`
+}
+
+type xmlVideo struct {
+	ThumbnailLoc    string `xml:"video:thumbnail_loc"`
+	Title           string `xml:"video:title"`
+	Description     string `xml:"video:description"`
+	ContentLoc      string `xml:"video:content_loc,omitempty"`
+	PlayerLoc       string `xml:"video:player_loc,omitempty"`
+	Duration        int    `xml:"video:duration,omitempty"`
+	PublicationDate string `xml:"video:publication_date,omitempty"`
+}
+
+// xmlIndexEntry is the on-the-wire <sitemap> element of an index
+type xmlIndexEntry struct {
+	XMLName xml.Name `xml:"sitemap"`
+	Loc     string   `xml:"loc"`
+	LastMod string   `xml:"lastmod,omitempty"`
+}
+
+// Builder builds sitemaps from the items of feeds
+type Builder struct {
+	feeds      []*feed.Feed
+	extra      []URL
+	changeFreq string
+	priority   float64
+	configure  func(feed.Item, *URL)
+	maxURLs    int
+	maxBytes   int
+	now        func() time.Time
+	name       string
+	language   string
+}
+
+// NewBuilder creates a Builder for the items of the given feeds
+func NewBuilder(feeds ...*feed.Feed) *Builder {
+	return &Builder{
+		feeds:    feeds,
+		maxURLs:  MaxURLs,
+		maxBytes: MaxBytes,
+		now:      time.Now,
+	}
+}
+
+// AddURL adds a page that is not an item, such as the home page
+func (b *Builder) AddURL(u URL) *Builder {
+	b.extra = append(b.extra, u)
+	return b
+}
+
+// SetChangeFreq sets the change frequency of item URLs
+func (b *Builder) SetChangeFreq(freq string) *Builder {
+	b.changeFreq = freq
+	return b
+}
+
+// SetPriority sets the priority of item URLs, between 0 and 1
+func (b *Builder) SetPriority(priority float64) *Builder {
+	b.priority = priority
+	return b
+}
+
+// SetConfigure sets a function called with every item and the URL built
+// for it, to adjust the change frequency, priority, images or videos
+func (b *Builder) SetConfigure(fn func(feed.Item, *URL)) *Builder {
+	b.configure = fn
+	return b
+}
+
+// SetLimits sets the most URLs and bytes per sitemap file. Zero keeps the
+// protocol limits, which are also the most allowed.
+func (b *Builder) SetLimits(urls, bytes int) *Builder {
+	b.maxURLs, b.maxBytes = MaxURLs, MaxBytes
+	if urls > 0 && urls < MaxURLs {
+		b.maxURLs = urls
+	}
+	if bytes > 0 && bytes < MaxBytes {
+		b.maxBytes = bytes
+	}
+	return b
+}
+
+// SetNow sets the clock used to skip scheduled and expired items and to
+// select recent items for the news sitemap
+func (b *Builder) SetNow(now func() time.Time) *Builder {
+	b.now = now
+	return b
+}
+
+// URLs returns the URLs of the sitemap: the added pages, then one per item
+// link, newest first. Relative links are resolved against the link of
+// their feed, and a link is listed once.
+func (b *Builder) URLs() []URL {
+	urls := append([]URL(nil), b.extra...)
+	now := b.now()
+	for _, entry := range b.entries(now) {
+		u := URL{
+			Loc:        entry.loc,
+			LastMod:    entry.item.PubDate,
+			ChangeFreq: b.changeFreq,
+			Priority:   b.priority,
+			Images:     itemImages(entry.item, entry.base),
+			Videos:     itemVideos(entry.item, entry.base),
+		}
+		if b.configure != nil {
+			b.configure(entry.item, &u)
+		}
+		urls = append(urls, u)
+	}
+	return dedupe(urls)
+}
+
+// entry is a live item with its absolute link
+type entry struct {
+	item feed.Item
+	loc  string
+	base *url.URL
+}
+
+// entries returns the live items with a link, newest first
+func (b *Builder) entries(now time.Time) []entry {
+	var entries []entry
+	for _, f := range b.feeds {
+		base, _ := url.Parse(f.GetLink())
+		for _, item := range f.GetItems() {
+			if item.Link == "" || !item.IsLive(now) {
+				continue
+			}
+			entries = append(entries, entry{item: item, loc: resolve(base, item.Link), base: base})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].item.PubDate.After(entries[j].item.PubDate)
+	})
+	return entries
+}
+
+// dedupe keeps the first URL for each location
+func dedupe(urls []URL) []URL {
+	seen := make(map[string]bool, len(urls))
+	out := urls[:0]
+	for _, u := range urls {
+		if !seen[u.Loc] {
+			seen[u.Loc] = true
+			out = append(out, u)
+		}
+	}
+	return out
+}
+
+// Sitemap renders all URLs as a single sitemap. It returns ErrTooLarge
+// when they exceed the limits of one file; use Split and Index instead.
+func (b *Builder) Sitemap() ([]byte, error) {
+	parts, err := b.split(b.URLs())
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) > 1 {
+		return nil, fmt.Errorf("%w: %d files needed", ErrTooLarge, len(parts))
+	}
+	return parts[0].data, nil
+}
+
+// Split renders the URLs as as many sitemaps as the limits require
+func (b *Builder) Split() ([][]byte, error) {
+	parts, err := b.split(b.URLs())
+	if err != nil {
+		return nil, err
+	}
+	files := make([][]byte, len(parts))
+	for i, p := range parts {
+		files[i] = p.data
+	}
+	return files, nil
+}
+
+// Index renders a sitemap index of the files returned by Split. loc returns
+// the absolute URL the nth file, counting from 1, is served at. Each entry
+// is dated with the newest lastmod of its file.
+func (b *Builder) Index(loc func(n int) string) ([]byte, error) {
+	parts, err := b.split(b.URLs())
+	if err != nil {
+		return nil, err
+	}
+	return renderIndex(parts, loc)
+}
+
+// part is a rendered sitemap file
+type part struct {
+	data    []byte
+	lastMod time.Time
+}
+
+// split renders the URLs into files within the limits
+func (b *Builder) split(urls []URL) ([]part, error) {
+	encoded := make([][]byte, len(urls))
+	images, videos := false, false
+	for i, u := range urls {
+		x, err := toXML(u)
+		if err != nil {
+			return nil, err
+		}
+		images = images || len(x.Images) > 0
+		videos = videos || len(x.Videos) > 0
+		if encoded[i], err = xml.MarshalIndent(x, "  ", "  "); err != nil {
+			return nil, fmt.Errorf("failed to marshal sitemap URL: %w", err)
+		}
+	}
+
+	open := `<urlset xmlns="` + sitemapNamespace + `"`
+	if images {
+		open += ` xmlns:image="` + imageNamespace + `"`
+	}
+	if videos {
+		open += ` xmlns:video="` + videoNamespace + `"`
+	}
+	open += ">\n"
+	overhead := len(xml.Header) + len(open) + len("</urlset>\n")
+
+	var parts []part
+	var current []byte
+	var lastMod time.Time
+	count, size := 0, overhead
+	flush := func() {
+		data := make([]byte, 0, size)
+		data = append(append(append(data, xml.Header...), open...), current...)
+		data = append(data, "</urlset>\n"...)
+		parts = append(parts, part{data: data, lastMod: lastMod})
+		current, lastMod, count, size = nil, time.Time{}, 0, overhead
+	}
+	for i, e := range encoded {
+		n := len(e) + 1
+		if overhead+n > b.maxBytes {
+			return nil, fmt.Errorf("%w: the entry for %s alone is %d bytes", ErrTooLarge, urls[i].Loc, n)
+		}
+		if count > 0 && (count == b.maxURLs || size+n > b.maxBytes) {
+			flush()
+		}
+		current = append(append(current, e...), '\n')
+		count++
+		size += n
+		if urls[i].LastMod.After(lastMod) {
+			lastMod = urls[i].LastMod
+		}
+	}
+	if count > 0 || len(parts) == 0 {
+		flush()
+	}
+	return parts, nil
+}
+
+// toXML converts a URL to its on-the-wire form
+func toXML(u URL) (xmlURL, error) {
+	x := xmlURL{
+		Loc:        u.Loc,
+		LastMod:    formatDate(u.LastMod),
+		ChangeFreq: u.ChangeFreq,
+	}
+	if u.Priority < 0 || u.Priority > 1 {
+		return x, fmt.Errorf("%w: %s has %v", ErrInvalidPriority, u.Loc, u.Priority)
+	}
+	if u.Priority > 0 {
+		x.Priority = strconv.FormatFloat(u.Priority, 'f', -1, 64)
+	}
+	for _, img := range u.Images {
+		x.Images = append(x.Images, xmlImage{Loc: img})
+	}
+	for _, v := range u.Videos {
+		x.Videos = append(x.Videos, xmlVideo{
+			ThumbnailLoc:    v.ThumbnailURL,
+			Title:           v.Title,
+			Description:     v.Description,
+			ContentLoc:      v.ContentURL,
+			PlayerLoc:       v.PlayerURL,
+			Duration:        int(v.Duration / time.Second),
+			PublicationDate: formatDate(v.PublicationDate),
+		})
+	}
+	return x, nil
+}
+
+// renderIndex renders a sitemap index of the parts
+func renderIndex(parts []part, loc func(n int) string) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<sitemapindex xmlns="` + sitemapNamespace + "\">\n")
+	for i, p := range parts {
+		data, err := xml.MarshalIndent(xmlIndexEntry{Loc: loc(i + 1), LastMod: formatDate(p.lastMod)}, "  ", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal sitemap index: %w", err)
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+	b.WriteString("</sitemapindex>\n")
+	return []byte(b.String()), nil
+}
+
+// descriptionExcerpter cuts video descriptions to Google's 2048 characters
+var descriptionExcerpter = feed.NewExcerpter().SetMaxChars(2047)
+
+// itemImages returns the images of an item: its Images, image enclosures
+// and image media contents
+func itemImages(item feed.Item, base *url.URL) []string {
+	var images []string
+	for _, img := range item.Images {
+		images = append(images, resolve(base, img.URL))
+	}
+	for _, enc := range enclosures(item) {
+		if strings.HasPrefix(enc.Type, "image/") {
+			images = append(images, resolve(base, enc.URL))
+		}
+	}
+	for _, c := range mediaContents(item) {
+		if c.content.URL != "" && (c.content.Medium == "image" || strings.HasPrefix(c.content.Type, "image/")) {
+			images = append(images, resolve(base, c.content.URL))
+		}
+	}
+	return images
+}
+
+// itemVideos returns the videos of an item that have the thumbnail Google
+// requires: video media contents, whose thumbnail and player may come from
+// their group or the item's media, and video enclosures, which use the
+// first item image
+func itemVideos(item feed.Item, base *url.URL) []Video {
+	description := item.Description
+	if description == "" {
+		description = item.Content
+	}
+	video := Video{
+		Title:           item.Title,
+		Description:     descriptionExcerpter.Excerpt(description),
+		PublicationDate: item.PubDate,
+	}
+	if video.Description == "" {
+		video.Description = item.Title
+	}
+
+	fallback := ""
+	if len(item.Images) > 0 {
+		fallback = item.Images[0].URL
+	}
+
+	var videos []Video
+	add := func(v Video) {
+		if v.ThumbnailURL == "" || (v.ContentURL == "" && v.PlayerURL == "") {
+			return
+		}
+		v.ThumbnailURL = resolve(base, v.ThumbnailURL)
+		v.ContentURL = resolve(base, v.ContentURL)
+		v.PlayerURL = resolve(base, v.PlayerURL)
+		videos = append(videos, v)
+	}
+	for _, c := range mediaContents(item) {
+		if c.content.Medium != "video" && !strings.HasPrefix(c.content.Type, "video/") {
+			continue
+		}
+		v := video
+		v.ContentURL = c.content.URL
+		v.Duration = time.Duration(c.content.Duration) * time.Second
+		v.ThumbnailURL = fallback
+		for _, meta := range c.metadata {
+			if len(meta.Thumbnails) > 0 {
+				v.ThumbnailURL = meta.Thumbnails[0].URL
+				break
+			}
+		}
+		for _, meta := range c.metadata {
+			if meta.Player != nil {
+				v.PlayerURL = meta.Player.URL
+				break
+			}
+		}
+		add(v)
+	}
+	for _, enc := range enclosures(item) {
+		if strings.HasPrefix(enc.Type, "video/") {
+			v := video
+			v.ContentURL = enc.URL
+			v.ThumbnailURL = fallback
+			add(v)
+		}
+	}
+	return videos
+}
+
+// enclosures returns the enclosure and the additional enclosures of an item
+func enclosures(item feed.Item) []feed.Enclosure {
+	var out []feed.Enclosure
+	if item.Enclosure != nil {
+		out = append(out, *item.Enclosure)
+	}
+	return append(out, item.Enclosures...)
+}
+
+// mediaContent is a media content with the metadata that applies to it,
+// most specific first
+type mediaContent struct {
+	content  feed.MediaContent
+	metadata []feed.MediaMetadata
+}
+
+// mediaContents returns the media contents of an item, grouped or not
+func mediaContents(item feed.Item) []mediaContent {
+	m := item.Media
+	if m == nil {
+		return nil
+	}
+	var out []mediaContent
+	for _, g := range m.Groups {
+		for _, c := range g.Contents {
+			out = append(out, mediaContent{c, []feed.MediaMetadata{c.MediaMetadata, g.MediaMetadata, m.MediaMetadata}})
+		}
+	}
+	for _, c := range m.Contents {
+		out = append(out, mediaContent{c, []feed.MediaMetadata{c.MediaMetadata, m.MediaMetadata}})
+	}
+	return out
+}
+
+// resolve makes ref absolute against base
+func resolve(base *url.URL, ref string) string {
+	if ref == "" || base == nil {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
+
+// formatDate formats a time in the W3C datetime format
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,349 @@
+package sitemap
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.rumenx.com/feed"
+)
+
+var now = time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func testFeed() *feed.Feed {
+	return feed.New().
+		SetTitle("Example News").
+		SetDescription("News").
+		SetLink("https://example.com/").
+		SetLanguage("en-us").
+		AddItem(feed.Item{
+			Title:   "Older post",
+			Link:    "/posts/older",
+			PubDate: now.Add(-72 * time.Hour),
+			Images:  []feed.Image{{URL: "/img/older.png"}},
+		}).
+		AddItem(feed.Item{
+			Title:       "Launch video",
+			Description: "<p>Watch the <b>launch</b>.</p>",
+			Link:        "https://example.com/posts/launch",
+			PubDate:     now.Add(-2 * time.Hour),
+			Media: &feed.Media{
+				Contents: []feed.MediaContent{{
+					URL:      "https://cdn.example.com/launch.mp4",
+					Type:     "video/mp4",
+					Duration: 185,
+					MediaMetadata: feed.MediaMetadata{
+						Thumbnails: []feed.MediaThumbnail{{URL: "https://cdn.example.com/launch.jpg"}},
+					},
+				}},
+			},
+		}).
+		AddItem(feed.Item{
+			Title:     "Scheduled",
+			Link:      "https://example.com/posts/scheduled",
+			PubDate:   now.Add(time.Hour),
+			PublishAt: now.Add(time.Hour),
+		}).
+		AddItem(feed.Item{Title: "No link", PubDate: now})
+}
+
+func TestURLs(t *testing.T) {
+	urls := NewBuilder(testFeed()).
+		SetNow(func() time.Time { return now }).
+		SetChangeFreq(Weekly).
+		SetPriority(0.5).
+		AddURL(URL{Loc: "https://example.com/", ChangeFreq: Daily, Priority: 1}).
+		URLs()
+
+	if len(urls) != 3 {
+		t.Fatalf("Expected 3 URLs, got %+v", urls)
+	}
+	if urls[0].Loc != "https://example.com/" || urls[0].ChangeFreq != Daily {
+		t.Errorf("Expected the added URL first, got %+v", urls[0])
+	}
+	launch, older := urls[1], urls[2]
+	if launch.Loc != "https://example.com/posts/launch" || !launch.LastMod.Equal(now.Add(-2*time.Hour)) {
+		t.Errorf("Expected the newest item next, got %+v", launch)
+	}
+	if launch.ChangeFreq != Weekly || launch.Priority != 0.5 {
+		t.Errorf("Expected item defaults, got %+v", launch)
+	}
+	if older.Loc != "https://example.com/posts/older" {
+		t.Errorf("Expected relative link to be resolved, got %q", older.Loc)
+	}
+	if len(older.Images) != 1 || older.Images[0] != "https://example.com/img/older.png" {
+		t.Errorf("Expected resolved image, got %v", older.Images)
+	}
+
+	if len(launch.Videos) != 1 {
+		t.Fatalf("Expected a video, got %+v", launch.Videos)
+	}
+	v := launch.Videos[0]
+	if v.ThumbnailURL != "https://cdn.example.com/launch.jpg" || v.ContentURL != "https://cdn.example.com/launch.mp4" {
+		t.Errorf("Unexpected video URLs: %+v", v)
+	}
+	if v.Title != "Launch video" || v.Description != "Watch the launch." || v.Duration != 185*time.Second {
+		t.Errorf("Unexpected video metadata: %+v", v)
+	}
+}
+
+func TestURLsConfigureAndDedupe(t *testing.T) {
+	f := testFeed()
+	f.AddItem(feed.Item{Title: "Duplicate", Link: "https://example.com/posts/older", PubDate: now.Add(-100 * time.Hour)})
+
+	urls := NewBuilder(f).
+		SetNow(func() time.Time { return now }).
+		SetConfigure(func(item feed.Item, u *URL) {
+			if strings.Contains(item.Title, "video") {
+				u.Priority = 0.9
+			}
+		}).
+		URLs()
+	if len(urls) != 2 {
+		t.Fatalf("Expected duplicate links to be listed once, got %+v", urls)
+	}
+	if urls[0].Priority != 0.9 || urls[1].Priority != 0 {
+		t.Errorf("Expected configure to set priorities, got %v and %v", urls[0].Priority, urls[1].Priority)
+	}
+}
+
+func TestSitemap(t *testing.T) {
+	data, err := NewBuilder(testFeed()).
+		SetNow(func() time.Time { return now }).
+		SetChangeFreq(Weekly).
+		SetPriority(0.8).
+		Sitemap()
+	if err != nil {
+		t.Fatalf("Sitemap failed: %v", err)
+	}
+	out := string(data)
+
+	for _, want := range []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:image="http://www.google.com/schemas/sitemap-image/1.1" xmlns:video="http://www.google.com/schemas/sitemap-video/1.1">`,
+		`<loc>https://example.com/posts/launch</loc>`,
+		`<lastmod>2024-03-10T10:00:00Z</lastmod>`,
+		`<changefreq>weekly</changefreq>`,
+		`<priority>0.8</priority>`,
+		`<image:loc>https://example.com/img/older.png</image:loc>`,
+		`<video:thumbnail_loc>https://cdn.example.com/launch.jpg</video:thumbnail_loc>`,
+		`<video:content_loc>https://cdn.example.com/launch.mp4</video:content_loc>`,
+		`<video:duration>185</video:duration>`,
+		`<video:publication_date>2024-03-10T10:00:00Z</video:publication_date>`,
+		`</urlset>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected sitemap to contain %s, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "scheduled") {
+		t.Error("Expected scheduled items to be left out")
+	}
+
+	// Without extensions only the sitemap namespace is declared
+	plain := feed.New().SetLink("https://example.com/").AddItem(feed.Item{Link: "/a"})
+	data, err = NewBuilder(plain).Sitemap()
+	if err != nil {
+		t.Fatalf("Sitemap failed: %v", err)
+	}
+	if strings.Contains(string(data), "xmlns:image") || strings.Contains(string(data), "xmlns:video") {
+		t.Errorf("Expected no extension namespaces, got:\n%s", data)
+	}
+}
+
+func TestSitemapInvalidPriority(t *testing.T) {
+	if _, err := NewBuilder(testFeed()).SetPriority(1.5).Sitemap(); !errors.Is(err, ErrInvalidPriority) {
+		t.Errorf("Expected ErrInvalidPriority, got %v", err)
+	}
+}
+
+// manyItems returns a feed with n items, the newest first
+func manyItems(n int) *feed.Feed {
+	f := feed.New().SetLink("https://example.com/")
+	for i := 0; i < n; i++ {
+		f.AddItem(feed.Item{Link: fmt.Sprintf("/posts/%d", i), PubDate: now.Add(-time.Duration(i) * time.Hour)})
+	}
+	return f
+}
+
+func TestSplitByURLs(t *testing.T) {
+	b := NewBuilder(manyItems(5)).SetNow(func() time.Time { return now }).SetLimits(2, 0)
+
+	if _, err := b.Sitemap(); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Expected ErrTooLarge, got %v", err)
+	}
+
+	files, err := b.Split()
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("Expected 3 files, got %d", len(files))
+	}
+	for i, want := range []int{2, 2, 1} {
+		if got := strings.Count(string(files[i]), "<url>"); got != want {
+			t.Errorf("Expected file %d to have %d URLs, got %d", i+1, want, got)
+		}
+	}
+	if !strings.Contains(string(files[2]), "<loc>https://example.com/posts/4</loc>") {
+		t.Errorf("Expected the oldest item in the last file, got:\n%s", files[2])
+	}
+
+	index, err := b.Index(func(n int) string { return fmt.Sprintf("https://example.com/sitemap-%d.xml", n) })
+	if err != nil {
+		t.Fatalf("Index failed: %v", err)
+	}
+	out := string(index)
+	for _, want := range []string{
+		`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`,
+		"<sitemap>\n    <loc>https://example.com/sitemap-1.xml</loc>\n    <lastmod>2024-03-10T12:00:00Z</lastmod>\n  </sitemap>",
+		"<loc>https://example.com/sitemap-3.xml</loc>\n    <lastmod>2024-03-10T08:00:00Z</lastmod>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected index to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestSplitByBytes(t *testing.T) {
+	b := NewBuilder(manyItems(10)).SetNow(func() time.Time { return now }).SetLimits(0, 600)
+	files, err := b.Split()
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if len(files) < 2 {
+		t.Fatalf("Expected the byte limit to split the sitemap, got %d file", len(files))
+	}
+	total := 0
+	for i, file := range files {
+		if len(file) > 600 {
+			t.Errorf("Expected file %d to be at most 600 bytes, got %d", i+1, len(file))
+		}
+		total += strings.Count(string(file), "<url>")
+	}
+	if total != 10 {
+		t.Errorf("Expected 10 URLs in all, got %d", total)
+	}
+
+	if _, err := NewBuilder(manyItems(1)).SetLimits(0, 100).Split(); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Expected ErrTooLarge for an entry over the limit, got %v", err)
+	}
+}
+
+func TestSetLimitsCapsAtProtocol(t *testing.T) {
+	b := NewBuilder().SetLimits(100000, 100<<20)
+	if b.maxURLs != MaxURLs || b.maxBytes != MaxBytes {
+		t.Errorf("Expected limits capped at %d URLs and %d bytes, got %d and %d", MaxURLs, MaxBytes, b.maxURLs, b.maxBytes)
+	}
+}
+
+func TestNews(t *testing.T) {
+	data, err := NewBuilder(testFeed()).SetNow(func() time.Time { return now }).News()
+	if err != nil {
+		t.Fatalf("News failed: %v", err)
+	}
+	out := string(data)
+
+	for _, want := range []string{
+		`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:news="http://www.google.com/schemas/sitemap-news/0.9">`,
+		`<loc>https://example.com/posts/launch</loc>`,
+		"<news:publication>\n        <news:name>Example News</news:name>\n        <news:language>en</news:language>\n      </news:publication>",
+		`<news:publication_date>2024-03-10T10:00:00Z</news:publication_date>`,
+		`<news:title>Launch video</news:title>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected news sitemap to contain %s, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "older") {
+		t.Error("Expected items older than 48 hours to be left out")
+	}
+	if strings.Contains(out, "scheduled") {
+		t.Error("Expected scheduled items to be left out")
+	}
+
+	data, err = NewBuilder(testFeed()).SetNow(func() time.Time { return now }).SetPublication("The Daily", "zh_TW").News()
+	if err != nil {
+		t.Fatalf("News failed: %v", err)
+	}
+	if !strings.Contains(string(data), "<news:name>The Daily</news:name>") || !strings.Contains(string(data), "<news:language>zh-tw</news:language>") {
+		t.Errorf("Expected the publication to be overridden, got:\n%s", data)
+	}
+
+	if _, err := NewBuilder(feed.New()).News(); !errors.Is(err, ErrMissingPublication) {
+		t.Errorf("Expected ErrMissingPublication, got %v", err)
+	}
+}
+
+func TestNewsLimit(t *testing.T) {
+	f := feed.New().SetTitle("T").SetLanguage("en").SetLink("https://example.com/")
+	for i := 0; i < MaxNewsURLs+5; i++ {
+		f.AddItem(feed.Item{Title: "Post", Link: fmt.Sprintf("/%d", i), PubDate: now.Add(-time.Duration(i) * time.Second)})
+	}
+	data, err := NewBuilder(f).SetNow(func() time.Time { return now }).News()
+	if err != nil {
+		t.Fatalf("News failed: %v", err)
+	}
+	if got := strings.Count(string(data), "<url>"); got != MaxNewsURLs {
+		t.Errorf("Expected %d URLs, got %d", MaxNewsURLs, got)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	h := Handler(func() *feed.Feed { return manyItems(3) }, func(b *Builder) {
+		b.SetNow(func() time.Time { return now }).SetLimits(2, 0)
+	})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "https://example.com/sitemap.xml", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != contentType {
+		t.Fatalf("Unexpected response: %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rec.Body.String(), "<loc>https://example.com/sitemap.xml?page=2</loc>") {
+		t.Errorf("Expected a sitemap index, got:\n%s", rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "https://example.com/sitemap.xml?page=2", nil))
+	if rec.Code != http.StatusOK || strings.Count(rec.Body.String(), "<url>") != 1 {
+		t.Errorf("Expected the second file, got %d:\n%s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "https://example.com/sitemap.xml?page=3", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing page, got %d", rec.Code)
+	}
+
+	// A sitemap that fits is served directly
+	rec = httptest.NewRecorder()
+	Handler(func() *feed.Feed { return manyItems(3) }).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+	if strings.Count(rec.Body.String(), "<url>") != 3 {
+		t.Errorf("Expected a single sitemap, got:\n%s", rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	Handler(func() *feed.Feed { return nil }).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 without a feed, got %d", rec.Code)
+	}
+}
+
+func TestNewsHandler(t *testing.T) {
+	h := NewsHandler(testFeed, func(b *Builder) { b.SetNow(func() time.Time { return now }) })
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/news-sitemap.xml", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "<news:title>Launch video</news:title>") {
+		t.Errorf("Unexpected response %d:\n%s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	NewsHandler(func() *feed.Feed { return feed.New() }).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/news-sitemap.xml", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 without a publication, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,98 @@
+package feed
+
+import (
+	"time"
+)
+
+// Renderer is implemented by anything that can render feed output,
+// such as *Feed, *Snapshot and *SafeFeed
+type Renderer interface {
+	RSS() ([]byte, error)
+	Atom() ([]byte, error)
+}
+
+// Snapshot is an immutable, point-in-time copy of a Feed.
+// It is safe for concurrent use without locking.
+type Snapshot struct {
+	feed *Feed
+}
+
+// Snapshot returns an immutable copy of the feed
+func (f *Feed) Snapshot() *Snapshot {
+	return &Snapshot{feed: f.Clone()}
+}
+
+// GetTitle returns the feed title
+func (s *Snapshot) GetTitle() string {
+	return s.feed.title
+}
+
+// GetDescription returns the feed description
+func (s *Snapshot) GetDescription() string {
+	return s.feed.description
+}
+
+// GetLink returns the feed link
+func (s *Snapshot) GetLink() string {
+	return s.feed.link
+}
+
+// GetLanguage returns the feed language
+func (s *Snapshot) GetLanguage() string {
+	return s.feed.language
+}
+
+// GetTTL returns the time-to-live in minutes
+func (s *Snapshot) GetTTL() int {
+	return s.feed.ttl
+}
+
+// GetLastBuildDate returns the last build date
+func (s *Snapshot) GetLastBuildDate() time.Time {
+	return s.feed.lastBuildDate
+}
+
+// NextChange returns the time at which the next scheduled item is
+// published or expires, or the zero time when nothing is scheduled
+func (s *Snapshot) NextChange() time.Time {
+	return s.feed.NextChange()
+}
+
+// MaxAge caps a cache lifetime at the next scheduled change
+func (s *Snapshot) MaxAge(ttl time.Duration) time.Duration {
+	return s.feed.MaxAge(ttl)
+}
+
+// Len returns the number of items in the snapshot
+func (s *Snapshot) Len() int {
+	return len(s.feed.items)
+}
+
+// GetItems returns a copy of the snapshot items
+func (s *Snapshot) GetItems() []Item {
+	items := make([]Item, len(s.feed.items))
+	for i, item := range s.feed.items {
+		items[i] = item.Clone()
+	}
+	return items
+}
+
+// Feed returns a mutable deep copy of the snapshot
+func (s *Snapshot) Feed() *Feed {
+	return s.feed.Clone()
+}
+
+// RSS generates RSS 2.0 XML output
+func (s *Snapshot) RSS() ([]byte, error) {
+	return s.feed.RSS()
+}
+
+// Atom generates Atom 1.0 XML output
+func (s *Snapshot) Atom() ([]byte, error) {
+	return s.feed.Atom()
+}
+
+// Render generates the snapshot in the given format
+func (s *Snapshot) Render(format Format) ([]byte, error) {
+	return s.feed.Render(format)
+}
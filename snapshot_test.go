@@ -0,0 +1,124 @@
+package feed
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSnapshotIsImmutable(t *testing.T) {
+	f := newCollectionFeed()
+	snap := f.Snapshot()
+
+	f.SetTitle("Changed")
+	f.AddItem(Item{Title: "Later", Link: "https://example.com/later"})
+	f.GetItems()[0].Title = "Mutated"
+
+	if snap.GetTitle() != "Test Feed" {
+		t.Errorf("Expected snapshot title 'Test Feed', got '%s'", snap.GetTitle())
+	}
+	if snap.Len() != 3 {
+		t.Errorf("Expected 3 snapshot items, got %d", snap.Len())
+	}
+	if snap.GetItems()[0].Title != "Old" {
+		t.Error("Snapshot items should not observe feed mutations")
+	}
+
+	// Mutating the returned items must not leak into the snapshot
+	snap.GetItems()[0].Title = "Mutated"
+	if snap.GetItems()[0].Title != "Old" {
+		t.Error("GetItems should return a copy")
+	}
+
+	rss, err := snap.RSS()
+	if err != nil {
+		t.Fatalf("RSS generation failed: %v", err)
+	}
+	if strings.Contains(string(rss), "Later") {
+		t.Error("Snapshot RSS should not contain items added afterwards")
+	}
+
+	if _, err := snap.Atom(); err != nil {
+		t.Fatalf("Atom generation failed: %v", err)
+	}
+
+	copied := snap.Feed()
+	copied.SetTitle("Copy")
+	if snap.GetTitle() != "Test Feed" {
+		t.Error("Feed() should return an independent copy")
+	}
+}
+
+func TestSafeFeedSnapshotCaching(t *testing.T) {
+	s := NewSafeFeed(newCollectionFeed())
+
+	first := s.Snapshot()
+	if s.Snapshot() != first {
+		t.Error("Snapshot should be reused while the feed is unchanged")
+	}
+
+	s.AddItem(Item{Title: "Added", Link: "https://example.com/added"})
+
+	second := s.Snapshot()
+	if second == first {
+		t.Error("Snapshot should be rebuilt after an update")
+	}
+	if first.Len() != 3 || second.Len() != 4 {
+		t.Errorf("Expected 3 and 4 items, got %d and %d", first.Len(), second.Len())
+	}
+}
+
+func TestSafeFeedNil(t *testing.T) {
+	s := NewSafeFeed(nil)
+	s.Update(func(f *Feed) {
+		f.SetTitle("Title").SetDescription("Description").SetLink("https://example.com")
+	})
+
+	if _, err := s.RSS(); err != nil {
+		t.Fatalf("RSS generation failed: %v", err)
+	}
+	if _, err := s.Atom(); err != nil {
+		t.Fatalf("Atom generation failed: %v", err)
+	}
+}
+
+func TestSafeFeedConcurrentAccess(t *testing.T) {
+	s := NewSafeFeed(newCollectionFeed())
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				s.AddItem(Item{
+					Title:   fmt.Sprintf("Item %d-%d", w, i),
+					Link:    fmt.Sprintf("https://example.com/%d/%d", w, i),
+					PubDate: time.Now(),
+				})
+			}
+		}(w)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if _, err := s.RSS(); err != nil {
+					t.Errorf("RSS generation failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := s.Snapshot().Len(); got != 3+4*50 {
+		t.Errorf("Expected %d items, got %d", 3+4*50, got)
+	}
+}
+
+func TestRendererInterface(t *testing.T) {
+	var _ Renderer = New()
+	var _ Renderer = &Snapshot{}
+	var _ Renderer = &SafeFeed{}
+}
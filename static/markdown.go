@@ -0,0 +1,514 @@
+package static
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// MarkdownRenderer converts the Markdown body of a post to HTML
+type MarkdownRenderer interface {
+	Render(markdown []byte) (string, error)
+}
+
+// RendererFunc adapts a function to a MarkdownRenderer, for example to plug
+// in a full CommonMark implementation:
+//
+//	b.SetRenderer(static.RendererFunc(func(src []byte) (string, error) {
+//		var buf bytes.Buffer
+//		err := goldmark.Convert(src, &buf)
+//		return buf.String(), err
+//	}))
+type RendererFunc func(markdown []byte) (string, error)
+
+// Render calls fn(markdown)
+func (fn RendererFunc) Render(markdown []byte) (string, error) {
+	return fn(markdown)
+}
+
+// Markdown is the built-in renderer. It supports the commonly used subset
+// of Markdown: ATX headings, paragraphs, emphasis, code spans and fenced
+// code blocks, links and images, block quotes, lists, horizontal rules
+// and raw HTML blocks.
+var Markdown MarkdownRenderer = RendererFunc(renderMarkdown)
+
+var (
+	headingRe   = regexp.MustCompile(`^(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	ruleRe      = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	fenceRe     = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})[ \t]*([^ \t`]*)")
+	bulletRe    = regexp.MustCompile(`^ {0,3}([-*+])[ \t]+`)
+	orderedRe   = regexp.MustCompile(`^ {0,3}(\d{1,9})([.)])[ \t]+`)
+	htmlBlockRe = regexp.MustCompile(`^ {0,3}<(?:/?[A-Za-z][A-Za-z0-9-]*(?:[ \t>/]|$)|!--)`)
+	entityRe    = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[A-Za-z][A-Za-z0-9]{1,31});`)
+	autolinkRe  = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9+.-]{1,31}:[^<>\s]*)>`)
+	inlineTagRe = regexp.MustCompile(`^</?[A-Za-z][A-Za-z0-9-]*(?:\s+[A-Za-z_:][A-Za-z0-9_.:-]*(?:\s*=\s*(?:"[^"]*"|'[^']*'|[^\s"'=<>` + "`" + `]+))?)*\s*/?>`)
+)
+
+func renderMarkdown(src []byte) (string, error) {
+	text := strings.ReplaceAll(string(src), "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\t", "    ")
+	return strings.Join(renderBlocks(strings.Split(text, "\n"), false), "\n"), nil
+}
+
+// renderBlocks renders block-level Markdown. Paragraphs of tight list
+// items are rendered without <p> tags.
+func renderBlocks(lines []string, tight bool) []string {
+	var blocks []string
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+
+		case fenceRe.MatchString(line):
+			m := fenceRe.FindStringSubmatch(line)
+			fence := m[1]
+			var code []string
+			for i++; i < len(lines); i++ {
+				if trimmed := strings.TrimSpace(lines[i]); strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+					i++
+					break
+				}
+				code = append(code, lines[i])
+			}
+			class := ""
+			if m[2] != "" {
+				class = ` class="language-` + html.EscapeString(m[2]) + `"`
+			}
+			body := html.EscapeString(strings.Join(code, "\n"))
+			if len(code) > 0 {
+				body += "\n"
+			}
+			blocks = append(blocks, "<pre><code"+class+">"+body+"</code></pre>")
+
+		case headingRe.MatchString(strings.TrimLeft(line, " ")) && leadingSpaces(line) < 4:
+			m := headingRe.FindStringSubmatch(strings.TrimLeft(line, " "))
+			level := string(rune('0' + len(m[1])))
+			blocks = append(blocks, "<h"+level+">"+renderInline(m[2])+"</h"+level+">")
+			i++
+
+		case ruleRe.MatchString(line):
+			blocks = append(blocks, "<hr>")
+			i++
+
+		case strings.HasPrefix(strings.TrimLeft(line, " "), ">") && leadingSpaces(line) < 4:
+			var quoted []string
+			for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+				l := strings.TrimLeft(lines[i], " ")
+				if strings.HasPrefix(l, ">") {
+					l = strings.TrimPrefix(strings.TrimPrefix(l, ">"), " ")
+				}
+				quoted = append(quoted, l)
+			}
+			blocks = append(blocks, "<blockquote>\n"+strings.Join(renderBlocks(quoted, false), "\n")+"\n</blockquote>")
+
+		case bulletRe.MatchString(line) || orderedRe.MatchString(line):
+			var list string
+			list, i = renderList(lines, i)
+			blocks = append(blocks, list)
+
+		case htmlBlockRe.MatchString(line):
+			var raw []string
+			for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+				raw = append(raw, lines[i])
+			}
+			blocks = append(blocks, strings.Join(raw, "\n"))
+
+		case leadingSpaces(line) >= 4:
+			var code []string
+			for ; i < len(lines) && (leadingSpaces(lines[i]) >= 4 || strings.TrimSpace(lines[i]) == ""); i++ {
+				code = append(code, strings.TrimPrefix(lines[i], "    "))
+			}
+			for len(code) > 0 && strings.TrimSpace(code[len(code)-1]) == "" {
+				code = code[:len(code)-1]
+			}
+			blocks = append(blocks, "<pre><code>"+html.EscapeString(strings.Join(code, "\n"))+"\n</code></pre>")
+
+		default:
+			var para []string
+			for ; i < len(lines) && !interruptsParagraph(lines[i]); i++ {
+				para = append(para, strings.TrimLeft(lines[i], " "))
+			}
+			content := renderInline(strings.TrimRight(strings.Join(para, "\n"), " "))
+			if tight {
+				blocks = append(blocks, content)
+			} else {
+				blocks = append(blocks, "<p>"+content+"</p>")
+			}
+		}
+	}
+	return blocks
+}
+
+// interruptsParagraph reports whether line ends a paragraph
+func interruptsParagraph(line string) bool {
+	if strings.TrimSpace(line) == "" {
+		return true
+	}
+	if leadingSpaces(line) >= 4 {
+		// Indented code can't interrupt a paragraph
+		return false
+	}
+	trimmed := strings.TrimLeft(line, " ")
+	return headingRe.MatchString(trimmed) || ruleRe.MatchString(line) || fenceRe.MatchString(line) ||
+		strings.HasPrefix(trimmed, ">") || bulletRe.MatchString(line) || htmlBlockRe.MatchString(line) ||
+		(orderedRe.MatchString(line) && orderedRe.FindStringSubmatch(line)[1] == "1")
+}
+
+// renderList renders the list starting at lines[i] and returns the index
+// of the first line after it
+func renderList(lines []string, i int) (string, int) {
+	ordered := !bulletRe.MatchString(lines[i])
+	marker := func(line string) (string, int, bool) {
+		if ordered {
+			if m := orderedRe.FindStringSubmatch(line); m != nil {
+				return m[2], len(m[0]), true
+			}
+			return "", 0, false
+		}
+		if m := bulletRe.FindStringSubmatch(line); m != nil && !ruleRe.MatchString(line) {
+			return m[1], len(m[0]), true
+		}
+		return "", 0, false
+	}
+
+	delim, _, _ := marker(lines[i])
+	start := ""
+	if ordered {
+		if n := strings.TrimLeft(orderedRe.FindStringSubmatch(lines[i])[1], "0"); n != "1" {
+			if n == "" {
+				n = "0"
+			}
+			start = ` start="` + n + `"`
+		}
+	}
+
+	var items [][]string
+	var width int
+	loose := false
+	for i < len(lines) {
+		line := lines[i]
+		// Markers indented past the item content start a nested list
+		if d, w, ok := marker(line); ok && d == delim && (len(items) == 0 || leadingSpaces(line) < width) {
+			items = append(items, []string{line[w:]})
+			width = w
+			i++
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			// A blank line continues the list only if more of it follows
+			next := i + 1
+			for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+				next++
+			}
+			if next == len(lines) {
+				i = next
+				break
+			}
+			d, _, ok := marker(lines[next])
+			if !(ok && d == delim) && leadingSpaces(lines[next]) < width {
+				break
+			}
+			loose = true
+			items[len(items)-1] = append(items[len(items)-1], "")
+			i++
+			continue
+		}
+		if leadingSpaces(line) >= width || !interruptsParagraph(line) {
+			// Continuation or lazy paragraph line
+			l := line
+			if leadingSpaces(l) >= width {
+				l = l[width:]
+			} else {
+				l = strings.TrimLeft(l, " ")
+			}
+			items[len(items)-1] = append(items[len(items)-1], l)
+			i++
+			continue
+		}
+		break
+	}
+
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	var b strings.Builder
+	b.WriteString("<" + tag + start + ">\n")
+	for _, item := range items {
+		blocks := renderBlocks(item, !loose)
+		b.WriteString("<li>")
+		if loose || len(blocks) > 1 {
+			b.WriteString(strings.Join(blocks, "\n"))
+		} else if len(blocks) == 1 {
+			b.WriteString(blocks[0])
+		}
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</" + tag + ">")
+	return b.String(), i
+}
+
+func leadingSpaces(s string) int {
+	return len(s) - len(strings.TrimLeft(s, " "))
+}
+
+// renderInline renders inline Markdown: code spans, emphasis, links,
+// images, autolinks, raw inline HTML, entities and backslash escapes
+func renderInline(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte("\\`*_{}[]()#+-.!<>&\"'|~", s[i+1]) >= 0:
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+			continue
+
+		case c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			b.WriteString("<br>\n")
+			i += 2
+			continue
+
+		case c == '`':
+			run := len(s[i:]) - len(strings.TrimLeft(s[i:], "`"))
+			fence := s[i : i+run]
+			if end := findCodeSpanEnd(s[i+run:], fence); end >= 0 {
+				code := strings.ReplaceAll(s[i+run:i+run+end], "\n", " ")
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+					code = code[1 : len(code)-1]
+				}
+				b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i += run + end + run
+				continue
+			}
+			b.WriteString(fence)
+			i += run
+			continue
+
+		case c == '!' && strings.HasPrefix(s[i+1:], "["):
+			if text, dest, title, n, ok := parseLink(s[i+1:]); ok {
+				b.WriteString(`<img src="` + html.EscapeString(dest) + `" alt="` + html.EscapeString(plainInline(text)) + `"`)
+				if title != "" {
+					b.WriteString(` title="` + html.EscapeString(title) + `"`)
+				}
+				b.WriteString(">")
+				i += 1 + n
+				continue
+			}
+
+		case c == '[':
+			if text, dest, title, n, ok := parseLink(s[i:]); ok {
+				b.WriteString(`<a href="` + html.EscapeString(dest) + `"`)
+				if title != "" {
+					b.WriteString(` title="` + html.EscapeString(title) + `"`)
+				}
+				b.WriteString(">" + renderInline(text) + "</a>")
+				i += n
+				continue
+			}
+
+		case c == '*' || c == '_':
+			if n, inner, strong, ok := parseEmphasis(s, i); ok {
+				if strong {
+					b.WriteString("<strong>" + renderInline(inner) + "</strong>")
+				} else {
+					b.WriteString("<em>" + renderInline(inner) + "</em>")
+				}
+				i += n
+				continue
+			}
+			run := len(s[i:]) - len(strings.TrimLeft(s[i:], string(c)))
+			b.WriteString(s[i : i+run])
+			i += run
+			continue
+
+		case c == '<':
+			if m := autolinkRe.FindStringSubmatch(s[i:]); m != nil {
+				b.WriteString(`<a href="` + html.EscapeString(m[1]) + `">` + html.EscapeString(m[1]) + "</a>")
+				i += len(m[0])
+				continue
+			}
+			if m := inlineTagRe.FindString(s[i:]); m != "" {
+				b.WriteString(m)
+				i += len(m)
+				continue
+			}
+
+		case c == '&':
+			if m := entityRe.FindString(s[i:]); m != "" {
+				b.WriteString(m)
+				i += len(m)
+				continue
+			}
+
+		case c == ' ' && strings.HasPrefix(s[i:], "  \n"):
+			end := i + len(s[i:]) - len(strings.TrimLeft(s[i:], " "))
+			b.WriteString("<br>\n")
+			i = end + 1
+			continue
+		}
+
+		b.WriteString(html.EscapeString(s[i : i+1]))
+		i++
+	}
+	return b.String()
+}
+
+// findCodeSpanEnd returns the offset of the backtick run in s that closes
+// a code span opened by fence, or -1
+func findCodeSpanEnd(s, fence string) int {
+	for i := 0; i < len(s); {
+		if s[i] != '`' {
+			i++
+			continue
+		}
+		run := len(s[i:]) - len(strings.TrimLeft(s[i:], "`"))
+		if run == len(fence) {
+			return i
+		}
+		i += run
+	}
+	return -1
+}
+
+// parseLink parses "[text](dest "title")" at the start of s and returns
+// the number of bytes consumed
+func parseLink(s string) (text, dest, title string, n int, ok bool) {
+	depth := 0
+	end := -1
+	for i := 0; i < len(s) && end < 0; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '`':
+			// Brackets inside code spans don't count
+			run := len(s[i:]) - len(strings.TrimLeft(s[i:], "`"))
+			if close := findCodeSpanEnd(s[i+run:], s[i:i+run]); close >= 0 {
+				i += run + close + run - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				end = i
+			}
+		}
+	}
+	if end < 0 || end+1 >= len(s) || s[end+1] != '(' {
+		return "", "", "", 0, false
+	}
+	text = s[1:end]
+
+	rest := s[end+2:]
+	closing := -1
+	parens := 0
+	var quote byte
+	for i := 0; i < len(rest) && closing < 0; i++ {
+		c := rest[i]
+		switch {
+		case c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && i > 0 && rest[i-1] == ' ':
+			quote = c
+		case c == '(':
+			parens++
+		case c == ')':
+			if parens == 0 {
+				closing = i
+			}
+			parens--
+		}
+	}
+	if closing < 0 {
+		return "", "", "", 0, false
+	}
+	inner := strings.TrimSpace(rest[:closing])
+	dest = inner
+	if sp := strings.IndexAny(inner, " \n"); sp >= 0 {
+		dest = inner[:sp]
+		t := strings.TrimSpace(inner[sp:])
+		if len(t) < 2 || (t[0] != '"' && t[0] != '\'') || t[len(t)-1] != t[0] {
+			return "", "", "", 0, false
+		}
+		title = unescapeMarkdown(t[1 : len(t)-1])
+	}
+	dest = unescapeMarkdown(strings.TrimSuffix(strings.TrimPrefix(dest, "<"), ">"))
+	return text, dest, title, end + 2 + closing + 1, true
+}
+
+// parseEmphasis parses emphasis opened by the delimiter run at s[i] and
+// returns the number of bytes consumed
+func parseEmphasis(s string, i int) (n int, inner string, strong, ok bool) {
+	c := s[i]
+	run := len(s[i:]) - len(strings.TrimLeft(s[i:], string(c)))
+	// Underscores inside words don't emphasize, as in snake_case
+	if c == '_' && i > 0 && isWordByte(s[i-1]) {
+		return 0, "", false, false
+	}
+	if i+run >= len(s) || s[i+run] == ' ' || s[i+run] == '\n' {
+		return 0, "", false, false
+	}
+
+	for _, width := range []int{2, 1} {
+		if run < width {
+			continue
+		}
+		delim := strings.Repeat(string(c), width)
+		for j := i + width + 1; j <= len(s)-width; j++ {
+			if s[j] == '\\' || s[j] == '`' {
+				if s[j] == '\\' {
+					j++
+				}
+				continue
+			}
+			if s[j:j+width] != delim || s[j-1] == ' ' || s[j-1] == '\n' {
+				continue
+			}
+			if width == 2 {
+				// In "***", the last two close the strong emphasis
+				for j+width < len(s) && s[j+width] == c {
+					j++
+				}
+			}
+			after := j + width
+			// The closing run must be exactly as long as the delimiter,
+			// unless it also closes an enclosing emphasis
+			if width == 1 && after < len(s) && s[after] == c {
+				j++
+				continue
+			}
+			if c == '_' && after < len(s) && isWordByte(s[after]) {
+				continue
+			}
+			return after - i, s[i+width : j], width == 2, true
+		}
+	}
+	return 0, "", false, false
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 0x80 || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// plainInline returns the text of inline Markdown, as used for image alt text
+func plainInline(s string) string {
+	return strings.NewReplacer("*", "", "_", "", "`", "").Replace(s)
+}
+
+// unescapeMarkdown removes backslash escapes and decodes entities
+func unescapeMarkdown(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\\`*_{}[]()#+-.!<>&\"'|~", s[i+1]) >= 0 {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return html.UnescapeString(b.String())
+}
@@ -0,0 +1,43 @@
+package static
+
+import (
+	"testing"
+)
+
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"paragraphs", "One\ntwo\n\nThree", "<p>One\ntwo</p>\n<p>Three</p>"},
+		{"headings", "# Title #\n### Sub", "<h1>Title</h1>\n<h3>Sub</h3>"},
+		{"emphasis", "*em* and **strong** and _under_ and snake_case_name", "<p><em>em</em> and <strong>strong</strong> and <em>under</em> and snake_case_name</p>"},
+		{"nested emphasis", "**bold *and em***", "<p><strong>bold <em>and em</em></strong></p>"},
+		{"code span", "Use `a < b` and ``x ` y``", "<p>Use <code>a &lt; b</code> and <code>x ` y</code></p>"},
+		{"escapes", `\*not em\* & <3`, "<p>*not em* &amp; &lt;3</p>"},
+		{"entities", "&copy; &amp; &#169;", "<p>&copy; &amp; &#169;</p>"},
+		{"link", `[the *site*](https://example.com/a_(b) "Title")`, `<p><a href="https://example.com/a_(b)" title="Title">the <em>site</em></a></p>`},
+		{"image", `![alt *text*](/img.png)`, `<p><img src="/img.png" alt="alt text"></p>`},
+		{"autolink", "<https://example.com?a=1&b=2>", `<p><a href="https://example.com?a=1&amp;b=2">https://example.com?a=1&amp;b=2</a></p>`},
+		{"inline html", `Hi <span class="x">there</span>`, `<p>Hi <span class="x">there</span></p>`},
+		{"hard break", "one  \ntwo", "<p>one<br>\ntwo</p>"},
+		{"fenced code", "```go\nif a < b {\n}\n```", "<pre><code class=\"language-go\">if a &lt; b {\n}\n</code></pre>"},
+		{"indented code", "    x := 1\n    y := 2", "<pre><code>x := 1\ny := 2\n</code></pre>"},
+		{"rule", "a\n\n---\n\nb", "<p>a</p>\n<hr>\n<p>b</p>"},
+		{"blockquote", "> quoted\n> *text*", "<blockquote>\n<p>quoted\n<em>text</em></p>\n</blockquote>"},
+		{"tight list", "- one\n- two", "<ul>\n<li>one</li>\n<li>two</li>\n</ul>"},
+		{"loose list", "1. one\n\n2. two", "<ol>\n<li><p>one</p></li>\n<li><p>two</p></li>\n</ol>"},
+		{"ordered start", "3) three\n4) four", "<ol start=\"3\">\n<li>three</li>\n<li>four</li>\n</ol>"},
+		{"nested list", "- one\n  - sub\n- two", "<ul>\n<li>one\n<ul>\n<li>sub</li>\n</ul></li>\n<li>two</li>\n</ul>"},
+		{"html block", "<div>\n*raw*\n</div>\n\ntext", "<div>\n*raw*\n</div>\n<p>text</p>"},
+		{"paragraph then list", "Intro\n- item", "<p>Intro</p>\n<ul>\n<li>item</li>\n</ul>"},
+	}
+	for _, tt := range tests {
+		got, err := Markdown.Render([]byte(tt.in))
+		if err != nil {
+			t.Fatalf("%s: Render failed: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,470 @@
+// Package static builds feeds for static sites from a directory of
+// Markdown posts with YAML, TOML or JSON front matter.
+//
+// A Builder reads the posts of an fs.FS, skips drafts and future-dated
+// posts, and builds a feed for the whole site, one per tag and one per
+// author. Output is deterministic, so repeated builds of the same posts
+// produce identical files:
+//
+//	b := static.NewBuilder(os.DirFS("content/posts"), static.Site{
+//		Title:       "Docs",
+//		Description: "Release notes and guides",
+//		Link:        "https://docs.example.com",
+//	})
+//	if err := b.WriteDir("public"); err != nil {
+//		log.Fatal(err)
+//	}
+package static
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.rumenx.com/feed"
+	"go.rumenx.com/feed/internal/frontmatter"
+)
+
+// Common errors
+var (
+	ErrInvalidFrontMatter = frontmatter.ErrInvalid
+	ErrMissingPostTitle   = errors.New("post title is required")
+	ErrDuplicateSlug      = errors.New("posts share a slug")
+)
+
+// Site describes the site the feeds are built for
+type Site struct {
+	Title       string
+	Description string
+	Link        string // site root URL, such as https://example.com
+	Language    string
+	Author      string // default author of posts without one
+	Copyright   string
+}
+
+// Post is a Markdown post read from the file system
+type Post struct {
+	Path        string // path within the file system
+	Slug        string
+	Title       string
+	Description string
+	Date        time.Time
+	Author      string
+	Tags        []string
+	Draft       bool
+	Params      map[string]any // all front matter values
+	HTML        string         // rendered body
+}
+
+// Builder builds feeds from the Markdown posts of a file system
+type Builder struct {
+	fsys          fs.FS
+	site          Site
+	renderer      MarkdownRenderer
+	permalink     func(Post) string
+	now           func() time.Time
+	formats       []feed.Format
+	maxItems      int
+	includeDrafts bool
+	includeFuture bool
+	configure     func(*feed.Feed)
+}
+
+// NewBuilder creates a Builder for the posts in fsys, which are the files
+// with a .md or .markdown extension outside hidden directories
+func NewBuilder(fsys fs.FS, site Site) *Builder {
+	return &Builder{
+		fsys:     fsys,
+		site:     site,
+		renderer: Markdown,
+		now:      time.Now,
+		formats:  feed.Formats,
+	}
+}
+
+// SetRenderer sets the Markdown renderer; the built-in Markdown renderer
+// is used by default
+func (b *Builder) SetRenderer(r MarkdownRenderer) *Builder {
+	b.renderer = r
+	return b
+}
+
+// SetPermalink sets the function that returns the URL of a post. By default
+// posts live at <site link>/<slug>/.
+func (b *Builder) SetPermalink(fn func(Post) string) *Builder {
+	b.permalink = fn
+	return b
+}
+
+// SetNow sets the clock used to skip future-dated posts
+func (b *Builder) SetNow(now func() time.Time) *Builder {
+	b.now = now
+	return b
+}
+
+// SetFormats sets the formats written by WriteDir; all formats by default
+func (b *Builder) SetFormats(formats ...feed.Format) *Builder {
+	b.formats = formats
+	return b
+}
+
+// SetMaxItems caps the number of items of every feed. Zero means no limit.
+func (b *Builder) SetMaxItems(n int) *Builder {
+	b.maxItems = n
+	return b
+}
+
+// SetIncludeDrafts includes posts marked as drafts
+func (b *Builder) SetIncludeDrafts(include bool) *Builder {
+	b.includeDrafts = include
+	return b
+}
+
+// SetIncludeFuture includes posts dated in the future
+func (b *Builder) SetIncludeFuture(include bool) *Builder {
+	b.includeFuture = include
+	return b
+}
+
+// SetConfigure sets a function called on every feed after it is built, for
+// example to set an excerpter, sanitizer or stylesheet
+func (b *Builder) SetConfigure(fn func(*feed.Feed)) *Builder {
+	b.configure = fn
+	return b
+}
+
+// Posts reads, filters and renders the posts, newest first. Posts with the
+// same date are ordered by slug.
+func (b *Builder) Posts() ([]Post, error) {
+	var posts []Post
+	err := fs.WalkDir(b.fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if p != "." && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if ext := strings.ToLower(path.Ext(name)); ext != ".md" && ext != ".markdown" {
+			return nil
+		}
+
+		post, err := b.readPost(p)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		if post.Draft && !b.includeDrafts {
+			return nil
+		}
+		if post.Date.After(b.now()) && !b.includeFuture {
+			return nil
+		}
+		posts = append(posts, post)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(posts, func(i, j int) bool {
+		if !posts[i].Date.Equal(posts[j].Date) {
+			return posts[i].Date.After(posts[j].Date)
+		}
+		return posts[i].Slug < posts[j].Slug
+	})
+	return posts, nil
+}
+
+// readPost reads a single post
+func (b *Builder) readPost(p string) (Post, error) {
+	src, err := fs.ReadFile(b.fsys, p)
+	if err != nil {
+		return Post{}, err
+	}
+	values, body, err := frontmatter.Split(src)
+	if err != nil {
+		return Post{}, err
+	}
+
+	post := Post{
+		Path:        p,
+		Params:      values,
+		Title:       stringValue(values, "title"),
+		Description: stringValue(values, "description", "summary"),
+		Slug:        stringValue(values, "slug"),
+		Author:      stringValue(values, "author"),
+		Tags:        stringsValue(values, "tags"),
+		Draft:       values["draft"] == true,
+	}
+	if post.Title == "" {
+		return Post{}, ErrMissingPostTitle
+	}
+	if post.Author == "" {
+		if authors := stringsValue(values, "authors"); len(authors) > 0 {
+			post.Author = authors[0]
+		} else {
+			post.Author = b.site.Author
+		}
+	}
+	if post.Slug == "" {
+		post.Slug = defaultSlug(p)
+	}
+
+	switch date := values["date"].(type) {
+	case time.Time:
+		post.Date = date
+	case string:
+		t, ok := frontmatter.ParseDate(date)
+		if !ok {
+			return Post{}, fmt.Errorf("%w: %q", feed.ErrInvalidDate, date)
+		}
+		post.Date = t
+	}
+
+	post.HTML, err = b.renderer.Render(body)
+	if err != nil {
+		return Post{}, fmt.Errorf("failed to render Markdown: %w", err)
+	}
+	return post, nil
+}
+
+// Feeds holds the feeds built for a site
+type Feeds struct {
+	Site    *feed.Feed
+	Tags    map[string]*feed.Feed // keyed by tag slug
+	Authors map[string]*feed.Feed // keyed by author slug
+}
+
+// Build reads the posts and builds the site, tag and author feeds. It
+// returns ErrDuplicateSlug when two posts end up with the same link, such
+// as 2023/hello.md and 2024/hello.md without a slug or permalink to tell
+// them apart.
+func (b *Builder) Build() (*Feeds, error) {
+	posts, err := b.Posts()
+	if err != nil {
+		return nil, err
+	}
+
+	feeds := &Feeds{
+		Site:    b.newFeed(b.site.Title, b.site.Description, b.site.Link),
+		Tags:    make(map[string]*feed.Feed),
+		Authors: make(map[string]*feed.Feed),
+	}
+	paths := make(map[string]string, len(posts))
+	for _, post := range posts {
+		item := b.item(post)
+		if other, ok := paths[item.Link]; ok {
+			return nil, fmt.Errorf("%w: %s and %s both link to %s", ErrDuplicateSlug, other, post.Path, item.Link)
+		}
+		paths[item.Link] = post.Path
+		feeds.Site.AddItem(item)
+
+		for _, tag := range post.Tags {
+			slug := Slugify(tag)
+			if slug == "" {
+				continue
+			}
+			f, ok := feeds.Tags[slug]
+			if !ok {
+				f = b.newFeed(b.site.Title+": "+tag, fmt.Sprintf("Posts tagged %q", tag), b.link("tags", slug))
+				feeds.Tags[slug] = f
+			}
+			if !hasItem(f, item.GUID) {
+				f.AddItem(item)
+			}
+		}
+
+		if slug := Slugify(post.Author); slug != "" {
+			f, ok := feeds.Authors[slug]
+			if !ok {
+				f = b.newFeed(b.site.Title+": "+post.Author, "Posts by "+post.Author, b.link("authors", slug))
+				feeds.Authors[slug] = f
+			}
+			f.AddItem(item)
+		}
+	}
+
+	for _, f := range feeds.all() {
+		// The newest post dates the feed, so output doesn't depend on the
+		// time of the build
+		if items := f.GetItems(); len(items) > 0 {
+			f.SetLastBuildDate(items[0].PubDate)
+		}
+		if b.configure != nil {
+			b.configure(f)
+		}
+	}
+	return feeds, nil
+}
+
+// WriteDir builds the feeds and writes them to dir: the site feed as
+// feed.xml and feed.atom, tag feeds under tags/<slug>/ and author feeds
+// under authors/<slug>/
+func (b *Builder) WriteDir(dir string) error {
+	feeds, err := b.Build()
+	if err != nil {
+		return err
+	}
+	for _, name := range feeds.names() {
+		f := feeds.byName(name)
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(target, 0o755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", target, err)
+		}
+		for _, format := range b.formats {
+			data, err := f.Render(format)
+			if err != nil {
+				return fmt.Errorf("failed to render %s feed %q: %w", format, name, err)
+			}
+			file := filepath.Join(target, "feed"+format.Extension())
+			if err := os.WriteFile(file, data, 0o644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", file, err)
+			}
+		}
+	}
+	return nil
+}
+
+// names returns the output directories of the feeds in sorted order
+func (s *Feeds) names() []string {
+	names := []string{"."}
+	for slug := range s.Tags {
+		names = append(names, "tags/"+slug)
+	}
+	for slug := range s.Authors {
+		names = append(names, "authors/"+slug)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// byName returns the feed of an output directory returned by names
+func (s *Feeds) byName(name string) *feed.Feed {
+	if slug, ok := strings.CutPrefix(name, "tags/"); ok {
+		return s.Tags[slug]
+	}
+	if slug, ok := strings.CutPrefix(name, "authors/"); ok {
+		return s.Authors[slug]
+	}
+	return s.Site
+}
+
+// all returns every feed in output order
+func (s *Feeds) all() []*feed.Feed {
+	var feeds []*feed.Feed
+	for _, name := range s.names() {
+		feeds = append(feeds, s.byName(name))
+	}
+	return feeds
+}
+
+func (b *Builder) newFeed(title, description, link string) *feed.Feed {
+	return feed.New().
+		SetTitle(title).
+		SetDescription(description).
+		SetLink(link).
+		SetLanguage(b.site.Language).
+		SetCopyright(b.site.Copyright).
+		SetLastBuildDate(time.Time{}).
+		SetMaxItems(b.maxItems)
+}
+
+// item converts a post to a feed item
+func (b *Builder) item(post Post) feed.Item {
+	link := b.link(post.Slug)
+	if b.permalink != nil {
+		link = b.permalink(post)
+	}
+	return feed.Item{
+		Title:       post.Title,
+		Description: post.Description,
+		Content:     post.HTML,
+		Link:        link,
+		GUID:        link,
+		Author:      post.Author,
+		PubDate:     post.Date,
+		Categories:  post.Tags,
+	}
+}
+
+// link returns a URL below the site link
+func (b *Builder) link(parts ...string) string {
+	return strings.TrimSuffix(b.site.Link, "/") + "/" + strings.Join(parts, "/") + "/"
+}
+
+func hasItem(f *feed.Feed, guid string) bool {
+	for _, item := range f.GetItems() {
+		if item.GUID == guid {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultSlug derives a slug from a post path: the file name, or the
+// directory name for index files
+func defaultSlug(p string) string {
+	name := strings.TrimSuffix(path.Base(p), path.Ext(p))
+	if (name == "index" || name == "_index") && path.Dir(p) != "." {
+		name = path.Base(path.Dir(p))
+	}
+	return Slugify(name)
+}
+
+// Slugify returns a URL-safe slug: letters and digits are lowercased and
+// runs of anything else become a single hyphen
+func Slugify(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.TrimSpace(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			hyphen = false
+		} else {
+			hyphen = true
+		}
+	}
+	return b.String()
+}
+
+// stringValue returns the first of keys with a string value
+func stringValue(values map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if s, ok := values[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// stringsValue returns a list of strings, accepting a single string too
+func stringsValue(values map[string]any, key string) []string {
+	switch v := values[key].(type) {
+	case string:
+		if v != "" {
+			return []string{v}
+		}
+	case []any:
+		var list []string
+		for _, value := range v {
+			if s := fmt.Sprint(value); value != nil && s != "" {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
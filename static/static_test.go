@@ -0,0 +1,224 @@
+package static
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"go.rumenx.com/feed"
+)
+
+var testSite = Site{
+	Title:       "Docs",
+	Description: "Release notes",
+	Link:        "https://docs.example.com/",
+	Author:      "Docs Team",
+}
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"hello.md":              {Data: []byte("---\ntitle: Hello\ndate: 2024-03-01\ntags: [Go, Feeds]\nauthor: Jane Doe\n---\n# Hello\n\nFirst *post*.\n")},
+		"guides/setup/index.md": {Data: []byte("+++\ntitle = \"Setup\"\ndate = 2024-03-05T09:00:00Z\ntags = [\"go\"]\n+++\nInstall it.\n")},
+		"json.markdown":         {Data: []byte("{\"title\": \"JSON post\", \"date\": \"2024-03-05T09:00:00Z\", \"slug\": \"custom\", \"description\": \"Summary\"}\nBody\n")},
+		"draft.md":              {Data: []byte("---\ntitle: Draft\ndate: 2024-03-02\ndraft: true\n---\nWIP\n")},
+		"future.md":             {Data: []byte("---\ntitle: Future\ndate: 2030-01-01\n---\nSoon\n")},
+		"_drafts/x.md":          {Data: []byte("---\ntitle: Hidden\n---\n")},
+		".git/HEAD.md":          {Data: []byte("not a post")},
+		"notes.txt":             {Data: []byte("ignored")},
+	}
+}
+
+func testBuilder(fsys fstest.MapFS) *Builder {
+	return NewBuilder(fsys, testSite).SetNow(func() time.Time {
+		return time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	})
+}
+
+func TestPosts(t *testing.T) {
+	posts, err := testBuilder(testFS()).Posts()
+	if err != nil {
+		t.Fatalf("Posts failed: %v", err)
+	}
+
+	var slugs []string
+	for _, post := range posts {
+		slugs = append(slugs, post.Slug)
+	}
+	if got := strings.Join(slugs, ","); got != "custom,setup,hello" {
+		t.Errorf("Expected posts custom,setup,hello, got %s", got)
+	}
+
+	hello := posts[2]
+	if hello.Author != "Jane Doe" || len(hello.Tags) != 2 || hello.Path != "hello.md" {
+		t.Errorf("Unexpected post %+v", hello)
+	}
+	if hello.HTML != "<h1>Hello</h1>\n<p>First <em>post</em>.</p>" {
+		t.Errorf("Unexpected HTML %q", hello.HTML)
+	}
+	if posts[1].Author != "Docs Team" {
+		t.Errorf("Expected default author, got %q", posts[1].Author)
+	}
+	if posts[0].Description != "Summary" {
+		t.Errorf("Expected description from front matter, got %q", posts[0].Description)
+	}
+}
+
+func TestPostsIncludeDraftsAndFuture(t *testing.T) {
+	posts, err := testBuilder(testFS()).SetIncludeDrafts(true).SetIncludeFuture(true).Posts()
+	if err != nil {
+		t.Fatalf("Posts failed: %v", err)
+	}
+	if len(posts) != 5 || posts[0].Slug != "future" {
+		t.Errorf("Expected 5 posts starting with future, got %d", len(posts))
+	}
+}
+
+func TestPostsErrors(t *testing.T) {
+	fsys := fstest.MapFS{"untitled.md": {Data: []byte("---\ndate: 2024-01-01\n---\n")}}
+	if _, err := testBuilder(fsys).Posts(); !errors.Is(err, ErrMissingPostTitle) {
+		t.Errorf("Expected ErrMissingPostTitle, got %v", err)
+	}
+
+	fsys = fstest.MapFS{"bad.md": {Data: []byte("---\ntitle: Bad\ndate: someday\n---\n")}}
+	if _, err := testBuilder(fsys).Posts(); !errors.Is(err, feed.ErrInvalidDate) || !strings.Contains(err.Error(), "bad.md") {
+		t.Errorf("Expected ErrInvalidDate for bad.md, got %v", err)
+	}
+
+	failing := RendererFunc(func([]byte) (string, error) { return "", errors.New("boom") })
+	if _, err := testBuilder(testFS()).SetRenderer(failing).Posts(); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Expected renderer error, got %v", err)
+	}
+}
+
+func TestBuildDuplicateSlugs(t *testing.T) {
+	fsys := fstest.MapFS{
+		"2023/hello.md": {Data: []byte("---\ntitle: Hello 2023\ndate: 2023-01-01\n---\n")},
+		"2024/hello.md": {Data: []byte("---\ntitle: Hello 2024\ndate: 2024-01-01\n---\n")},
+	}
+	_, err := testBuilder(fsys).Build()
+	if !errors.Is(err, ErrDuplicateSlug) || !strings.Contains(err.Error(), "2023/hello.md") || !strings.Contains(err.Error(), "2024/hello.md") {
+		t.Errorf("Expected ErrDuplicateSlug naming both files, got %v", err)
+	}
+
+	// A permalink that tells them apart resolves the clash
+	feeds, err := testBuilder(fsys).SetPermalink(func(p Post) string {
+		return "https://docs.example.com/" + p.Date.Format("2006") + "/" + p.Slug + "/"
+	}).Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if n := len(feeds.Site.GetItems()); n != 2 {
+		t.Errorf("Expected 2 items, got %d", n)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	feeds, err := testBuilder(testFS()).
+		SetPermalink(func(p Post) string { return "https://docs.example.com/posts/" + p.Slug }).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if got := len(feeds.Site.GetItems()); got != 3 {
+		t.Errorf("Expected 3 site items, got %d", got)
+	}
+	if want := time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC); !feeds.Site.GetLastBuildDate().Equal(want) {
+		t.Errorf("Expected last build date %v, got %v", want, feeds.Site.GetLastBuildDate())
+	}
+
+	goFeed := feeds.Tags["go"]
+	if goFeed == nil || len(goFeed.GetItems()) != 2 {
+		t.Fatalf("Expected 2 items tagged go, got %v", goFeed)
+	}
+	if goFeed.GetLink() != "https://docs.example.com/tags/go/" {
+		t.Errorf("Unexpected tag feed link %q", goFeed.GetLink())
+	}
+	if item := goFeed.GetItems()[0]; item.Link != "https://docs.example.com/posts/setup" || item.GUID != item.Link {
+		t.Errorf("Expected permalink, got %q", item.Link)
+	}
+
+	if jane := feeds.Authors["jane-doe"]; jane == nil || len(jane.GetItems()) != 1 {
+		t.Errorf("Expected one item by Jane Doe, got %v", jane)
+	}
+	if team := feeds.Authors["docs-team"]; team == nil || len(team.GetItems()) != 2 {
+		t.Errorf("Expected two items by the default author, got %v", team)
+	}
+}
+
+func TestWriteDirIsDeterministic(t *testing.T) {
+	var outputs []map[string]string
+	for i := 0; i < 2; i++ {
+		dir := t.TempDir()
+		err := testBuilder(testFS()).SetConfigure(func(f *feed.Feed) {
+			f.SetExcerpter(feed.NewExcerpter())
+		}).WriteDir(dir)
+		if err != nil {
+			t.Fatalf("WriteDir failed: %v", err)
+		}
+
+		files := make(map[string]string)
+		err = filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			data, err := os.ReadFile(p)
+			rel, _ := filepath.Rel(dir, p)
+			files[filepath.ToSlash(rel)] = string(data)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		outputs = append(outputs, files)
+	}
+
+	for _, name := range []string{"feed.xml", "feed.atom", "tags/go/feed.xml", "tags/feeds/feed.atom", "authors/jane-doe/feed.xml"} {
+		if _, ok := outputs[0][name]; !ok {
+			t.Errorf("Expected %s to be written", name)
+		}
+	}
+	if len(outputs[0]) != 10 {
+		t.Errorf("Expected 10 files, got %d", len(outputs[0]))
+	}
+	for name, data := range outputs[0] {
+		if outputs[1][name] != data {
+			t.Errorf("Expected identical output for %s", name)
+		}
+	}
+	if site := outputs[0]["feed.xml"]; !strings.Contains(site, "<description>Install it.</description>") {
+		t.Errorf("Expected excerpt description in site feed, got %s", site)
+	}
+}
+
+func TestWriteDirFormats(t *testing.T) {
+	dir := t.TempDir()
+	if err := testBuilder(testFS()).SetFormats(feed.FormatAtom).WriteDir(dir); err != nil {
+		t.Fatalf("WriteDir failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "feed.xml")); !os.IsNotExist(err) {
+		t.Error("Expected RSS output to be skipped")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "feed.atom")); err != nil {
+		t.Errorf("Expected Atom output, got %v", err)
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Hello, World!":  "hello-world",
+		"  Go 1.22  ":    "go-1-22",
+		"Ünïcode Tägs":   "ünïcode-tägs",
+		"--already-ok--": "already-ok",
+		"日本語":            "日本語",
+	}
+	for in, want := range tests {
+		if got := Slugify(in); got != want {
+			t.Errorf("Slugify(%q): expected %q, got %q", in, want, got)
+		}
+	}
+}